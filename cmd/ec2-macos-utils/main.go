@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 
@@ -11,18 +12,35 @@ import (
 )
 
 func main() {
-	sys, err := system.Scan()
+	// Commands which don't use diskutil (e.g. usage) still run when the system can't be identified, and the others can
+	// be run with a forced release. The error is kept in the context for those commands to report (see requireProduct)
+	// once logging is set up.
+	ctx, _ := startup(context.Background(), system.Scan)
+
+	if err := cmd.MainCommand().ExecuteContext(ctx); err != nil {
+		var exitErr cmd.ExitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code())
+		}
+		os.Exit(1)
+	}
+}
+
+// startup identifies the system with scan and provides its Product in the returned context. If the system can't be
+// identified, the context is returned without a Product, providing the error instead (see contextual.ProductErr),
+// along with the error.
+func startup(ctx context.Context, scan func() (*system.System, error)) (context.Context, error) {
+	sys, err := scan()
 	if err != nil {
-		panic(fmt.Errorf("cannot identify system: %w", err))
+		err = fmt.Errorf("cannot identify system: %w", err)
+		return contextual.WithProductErr(ctx, err), err
 	}
+
 	p := sys.Product()
 	if p == nil {
-		panic("no product associated with identified system")
+		err := errors.New("no product associated with identified system")
+		return contextual.WithProductErr(ctx, err), err
 	}
 
-	ctx := contextual.WithProduct(context.Background(), p)
-
-	if err := cmd.MainCommand().ExecuteContext(ctx); err != nil {
-		os.Exit(1)
-	}
+	return contextual.WithProduct(ctx, p), nil
 }
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/contextual"
+	"github.com/aws/ec2-macos-utils/internal/system"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartup(t *testing.T) {
+	ctx, err := startup(context.Background(), func() (*system.System, error) {
+		return system.ScanRoot("../../internal/system/testdata/monterey")
+	})
+
+	assert.NoError(t, err, "should identify the system")
+	if assert.NotNil(t, contextual.Product(ctx), "should provide the product") {
+		assert.Equal(t, system.Monterey, contextual.Product(ctx).Release, "should provide the scanned product")
+	}
+}
+
+func TestStartup_WithScanErr(t *testing.T) {
+	ctx, err := startup(context.Background(), func() (*system.System, error) {
+		return nil, errors.New("error")
+	})
+
+	assert.Error(t, err, "should fail when the system can't be scanned")
+	assert.NotNil(t, ctx, "should still return a usable context")
+	assert.Nil(t, contextual.Product(ctx), "shouldn't provide a product")
+	assert.Equal(t, err, contextual.ProductErr(ctx), "should provide the error for commands requiring a product")
+}
+
+func TestStartup_WithoutProduct(t *testing.T) {
+	ctx, err := startup(context.Background(), func() (*system.System, error) {
+		return &system.System{}, nil
+	})
+
+	assert.Error(t, err, "should fail when the system has no product")
+	assert.NotNil(t, ctx, "should still return a usable context")
+	assert.Nil(t, contextual.Product(ctx), "shouldn't provide a product")
+	assert.Equal(t, err, contextual.ProductErr(ctx), "should provide the error for commands requiring a product")
+}
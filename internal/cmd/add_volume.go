@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+)
+
+// addVolumeDefaultFormat is the file system new volumes are formatted with by default.
+const addVolumeDefaultFormat = "APFS"
+
+// addVolume is a struct for holding all information passed into the add-volume command.
+type addVolume struct {
+	dryrun    bool
+	container string
+	name      string
+	format    string
+
+	// out is where command output (as opposed to logs) is written.
+	out io.Writer
+}
+
+// addVolumeCommand creates a new command which adds volumes (e.g. dedicated data volumes) to APFS containers.
+func addVolumeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add-volume",
+		Short: "add a volume to a container",
+		Long: strings.TrimSpace(`
+add-volume adds a new volume named --name to the APFS
+container with the given identifier (e.g. disk5 or /dev/disk5)
+using 'diskutil apfs addVolume', formatted with --format.
+The new volume's identifier is printed once it's added.
+Use --dry-run to check the container without adding a volume.
+		`),
+	}
+
+	// Set up the flags to be passed into the command
+	addArgs := addVolume{}
+	cmd.PersistentFlags().StringVar(&addArgs.container, "container", "", "container identifier to add the volume to")
+	cmd.PersistentFlags().StringVar(&addArgs.name, "name", "", "name of the new volume")
+	cmd.PersistentFlags().StringVar(&addArgs.format, "format", addVolumeDefaultFormat, `file system of the new volume (e.g. APFS, "Case-sensitive APFS")`)
+	cmd.PersistentFlags().BoolVar(&addArgs.dryrun, "dry-run", false, "run command without mutating changes")
+	cmd.MarkPersistentFlagRequired("container")
+	cmd.MarkPersistentFlagRequired("name")
+
+	// Set up the command's pre-run to check for root permissions.
+	// This is necessary since diskutil apfs addVolume requires root permissions to run.
+	cmd.PreRunE = assertRootPrivileges
+
+	// Set up the command's run function
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		addArgs.out = cmd.OutOrStdout()
+
+		ctx := cmd.Context()
+		product, err := requireProduct(ctx)
+		if err != nil {
+			return err
+		}
+
+		logrus.WithField("product", product).Info("Configuring diskutil for product")
+		d, err := diskutil.ForProduct(product)
+		if err != nil {
+			return err
+		}
+
+		if addArgs.dryrun {
+			d = diskutil.Dryrun(d)
+		}
+
+		logrus.WithField("args", addArgs).Debug("Running add-volume command with args")
+		return runAddVolume(ctx, d, addArgs)
+	}
+
+	return cmd
+}
+
+// runAddVolume adds the volume with diskutil.AddVolume and prints its device identifier. Skipping the addition in a
+// dry run isn't an error.
+func runAddVolume(ctx context.Context, du diskutil.DiskUtil, args addVolume) error {
+	id, err := diskutil.AddVolume(ctx, du, args.container, args.name, args.format)
+	if errors.Is(err, diskutil.ErrReadOnly) {
+		logrus.WithError(err).Warn("Would have added volume")
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("cannot add volume: %w", err)
+	}
+	logrus.WithFields(logrus.Fields{"container": args.container, "id": id}).Info("Volume added")
+	fmt.Fprintln(args.out, id)
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// addVolumeFixture creates a FakeUtil with the APFS container disk5 and the non-APFS disk6. Adding a volume to disk5
+// lists it as disk5s2.
+func addVolumeFixture() *diskutil.FakeUtil {
+	partitions := &types.SystemPartitions{
+		AllDisks: []string{"disk5", "disk6"},
+		AllDisksAndPartitions: []types.DiskPart{
+			{DeviceIdentifier: "disk5", APFSVolumes: []types.APFSVolume{}},
+			{DeviceIdentifier: "disk6", Content: "GUID_partition_scheme"},
+		},
+	}
+
+	fake := diskutil.NewFakeUtil(partitions, map[string]*types.DiskInfo{
+		"disk5": {ContainerInfo: types.ContainerInfo{FilesystemType: "apfs"}, DeviceIdentifier: "disk5"},
+		"disk6": {DeviceIdentifier: "disk6", Content: "GUID_partition_scheme"},
+	})
+	fake.AddVolumeFunc = func(ctx context.Context, containerID string, name string, format string) (string, error) {
+		container := &partitions.AllDisksAndPartitions[0]
+		container.APFSVolumes = append(container.APFSVolumes, types.APFSVolume{DeviceIdentifier: "disk5s2", VolumeName: name})
+
+		return "", nil
+	}
+
+	return fake
+}
+
+func TestRunAddVolume(t *testing.T) {
+	fake := addVolumeFixture()
+	var out bytes.Buffer
+
+	err := runAddVolume(context.Background(), fake, addVolume{container: "disk5", name: "Data", format: "APFS", out: &out})
+
+	assert.NoError(t, err, "should add the volume")
+	assert.Equal(t, "disk5s2\n", out.String(), "should print the new volume's identifier")
+}
+
+func TestRunAddVolume_WithoutAPFS(t *testing.T) {
+	fake := addVolumeFixture()
+	var out bytes.Buffer
+
+	err := runAddVolume(context.Background(), fake, addVolume{container: "disk6", name: "Data", format: "APFS", out: &out})
+
+	assert.Error(t, err, "should refuse to add a volume to a disk which isn't APFS")
+	assert.Empty(t, out.String(), "shouldn't print a volume")
+}
+
+func TestRunAddVolume_WithDryrun(t *testing.T) {
+	fake := addVolumeFixture()
+	var out bytes.Buffer
+
+	err := runAddVolume(context.Background(), diskutil.Dryrun(fake), addVolume{dryrun: true, container: "disk5", name: "Data", format: "APFS", out: &out})
+
+	assert.NoError(t, err, "should skip adding the volume in a dry run")
+	assert.Empty(t, out.String(), "shouldn't print a volume")
+	for _, call := range fake.Calls() {
+		assert.NotEqual(t, "AddVolume", call.Method, "shouldn't add the volume in a dry run")
+	}
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	"github.com/aws/ec2-macos-utils/internal/system"
+)
+
+// uuidPattern matches UUIDs (e.g. 6D0A5C0C-5B9E-4D5A-9E4B-0F1E2D3C4B5A) in diskutil's output.
+var uuidPattern = regexp.MustCompile(`(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+
+// captureFixtures is a struct for holding all information passed into the capture command.
+type captureFixtures struct {
+	dir         string
+	redactUUIDs bool
+}
+
+// capturedSystem is the system information written alongside the captured plists.
+type capturedSystem struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Product       string `json:"product"`
+	Version       string `json:"version"`
+	Arch          string `json:"arch"`
+}
+
+// captureCommand creates a new hidden command which captures diskutil's raw output for support.
+func captureCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "capture",
+		Short:  "capture raw diskutil output for support",
+		Hidden: true,
+		Long: strings.TrimSpace(`
+capture runs 'diskutil list' and 'diskutil info' for every
+disk and writes the raw plists (list.plist and
+info-<id>.plist) along with the system version
+(system.json) to the directory given with --dir. The files
+use the same format as the test fixtures so issues can be
+reproduced. Nothing is redacted unless --redact-uuids is
+provided, which replaces each UUID with a placeholder.
+		`),
+	}
+
+	// Set up the flags to be passed into the command
+	captureArgs := captureFixtures{}
+	cmd.PersistentFlags().StringVar(&captureArgs.dir, "dir", "", "directory to write the captured output to")
+	cmd.PersistentFlags().BoolVar(&captureArgs.redactUUIDs, "redact-uuids", false, "replace UUIDs in the captured output with placeholders")
+	cmd.MarkPersistentFlagRequired("dir")
+
+	// Set up the command's run function
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		product, err := requireProduct(ctx)
+		if err != nil {
+			return err
+		}
+
+		c := newCollector()
+		logrus.WithField("product", product).Info("Configuring diskutil for product")
+		d, err := diskutil.ForProductWithDecoder(product, c.decoder(&diskutil.PlistDecoder{}))
+		if err != nil {
+			return err
+		}
+
+		logrus.WithField("args", captureArgs).Debug("Running capture command with args")
+		if err := runCapture(ctx, d, c, product, captureArgs); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "captured diskutil output to %s\n", captureArgs.dir)
+
+		return nil
+	}
+
+	return cmd
+}
+
+// runCapture lists the system's disks and fetches the information of each with du, whose decoder must be created by
+// the collector, and writes the raw plists captured by the collector and the product's version to the directory.
+// Disks whose information can't be fetched are skipped with a warning.
+func runCapture(ctx context.Context, du diskutil.DiskUtil, c *collector, product *system.Product, args captureFixtures) error {
+	if err := os.MkdirAll(args.dir, 0755); err != nil {
+		return fmt.Errorf("cannot create capture directory: %w", err)
+	}
+
+	var redact func([]byte) []byte
+	if args.redactUUIDs {
+		redact = newUUIDRedactor()
+	}
+
+	partitions, err := du.List(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("cannot list disks: %w", err)
+	}
+	if err := writeCapturedPlist(c, "list", filepath.Join(args.dir, "list.plist"), redact); err != nil {
+		return err
+	}
+
+	for _, id := range partitions.AllDisks {
+		if _, err := du.Info(ctx, id); err != nil {
+			logrus.WithError(err).WithField("device_id", id).Warn("Unable to capture disk information")
+			continue
+		}
+		if err := writeCapturedPlist(c, "info", filepath.Join(args.dir, "info-"+id+".plist"), redact); err != nil {
+			return err
+		}
+	}
+
+	sys := capturedSystem{
+		SchemaVersion: schemaVersion,
+		Product:       product.String(),
+		Version:       product.Version.String(),
+		Arch:          string(product.Arch),
+	}
+	if err := writeJSONFile(filepath.Join(args.dir, "system.json"), sys); err != nil {
+		return fmt.Errorf("cannot write system version: %w", err)
+	}
+
+	return nil
+}
+
+// writeCapturedPlist writes the most recent raw plist of the given kind captured by the collector to the file,
+// redacting it first when redact isn't nil.
+func writeCapturedPlist(c *collector, kind, path string, redact func([]byte) []byte) error {
+	data, ok := c.lastPlist(kind)
+	if !ok {
+		return fmt.Errorf("no %s output was captured", kind)
+	}
+	if redact != nil {
+		data = redact(data)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("cannot write %s: %w", kind, err)
+	}
+
+	return nil
+}
+
+// newUUIDRedactor creates a function which replaces each UUID with a placeholder. The same UUID is always replaced with
+// the same placeholder so references between disks and volumes are preserved. Placeholders are the same length as
+// UUIDs so binary plists stay valid.
+func newUUIDRedactor() func([]byte) []byte {
+	placeholders := map[string][]byte{}
+
+	return func(data []byte) []byte {
+		return uuidPattern.ReplaceAllFunc(data, func(uuid []byte) []byte {
+			key := strings.ToUpper(string(uuid))
+			placeholder, ok := placeholders[key]
+			if !ok {
+				placeholder = []byte(fmt.Sprintf("00000000-0000-0000-0000-%012X", len(placeholders)+1))
+				placeholders[key] = placeholder
+			}
+
+			return placeholder
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+	"github.com/aws/ec2-macos-utils/internal/system"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/assert"
+)
+
+const captureVolumeUUID = "6D0A5C0C-5B9E-4D5A-9E4B-0F1E2D3C4B5A"
+
+// captureInfoPlist is the raw disk information of disk1 which includes its volume's UUID.
+var captureInfoPlist = strings.Replace(collectInfoPlist, "<key>TotalSize</key>",
+	"<key>VolumeUUID</key>\n\t<string>"+captureVolumeUUID+"</string>\n\t<key>TotalSize</key>", 1)
+
+// rawUtil is a diskutil.DiskUtil which decodes raw plists with its decoder, like diskutil.DiskUtil implementations
+// decode diskutil's output.
+type rawUtil struct {
+	diskutil.DiskUtil
+
+	dec  diskutil.Decoder
+	list string
+	info map[string]string
+}
+
+func (u *rawUtil) List(ctx context.Context, args []string) (*types.SystemPartitions, error) {
+	return u.dec.DecodeSystemPartitions(strings.NewReader(u.list))
+}
+
+func (u *rawUtil) Info(ctx context.Context, id string) (*types.DiskInfo, error) {
+	raw, ok := u.info[id]
+	if !ok {
+		return nil, fmt.Errorf("Unable to find disk for %s", id)
+	}
+
+	return u.dec.DecodeDiskInfo(strings.NewReader(raw))
+}
+
+func testCaptureProduct() *system.Product {
+	return &system.Product{Release: system.Ventura, Version: *semver.MustParse("13.6.0"), Arch: system.ArchARM64}
+}
+
+func TestRunCapture(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "capture")
+
+	c := newCollector()
+	du := &rawUtil{
+		dec:  c.decoder(&diskutil.PlistDecoder{}),
+		list: collectListPlist,
+		info: map[string]string{"disk1": captureInfoPlist},
+	}
+
+	err := runCapture(context.Background(), du, c, testCaptureProduct(), captureFixtures{dir: dir})
+	if !assert.NoError(t, err, "should be able to capture") {
+		return
+	}
+
+	partitions, err := diskutil.DecodeSystemPartitionsFile(&diskutil.PlistDecoder{}, filepath.Join(dir, "list.plist"))
+	assert.NoError(t, err, "should be able to decode the captured list")
+	if assert.NotNil(t, partitions) {
+		assert.Equal(t, []string{"disk1"}, partitions.AllDisks, "should capture the list")
+	}
+
+	disk, err := diskutil.DecodeDiskInfoFile(&diskutil.PlistDecoder{}, filepath.Join(dir, "info-disk1.plist"))
+	assert.NoError(t, err, "should be able to decode the captured disk information")
+	if assert.NotNil(t, disk) {
+		assert.Equal(t, "disk1", disk.DeviceIdentifier, "should capture the disk information")
+		assert.Equal(t, captureVolumeUUID, disk.VolumeUUID, "shouldn't redact UUIDs by default")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "system.json"))
+	assert.NoError(t, err, "should capture the system version")
+	var sys capturedSystem
+	assert.NoError(t, json.Unmarshal(data, &sys), "should write valid JSON")
+	assert.Equal(t, "13.6.0", sys.Version, "should capture the product's version")
+	assert.Equal(t, string(system.ArchARM64), sys.Arch, "should capture the product's architecture")
+}
+
+func TestRunCapture_WithRedactedUUIDs(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "capture")
+
+	c := newCollector()
+	du := &rawUtil{
+		dec:  c.decoder(&diskutil.PlistDecoder{}),
+		list: collectListPlist,
+		info: map[string]string{"disk1": captureInfoPlist},
+	}
+
+	err := runCapture(context.Background(), du, c, testCaptureProduct(), captureFixtures{dir: dir, redactUUIDs: true})
+	if !assert.NoError(t, err, "should be able to capture") {
+		return
+	}
+
+	disk, err := diskutil.DecodeDiskInfoFile(&diskutil.PlistDecoder{}, filepath.Join(dir, "info-disk1.plist"))
+	assert.NoError(t, err, "should be able to decode the redacted disk information")
+	if assert.NotNil(t, disk) {
+		assert.Equal(t, "00000000-0000-0000-0000-000000000001", disk.VolumeUUID, "should redact the UUID")
+	}
+}
+
+func TestRunCapture_WithInfoErr(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "capture")
+
+	c := newCollector()
+	du := &rawUtil{
+		dec:  c.decoder(&diskutil.PlistDecoder{}),
+		list: collectListPlist,
+	}
+
+	err := runCapture(context.Background(), du, c, testCaptureProduct(), captureFixtures{dir: dir})
+
+	assert.NoError(t, err, "should skip disks whose information can't be fetched")
+	assert.FileExists(t, filepath.Join(dir, "list.plist"), "should still capture the list")
+	_, err = os.Stat(filepath.Join(dir, "info-disk1.plist"))
+	assert.True(t, os.IsNotExist(err), "shouldn't capture the missing disk information")
+}
+
+func TestNewUUIDRedactor(t *testing.T) {
+	const (
+		first  = "6D0A5C0C-5B9E-4D5A-9E4B-0F1E2D3C4B5A"
+		second = "11111111-2222-3333-4444-555555555555"
+	)
+	redact := newUUIDRedactor()
+
+	actual := redact([]byte(first + " " + second + " " + strings.ToLower(first)))
+	assert.Equal(t, "00000000-0000-0000-0000-000000000001 00000000-0000-0000-0000-000000000002 "+
+		"00000000-0000-0000-0000-000000000001", string(actual), "should consistently replace each UUID")
+
+	// Redaction is shared between files so references stay consistent
+	actual = redact([]byte(second))
+	assert.Equal(t, "00000000-0000-0000-0000-000000000002", string(actual), "should remember replaced UUIDs")
+
+	assert.Equal(t, "no uuids", string(redact([]byte("no uuids"))), "should leave other data as-is")
+}
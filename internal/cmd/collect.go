@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+)
+
+// capturedPlist is raw plist data from diskutil which was captured while decoding it.
+type capturedPlist struct {
+	// kind describes what the data is (e.g. "list" or "info").
+	kind string
+	// data is the raw plist data as it was provided to the decoder.
+	data []byte
+}
+
+// collectedTopology is the parsed topology of the system which was decoded while a command ran.
+type collectedTopology struct {
+	SchemaVersion int                     `json:"schemaVersion"`
+	Partitions    *types.SystemPartitions `json:"partitions"`
+	Disks         []*types.DiskInfo       `json:"disks"`
+}
+
+// collector captures diagnostics while a command runs so they can be written to a directory with --collect when the
+// command fails. It's a logrus.Hook which buffers the log transcript and captures the raw plists and parsed topology
+// through the diskutil.Decoder created by decoder.
+type collector struct {
+	mu sync.Mutex
+
+	// formatter formats the buffered log entries.
+	formatter logrus.Formatter
+	// transcript is the buffered log transcript.
+	transcript bytes.Buffer
+	// plists are the raw plists in the order they were decoded.
+	plists []capturedPlist
+	// topology is the most recently decoded system partitions and every decoded disk.
+	topology collectedTopology
+}
+
+// newCollector creates a collector with an empty transcript.
+func newCollector() *collector {
+	return &collector{
+		formatter: &logrus.TextFormatter{
+			DisableColors:   true,
+			FullTimestamp:   true,
+			TimestampFormat: time.RFC822,
+		},
+		topology: collectedTopology{SchemaVersion: schemaVersion},
+	}
+}
+
+// Levels provides the levels the collector is fired for, which is every level.
+func (c *collector) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire buffers the entry in the transcript.
+func (c *collector) Fire(entry *logrus.Entry) error {
+	line, err := c.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.transcript.Write(line)
+
+	return nil
+}
+
+// decoder wraps dec so the raw plists it decodes and their parsed topology are captured.
+func (c *collector) decoder(dec diskutil.Decoder) diskutil.Decoder {
+	return &collectingDecoder{dec: dec, c: c}
+}
+
+// capture records the raw plist of the given kind.
+func (c *collector) capture(kind string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.plists = append(c.plists, capturedPlist{kind: kind, data: data})
+}
+
+// lastPlist provides the most recently captured raw plist of the given kind, if any.
+func (c *collector) lastPlist(kind string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := len(c.plists) - 1; i >= 0; i-- {
+		if c.plists[i].kind == kind {
+			return c.plists[i].data, true
+		}
+	}
+
+	return nil, false
+}
+
+// write writes the captured diagnostics and the structured error to the directory, creating it if necessary:
+//   - NN-<kind>.plist for each raw plist (e.g. 01-list.plist)
+//   - topology.json for the parsed topology
+//   - log.txt for the log transcript
+//   - error.json for the error
+func (c *collector) write(dir string, cmdErr error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create collection directory: %w", err)
+	}
+
+	for i, p := range c.plists {
+		name := fmt.Sprintf("%02d-%s.plist", i+1, p.kind)
+		if err := os.WriteFile(filepath.Join(dir, name), p.data, 0644); err != nil {
+			return fmt.Errorf("cannot write plist: %w", err)
+		}
+	}
+
+	if err := writeJSONFile(filepath.Join(dir, "topology.json"), c.topology); err != nil {
+		return fmt.Errorf("cannot write topology: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "log.txt"), c.transcript.Bytes(), 0644); err != nil {
+		return fmt.Errorf("cannot write log transcript: %w", err)
+	}
+
+	errOut := errorOutput{SchemaVersion: schemaVersion, Error: cmdErr.Error()}
+	if err := writeJSONFile(filepath.Join(dir, "error.json"), errOut); err != nil {
+		return fmt.Errorf("cannot write error: %w", err)
+	}
+
+	return nil
+}
+
+// collectOnError writes the collected diagnostics to the directory when err isn't nil. Failing to write them is logged
+// rather than returned so the original error is preserved. The error is returned as-is.
+func collectOnError(c *collector, dir string, err error) error {
+	if err == nil || c == nil {
+		return err
+	}
+
+	if werr := c.write(dir, err); werr != nil {
+		logrus.WithError(werr).Warn("Unable to collect diagnostics")
+	} else {
+		logrus.WithField("dir", dir).Info("Collected diagnostics")
+	}
+
+	return err
+}
+
+// collectingDecoder is a diskutil.Decoder which captures the raw plists and parsed topology before and after
+// delegating to another diskutil.Decoder.
+type collectingDecoder struct {
+	dec diskutil.Decoder
+	c   *collector
+}
+
+// DecodeSystemPartitions captures the raw list and the decoded partitions.
+func (d *collectingDecoder) DecodeSystemPartitions(reader io.ReadSeeker) (*types.SystemPartitions, error) {
+	if err := d.captureRaw("list", reader); err != nil {
+		return nil, err
+	}
+
+	partitions, err := d.dec.DecodeSystemPartitions(reader)
+	if err == nil {
+		d.c.mu.Lock()
+		d.c.topology.Partitions = partitions
+		d.c.mu.Unlock()
+	}
+
+	return partitions, err
+}
+
+// DecodeDiskInfo captures the raw disk information and the decoded disk.
+func (d *collectingDecoder) DecodeDiskInfo(reader io.ReadSeeker) (*types.DiskInfo, error) {
+	if err := d.captureRaw("info", reader); err != nil {
+		return nil, err
+	}
+
+	disk, err := d.dec.DecodeDiskInfo(reader)
+	if err == nil {
+		d.c.mu.Lock()
+		d.c.topology.Disks = append(d.c.topology.Disks, disk)
+		d.c.mu.Unlock()
+	}
+
+	return disk, err
+}
+
+// DecodeResizeLimits captures the raw resize limits.
+func (d *collectingDecoder) DecodeResizeLimits(reader io.ReadSeeker) (*types.ResizeLimits, error) {
+	if err := d.captureRaw("limits", reader); err != nil {
+		return nil, err
+	}
+
+	return d.dec.DecodeResizeLimits(reader)
+}
+
+// DecodeSnapshots captures the raw snapshot list.
+func (d *collectingDecoder) DecodeSnapshots(reader io.ReadSeeker) ([]types.Snapshot, error) {
+	if err := d.captureRaw("snapshots", reader); err != nil {
+		return nil, err
+	}
+
+	return d.dec.DecodeSnapshots(reader)
+}
+
+// captureRaw reads and captures the raw data from the reader before seeking back to the start for it to be decoded.
+func (d *collectingDecoder) captureRaw(kind string, reader io.ReadSeeker) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", kind, err)
+	}
+	if _, err := reader.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("error reading %s: %w", kind, err)
+	}
+	d.c.capture(kind, data)
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+const collectListPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>AllDisks</key>
+	<array>
+		<string>disk1</string>
+	</array>
+	<key>AllDisksAndPartitions</key>
+	<array>
+		<dict>
+			<key>DeviceIdentifier</key>
+			<string>disk1</string>
+			<key>Size</key>
+			<integer>3000000</integer>
+		</dict>
+	</array>
+</dict>
+</plist>
+`
+
+const collectInfoPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>DeviceIdentifier</key>
+	<string>disk1</string>
+	<key>TotalSize</key>
+	<integer>3000000</integer>
+</dict>
+</plist>
+`
+
+func TestCollectOnError(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "collect")
+
+	c := newCollector()
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.AddHook(c)
+
+	// Simulate a grow which decodes diskutil's output before failing
+	dec := c.decoder(&diskutil.PlistDecoder{})
+	_, err := dec.DecodeSystemPartitions(strings.NewReader(collectListPlist))
+	assert.NoError(t, err, "should decode the list through the collector")
+	disk, err := dec.DecodeDiskInfo(strings.NewReader(collectInfoPlist))
+	assert.NoError(t, err, "should decode the disk info through the collector")
+	assert.Equal(t, "disk1", disk.DeviceIdentifier, "should decode with the wrapped decoder")
+	logger.WithField("device_id", "disk1").Info("Resizing container...")
+
+	expectedErr := errors.New("resize failed")
+	actualErr := collectOnError(c, dir, expectedErr)
+	assert.Equal(t, expectedErr, actualErr, "should return the error as-is")
+
+	list, err := os.ReadFile(filepath.Join(dir, "01-list.plist"))
+	assert.NoError(t, err, "should collect the raw list")
+	assert.Equal(t, collectListPlist, string(list), "should collect the list as it was decoded")
+	partitions, err := diskutil.DecodeSystemPartitionsFile(&diskutil.PlistDecoder{}, filepath.Join(dir, "01-list.plist"))
+	assert.NoError(t, err, "should be able to decode the collected list")
+	if assert.NotNil(t, partitions) {
+		assert.Equal(t, []string{"disk1"}, partitions.AllDisks, "should decode the collected list")
+	}
+
+	info, err := os.ReadFile(filepath.Join(dir, "02-info.plist"))
+	assert.NoError(t, err, "should collect the raw disk info")
+	assert.Equal(t, collectInfoPlist, string(info), "should collect the disk info as it was decoded")
+
+	topologyData, err := os.ReadFile(filepath.Join(dir, "topology.json"))
+	assert.NoError(t, err, "should collect the topology")
+	var topology collectedTopology
+	assert.NoError(t, json.Unmarshal(topologyData, &topology), "should collect the topology as JSON")
+	if assert.NotNil(t, topology.Partitions, "should collect the decoded partitions") {
+		assert.Equal(t, []string{"disk1"}, topology.Partitions.AllDisks)
+	}
+	assert.Len(t, topology.Disks, 1, "should collect the decoded disk")
+
+	transcript, err := os.ReadFile(filepath.Join(dir, "log.txt"))
+	assert.NoError(t, err, "should collect the log transcript")
+	assert.Contains(t, string(transcript), "Resizing container...", "should collect the logged entries")
+
+	errData, err := os.ReadFile(filepath.Join(dir, "error.json"))
+	assert.NoError(t, err, "should collect the error")
+	var errOut errorOutput
+	assert.NoError(t, json.Unmarshal(errData, &errOut), "should collect the error as JSON")
+	assert.Equal(t, errorOutput{SchemaVersion: schemaVersion, Error: "resize failed"}, errOut)
+}
+
+func TestCollectOnError_WithoutErr(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "collect")
+
+	err := collectOnError(newCollector(), dir, nil)
+
+	assert.NoError(t, err)
+	_, statErr := os.Stat(dir)
+	assert.True(t, os.IsNotExist(statErr), "shouldn't collect without an error")
+}
+
+func TestCollectOnError_WithoutCollector(t *testing.T) {
+	expectedErr := errors.New("error")
+
+	err := collectOnError(nil, "", expectedErr)
+
+	assert.Equal(t, expectedErr, err, "should return the error as-is")
+}
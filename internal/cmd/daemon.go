@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	"github.com/aws/ec2-macos-utils/internal/util"
+)
+
+// daemonDefaultInterval is the default amount of time between each attempt to grow the container.
+const daemonDefaultInterval = 5 * time.Minute
+
+// daemon is a struct for holding all information passed into the daemon command.
+type daemon struct {
+	grow     growContainer
+	interval time.Duration
+
+	// ids are the identifiers read from stdin when the id is stdinID, which are replayed for each attempt since stdin
+	// can only be read once.
+	ids []string
+}
+
+// daemonCommand creates a new command which periodically grows an APFS container to its maximum size.
+func daemonCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "periodically resize container to max size",
+		Long: strings.TrimSpace(`
+daemon periodically checks if the container can be grown and
+resizes it to its maximum size, just like grow. This is useful
+for long-lived instances whose EBS volumes are resized without
+a reboot. The daemon runs until it receives SIGINT or SIGTERM.
+Containers are selected with --id like grow, including globs,
+'all', and '-' to read identifiers from stdin once at startup.
+Each attempt is bounded by --timeout so a hanging diskutil
+doesn't stop later attempts, and failed repairs are retried
+like grow's (see --repair-retries).
+		`),
+	}
+
+	// Set up the flags to be passed into the command
+	daemonArgs := daemon{}
+	cmd.PersistentFlags().StringVar(&daemonArgs.grow.id, "id", "", `container identifier to be resized, "root", "/", a glob (e.g. "disk1*"), "all" for every APFS container, or "-" to read identifiers from stdin`)
+	cmd.PersistentFlags().BoolVar(&daemonArgs.grow.dryrun, "dry-run", false, "run command without mutating changes")
+	cmd.PersistentFlags().DurationVar(&daemonArgs.interval, "interval", daemonDefaultInterval, "Set the interval between grow attempts (e.g. 30s, 1m, 1.5h)")
+	cmd.PersistentFlags().UintVar(&daemonArgs.grow.repairRetries, "repair-retries", growDefaultRepairRetries, "number of times a failed repair of the parent disk is retried with exponential backoff, 0 doesn't retry")
+	cmd.PersistentFlags().StringVar(&daemonArgs.grow.repairStrategy, "repair-strategy", string(diskutil.RepairParentDisk), `what is repaired before growing, one of: "disk" (refreshes the partition map), "volume" (only the container, faster)`)
+	cmd.PersistentFlags().DurationVar(&daemonArgs.grow.timeout, "timeout", growDefaultTimeout, "Set the timeout for each grow attempt (e.g. 30s, 1m, 1.5h), 0s will disable the timeout")
+	cmd.MarkPersistentFlagRequired("id")
+
+	// Set up the command's pre-run to check for root permissions.
+	// This is necessary since diskutil repairDisk requires root permissions to run.
+	cmd.PreRunE = assertRootPrivileges
+
+	// Set up the command's run function
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if daemonArgs.interval <= 0 {
+			return errors.New("interval must be greater than 0")
+		}
+		strategy, err := diskutil.ParseRepairStrategy(daemonArgs.grow.repairStrategy)
+		if err != nil {
+			return err
+		}
+		daemonArgs.grow.repairTarget = strategy
+
+		if daemonArgs.grow.id == stdinID {
+			ids, err := readIDs(cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+			daemonArgs.ids = ids
+		}
+
+		// Like grow, the grow's commands are bounded by --timeout for each attempt instead of --command-timeout
+		ctx, stop := signal.NotifyContext(util.WithCommandTimeout(cmd.Context(), 0), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		product, err := requireProduct(ctx)
+		if err != nil {
+			return err
+		}
+
+		logrus.WithField("product", product).Info("Configuring diskutil for product")
+		d, err := diskutil.ForProduct(product)
+		if err != nil {
+			return err
+		}
+
+		daemonArgs.grow.out = cmd.OutOrStdout()
+
+		ticker := time.NewTicker(daemonArgs.interval)
+		defer ticker.Stop()
+
+		logrus.WithField("args", daemonArgs).Debug("Running daemon command with args")
+		return runDaemon(ctx, d, daemonArgs, ticker.C)
+	}
+
+	return cmd
+}
+
+// runDaemon attempts to grow the containers immediately and then again each time ticks fires until the context is
+// done. Errors from individual attempts are logged rather than stopping the daemon.
+func runDaemon(ctx context.Context, utility diskutil.DiskUtil, args daemon, ticks <-chan time.Time) error {
+	for {
+		logrus.WithField("id", args.grow.id).Info("Checking if container can be grown...")
+		if err := attemptGrow(ctx, utility, args); err != nil && !isNothingToGrow(err) {
+			logrus.WithError(err).Error("Failed to grow container")
+		}
+
+		select {
+		case <-ctx.Done():
+			logrus.Info("Stopping daemon")
+			return nil
+		case <-ticks:
+		}
+	}
+}
+
+// attemptGrow grows the containers once with runIDs, just like grow, bounded by the grow's timeout. Dry runs use a new
+// wrapper for each attempt so that only the attempt's planned commands are written.
+func attemptGrow(ctx context.Context, utility diskutil.DiskUtil, args daemon) error {
+	if args.grow.timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, args.grow.timeout)
+		defer cancel()
+	}
+
+	growArgs := args.grow
+	if args.ids != nil {
+		growArgs.in = strings.NewReader(strings.Join(args.ids, "\n"))
+	}
+
+	var err error
+	if growArgs.dryrun {
+		dry := diskutil.Dryrun(utility)
+		err = runIDs(ctx, dry, growArgs)
+		writePlannedCommands(growArgs.out, dry.PlannedCommands())
+	} else {
+		err = runIDs(ctx, utility, growArgs)
+	}
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timeout exceeded: %w", err)
+	}
+
+	return err
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	mock_diskutil "github.com/aws/ec2-macos-utils/internal/diskutil/mocks"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunDaemon_GrowsEachInterval(t *testing.T) {
+	const (
+		testDiskID = "disk1"
+		// number of ticks to drive the daemon with
+		ticks = 3
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Each attempt starts by listing partitions to validate the target, so failing the list is enough to count the
+	// number of attempts without running the rest of the grow.
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	mock.EXPECT().List(gomock.Any(), nil).Return(nil, fmt.Errorf("error")).Times(ticks + 1)
+
+	tickCh := make(chan time.Time)
+	done := make(chan error)
+	go func() {
+		done <- runDaemon(ctx, mock, daemon{grow: growContainer{id: testDiskID}}, tickCh)
+	}()
+
+	for i := 0; i < ticks; i++ {
+		tickCh <- time.Now()
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err, "daemon should stop cleanly when its context is done")
+	case <-time.After(5 * time.Second):
+		t.Fatal("daemon didn't stop after its context was done")
+	}
+}
+
+func TestRunDaemon_ReplaysStdinIDs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Each identifier read from stdin is attempted on every tick, not only the first
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	mock.EXPECT().List(gomock.Any(), nil).Return(nil, fmt.Errorf("error")).Times(4)
+
+	tickCh := make(chan time.Time)
+	done := make(chan error)
+	args := daemon{grow: growContainer{id: stdinID}, ids: []string{"disk1", "disk2"}}
+	go func() {
+		done <- runDaemon(ctx, mock, args, tickCh)
+	}()
+
+	tickCh <- time.Now()
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err, "daemon should stop cleanly when its context is done")
+	case <-time.After(5 * time.Second):
+		t.Fatal("daemon didn't stop after its context was done")
+	}
+}
+
+func TestAttemptGrow_WithTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	mock.EXPECT().List(gomock.Any(), nil).DoAndReturn(func(ctx context.Context, args []string) (*types.SystemPartitions, error) {
+		_, ok := ctx.Deadline()
+		assert.True(t, ok, "should bound the attempt by the timeout")
+		return nil, fmt.Errorf("error")
+	})
+
+	err := attemptGrow(context.Background(), mock, daemon{grow: growContainer{id: "disk1", timeout: time.Minute}})
+
+	assert.Error(t, err, "should return the attempt's error")
+}
+
+func TestAttemptGrow_DryRunPlansEachAttempt(t *testing.T) {
+	fake := notifyGrowFixture()
+	var out bytes.Buffer
+	args := daemon{grow: growContainer{id: "disk1", dryrun: true, out: &out}}
+
+	assert.NoError(t, attemptGrow(context.Background(), fake, args), "first attempt should succeed")
+	first := out.String()
+	out.Reset()
+	assert.NoError(t, attemptGrow(context.Background(), fake, args), "second attempt should succeed")
+
+	assert.Contains(t, first, "Commands which would be run:", "should write the planned commands")
+	assert.Equal(t, first, out.String(), "should only write the attempt's planned commands")
+}
+
+func TestDaemonCommand_GrowDefaults(t *testing.T) {
+	grow := growContainerCommand()
+	d := daemonCommand()
+
+	for _, name := range []string{"repair-retries", "repair-strategy", "timeout"} {
+		growFlag := grow.PersistentFlags().Lookup(name)
+		daemonFlag := d.PersistentFlags().Lookup(name)
+		if assert.NotNil(t, daemonFlag, "daemon should have grow's --%s", name) {
+			assert.Equal(t, growFlag.DefValue, daemonFlag.DefValue, "daemon's --%s should default like grow's", name)
+		}
+	}
+}
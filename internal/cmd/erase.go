@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+)
+
+// eraseDefaultFormat is the file system volumes are reformatted with by default.
+const eraseDefaultFormat = "APFS"
+
+// eraseVolume is a struct for holding all information passed into the erase command.
+type eraseVolume struct {
+	dryrun  bool
+	confirm bool
+	id      string
+	format  string
+	name    string
+}
+
+// eraseCommand creates a new command which erases volumes (e.g. ephemeral scratch disks) for reuse.
+func eraseCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "erase",
+		Short: "erase a volume",
+		Long: strings.TrimSpace(`
+erase erases the volume with the given identifier (e.g.
+disk4s1 or /dev/disk4s1) using 'diskutil eraseVolume',
+reformatting it with --format and naming it --name.
+Everything on the volume is destroyed so --confirm is
+required. Volumes on the disk holding the root filesystem
+are always refused.
+Use --dry-run to check the volume without erasing it.
+		`),
+	}
+
+	// Set up the flags to be passed into the command
+	eraseArgs := eraseVolume{}
+	cmd.PersistentFlags().StringVar(&eraseArgs.id, "id", "", "volume identifier to be erased")
+	cmd.PersistentFlags().StringVar(&eraseArgs.format, "format", eraseDefaultFormat, "file system to reformat the volume with (e.g. APFS, JHFS+)")
+	cmd.PersistentFlags().StringVar(&eraseArgs.name, "name", "", "name of the erased volume")
+	cmd.PersistentFlags().BoolVar(&eraseArgs.confirm, "confirm", false, "confirm that everything on the volume should be destroyed")
+	cmd.PersistentFlags().BoolVar(&eraseArgs.dryrun, "dry-run", false, "run command without mutating changes")
+	cmd.MarkPersistentFlagRequired("id")
+	cmd.MarkPersistentFlagRequired("name")
+
+	// Set up the command's pre-run to check for root permissions.
+	// This is necessary since diskutil eraseVolume requires root permissions to run.
+	cmd.PreRunE = assertRootPrivileges
+
+	// Set up the command's run function
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		product, err := requireProduct(ctx)
+		if err != nil {
+			return err
+		}
+
+		logrus.WithField("product", product).Info("Configuring diskutil for product")
+		d, err := diskutil.ForProduct(product)
+		if err != nil {
+			return err
+		}
+
+		if eraseArgs.dryrun {
+			d = diskutil.Dryrun(d)
+		}
+
+		logrus.WithField("args", eraseArgs).Debug("Running erase command with args")
+		return runErase(ctx, d, eraseArgs)
+	}
+
+	return cmd
+}
+
+// runErase erases the volume with diskutil.EraseVolume once confirmed. Dry runs don't need to be confirmed and skipping
+// the erase in a dry run isn't an error.
+func runErase(ctx context.Context, du diskutil.DiskUtil, args eraseVolume) error {
+	if !args.confirm && !args.dryrun {
+		return fmt.Errorf("refusing to erase %s without --confirm", args.id)
+	}
+
+	err := diskutil.EraseVolume(ctx, du, args.id, args.format, args.name)
+	if errors.Is(err, diskutil.ErrReadOnly) {
+		logrus.WithError(err).Warn("Would have erased volume")
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("cannot erase volume: %w", err)
+	}
+	logrus.WithField("id", args.id).Info("Volume erased")
+
+	return nil
+}
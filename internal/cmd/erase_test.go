@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// eraseFixture creates a FakeUtil with the root filesystem on disk3 and a scratch volume on disk5.
+func eraseFixture() *diskutil.FakeUtil {
+	return diskutil.NewFakeUtil(nil, map[string]*types.DiskInfo{
+		"/": {
+			APFSPhysicalStores: []types.APFSPhysicalStore{{DeviceIdentifier: "disk0s2"}},
+			DeviceIdentifier:   "disk3s1s1",
+			MountPoint:         "/",
+			ParentWholeDisk:    "disk3",
+		},
+		"disk3s5": {
+			APFSPhysicalStores: []types.APFSPhysicalStore{{DeviceIdentifier: "disk0s2"}},
+			DeviceIdentifier:   "disk3s5",
+			ParentWholeDisk:    "disk3",
+		},
+		"disk5s1": {
+			DeviceIdentifier: "disk5s1",
+			ParentWholeDisk:  "disk5",
+		},
+	})
+}
+
+func TestRunErase(t *testing.T) {
+	fake := eraseFixture()
+
+	err := runErase(context.Background(), fake, eraseVolume{confirm: true, id: "disk5s1", format: "APFS", name: "Scratch"})
+
+	assert.NoError(t, err, "should erase the confirmed volume")
+	assert.Contains(t, fake.Calls(), diskutil.FakeCall{Method: "EraseVolume", Args: []string{"disk5s1", "APFS", "Scratch"}})
+}
+
+func TestRunErase_WithoutConfirm(t *testing.T) {
+	fake := eraseFixture()
+
+	err := runErase(context.Background(), fake, eraseVolume{id: "disk5s1", format: "APFS", name: "Scratch"})
+
+	assert.Error(t, err, "should refuse to erase without confirmation")
+	assert.Empty(t, fake.Calls(), "shouldn't call diskutil")
+}
+
+func TestRunErase_OnRootDisk(t *testing.T) {
+	fake := eraseFixture()
+
+	err := runErase(context.Background(), fake, eraseVolume{confirm: true, id: "disk3s5", format: "APFS", name: "Scratch"})
+
+	var rootErr diskutil.RootDiskError
+	assert.True(t, errors.As(err, &rootErr), "should refuse to erase a volume on the root disk")
+	for _, call := range fake.Calls() {
+		assert.NotEqual(t, "EraseVolume", call.Method, "shouldn't erase the volume")
+	}
+}
+
+func TestRunErase_WithDryrun(t *testing.T) {
+	fake := eraseFixture()
+
+	err := runErase(context.Background(), diskutil.Dryrun(fake), eraseVolume{dryrun: true, id: "disk5s1", format: "APFS", name: "Scratch"})
+
+	assert.NoError(t, err, "should skip the erase in a dry run without confirmation")
+	for _, call := range fake.Calls() {
+		assert.NotEqual(t, "EraseVolume", call.Method, "shouldn't erase the volume in a dry run")
+	}
+}
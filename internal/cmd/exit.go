@@ -0,0 +1,26 @@
+package cmd
+
+import "fmt"
+
+// ExitCodeError defines an error which requests a specific exit code for the program.
+type ExitCodeError struct {
+	code int
+	err  error
+}
+
+func (e ExitCodeError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit code %d", e.code)
+	}
+
+	return e.err.Error()
+}
+
+func (e ExitCodeError) Unwrap() error {
+	return e.err
+}
+
+// Code is the exit code the program should exit with.
+func (e ExitCodeError) Code() int {
+	return e.code
+}
@@ -1,9 +1,15 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,10 +17,12 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
-	"github.com/aws/ec2-macos-utils/internal/contextual"
 	"github.com/aws/ec2-macos-utils/internal/diskutil"
 	"github.com/aws/ec2-macos-utils/internal/diskutil/identifier"
 	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+	"github.com/aws/ec2-macos-utils/internal/imds"
+	"github.com/aws/ec2-macos-utils/internal/system"
+	"github.com/aws/ec2-macos-utils/internal/util"
 )
 
 // growDefaultTimeout is the default maximum run duration of 5 minutes. This time limit should be sufficiently long
@@ -22,11 +30,79 @@ import (
 // as unresponsive and the process will be terminated. This default time limit can be overridden with a flag.
 const growDefaultTimeout = 5 * time.Minute
 
+// growDefaultRepairRetries is the default number of times a failed repair of the parent disk is retried. Freshly
+// resized EBS volumes can fail the first repair until the kernel picks up the new GPT.
+const growDefaultRepairRetries = 3
+
+// exitNothingToGrow is the exit code used by grow when there isn't enough free space to grow the container, so that
+// automation can tell it apart from a successful grow.
+const exitNothingToGrow = 5
+
+// waitForDiskInterval is the amount of time between each check for the disk to appear when waiting for it.
+const waitForDiskInterval = 2 * time.Second
+
+// waitForSpaceInterval is the amount of time between each attempt to grow when waiting for enough free space.
+const waitForSpaceInterval = 10 * time.Second
+
+// stdinID is the --id value which indicates that newline-separated identifiers should be read from stdin.
+const stdinID = "-"
+
+// allID is the --id value which indicates that every APFS container should be grown.
+const allID = "all"
+
 // growContainer is a struct for holding all information passed into the grow container command.
 type growContainer struct {
-	dryrun  bool
-	id      string
-	timeout time.Duration
+	collect        string
+	dontAutoMount  bool
+	dryrun         bool
+	force          bool
+	forceInternal  bool
+	id             string
+	maxGrowBytes   uint64
+	minFree        int64
+	noEFIUpdate    bool
+	notifyURL      string
+	output         string
+	plan           bool
+	rebootIfNeeded bool
+	repairRetries  uint
+	repairStrategy string
+	report         string
+	resizedAt      string
+	sinceReboot    bool
+	size           string
+	sizeSource     string
+	timeout        time.Duration
+	volumeName     string
+	wait           time.Duration
+	waitForDisk    time.Duration
+
+	// targetSize is the parsed size, if any.
+	targetSize uint64
+	// freeSpaceSource is the parsed sizeSource.
+	freeSpaceSource diskutil.FreeSpaceSource
+	// repairTarget is the parsed repairStrategy.
+	repairTarget diskutil.RepairStrategy
+	// minFreeSpace is the validated minFree, nil uses diskutil.DefaultMinFreeSpace.
+	minFreeSpace *uint64
+	// bootTime fetches the time the system was last booted for the since-reboot guard.
+	bootTime BootTimeFunc
+	// reboot schedules a reboot when one is required and rebootIfNeeded is set.
+	reboot RebootFunc
+	// httpClient is used to post the grow result to https notify URLs.
+	httpClient *http.Client
+	// metadata looks up the instance's metadata when there isn't enough free space, if not nil.
+	metadata *imds.Client
+	// notifyTarget is the parsed notifyURL, if any.
+	notifyTarget *url.URL
+	// sizes records the container's sizes during the grow, if not nil.
+	sizes *growSizes
+	// results streams the result of each grow of a multi-disk run (see runIDs) with JSON output, if not nil.
+	results *resultStream
+	// in is where identifiers are read from when the id is stdinID.
+	in io.Reader
+	// out is where command output (as opposed to logs) is written.
+	out io.Writer
 }
 
 // growContainerCommand creates a new command which grows APFS containers to their maximum size.
@@ -38,50 +114,169 @@ func growContainerCommand() *cobra.Command {
 grow resizes the container to its maximum size using
 'diskutil'. The container to operate on can be specified
 with its identifier (e.g. disk1 or /dev/disk1). The string
-'root' may be provided to resize the OS's root volume.
+'root' (or the path '/') may be provided to resize the OS's
+root volume. Several containers can be grown at once with a
+glob (e.g. 'disk1*'), 'all', or '-' to read identifiers
+from stdin.
+The command exits with code 5 when there's nothing to grow,
+and with code 4 when a reboot is required.
 		`),
 	}
 
 	// Set up the flags to be passed into the command
 	growArgs := growContainer{}
-	cmd.PersistentFlags().StringVar(&growArgs.id, "id", "", `container identifier to be resized or "root"`)
+	cmd.PersistentFlags().StringVar(&growArgs.id, "id", "", `container identifier to be resized, "root", "/", a glob (e.g. "disk1*"), "all" for every APFS container, or "-" to read identifiers from stdin`)
+	cmd.PersistentFlags().StringVar(&growArgs.collect, "collect", "", "write diagnostics (raw plists, parsed topology, logs, and the error) to the given directory on failure")
+	cmd.PersistentFlags().BoolVar(&growArgs.dontAutoMount, "dont-auto-mount", false, "pass -dontAutoMount to 'diskutil apfs resizeContainer' so the container's volumes aren't mounted after the resize")
 	cmd.PersistentFlags().BoolVar(&growArgs.dryrun, "dry-run", false, "run command without mutating changes")
+	cmd.PersistentFlags().BoolVar(&growArgs.force, "force", false, "attempt the resize even when the container doesn't look like it can be APFS resized")
+	cmd.PersistentFlags().BoolVar(&growArgs.forceInternal, "force-internal", false, "allow resizing containers on the internal disk (disk0)")
+	cmd.PersistentFlags().Uint64Var(&growArgs.maxGrowBytes, "max-grow-bytes", 0, "maximum number of bytes to grow the container by, 0 grows to max size")
+	cmd.PersistentFlags().Int64Var(&growArgs.minFree, "min-free", diskutil.DefaultMinFreeSpace, "minimum number of free bytes on the disk required to grow the container, 0 disables the check")
+	cmd.PersistentFlags().BoolVar(&growArgs.noEFIUpdate, "no-efi-update", false, "pass -noEFIUpdate to 'diskutil apfs resizeContainer' so the EFI firmware isn't updated during the resize")
+	cmd.PersistentFlags().StringVar(&growArgs.notifyURL, "notify-url", "", "file:// or https:// URL to send the JSON grow result to on completion (best-effort)")
+	cmd.PersistentFlags().BoolVar(&growArgs.plan, "plan", false, "explain each decision the grow would make without running it")
+	cmd.PersistentFlags().BoolVar(&growArgs.rebootIfNeeded, "reboot-if-needed", false, "schedule a reboot and exit with code 4 when --since-reboot finds one is required")
+	cmd.PersistentFlags().UintVar(&growArgs.repairRetries, "repair-retries", growDefaultRepairRetries, "number of times a failed repair of the parent disk is retried with exponential backoff, 0 doesn't retry")
+	cmd.PersistentFlags().StringVar(&growArgs.repairStrategy, "repair-strategy", string(diskutil.RepairParentDisk), `what is repaired before growing, one of: "disk" (refreshes the partition map), "volume" (only the container, faster)`)
+	cmd.PersistentFlags().StringVar(&growArgs.report, "report", "", "write a JSON report of the partition layouts before and after the grow, and its error if it fails, to the given file")
+	cmd.PersistentFlags().StringVar(&growArgs.resizedAt, "resized-at", "", "time the EBS volume was resized (RFC3339, e.g. 2023-10-11T16:00:00Z), required by --since-reboot")
+	cmd.PersistentFlags().BoolVar(&growArgs.sinceReboot, "since-reboot", false, "fail with advice to reboot or wait when there isn't enough free space, based on the last boot and --resized-at")
+	cmd.PersistentFlags().StringVar(&growArgs.size, "size", "", "size to grow the container to (e.g. 120g, 1.5t) instead of its maximum size")
+	cmd.PersistentFlags().StringVar(&growArgs.sizeSource, "size-source", string(diskutil.FreeSpaceFromPartitions), `source of the free space deciding the grow when they diverge, one of: "partitions", "info"`)
 	cmd.PersistentFlags().DurationVar(&growArgs.timeout, "timeout", growDefaultTimeout, "Set the timeout for the command (e.g. 30s, 1m, 1.5h), 0s will disable the timeout")
-	cmd.MarkPersistentFlagRequired("id")
+	cmd.PersistentFlags().StringVar(&growArgs.volumeName, "volume-name", "", "name of a volume in the container to be resized (alternative to --id)")
+	cmd.PersistentFlags().DurationVar(&growArgs.wait, "wait", 0, "keep trying to grow for up to the given duration (e.g. 5m) while there isn't enough free space, 0s will not wait")
+	cmd.PersistentFlags().DurationVar(&growArgs.waitForDisk, "wait-for-disk", 0, "wait up to the given duration (e.g. 30s, 1m) for the disk to appear before growing, 0s will not wait")
+	cmd.MarkFlagsMutuallyExclusive("id", "volume-name")
+	cmd.MarkFlagsMutuallyExclusive("size", "max-grow-bytes")
 
-	// Set up the command's pre-run to check for root permissions.
+	// Set up the command's pre-run to check for root permissions, unless it's a dry run or a plan.
 	// This is necessary since diskutil repairDisk requires root permissions to run.
 	cmd.PreRunE = assertRootPrivileges
 
 	// Set up the command's run function
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		ctx := cmd.Context()
+		if growArgs.id == "" && growArgs.volumeName == "" {
+			return errors.New("one of --id or --volume-name is required")
+		}
+		if growArgs.id == stdinID && growArgs.report != "" {
+			return errors.New("--report can't be used when reading identifiers from stdin")
+		}
+		if isIDGlob(growArgs.id) && growArgs.report != "" {
+			return errors.New("--report can't be used with an id glob")
+		}
+		if strings.EqualFold(growArgs.id, allID) && growArgs.report != "" {
+			return errors.New("--report can't be used with --id all")
+		}
+		if growArgs.sinceReboot {
+			if _, err := time.Parse(time.RFC3339, growArgs.resizedAt); err != nil {
+				return fmt.Errorf("--since-reboot requires a valid --resized-at: %w", err)
+			}
+		}
+		output, err := streamOutputFlag(cmd)
+		if err != nil {
+			return err
+		}
+		growArgs.output = output
+		if isJSONOutput(growArgs.output) && growArgs.plan {
+			return fmt.Errorf("--plan can't be used with --output %s", growArgs.output)
+		}
+		if growArgs.rebootIfNeeded && !growArgs.sinceReboot {
+			return errors.New("--reboot-if-needed requires --since-reboot")
+		}
+		if growArgs.size != "" {
+			size, err := parseTargetSize(growArgs.size)
+			if err != nil {
+				return err
+			}
+			growArgs.targetSize = size
+		}
+		source, err := diskutil.ParseFreeSpaceSource(growArgs.sizeSource)
+		if err != nil {
+			return err
+		}
+		growArgs.freeSpaceSource = source
+		strategy, err := diskutil.ParseRepairStrategy(growArgs.repairStrategy)
+		if err != nil {
+			return err
+		}
+		growArgs.repairTarget = strategy
+		minFree, err := parseMinFree(growArgs.minFree)
+		if err != nil {
+			return err
+		}
+		growArgs.minFreeSpace = minFree
+		if growArgs.notifyURL != "" {
+			u, err := parseNotifyURL(growArgs.notifyURL)
+			if err != nil {
+				return err
+			}
+			growArgs.notifyTarget = u
+			growArgs.httpClient = &http.Client{Timeout: notifyTimeout}
+		}
+
+		// The grow's commands are bounded by its --timeout instead of --command-timeout since resizes can take longer
+		ctx := util.WithCommandTimeout(cmd.Context(), 0)
 		if growArgs.timeout != 0 {
-			ctx, _ = context.WithTimeout(ctx, growArgs.timeout)
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, growArgs.timeout)
+			defer cancel()
 		}
 
-		product := contextual.Product(ctx)
-		if product == nil {
-			return errors.New("product required in context")
+		product, err := requireProduct(ctx)
+		if err != nil {
+			return err
+		}
+
+		var c *collector
+		var dec diskutil.Decoder = &diskutil.PlistDecoder{}
+		if growArgs.collect != "" {
+			c = newCollector()
+			logrus.AddHook(c)
+			dec = c.decoder(dec)
 		}
 
 		logrus.WithField("product", product).Info("Configuring diskutil for product")
-		d, err := diskutil.ForProduct(product)
+		d, err := diskutil.ForProductWithDecoder(product, dec)
 		if err != nil {
-			return err
+			return collectOnError(c, growArgs.collect, err)
 		}
 
-		if growArgs.dryrun {
-			d = diskutil.Dryrun(d)
+		if product.HasSealedSystemVolume() && (strings.EqualFold("root", growArgs.id) || growArgs.id == "/") {
+			logrus.Info("The system volume is sealed and stays read-only, " +
+				"growing the container it shares with the data volume instead")
+		}
+
+		var plannedCommands func() [][]string
+		if growArgs.dryrun || growArgs.plan {
+			dry := diskutil.Dryrun(d)
+			plannedCommands = dry.PlannedCommands
+			d = dry
+		}
+		growArgs.bootTime = func(ctx context.Context) (time.Time, error) {
+			return system.BootTime(ctx, system.Sysctl)
 		}
+		growArgs.reboot = scheduleReboot
+		growArgs.metadata = imds.New(&http.Client{Timeout: imdsTimeout})
+		growArgs.in = cmd.InOrStdin()
+		growArgs.out = cmd.OutOrStdout()
 
 		logrus.WithField("args", growArgs).Debug("Running grow command with args")
-		if err := run(ctx, d, growArgs); err != nil {
+		err = runIDs(ctx, d, growArgs)
+		// The commands are only previewed for dry runs since --plan explains itself and JSON output must stay valid
+		if growArgs.dryrun && !growArgs.plan && !isJSONOutput(growArgs.output) {
+			writePlannedCommands(growArgs.out, plannedCommands())
+		}
+		if err != nil {
+			if isNothingToGrow(err) {
+				return err
+			}
 			if ctx.Err() == context.DeadlineExceeded {
-				return errors.New("timeout exceeded")
+				err = errors.New("timeout exceeded")
 			}
 
-			return err
+			return collectOnError(c, growArgs.collect, err)
 		}
 
 		return nil
@@ -90,44 +285,584 @@ with its identifier (e.g. disk1 or /dev/disk1). The string
 	return cmd
 }
 
+// runIDs calls run for each identifier read from args.in when the id is stdinID, for each identifier matched by the id
+// when it's a glob (see matchIDs), or for each APFS container when the id is allID (see containerIDs). Every identifier
+// is attempted even if an earlier one fails, and containers without enough free space to grow aren't failures unless
+// none could be grown. Otherwise, run is called for the provided id as-is.
+func runIDs(ctx context.Context, utility diskutil.DiskUtil, args growContainer) error {
+	var ids []string
+	var err error
+	switch {
+	case args.id == stdinID:
+		ids, err = readIDs(args.in)
+	case isIDGlob(args.id):
+		ids, err = matchIDs(ctx, utility, args.id)
+	case strings.EqualFold(args.id, allID):
+		ids, err = containerIDs(ctx, utility, args.forceInternal)
+	default:
+		return runAndNotify(ctx, utility, args)
+	}
+	if err != nil {
+		return err
+	}
+
+	// The results are streamed as each grow completes rather than written as separate JSON documents
+	if isJSONOutput(args.output) {
+		results := newResultStream(args.out, args.output)
+		defer func() {
+			if err := results.close(); err != nil {
+				logrus.WithError(err).Warn("Unable to end grow results")
+			}
+		}()
+		args.results = results
+	}
+
+	var failed []string
+	var nothingToGrow int
+	for _, id := range ids {
+		idArgs := args
+		idArgs.id = id
+		err := runAndNotify(ctx, utility, idArgs)
+		switch {
+		case isNothingToGrow(err):
+			nothingToGrow++
+		case err != nil:
+			logrus.WithError(err).WithField("id", id).Error("Failed to grow container")
+			failed = append(failed, id)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to grow %d of %d containers: %s", len(failed), len(ids), strings.Join(failed, ", "))
+	}
+	if nothingToGrow == len(ids) {
+		return ExitCodeError{code: exitNothingToGrow, err: fmt.Errorf("nothing to grow for %d containers", len(ids))}
+	}
+
+	return nil
+}
+
+// isNothingToGrow checks if the error is the ExitCodeError with exitNothingToGrow returned when there isn't enough
+// free space to grow.
+func isNothingToGrow(err error) bool {
+	var exitErr ExitCodeError
+
+	return errors.As(err, &exitErr) && exitErr.Code() == exitNothingToGrow
+}
+
+// isIDGlob checks if the id is a glob (e.g. "disk1*") rather than an exact identifier.
+func isIDGlob(id string) bool {
+	return strings.Contains(id, "*")
+}
+
+// matchIDs lists the system's disks and returns the identifier of each whole disk or container that matches the glob
+// (e.g. "disk1*" matches "disk1" and "disk10"). Slices (e.g. "disk1s1") aren't matched since growing one grows its
+// container again. Identifiers are matched case-insensitively. An error is returned if none match.
+func matchIDs(ctx context.Context, du diskutil.DiskUtil, glob string) ([]string, error) {
+	pattern := strings.ToLower(strings.TrimPrefix(glob, "/dev/"))
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid id glob %q: %w", glob, err)
+	}
+
+	partitions, err := du.List(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list partitions: %w", err)
+	}
+
+	var ids []string
+	for _, disk := range partitions.AllDisksAndPartitions {
+		if ok, _ := path.Match(pattern, strings.ToLower(disk.DeviceIdentifier)); ok {
+			ids = append(ids, disk.DeviceIdentifier)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no disks match id glob %q", glob)
+	}
+	logrus.WithFields(logrus.Fields{
+		"glob": glob,
+		"ids":  ids,
+	}).Info("Matched disks for id glob")
+
+	return ids, nil
+}
+
+// containerIDs lists the system's disks and returns the device identifier of each APFS container. Containers aren't
+// filtered by their free space since it's only accurate once the disk is repaired, so growing them decides instead.
+// Containers on the internal disk are skipped unless forceInternal is set. An error is returned if there are none.
+func containerIDs(ctx context.Context, du diskutil.DiskUtil, forceInternal bool) ([]string, error) {
+	partitions, err := du.List(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list partitions: %w", err)
+	}
+
+	var ids []string
+	for _, container := range partitions.APFSContainers() {
+		if !forceInternal && onInternalDisk(container) {
+			logrus.WithField("device_id", container.DeviceIdentifier).Info("Skipping container on the internal disk")
+			continue
+		}
+		ids = append(ids, container.DeviceIdentifier)
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("no APFS containers found")
+	}
+	logrus.WithField("ids", ids).Info("Found APFS containers")
+
+	return ids, nil
+}
+
+// onInternalDisk checks if the container is on the internal disk, either through its physical stores or, when it has
+// none, as a physical container itself.
+func onInternalDisk(container types.DiskPart) bool {
+	if len(container.APFSPhysicalStores) == 0 {
+		return diskutil.IsInternalDisk(identifier.ParseDiskID(container.DeviceIdentifier))
+	}
+
+	for _, store := range container.APFSPhysicalStores {
+		if diskutil.IsInternalDisk(identifier.ParseDiskID(store.DeviceIdentifier)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readIDs reads newline-separated identifiers from r. Blank lines are ignored.
+func readIDs(r io.Reader) ([]string, error) {
+	if r == nil {
+		return nil, errors.New("no input to read identifiers from")
+	}
+
+	var ids []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if id := strings.TrimSpace(scanner.Text()); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read identifiers: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return nil, errors.New("no identifiers provided on stdin")
+	}
+
+	return ids, nil
+}
+
+// runAndNotify calls run and, unless only planning, sends the grow result to the notify target if there is one and
+// writes it to args.out (or args.results when streamed) with JSON output.
+func runAndNotify(ctx context.Context, utility diskutil.DiskUtil, args growContainer) error {
+	args.sizes = &growSizes{}
+	err := run(ctx, utility, args)
+	if args.plan {
+		return err
+	}
+
+	// Having nothing to grow isn't a failed grow
+	resultErr := err
+	if isNothingToGrow(err) {
+		resultErr = nil
+	}
+	result := newGrowResult(args.id, args.dryrun, *args.sizes, resultErr)
+	if args.notifyTarget != nil {
+		notify(ctx, args.httpClient, args.notifyTarget, result)
+	}
+	var werr error
+	switch {
+	case args.results != nil:
+		werr = args.results.write(result)
+	case args.output == outputJSON:
+		werr = writeJSON(args.out, result)
+	case args.output == outputNDJSON:
+		werr = writeJSONLine(args.out, result)
+	}
+	if werr != nil {
+		logrus.WithError(werr).Warn("Unable to write grow result")
+	}
+
+	return err
+}
+
 // run attempts to grow the disk for the specified device identifier to its maximum size using diskutil.GrowContainer.
+// If a report file is requested, the partition layouts before and after the grow are captured and written to it, along
+// with the error if the grow fails. The listed partitions are reused (see listCache) until they may have been changed
+// by a repair or resize.
 func run(ctx context.Context, utility diskutil.DiskUtil, args growContainer) error {
-	di, err := getTargetDiskInfo(ctx, utility, args.id)
+	if args.waitForDisk > 0 {
+		if err := waitForDisk(ctx, utility, args.id, args.waitForDisk, waitForDiskInterval); err != nil {
+			return err
+		}
+	}
+	utility = newListCache(utility)
+
+	if args.plan {
+		return plan(ctx, utility, args)
+	}
+
+	if args.report == "" {
+		return grow(ctx, utility, args)
+	}
+
+	before, err := utility.List(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("cannot grow container: %w", err)
+		return fmt.Errorf("cannot capture partitions for report: %w", err)
 	}
 
-	logrus.WithField("device_id", di.DeviceIdentifier).Info("Attempting to grow container...")
-	if err := diskutil.GrowContainer(ctx, utility, di); err != nil {
-		// Don't treat FreeSpaceErrors as fatal, instead exit quietly since there's nothing else to do.
-		if errors.As(err, &diskutil.FreeSpaceError{}) {
-			logrus.WithField("id", args.id).Info("Nothing to do without free space, stopping command")
+	growErr := grow(ctx, utility, args)
+	if growErr != nil {
+		// The failed grow may have changed the partitions (e.g. repaired the disk) after they were last listed
+		forgetList(utility)
+	}
+
+	// A failed grow is still reported since its layouts are needed the most, even if they can't all be captured
+	after, err := utility.List(ctx, nil)
+	if err != nil {
+		if growErr == nil {
+			return fmt.Errorf("cannot capture partitions for report: %w", err)
+		}
+		logrus.WithError(err).Warn("Unable to capture partitions after the failed grow for report")
+	}
+
+	report := &growReport{
+		SchemaVersion: schemaVersion,
+		DeviceID:      args.id,
+		Before:        before,
+		After:         after,
+	}
+	if growErr != nil {
+		report.Error = growErr.Error()
+	}
+	logrus.WithField("report", args.report).Info("Writing grow report...")
+	if err := writeReport(args.report, report); err != nil {
+		if growErr == nil {
+			return err
+		}
+		logrus.WithError(err).Warn("Unable to write report for the failed grow")
+	}
+
+	return growErr
+}
+
+// parseTargetSize parses a human-readable size (e.g. 120g, 1.5t) into bytes. Unit prefixes are decimal (1g is 10^9
+// bytes) unless the binary form is used (e.g. 120gib).
+func parseTargetSize(size string) (uint64, error) {
+	bytes, err := humanize.ParseBytes(size)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --size %q: %w", size, err)
+	}
+	if bytes == 0 {
+		return 0, fmt.Errorf("invalid --size %q: must be larger than 0", size)
+	}
+
+	return bytes, nil
+}
+
+// parseMinFree validates the minimum number of free bytes required to grow. A minimum of 0 disables the check.
+func parseMinFree(minFree int64) (*uint64, error) {
+	if minFree < 0 {
+		return nil, fmt.Errorf("invalid --min-free %d: must be non-negative", minFree)
+	}
+	bytes := uint64(minFree)
+
+	return &bytes, nil
+}
+
+// waitForDisk polls the system partitions every interval until the disk with the given identifier is listed or the
+// timeout elapses. Targets which aren't device identifiers (e.g. "root" or mount points) exist by definition, so there's
+// nothing to wait for.
+func waitForDisk(ctx context.Context, du diskutil.DiskUtil, id string, timeout, interval time.Duration) error {
+	if id == "" || strings.EqualFold("root", id) || isMountPath(id) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		partitions, err := du.List(ctx, nil)
+		if err != nil {
+			logrus.WithError(err).Debug("Unable to list partitions while waiting for disk")
+		} else if validateDeviceID(id, partitions) == nil {
 			return nil
 		}
 
-		return err
+		logrus.WithField("id", id).Info("Waiting for disk to appear...")
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("disk %s didn't appear within %s: %w", id, timeout, ctx.Err())
+		case <-ticker.C:
+		}
 	}
+}
 
-	logrus.WithField("device_id", di.ParentWholeDisk).Info("Fetching updated information for device...")
-	updatedDi, err := getTargetDiskInfo(ctx, utility, di.ParentWholeDisk)
+// plan resolves the target disk and writes each decision diskutil.GrowContainer would make for it without making any
+// mutating changes.
+func plan(ctx context.Context, utility diskutil.DiskUtil, args growContainer) error {
+	target, err := resolveTarget(ctx, utility, args)
 	if err != nil {
-		logrus.WithError(err).Error("Error while fetching updated disk information")
+		return fmt.Errorf("cannot plan container grow: %w", err)
+	}
+
+	di, err := getTargetDiskInfo(ctx, utility, target)
+	if err != nil {
+		return fmt.Errorf("cannot plan container grow: %w", err)
+	}
+
+	decisions, err := diskutil.PlanGrowContainer(ctx, utility, di, args.growOptions())
+	for _, d := range decisions {
+		fmt.Fprintln(args.out, d)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot plan container grow: %w", err)
+	}
+
+	return nil
+}
+
+// writePlannedCommands writes the diskutil commands a dry run skipped, one per line, so the changes can be reviewed
+// before they're made. Arguments containing whitespace are quoted.
+func writePlannedCommands(w io.Writer, commands [][]string) {
+	if len(commands) == 0 {
+		fmt.Fprintln(w, "No commands would be run")
+		return
+	}
+
+	fmt.Fprintln(w, "Commands which would be run:")
+	for _, command := range commands {
+		args := make([]string, len(command))
+		for i, arg := range command {
+			if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+				arg = strconv.Quote(arg)
+			}
+			args[i] = arg
+		}
+		fmt.Fprintf(w, "  %s\n", strings.Join(args, " "))
+	}
+}
+
+// growOptions provides the diskutil.GrowOptions configured by the arguments.
+func (args growContainer) growOptions() diskutil.GrowOptions {
+	return diskutil.GrowOptions{
+		MaxGrowBytes:    args.maxGrowBytes,
+		TargetSize:      args.targetSize,
+		MinFreeSpace:    args.minFreeSpace,
+		RepairRetries:   args.repairRetries,
+		RepairStrategy:  args.repairTarget,
+		FreeSpaceSource: args.freeSpaceSource,
+		ForceInternal:   args.forceInternal,
+		Force:           args.force,
+		ResizeOptions:   args.resizeOptions(),
+	}
+}
+
+// resizeOptions provides the types.ResizeOption flags requested by the arguments, nil when there are none.
+func (args growContainer) resizeOptions() []types.ResizeOption {
+	var opts []types.ResizeOption
+	if args.noEFIUpdate {
+		opts = append(opts, types.ResizeNoEFIUpdate)
+	}
+	if args.dontAutoMount {
+		opts = append(opts, types.ResizeDontAutoMount)
+	}
+
+	return opts
+}
+
+// grow resolves the target disk and grows it with diskutil.GrowContainer. An ExitCodeError with exitNothingToGrow is
+// returned when there isn't enough free space to grow (see isNothingToGrow).
+func grow(ctx context.Context, utility diskutil.DiskUtil, args growContainer) error {
+	target, err := resolveTarget(ctx, utility, args)
+	if err != nil {
+		return fmt.Errorf("cannot grow container: %w", err)
+	}
+
+	di, err := getTargetDiskInfo(ctx, utility, target)
+	if err != nil {
+		return fmt.Errorf("cannot grow container: %w", err)
+	}
+
+	logrus.WithField("device_id", di.DeviceIdentifier).Info("Attempting to grow container...")
+	res, err := growWithWait(ctx, utility, di, args, waitForSpaceInterval)
+	if args.sizes != nil {
+		*args.sizes = growSizes{old: res.PreviousSize, new: res.PreviousSize}
+	}
+	if err != nil {
+		// Don't treat FreeSpaceErrors as failures, instead exit with exitNothingToGrow since there's nothing else to do.
+		var freeSpaceErr diskutil.FreeSpaceError
+		if errors.As(err, &freeSpaceErr) {
+			logInstanceMetadata(ctx, args.metadata, di, freeSpaceErr.FreeSpaceBytes())
+			if args.sinceReboot {
+				return sinceReboot(ctx, args)
+			}
+			// A requested size that can't be met is reported rather than treated as having nothing to do
+			if args.targetSize != 0 {
+				return err
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"id":         args.id,
+				"free_space": humanize.Bytes(freeSpaceErr.FreeSpaceBytes()),
+			}).Info("Nothing to do without free space, stopping command")
+			return ExitCodeError{code: exitNothingToGrow, err: fmt.Errorf("nothing to grow: %w", err)}
+		}
+
 		return err
 	}
+
+	logrus.WithFields(logrus.Fields{
+		"device_id":  res.DeviceID,
+		"free_space": humanize.Bytes(res.FreeSpace),
+		"resized":    res.Resized,
+	}).Debug("Grow result")
+
+	// The new size is taken from diskutil's resize output, only falling back to fetching the updated information when
+	// the output didn't report it.
+	size := res.Size
+	if size == 0 {
+		logrus.WithField("device_id", di.ParentWholeDisk).Info("Fetching updated information for device...")
+		updatedDi, err := getTargetDiskInfo(ctx, utility, di.ParentWholeDisk)
+		if err != nil {
+			logrus.WithError(err).Error("Error while fetching updated disk information")
+			return err
+		}
+		size = updatedDi.TotalSize
+	}
 	logrus.WithFields(logrus.Fields{
 		"device_id":  di.DeviceIdentifier,
-		"total_size": humanize.Bytes(updatedDi.TotalSize),
-	}).Info("Successfully grew device to maximum size")
+		"total_size": humanize.Bytes(size),
+	}).Info(growMessage(res, args))
+
+	if args.sizes != nil {
+		args.sizes.resized = res.Resized
+		args.sizes.new = size
+	}
 
 	return nil
 }
 
-// getTargetDiskInfo retrieves the disk info for the specified target identifier. If the identifier is "root", simply
-// return the disk information for "/". Otherwise, check if the identifier exists in the system partitions before
+// growMessage describes the outcome of a grow which didn't fail from its result. The container only reaches its maximum
+// size when it was resized without a requested size (--size) or a cap on its growth (--max-grow-bytes) limiting it.
+func growMessage(res diskutil.GrowResult, args growContainer) string {
+	switch {
+	case args.dryrun:
+		return "Would grow device, nothing was changed in dry run"
+	case !res.Resized && args.targetSize != 0:
+		return "Device already at the requested size, nothing was changed"
+	case !res.Resized:
+		return "Device already at maximum size, nothing was changed"
+	case args.targetSize != 0:
+		return "Successfully grew device to the requested size"
+	case args.maxGrowBytes != 0 && res.FreeSpace > args.maxGrowBytes:
+		return "Successfully grew device by the maximum allowed growth"
+	default:
+		return "Successfully grew device to maximum size"
+	}
+}
+
+// growWithWait grows the container with diskutil.GrowContainerWithResult. While there isn't enough free space, the
+// grow (including the repair which picks up a resized disk) is retried every interval until args.wait elapses or the
+// context is done, in which case the last FreeSpaceError is returned.
+func growWithWait(ctx context.Context, utility diskutil.DiskUtil, di *types.DiskInfo, args growContainer, interval time.Duration) (diskutil.GrowResult, error) {
+	res, err := diskutil.GrowContainerWithResult(ctx, utility, di, args.growOptions())
+	if args.wait <= 0 {
+		return res, err
+	}
+
+	deadline := time.NewTimer(args.wait)
+	defer deadline.Stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var freeSpaceErr diskutil.FreeSpaceError
+	if errors.As(err, &freeSpaceErr) {
+		logrus.WithField("wait", args.wait).Info("Waiting for enough free space to grow...")
+	}
+	for attempt := 1; errors.As(err, &freeSpaceErr); attempt++ {
+		select {
+		case <-ctx.Done():
+			return res, err
+		case <-deadline.C:
+			logrus.WithField("wait", args.wait).Warn("Gave up waiting for enough free space")
+			return res, err
+		case <-ticker.C:
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"attempt":    attempt,
+			"free_space": humanize.Bytes(freeSpaceErr.FreeSpaceBytes()),
+		}).Debug("Polling for free space")
+		// The free space is polled for so it must be listed again, even in a dry run
+		forgetList(utility)
+		res, err = diskutil.GrowContainerWithResult(ctx, utility, di, args.growOptions())
+	}
+
+	return res, err
+}
+
+// sinceReboot explains why there's no free space to grow into given when the volume was resized. The resized time is
+// expected to have been validated already. If a reboot is required and rebootIfNeeded is set, the reboot is scheduled.
+func sinceReboot(ctx context.Context, args growContainer) error {
+	resizedAt, err := time.Parse(time.RFC3339, args.resizedAt)
+	if err != nil {
+		return fmt.Errorf("invalid resized time: %w", err)
+	}
+
+	err = explainResizeNotVisible(ctx, args.bootTime, resizedAt)
+	if !args.rebootIfNeeded {
+		return err
+	}
+
+	return rebootIfRequired(ctx, args.reboot, args.dryrun, err)
+}
+
+// resolveTarget determines the identifier of the container to operate on. If a volume name is provided, the container
+// holding the volume is used. Otherwise, the provided identifier is used as-is.
+func resolveTarget(ctx context.Context, du diskutil.DiskUtil, args growContainer) (string, error) {
+	if args.volumeName == "" {
+		return args.id, nil
+	}
+
+	partitions, err := du.List(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("cannot list partitions: %w", err)
+	}
+
+	container, _, ok := partitions.FindByVolumeName(args.volumeName)
+	if !ok {
+		return "", fmt.Errorf("no volume found with name %q", args.volumeName)
+	}
+	logrus.WithFields(logrus.Fields{
+		"volume_name": args.volumeName,
+		"device_id":   container.DeviceIdentifier,
+	}).Info("Resolved volume name to container")
+
+	return container.DeviceIdentifier, nil
+}
+
+// getTargetDiskInfo retrieves the disk info for the specified target identifier. If the identifier is "root" (or "/"),
+// return the disk information for the container of the root volume group as long as the root is writable (see
+// diskutil.RootContainerInfo). If the identifier is any other absolute path that
+// isn't a device node (e.g. the mount point "/Volumes/scratch"), the disk information is looked up by that path since
+// diskutil resolves mount points itself. Otherwise, check if the identifier exists in the system partitions before
 // returning the disk information.
 func getTargetDiskInfo(ctx context.Context, du diskutil.DiskUtil, target string) (*types.DiskInfo, error) {
-	if strings.EqualFold("root", target) {
-		return du.Info(ctx, "/")
+	if strings.EqualFold("root", target) || target == "/" {
+		return diskutil.RootContainerInfo(ctx, du)
+	}
+
+	if isMountPath(target) {
+		di, err := du.Info(ctx, target)
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve path %s: %w", target, err)
+		}
+
+		return di, nil
 	}
 
 	partitions, err := du.List(ctx, nil)
@@ -139,28 +874,83 @@ func getTargetDiskInfo(ctx context.Context, du diskutil.DiskUtil, target string)
 		return nil, fmt.Errorf("invalid target: %w", err)
 	}
 
-	return du.Info(ctx, target)
+	di, err := du.Info(ctx, target)
+	if err != nil {
+		return nil, checkDiskDisappeared(ctx, du, target, err)
+	}
+
+	return di, nil
+}
+
+// DiskDisappearedError defines an error to distinguish when a disk was listed but disappeared (e.g. it was detached)
+// before its information could be fetched.
+type DiskDisappearedError struct {
+	id  string
+	err error
+}
+
+func (e DiskDisappearedError) Error() string {
+	return fmt.Sprintf("disk %s disappeared after it was listed: %v", e.id, e.err)
+}
+
+func (e DiskDisappearedError) Unwrap() error {
+	return e.err
+}
+
+// checkDiskDisappeared lists the partitions once more after fetching the information for a listed target failed to
+// confirm if the disk disappeared in between. A DiskDisappearedError is returned if it did, otherwise infoErr is
+// returned as-is.
+func checkDiskDisappeared(ctx context.Context, du diskutil.DiskUtil, target string, infoErr error) error {
+	// The disk was listed before so the partitions must be listed again to see if it's still there
+	forgetList(du)
+	partitions, err := du.List(ctx, nil)
+	if err != nil {
+		logrus.WithError(err).Debug("Unable to list partitions to confirm disk still exists")
+		return infoErr
+	}
+
+	if err := validateDeviceID(target, partitions); err != nil {
+		return DiskDisappearedError{id: target, err: infoErr}
+	}
+
+	return infoErr
+}
+
+// isMountPath checks if the target is an absolute path to a mount point rather than a device node.
+func isMountPath(target string) bool {
+	return strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "/dev/")
 }
 
-// validateDeviceID verifies if the provided ID is a valid device identifier or device node.
+// validateDeviceID verifies if the provided ID is a valid device identifier or device node. When the ID is for a slice
+// (e.g. disk2s1), both its whole disk and the slice itself must be listed.
 func validateDeviceID(id string, partitions *types.SystemPartitions) error {
 	// Check if ID is provided
 	if strings.TrimSpace(id) == "" {
 		return errors.New("empty device id")
 	}
 
-	// Get the device identifier
-	deviceID := identifier.ParseDiskID(id)
-	if deviceID == "" {
+	// Get the device identifier, keeping any slice, and its whole disk
+	deviceID := identifier.ParseFullDiskID(id)
+	diskID := identifier.ParseDiskID(deviceID)
+	if diskID == "" {
 		return errors.New("id does not match the expected device identifier format")
 	}
 
-	// Check the device directory for the given identifier
+	// Check the device directory for the given identifier's whole disk
+	found := false
 	for _, name := range partitions.AllDisks {
-		if strings.EqualFold(name, deviceID) {
-			return nil
+		if strings.EqualFold(name, diskID) {
+			found = true
+			break
 		}
 	}
+	if !found {
+		return fmt.Errorf("invalid device identifier: whole disk %s not found", diskID)
+	}
+
+	if deviceID != diskID && !partitions.HasSlice(deviceID) {
+		return fmt.Errorf("invalid device identifier: slice %s not found on disk %s", deviceID, diskID)
+	}
 
-	return errors.New("invalid device identifier")
+	return nil
 }
@@ -1,11 +1,20 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/diskutiltest"
 	mock_diskutil "github.com/aws/ec2-macos-utils/internal/diskutil/mocks"
 	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
 
@@ -111,7 +120,12 @@ func TestRun_WithoutFreeSpace(t *testing.T) {
 		id: testDiskID,
 	})
 
-	assert.NoError(t, err, "should exit quietly if there isn't enough free space to grow")
+	var exitErr ExitCodeError
+	if assert.True(t, errors.As(err, &exitErr), "should request an exit code when there's nothing to grow") {
+		assert.Equal(t, exitNothingToGrow, exitErr.Code(), "should exit with the nothing to grow code")
+	}
+	var freeSpaceErr diskutil.FreeSpaceError
+	assert.True(t, errors.As(err, &freeSpaceErr), "should wrap the FreeSpaceError")
 }
 
 func TestRun_WithUpdatedInfoErr(t *testing.T) {
@@ -224,174 +238,1493 @@ func TestRun_Success(t *testing.T) {
 	assert.NoError(t, err, "should be able to grow container with valid data")
 }
 
-func TestGetTargetDiskInfo_WithRootInfoErr(t *testing.T) {
-	const testDiskID = "root"
+func TestRun_SuccessWithScenario(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The same grow as TestRun_Success, set up from the fixture files. The scenario must match the calls left after the
+	// listed partitions are reused (see listCache), so every call it expects is made exactly once and in order.
+	parts, disk := diskutiltest.GrowFixture(t)
+	mock := diskutiltest.NewScenario(ctrl).Grow("disk1", parts, disk).Build()
+
+	sizes := growSizes{}
+	err := run(context.Background(), mock, growContainer{
+		id:    "disk1",
+		sizes: &sizes,
+	})
+
+	assert.NoError(t, err, "should be able to grow container with valid data")
+	assert.Equal(t, growSizes{old: disk.TotalSize, new: disk.TotalSize, resized: true}, sizes, "should record the grow")
+}
+
+func TestRun_WithResizeOutput(t *testing.T) {
+	const (
+		testDiskID        = "disk1"
+		diskSize   uint64 = 3_000_000
+		partSize   uint64 = 500_000
+		grownSize  uint64 = 2_500_000
+	)
 	var ctx = context.Background()
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
+	parts := types.SystemPartitions{
+		AllDisks: []string{testDiskID},
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             diskSize,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		TotalSize:         partSize,
+		VirtualOrPhysical: "Physical",
+	}
+
+	// The updated information isn't fetched since the resize output reports the new size
 	mock := mock_diskutil.NewMockDiskUtil(ctrl)
-	mock.EXPECT().Info(ctx, "/").Return(nil, fmt.Errorf("error"))
+	gomock.InOrder(
+		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mock.EXPECT().Info(ctx, testDiskID).Return(&disk, nil),
+		mock.EXPECT().RepairDisk(ctx, testDiskID).Return("", nil),
+		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mock.EXPECT().ResizeContainer(ctx, testDiskID, "0").Return("the new size is 2,500,000 bytes", nil),
+	)
 
-	di, err := getTargetDiskInfo(ctx, mock, testDiskID)
+	sizes := growSizes{}
+	err := run(ctx, mock, growContainer{
+		id:    testDiskID,
+		sizes: &sizes,
+	})
 
-	assert.Error(t, err, `should fail to get DiskInfo for /`)
-	assert.Nil(t, di)
+	assert.NoError(t, err, "should be able to grow container with valid data")
+	assert.Equal(t, growSizes{old: partSize, new: grownSize, resized: true}, sizes, "should take the new size from the resize output")
 }
 
-func TestGetTargetDiskInfo_WithListErr(t *testing.T) {
-	const testDiskID = "disk1"
+func TestRun_WithPlan(t *testing.T) {
+	const (
+		testDiskID        = "disk1"
+		diskSize   uint64 = 3_000_000
+		partSize   uint64 = 500_000
+	)
 	var ctx = context.Background()
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
+	parts := types.SystemPartitions{
+		AllDisks: []string{testDiskID},
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             diskSize,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		VirtualOrPhysical: "Physical",
+	}
+
+	// No repair or resize is expected since planning doesn't mutate anything, so the partitions are only listed once
 	mock := mock_diskutil.NewMockDiskUtil(ctrl)
-	mock.EXPECT().List(ctx, nil).Return(nil, fmt.Errorf("error"))
+	gomock.InOrder(
+		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mock.EXPECT().Info(ctx, testDiskID).Return(&disk, nil),
+	)
 
-	di, err := getTargetDiskInfo(ctx, mock, testDiskID)
+	var out bytes.Buffer
+	err := run(ctx, mock, growContainer{
+		id:   testDiskID,
+		plan: true,
+		out:  &out,
+	})
 
-	assert.Error(t, err, "should fail to get partition information")
-	assert.Nil(t, di)
+	expectedOut := strings.Join([]string{
+		"✓ container is APFS: disk1 can be resized",
+		"✓ parent disk: parent is disk1",
+		"✓ internal disk: disk1 is not the internal disk",
+		"✓ repair parent disk: would repair disk1 to update its free space",
+		"✓ free space: 2.0 MB ≥ 1.0 MB",
+		"✓ resize container: would resize disk1 to max size",
+	}, "\n") + "\n"
+
+	assert.NoError(t, err, "should be able to plan with valid data")
+	assert.Equal(t, expectedOut, out.String(), "should list each check result")
 }
 
-func TestGetTargetDiskInfo_NoTargetDisk(t *testing.T) {
+func TestRun_WithReport(t *testing.T) {
 	const (
-		testDiskID     = "disk1"
-		testAllDisksID = "disk0"
+		testDiskID        = "disk1"
+		diskSize   uint64 = 3_000_000
+		partSize   uint64 = 500_000
+		grownSize  uint64 = 2_500_000
 	)
 	var ctx = context.Background()
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	parts := types.SystemPartitions{
-		AllDisks: []string{testAllDisksID},
+	before := types.SystemPartitions{
+		AllDisks: []string{testDiskID},
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             diskSize,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
+
+	after := types.SystemPartitions{
+		AllDisks: []string{testDiskID},
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             diskSize,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: grownSize},
+				},
+			},
+		},
+	}
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		TotalSize:         diskSize,
+		VirtualOrPhysical: "Physical",
 	}
 
+	// The partitions listed for the report are reused to validate the target, and those listed for the updated
+	// information are reused for the report, so only the repair and resize require listing them again
 	mock := mock_diskutil.NewMockDiskUtil(ctrl)
-	mock.EXPECT().List(ctx, nil).Return(&parts, nil)
+	gomock.InOrder(
+		mock.EXPECT().List(ctx, nil).Return(&before, nil),
+		mock.EXPECT().Info(ctx, testDiskID).Return(&disk, nil),
+		mock.EXPECT().RepairDisk(ctx, testDiskID).Return("", nil),
+		mock.EXPECT().List(ctx, nil).Return(&before, nil),
+		mock.EXPECT().ResizeContainer(ctx, testDiskID, "0").Return("", nil),
+		mock.EXPECT().List(ctx, nil).Return(&after, nil),
+		mock.EXPECT().Info(ctx, testDiskID).Return(&disk, nil),
+	)
 
-	di, err := getTargetDiskInfo(ctx, mock, testDiskID)
+	reportPath := filepath.Join(t.TempDir(), "report.json")
 
-	assert.Error(t, err, "should fail to find targetDiskID in partition information")
-	assert.Nil(t, di, "should get nil data for invalid target disk")
+	err := run(ctx, mock, growContainer{
+		id:     testDiskID,
+		report: reportPath,
+	})
+	assert.NoError(t, err, "should be able to grow container and write report")
+
+	data, err := os.ReadFile(reportPath)
+	assert.NoError(t, err, "should be able to read the written report")
+
+	var actual growReport
+	err = json.Unmarshal(data, &actual)
+	assert.NoError(t, err, "should be able to decode the written report")
+	assert.Equal(t, schemaVersion, actual.SchemaVersion, "report should include the schema version")
+	assert.Equal(t, testDiskID, actual.DeviceID, "report should include the requested device")
+	assert.Equal(t, &before, actual.Before, "report should include the layout before the grow")
+	assert.Equal(t, &after, actual.After, "report should include the layout after the grow")
 }
 
-func TestGetTargetDiskInfo_WithInfoErr(t *testing.T) {
+func TestRun_WithReportOnFailure(t *testing.T) {
 	const testDiskID = "disk1"
 	var ctx = context.Background()
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	parts := types.SystemPartitions{
-		AllDisks: []string{testDiskID},
+	before := types.SystemPartitions{AllDisks: []string{testDiskID}}
+	after := types.SystemPartitions{AllDisks: []string{testDiskID, "disk2"}}
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		VirtualOrPhysical: "Physical",
 	}
 
+	// The partitions are listed again after the failed repair rather than reusing those listed before it
 	mock := mock_diskutil.NewMockDiskUtil(ctrl)
 	gomock.InOrder(
-		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
-		mock.EXPECT().Info(ctx, testDiskID).Return(nil, fmt.Errorf("error")),
+		mock.EXPECT().List(ctx, nil).Return(&before, nil),
+		mock.EXPECT().Info(ctx, testDiskID).Return(&disk, nil),
+		mock.EXPECT().RepairDisk(ctx, testDiskID).Return("", fmt.Errorf("repair error")),
+		mock.EXPECT().List(ctx, nil).Return(&after, nil),
 	)
 
-	di, err := getTargetDiskInfo(ctx, mock, testDiskID)
+	reportPath := filepath.Join(t.TempDir(), "report.json")
 
-	assert.Error(t, err, "should fail to get disk information")
-	assert.Nil(t, di, "should get nil data with info error")
+	err := run(ctx, mock, growContainer{
+		id:     testDiskID,
+		report: reportPath,
+	})
+	assert.Error(t, err, "should fail to grow the container")
+
+	data, rerr := os.ReadFile(reportPath)
+	assert.NoError(t, rerr, "should write the report for the failed grow")
+
+	var actual growReport
+	assert.NoError(t, json.Unmarshal(data, &actual), "should be able to decode the written report")
+	assert.Equal(t, &before, actual.Before, "report should include the layout before the grow")
+	assert.Equal(t, &after, actual.After, "report should include the layout after the failed grow")
+	assert.Equal(t, err.Error(), actual.Error, "report should include why the grow failed")
 }
 
-func TestGetTargetDiskInfo_Success(t *testing.T) {
-	const testDiskID = "disk1"
+func TestRunIDs_FromStdin(t *testing.T) {
 	var ctx = context.Background()
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	parts := types.SystemPartitions{
-		AllDisks: []string{testDiskID},
+		AllDisks: []string{"disk1", "disk2", "disk3"},
 	}
 
-	expectedDisk := &types.DiskInfo{}
-
 	mock := mock_diskutil.NewMockDiskUtil(ctrl)
 	gomock.InOrder(
 		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
-		mock.EXPECT().Info(ctx, testDiskID).Return(expectedDisk, nil),
+		mock.EXPECT().Info(ctx, "disk1").Return(nil, fmt.Errorf("error")),
+		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mock.EXPECT().Info(ctx, "/dev/disk2").Return(nil, fmt.Errorf("error")),
+		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mock.EXPECT().Info(ctx, "disk3").Return(nil, fmt.Errorf("error")),
+		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
 	)
 
-	actualDisk, err := getTargetDiskInfo(ctx, mock, testDiskID)
+	err := runIDs(ctx, mock, growContainer{
+		id: stdinID,
+		in: strings.NewReader("disk1\n\n/dev/disk2\n disk3 \n"),
+	})
 
-	assert.NoError(t, err, "should be able succeeded with valid info")
-	assert.Equal(t, expectedDisk, actualDisk, "should be able to get expected data from info")
+	assert.Error(t, err, "should fail when the ids can't be grown")
+	assert.Contains(t, err.Error(), "failed to grow 3 of 3 containers", "should attempt every id")
 }
 
-func TestValidateDeviceID(t *testing.T) {
-	type args struct {
-		id         string
-		partitions *types.SystemPartitions
+func TestRunIDs_WithNothingToGrow(t *testing.T) {
+	minFree := uint64(10_000_000)
+
+	err := runIDs(context.Background(), notifyGrowFixture(), growContainer{
+		id:           stdinID,
+		in:           strings.NewReader("disk1\ndisk1\n"),
+		minFreeSpace: &minFree,
+	})
+
+	var exitErr ExitCodeError
+	if assert.True(t, errors.As(err, &exitErr), "should request an exit code when there's nothing to grow") {
+		assert.Equal(t, exitNothingToGrow, exitErr.Code(), "should exit with the nothing to grow code")
+	}
+}
+
+func TestRunIDs_WithJSONOutput(t *testing.T) {
+	minFree := uint64(10_000_000)
+
+	var out bytes.Buffer
+	_ = runIDs(context.Background(), notifyGrowFixture(), growContainer{
+		id:           stdinID,
+		in:           strings.NewReader("disk1\ndisk1\n"),
+		minFreeSpace: &minFree,
+		output:       outputJSON,
+		out:          &out,
+	})
+
+	var decoded []growResult
+	if assert.NoError(t, json.Unmarshal(out.Bytes(), &decoded), "should write a single JSON array") {
+		assert.Len(t, decoded, 2, "should write a result per id")
+	}
+}
+
+func TestRunIDs_WithNDJSONOutput(t *testing.T) {
+	minFree := uint64(10_000_000)
+
+	var out bytes.Buffer
+	_ = runIDs(context.Background(), notifyGrowFixture(), growContainer{
+		id:           stdinID,
+		in:           strings.NewReader("disk1\ndisk1\n"),
+		minFreeSpace: &minFree,
+		output:       outputNDJSON,
+		out:          &out,
+	})
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if assert.Len(t, lines, 2, "should write a line per id") {
+		for _, line := range lines {
+			var decoded growResult
+			assert.NoError(t, json.Unmarshal([]byte(line), &decoded), "each line should be a JSON document")
+		}
 	}
+}
+
+func TestRunIDs_WithEmptyStdin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+
+	err := runIDs(context.Background(), mock, growContainer{
+		id: stdinID,
+		in: strings.NewReader("\n\n"),
+	})
+
+	assert.EqualError(t, err, "no identifiers provided on stdin", "should fail without ids")
+}
+
+func TestReadIDs(t *testing.T) {
 	tests := []struct {
-		name      string
-		args      args
-		wantValid bool
-		wantErr   bool
+		name    string
+		in      string
+		want    []string
+		wantErr bool
 	}{
 		{
-			name: "without ID",
-			args: args{
-				id:         "",
-				partitions: nil,
-			},
-			wantErr: true,
-		},
-		{
-			name: "invalid ID prefix",
-			args: args{
-				id:         "bad",
-				partitions: nil,
-			},
+			name:    "without input",
+			in:      "",
 			wantErr: true,
 		},
 		{
-			name: "without disk number",
-			args: args{
-				id:         "disk",
-				partitions: nil,
-			},
-			wantErr: true,
+			name: "with single id",
+			in:   "disk1",
+			want: []string{"disk1"},
 		},
 		{
-			name: "no target disk",
-			args: args{
-				id: "disk2",
-				partitions: &types.SystemPartitions{
-					AllDisks: []string{"disk0", "disk1"},
-				},
-			},
-			wantErr: true,
+			name: "with several ids and blank lines",
+			in:   "disk1\n\n  disk2\r\n/dev/disk3\n",
+			want: []string{"disk1", "disk2", "/dev/disk3"},
 		},
-		{
-			name: "success",
-			args: args{
-				id: "disk0",
-				partitions: &types.SystemPartitions{
-					AllDisks: []string{"disk0", "disk1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readIDs(strings.NewReader(tt.in))
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got, "should read expected ids")
+		})
+	}
+}
+
+func TestWaitForDisk_AppearsOnSecondPoll(t *testing.T) {
+	const testDiskID = "disk2"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	before := types.SystemPartitions{
+		AllDisks: []string{"disk0", "disk1"},
+	}
+	after := types.SystemPartitions{
+		AllDisks: []string{"disk0", "disk1", testDiskID},
+	}
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mock.EXPECT().List(gomock.Any(), nil).Return(&before, nil),
+		mock.EXPECT().List(gomock.Any(), nil).Return(&after, nil),
+	)
+
+	err := waitForDisk(context.Background(), mock, testDiskID, 5*time.Second, time.Millisecond)
+
+	assert.NoError(t, err, "should stop waiting once the disk appears")
+}
+
+func TestWaitForDisk_WithTimeout(t *testing.T) {
+	const testDiskID = "disk2"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts := types.SystemPartitions{
+		AllDisks: []string{"disk0", "disk1"},
+	}
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	mock.EXPECT().List(gomock.Any(), nil).Return(&parts, nil).MinTimes(1)
+
+	err := waitForDisk(context.Background(), mock, testDiskID, 10*time.Millisecond, time.Millisecond)
+
+	assert.Error(t, err, "should fail when the disk doesn't appear in time")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "should fail due to the timeout")
+}
+
+func TestWaitForDisk_WithCancelledContext(t *testing.T) {
+	const testDiskID = "disk2"
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	mock.EXPECT().List(gomock.Any(), nil).Return(nil, fmt.Errorf("error"))
+
+	err := waitForDisk(ctx, mock, testDiskID, time.Minute, time.Minute)
+
+	assert.True(t, errors.Is(err, context.Canceled), "should stop waiting when the context is cancelled")
+}
+
+func TestWaitForDisk_WithRoot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+
+	err := waitForDisk(context.Background(), mock, "root", time.Minute, time.Minute)
+
+	assert.NoError(t, err, "shouldn't wait for the root disk")
+}
+
+func TestResolveTarget_WithoutVolumeName(t *testing.T) {
+	const testDiskID = "disk1"
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+
+	target, err := resolveTarget(ctx, mock, growContainer{id: testDiskID})
+
+	assert.NoError(t, err, "should use the id without a volume name")
+	assert.Equal(t, testDiskID, target, "should resolve to the provided id")
+}
+
+func TestResolveTarget_WithListErr(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	mock.EXPECT().List(ctx, nil).Return(nil, fmt.Errorf("error"))
+
+	target, err := resolveTarget(ctx, mock, growContainer{volumeName: "Macintosh HD"})
+
+	assert.Error(t, err, "should fail to resolve volume name with list error")
+	assert.Empty(t, target)
+}
+
+func TestResolveTarget_WithVolumeName(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts := types.SystemPartitions{
+		AllDisks: []string{"disk0", "disk1", "disk1s1", "disk1s5"},
+		AllDisksAndPartitions: []types.DiskPart{
+			{DeviceIdentifier: "disk0"},
+			{
+				DeviceIdentifier: "disk1",
+				APFSVolumes: []types.APFSVolume{
+					{DeviceIdentifier: "disk1s1", VolumeName: "Macintosh HD - Data"},
+					{DeviceIdentifier: "disk1s5", VolumeName: "Macintosh HD"},
 				},
 			},
-			wantErr: false,
 		},
 	}
+
+	tests := []struct {
+		name       string
+		volumeName string
+		want       string
+		wantErr    bool
+	}{
+		{name: "exact name", volumeName: "Macintosh HD - Data", want: "disk1"},
+		{name: "different case", volumeName: "MACINTOSH HD", want: "disk1"},
+		{name: "unknown volume", volumeName: "scratch", wantErr: true},
+	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validateDeviceID(tt.args.id, tt.args.partitions)
+			mock := mock_diskutil.NewMockDiskUtil(ctrl)
+			mock.EXPECT().List(ctx, nil).Return(&parts, nil)
+
+			target, err := resolveTarget(ctx, mock, growContainer{volumeName: tt.volumeName})
+
+			assert.Equal(t, tt.want, target, "should resolve to the volume's container")
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGetTargetDiskInfo_WithRootInfoErr(t *testing.T) {
+	const testDiskID = "root"
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	mock.EXPECT().Info(ctx, "/").Return(nil, fmt.Errorf("error"))
+
+	di, err := getTargetDiskInfo(ctx, mock, testDiskID)
+
+	assert.Error(t, err, `should fail to get DiskInfo for /`)
+	assert.Nil(t, di)
+}
+
+func TestGetTargetDiskInfo_WithRootPath(t *testing.T) {
+	const testDiskID = "/"
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expectedDisk := &types.DiskInfo{
+		DeviceIdentifier: "disk1s5",
+		MountPoint:       "/",
+		ParentWholeDisk:  "disk1",
+		WritableMedia:    true,
+	}
+
+	// No List is expected since paths are resolved by diskutil directly
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	mock.EXPECT().Info(ctx, "/").Return(expectedDisk, nil)
+
+	actualDisk, err := getTargetDiskInfo(ctx, mock, testDiskID)
+
+	assert.NoError(t, err, "should be able to get DiskInfo for /")
+	assert.Equal(t, expectedDisk, actualDisk, "should resolve to the root container")
+}
+
+func TestGetTargetDiskInfo_WithRootVolumeGroup(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	root := &types.DiskInfo{
+		APFSContainerReference: "disk3",
+		ContainerInfo:          types.ContainerInfo{APFSVolumeGroupID: "A3F4E1F2-2A7C-4B1E-9C4D-5E6F7A8B9C0D"},
+		DeviceIdentifier:       "disk3s1s1",
+		MountPoint:             "/",
+		WritableMedia:          true,
+	}
+	container := &types.DiskInfo{DeviceIdentifier: "disk3", WritableMedia: true}
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mock.EXPECT().Info(ctx, "/").Return(root, nil),
+		mock.EXPECT().Info(ctx, "disk3").Return(container, nil),
+	)
+
+	actualDisk, err := getTargetDiskInfo(ctx, mock, "root")
+
+	assert.NoError(t, err, "should be able to get DiskInfo for root")
+	assert.Equal(t, container, actualDisk, "should resolve to the volume group's container")
+}
+
+func TestGetTargetDiskInfo_WithVolumePath(t *testing.T) {
+	const testDiskID = "/Volumes/foo"
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expectedDisk := &types.DiskInfo{
+		DeviceIdentifier: "disk4s1",
+		MountPoint:       "/Volumes/foo",
+		ParentWholeDisk:  "disk4",
+		WritableMedia:    true,
+	}
+
+	// No List is expected since paths are resolved by diskutil directly
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	mock.EXPECT().Info(ctx, testDiskID).Return(expectedDisk, nil)
+
+	actualDisk, err := getTargetDiskInfo(ctx, mock, testDiskID)
+
+	assert.NoError(t, err, "should be able to get DiskInfo for the mount point")
+	assert.Equal(t, expectedDisk, actualDisk, "should resolve to the mounted volume")
+}
+
+func TestGetTargetDiskInfo_WithRejectedPath(t *testing.T) {
+	const testDiskID = "/Users/ec2-user/not-a-volume"
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expectedErr := errors.New("Could not find disk: /Users/ec2-user/not-a-volume")
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	mock.EXPECT().Info(ctx, testDiskID).Return(nil, expectedErr)
+
+	di, err := getTargetDiskInfo(ctx, mock, testDiskID)
+
+	assert.True(t, errors.Is(err, expectedErr), "should fail with diskutil's error")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), testDiskID, "should include the rejected path")
+	}
+	assert.Nil(t, di)
+}
+
+func TestIsMountPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		want   bool
+	}{
+		{name: "root path", target: "/", want: true},
+		{name: "volume path", target: "/Volumes/scratch", want: true},
+		{name: "device node", target: "/dev/disk1", want: false},
+		{name: "device identifier", target: "disk1", want: false},
+		{name: "root alias", target: "root", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := isMountPath(tt.target)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGetTargetDiskInfo_WithReadOnlyRoot(t *testing.T) {
+	tests := []struct {
+		name     string
+		root     types.DiskInfo
+		wantDisk bool
+		wantErr  bool
+	}{
+		{
+			name: "read-only root",
+			root: types.DiskInfo{
+				DeviceIdentifier: "disk1s5s1",
+				MountPoint:       "/",
+				WritableMedia:    false,
+				WritableVolume:   false,
+			},
+			wantDisk: false,
+			wantErr:  true,
+		},
+		{
+			name: "sealed but writable root",
+			root: types.DiskInfo{
+				DeviceIdentifier: "disk1s5s1",
+				MountPoint:       "/",
+				WritableMedia:    true,
+				WritableVolume:   false,
+			},
+			wantDisk: true,
+			wantErr:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ctx = context.Background()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_diskutil.NewMockDiskUtil(ctrl)
+			mock.EXPECT().Info(ctx, "/").Return(&tt.root, nil)
+
+			di, err := getTargetDiskInfo(ctx, mock, "root")
 
 			if tt.wantErr {
 				assert.Error(t, err)
+				assert.True(t, errors.As(err, &diskutil.ReadOnlyRootError{}), "should get ReadOnlyRootError")
 			} else {
 				assert.NoError(t, err)
 			}
+			if tt.wantDisk {
+				assert.Equal(t, &tt.root, di, "should get the root disk information")
+			} else {
+				assert.Nil(t, di, "shouldn't get disk information for a read-only root")
+			}
 		})
 	}
 }
+
+func TestGetTargetDiskInfo_WithListErr(t *testing.T) {
+	const testDiskID = "disk1"
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	mock.EXPECT().List(ctx, nil).Return(nil, fmt.Errorf("error"))
+
+	di, err := getTargetDiskInfo(ctx, mock, testDiskID)
+
+	assert.Error(t, err, "should fail to get partition information")
+	assert.Nil(t, di)
+}
+
+func TestGetTargetDiskInfo_NoTargetDisk(t *testing.T) {
+	const (
+		testDiskID     = "disk1"
+		testAllDisksID = "disk0"
+	)
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts := types.SystemPartitions{
+		AllDisks: []string{testAllDisksID},
+	}
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	mock.EXPECT().List(ctx, nil).Return(&parts, nil)
+
+	di, err := getTargetDiskInfo(ctx, mock, testDiskID)
+
+	assert.Error(t, err, "should fail to find targetDiskID in partition information")
+	assert.Nil(t, di, "should get nil data for invalid target disk")
+}
+
+func TestGetTargetDiskInfo_WithInfoErr(t *testing.T) {
+	const testDiskID = "disk1"
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts := types.SystemPartitions{
+		AllDisks: []string{testDiskID},
+	}
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mock.EXPECT().Info(ctx, testDiskID).Return(nil, fmt.Errorf("error")),
+		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
+	)
+
+	di, err := getTargetDiskInfo(ctx, mock, testDiskID)
+
+	var disappearedErr DiskDisappearedError
+	assert.Error(t, err, "should fail to get disk information")
+	assert.False(t, errors.As(err, &disappearedErr), "shouldn't treat disk that's still listed as disappeared")
+	assert.Nil(t, di, "should get nil data with info error")
+}
+
+func TestGetTargetDiskInfo_WithDisappearedDisk(t *testing.T) {
+	const testDiskID = "disk2"
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	before := types.SystemPartitions{
+		AllDisks: []string{"disk1", testDiskID},
+	}
+	after := types.SystemPartitions{
+		AllDisks: []string{"disk1"},
+	}
+	infoErr := fmt.Errorf("error")
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mock.EXPECT().List(ctx, nil).Return(&before, nil),
+		mock.EXPECT().Info(ctx, testDiskID).Return(nil, infoErr),
+		mock.EXPECT().List(ctx, nil).Return(&after, nil),
+	)
+
+	di, err := getTargetDiskInfo(ctx, mock, testDiskID)
+
+	var disappearedErr DiskDisappearedError
+	assert.True(t, errors.As(err, &disappearedErr), "should get DiskDisappearedError when disk is no longer listed")
+	assert.True(t, errors.Is(err, infoErr), "should wrap the info error")
+	assert.Nil(t, di, "should get nil data for disappeared disk")
+}
+
+func TestGetTargetDiskInfo_WithInfoAndRelistErr(t *testing.T) {
+	const testDiskID = "disk1"
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts := types.SystemPartitions{
+		AllDisks: []string{testDiskID},
+	}
+	infoErr := fmt.Errorf("error")
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mock.EXPECT().Info(ctx, testDiskID).Return(nil, infoErr),
+		mock.EXPECT().List(ctx, nil).Return(nil, fmt.Errorf("list error")),
+	)
+
+	di, err := getTargetDiskInfo(ctx, mock, testDiskID)
+
+	assert.Equal(t, infoErr, err, "should get the info error when the disk can't be confirmed")
+	assert.Nil(t, di, "should get nil data with info error")
+}
+
+func TestGetTargetDiskInfo_Success(t *testing.T) {
+	const testDiskID = "disk1"
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts := types.SystemPartitions{
+		AllDisks: []string{testDiskID},
+	}
+
+	expectedDisk := &types.DiskInfo{}
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mock.EXPECT().Info(ctx, testDiskID).Return(expectedDisk, nil),
+	)
+
+	actualDisk, err := getTargetDiskInfo(ctx, mock, testDiskID)
+
+	assert.NoError(t, err, "should be able succeeded with valid info")
+	assert.Equal(t, expectedDisk, actualDisk, "should be able to get expected data from info")
+}
+
+func TestValidateDeviceID(t *testing.T) {
+	type args struct {
+		id         string
+		partitions *types.SystemPartitions
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantValid bool
+		wantErr   bool
+	}{
+		{
+			name: "without ID",
+			args: args{
+				id:         "",
+				partitions: nil,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid ID prefix",
+			args: args{
+				id:         "bad",
+				partitions: nil,
+			},
+			wantErr: true,
+		},
+		{
+			name: "without disk number",
+			args: args{
+				id:         "disk",
+				partitions: nil,
+			},
+			wantErr: true,
+		},
+		{
+			name: "no target disk",
+			args: args{
+				id: "disk2",
+				partitions: &types.SystemPartitions{
+					AllDisks: []string{"disk0", "disk1"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "success",
+			args: args{
+				id: "disk0",
+				partitions: &types.SystemPartitions{
+					AllDisks: []string{"disk0", "disk1"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "slice present",
+			args: args{
+				id:         "/dev/disk2s1",
+				partitions: sliceFixture(),
+			},
+			wantErr: false,
+		},
+		{
+			name: "slice absent",
+			args: args{
+				id:         "disk2s2",
+				partitions: sliceFixture(),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDeviceID(tt.args.id, tt.args.partitions)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// sliceFixture creates system partitions with a whole disk, disk2, holding a single slice, disk2s1.
+func sliceFixture() *types.SystemPartitions {
+	return &types.SystemPartitions{
+		AllDisks: []string{"disk0", "disk2"},
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: "disk2",
+				Partitions:       []types.Partition{{DeviceIdentifier: "disk2s1"}},
+			},
+		},
+	}
+}
+
+func TestValidateDeviceID_WithMissingSlice(t *testing.T) {
+	err := validateDeviceID("disk2s2", sliceFixture())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "slice disk2s2 not found on disk disk2", "should report the missing slice")
+	}
+
+	err = validateDeviceID("disk3s1", sliceFixture())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "whole disk disk3 not found", "should report the missing whole disk")
+	}
+}
+
+func TestRunIDs_WithGlob(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	container := func(id string) types.DiskPart {
+		return types.DiskPart{
+			APFSVolumes:      []types.APFSVolume{{DeviceIdentifier: id + "s1"}, {DeviceIdentifier: id + "s2"}},
+			DeviceIdentifier: id,
+		}
+	}
+	parts := types.SystemPartitions{
+		AllDisks: []string{"disk0", "disk0s1", "disk0s2", "disk1", "disk1s1", "disk1s2", "disk10", "disk10s1",
+			"disk10s2", "disk2"},
+		AllDisksAndPartitions: []types.DiskPart{
+			{DeviceIdentifier: "disk0", Partitions: []types.Partition{{DeviceIdentifier: "disk0s1"}, {DeviceIdentifier: "disk0s2"}}},
+			container("disk1"),
+			container("disk10"),
+			{DeviceIdentifier: "disk2"},
+		},
+	}
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mock.EXPECT().Info(ctx, "disk1").Return(nil, fmt.Errorf("error")),
+		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mock.EXPECT().Info(ctx, "disk10").Return(nil, fmt.Errorf("error")),
+		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
+	)
+
+	err := runIDs(ctx, mock, growContainer{
+		id: "disk1*",
+	})
+
+	assert.Error(t, err, "should fail when the ids can't be grown")
+	assert.Contains(t, err.Error(), "failed to grow 2 of 2 containers: disk1, disk10", "should attempt every matched container but not its slices")
+}
+
+func TestRunIDs_WithGlobWithoutMatches(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts := types.SystemPartitions{
+		AllDisks:              []string{"disk0", "disk2"},
+		AllDisksAndPartitions: []types.DiskPart{{DeviceIdentifier: "disk0"}, {DeviceIdentifier: "disk2"}},
+	}
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	mock.EXPECT().List(ctx, nil).Return(&parts, nil)
+
+	err := runIDs(ctx, mock, growContainer{
+		id: "disk1*",
+	})
+
+	assert.Error(t, err, "should fail when no disks match the glob")
+	assert.Contains(t, err.Error(), "no disks match", "should explain that nothing matched")
+}
+
+// allFixture returns a fake diskutil with APFS containers on disk1, which has 2,000,000 bytes of free space to grow
+// into, disk2, which has no free space, and the internal disk, disk0, which has no disk information.
+func allFixture() *diskutil.FakeUtil {
+	const partSize uint64 = 500_000
+
+	apfsDisk := func(id string) *types.DiskInfo {
+		return &types.DiskInfo{
+			APFSPhysicalStores: []types.APFSPhysicalStore{{DeviceIdentifier: id}},
+			ContainerInfo:      types.ContainerInfo{FilesystemType: "apfs"},
+			DeviceIdentifier:   id,
+			ParentWholeDisk:    id,
+			TotalSize:          partSize,
+			VirtualOrPhysical:  "Physical",
+		}
+	}
+	apfsPart := func(id string, size uint64) types.DiskPart {
+		return types.DiskPart{
+			APFSVolumes:      []types.APFSVolume{{DeviceIdentifier: id + "s1"}},
+			DeviceIdentifier: id,
+			Size:             size,
+			Partitions:       []types.Partition{{Size: partSize}, {Size: partSize}},
+		}
+	}
+
+	parts := &types.SystemPartitions{
+		AllDisks: []string{"disk0", "disk1", "disk2"},
+		AllDisksAndPartitions: []types.DiskPart{
+			apfsPart("disk0", 3_000_000),
+			apfsPart("disk1", 3_000_000),
+			apfsPart("disk2", 2*partSize),
+		},
+	}
+
+	return diskutil.NewFakeUtil(parts, map[string]*types.DiskInfo{
+		"disk1": apfsDisk("disk1"),
+		"disk2": apfsDisk("disk2"),
+	})
+}
+
+func TestRunIDs_WithAll(t *testing.T) {
+	fake := allFixture()
+
+	err := runIDs(context.Background(), fake, growContainer{id: allID})
+
+	assert.NoError(t, err, "shouldn't fail when a container has nothing to grow")
+	var resized []string
+	for _, call := range fake.Calls() {
+		if call.Method == "ResizeContainer" {
+			resized = append(resized, call.Args[0])
+		}
+		assert.NotEqual(t, []string{"disk0"}, call.Args, "shouldn't touch the internal disk")
+	}
+	assert.Equal(t, []string{"disk1"}, resized, "should only resize the growable container")
+}
+
+func TestContainerIDs(t *testing.T) {
+	ids, err := containerIDs(context.Background(), allFixture(), false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"disk1", "disk2"}, ids, "should skip the internal disk")
+
+	ids, err = containerIDs(context.Background(), allFixture(), true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"disk0", "disk1", "disk2"}, ids, "should include the internal disk when forced")
+}
+
+func TestContainerIDs_WithoutContainers(t *testing.T) {
+	fake := diskutil.NewFakeUtil(&types.SystemPartitions{AllDisks: []string{"disk1"}}, nil)
+
+	_, err := containerIDs(context.Background(), fake, false)
+
+	assert.Error(t, err, "should fail without APFS containers")
+}
+
+func TestOnInternalDisk(t *testing.T) {
+	tests := []struct {
+		name      string
+		container types.DiskPart
+		want      bool
+	}{
+		{name: "physical container", container: types.DiskPart{DeviceIdentifier: "disk0"}, want: true},
+		{
+			name: "synthesized container",
+			container: types.DiskPart{
+				DeviceIdentifier:   "disk3",
+				APFSPhysicalStores: []types.APFSPhysicalStoreID{{DeviceIdentifier: "disk0s2"}},
+			},
+			want: true,
+		},
+		{
+			name: "external container",
+			container: types.DiskPart{
+				DeviceIdentifier:   "disk5",
+				APFSPhysicalStores: []types.APFSPhysicalStoreID{{DeviceIdentifier: "disk4s2"}},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, onInternalDisk(tt.container), "should match expected internal disk check")
+		})
+	}
+}
+
+func TestIsIDGlob(t *testing.T) {
+	assert.True(t, isIDGlob("disk1*"), "should be a glob with a wildcard")
+	assert.False(t, isIDGlob("disk1"), "shouldn't be a glob without a wildcard")
+	assert.False(t, isIDGlob(stdinID), "stdin shouldn't be a glob")
+}
+
+func TestRun_WithSizeOverFreeSpace(t *testing.T) {
+	const (
+		testDiskID        = "disk1"
+		diskSize   uint64 = 3_000_000
+		partSize   uint64 = 500_000
+	)
+
+	parts := &types.SystemPartitions{
+		AllDisks: []string{testDiskID},
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             diskSize,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
+
+	disk := &types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		TotalSize:         partSize,
+		VirtualOrPhysical: "Physical",
+	}
+
+	fake := diskutil.NewFakeUtil(parts, map[string]*types.DiskInfo{testDiskID: disk})
+
+	err := run(context.Background(), fake, growContainer{
+		id:         testDiskID,
+		targetSize: 5_000_000,
+	})
+
+	var freeSpaceErr diskutil.FreeSpaceError
+	assert.Error(t, err, "should fail when the requested size can't be met")
+	assert.True(t, errors.As(err, &freeSpaceErr), "should fail with a FreeSpaceError")
+	for _, call := range fake.Calls() {
+		assert.NotEqual(t, "ResizeContainer", call.Method, "shouldn't resize the container")
+	}
+}
+
+func TestParseTargetSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     string
+		expected uint64
+		wantErr  bool
+	}{
+		{name: "gigabytes", size: "120g", expected: 120_000_000_000},
+		{name: "fractional terabytes", size: "1.5t", expected: 1_500_000_000_000},
+		{name: "gibibytes", size: "1gib", expected: 1 << 30},
+		{name: "zero", size: "0", wantErr: true},
+		{name: "invalid", size: "big", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := parseTargetSize(tt.size)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestGrowMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		res      diskutil.GrowResult
+		args     growContainer
+		expected string
+	}{
+		{name: "max size", res: diskutil.GrowResult{Resized: true}, expected: "Successfully grew device to maximum size"},
+		{name: "target size", res: diskutil.GrowResult{Resized: true}, args: growContainer{targetSize: 100}, expected: "Successfully grew device to the requested size"},
+		{name: "capped growth", res: diskutil.GrowResult{Resized: true, FreeSpace: 200}, args: growContainer{maxGrowBytes: 100}, expected: "Successfully grew device by the maximum allowed growth"},
+		{name: "uncapped growth", res: diskutil.GrowResult{Resized: true, FreeSpace: 50}, args: growContainer{maxGrowBytes: 100}, expected: "Successfully grew device to maximum size"},
+		{name: "no-op", res: diskutil.GrowResult{}, expected: "Device already at maximum size, nothing was changed"},
+		{name: "no-op target size", res: diskutil.GrowResult{}, args: growContainer{targetSize: 100}, expected: "Device already at the requested size, nothing was changed"},
+		{name: "dry run", res: diskutil.GrowResult{}, args: growContainer{dryrun: true}, expected: "Would grow device, nothing was changed in dry run"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, growMessage(tt.res, tt.args))
+		})
+	}
+}
+
+func TestParseMinFree(t *testing.T) {
+	tests := []struct {
+		name     string
+		minFree  int64
+		expected uint64
+		wantErr  bool
+	}{
+		{name: "default", minFree: diskutil.DefaultMinFreeSpace, expected: diskutil.DefaultMinFreeSpace},
+		{name: "disabled", minFree: 0, expected: 0},
+		{name: "negative", minFree: -1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := parseMinFree(tt.minFree)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			if assert.NotNil(t, actual) {
+				assert.Equal(t, tt.expected, *actual)
+			}
+		})
+	}
+}
+
+func TestGrowOptions_WithoutMinFree(t *testing.T) {
+	opts := growContainer{}.growOptions()
+
+	assert.Nil(t, opts.MinFreeSpace, "should use the default minimum when --min-free isn't parsed")
+}
+
+func TestGrowOptions_WithForce(t *testing.T) {
+	opts := growContainer{force: true}.growOptions()
+
+	assert.True(t, opts.Force, "should force the resize with --force")
+}
+
+func TestGrowOptions_WithRepairStrategy(t *testing.T) {
+	opts := growContainer{repairTarget: diskutil.RepairContainerVolume}.growOptions()
+
+	assert.Equal(t, diskutil.RepairContainerVolume, opts.RepairStrategy, "should repair with the parsed --repair-strategy")
+}
+
+func TestGrowOptions_WithResizeOptions(t *testing.T) {
+	opts := growContainer{}.growOptions()
+	assert.Empty(t, opts.ResizeOptions, "shouldn't pass resize options by default")
+
+	opts = growContainer{noEFIUpdate: true, dontAutoMount: true}.growOptions()
+	assert.Equal(t, []types.ResizeOption{types.ResizeNoEFIUpdate, types.ResizeDontAutoMount}, opts.ResizeOptions,
+		"should pass --no-efi-update and --dont-auto-mount to the resize")
+}
+
+// waitFixture creates a physical APFS disk whose partitions leave the given amount of free space.
+func waitFixture(free uint64) (*types.SystemPartitions, *types.DiskInfo) {
+	const (
+		testDiskID        = "disk1"
+		partSize   uint64 = 500_000
+	)
+
+	parts := &types.SystemPartitions{
+		AllDisks: []string{testDiskID},
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             2*partSize + free,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
+
+	disk := &types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		TotalSize:         partSize,
+		VirtualOrPhysical: "Physical",
+	}
+
+	return parts, disk
+}
+
+func TestGrowWithWait_SpaceAppears(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	insufficient, disk := waitFixture(0)
+	sufficient, _ := waitFixture(2_000_000)
+
+	// The first grow and poll don't find enough free space, the second poll does
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mock.EXPECT().RepairDisk(ctx, "disk1").Return("", nil),
+		mock.EXPECT().List(ctx, nil).Return(insufficient, nil),
+		mock.EXPECT().RepairDisk(ctx, "disk1").Return("", nil),
+		mock.EXPECT().List(ctx, nil).Return(insufficient, nil),
+		mock.EXPECT().RepairDisk(ctx, "disk1").Return("", nil),
+		mock.EXPECT().List(ctx, nil).Return(sufficient, nil),
+		mock.EXPECT().ResizeContainer(ctx, "disk1", "0").Return("", nil),
+	)
+
+	res, err := growWithWait(ctx, mock, disk, growContainer{wait: time.Minute}, time.Millisecond)
+
+	assert.NoError(t, err, "should grow once enough free space appears")
+	assert.True(t, res.Resized, "should resize the container")
+	assert.Equal(t, uint64(2_000_000), res.FreeSpace, "should report the free space which appeared")
+}
+
+func TestGrowWithWait_Expires(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	insufficient, disk := waitFixture(0)
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	mock.EXPECT().RepairDisk(ctx, "disk1").Return("", nil).MinTimes(1)
+	mock.EXPECT().List(ctx, nil).Return(insufficient, nil).MinTimes(1)
+
+	_, err := growWithWait(ctx, mock, disk, growContainer{wait: 20 * time.Millisecond}, time.Millisecond)
+
+	var freeSpaceErr diskutil.FreeSpaceError
+	assert.True(t, errors.As(err, &freeSpaceErr), "should return the FreeSpaceError once the wait expires")
+}
+
+func TestGrowWithWait_WithCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	insufficient, disk := waitFixture(0)
+
+	// Only the first grow is attempted since the context is already done
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mock.EXPECT().RepairDisk(ctx, "disk1").Return("", nil),
+		mock.EXPECT().List(ctx, nil).Return(insufficient, nil),
+	)
+
+	_, err := growWithWait(ctx, mock, disk, growContainer{wait: time.Hour}, time.Hour)
+
+	var freeSpaceErr diskutil.FreeSpaceError
+	assert.True(t, errors.As(err, &freeSpaceErr), "should return the FreeSpaceError when the context is done")
+}
+
+func TestGrowWithWait_WithoutWait(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	insufficient, disk := waitFixture(0)
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mock.EXPECT().RepairDisk(ctx, "disk1").Return("", nil),
+		mock.EXPECT().List(ctx, nil).Return(insufficient, nil),
+	)
+
+	_, err := growWithWait(ctx, mock, disk, growContainer{}, time.Millisecond)
+
+	var freeSpaceErr diskutil.FreeSpaceError
+	assert.True(t, errors.As(err, &freeSpaceErr), "should only try once without --wait")
+}
+
+func TestRunAndNotify_WithDryrunPlannedCommands(t *testing.T) {
+	var out bytes.Buffer
+	du := diskutil.Dryrun(notifyGrowFixture())
+
+	err := runAndNotify(context.Background(), du, growContainer{
+		id:     "disk1",
+		dryrun: true,
+		output: outputTable,
+		out:    &out,
+	})
+
+	assert.NoError(t, err, "should be able to dry run the grow")
+	assert.Equal(t, [][]string{
+		{"diskutil", "repairDisk", "disk1"},
+		{"diskutil", "apfs", "resizeContainer", "disk1", "0"},
+	}, du.PlannedCommands(), "should record the repair and resize which would have run")
+}
+
+func TestWritePlannedCommands(t *testing.T) {
+	var out bytes.Buffer
+
+	writePlannedCommands(&out, [][]string{
+		{"diskutil", "repairDisk", "disk0"},
+		{"diskutil", "apfs", "addVolume", "disk5", "APFS", "Macintosh HD"},
+	})
+
+	assert.Equal(t, "Commands which would be run:\n"+
+		"  diskutil repairDisk disk0\n"+
+		"  diskutil apfs addVolume disk5 APFS \"Macintosh HD\"\n", out.String(), "should write each command, quoting arguments with spaces")
+
+	out.Reset()
+	writePlannedCommands(&out, nil)
+	assert.Equal(t, "No commands would be run\n", out.String(), "should say when nothing would be run")
+}
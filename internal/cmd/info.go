@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+)
+
+// infoCommand creates a new command which displays information about a single disk.
+func infoCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "info",
+		Short: "display information about a disk",
+		Long: strings.TrimSpace(`
+info displays information about the disk, partition, container,
+or volume with the given identifier (e.g. disk1 or /dev/disk1)
+as reported by 'diskutil'. The string 'root' (or the path '/')
+may be provided for the OS's root volume. The displayed columns
+can be selected with --columns (e.g. id,size,free,fs).
+With --output json, every column is written as JSON instead.
+		`),
+	}
+
+	// Set up the flags to be passed into the command
+	var id, columns string
+	cmd.PersistentFlags().StringVar(&id, "id", "", `disk identifier, "root", or "/"`)
+	cmd.PersistentFlags().StringVar(&columns, "columns", defaultColumns, "comma-separated columns to display, any of: "+strings.Join(tableColumnNames, ","))
+	cmd.MarkPersistentFlagRequired("id")
+
+	// Set up the command's run function
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		output, err := outputFlag(cmd)
+		if err != nil {
+			return err
+		}
+		selected, err := parseColumns(columns)
+		if err != nil {
+			return err
+		}
+
+		disk, err := diskInfo(cmd.Context(), id)
+		if err != nil {
+			return writeOutputError(cmd.OutOrStdout(), output, err)
+		}
+
+		if output == outputJSON {
+			return writeJSON(cmd.OutOrStdout(), newInfoOutput(disk))
+		}
+
+		return renderTable(cmd.OutOrStdout(), selected, []diskRow{diskInfoRow(disk)})
+	}
+
+	return cmd
+}
+
+// diskInfo fetches the disk information for the identifier with diskutil for the product in the context. The string
+// "root" is resolved to the OS's root volume.
+func diskInfo(ctx context.Context, id string) (*types.DiskInfo, error) {
+	product, err := requireProduct(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.WithField("product", product).Debug("Configuring diskutil for product")
+	d, err := diskutil.ForProduct(product)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold("root", id) {
+		return d.Info(ctx, "/")
+	}
+
+	return d.Info(ctx, id)
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+)
+
+// listCommand creates a new command which lists the system's disks, partitions, and APFS volumes.
+func listCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list disks, partitions, and volumes",
+		Long: strings.TrimSpace(`
+list displays the system's disks, partitions, and APFS
+volumes as reported by 'diskutil'. The displayed columns
+can be selected with --columns (e.g. id,size,free,fs).
+With --output json, every column is written as JSON instead.
+		`),
+	}
+
+	// Set up the flags to be passed into the command
+	var columns string
+	cmd.PersistentFlags().StringVar(&columns, "columns", defaultColumns, "comma-separated columns to display, any of: "+strings.Join(tableColumnNames, ","))
+
+	// Set up the command's run function
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		output, err := outputFlag(cmd)
+		if err != nil {
+			return err
+		}
+		selected, err := parseColumns(columns)
+		if err != nil {
+			return err
+		}
+
+		partitions, err := listPartitions(cmd.Context())
+		if err != nil {
+			return writeOutputError(cmd.OutOrStdout(), output, err)
+		}
+
+		if output == outputJSON {
+			return writeJSON(cmd.OutOrStdout(), newListOutput(partitions))
+		}
+
+		return renderTable(cmd.OutOrStdout(), selected, partitionRows(partitions))
+	}
+
+	return cmd
+}
+
+// listPartitions lists the system partitions with diskutil for the product in the context.
+func listPartitions(ctx context.Context) (*types.SystemPartitions, error) {
+	product, err := requireProduct(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.WithField("product", product).Debug("Configuring diskutil for product")
+	d, err := diskutil.ForProduct(product)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.List(ctx, nil)
+}
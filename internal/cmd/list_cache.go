@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+)
+
+// listCache wraps a DiskUtil to reuse the listed partitions, saving repeated 'diskutil list' executions within a
+// single grow. Only unfiltered lists (without args) are cached. Each mutating call which isn't skipped in a dry run
+// (see diskutil.ErrReadOnly) forgets the cached partitions since they may have changed (e.g. a repair picking up a
+// resized disk) so they're listed again.
+type listCache struct {
+	diskutil.DiskUtil
+
+	partitions *types.SystemPartitions
+}
+
+// newListCache creates a new listCache for the DiskUtil.
+func newListCache(u diskutil.DiskUtil) *listCache {
+	return &listCache{DiskUtil: u}
+}
+
+// List provides the cached partitions if there are any. Otherwise, the partitions are listed and cached.
+func (c *listCache) List(ctx context.Context, args []string) (*types.SystemPartitions, error) {
+	if len(args) > 0 {
+		return c.DiskUtil.List(ctx, args)
+	}
+
+	if c.partitions != nil {
+		logrus.Trace("Reusing listed partitions")
+		return c.partitions, nil
+	}
+
+	partitions, err := c.DiskUtil.List(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.partitions = partitions
+
+	return partitions, nil
+}
+
+// forget forgets the cached partitions so they're listed again.
+func (c *listCache) forget() {
+	c.partitions = nil
+}
+
+// mutated forgets the cached partitions after a mutating call unless it was skipped in a dry run.
+func (c *listCache) mutated(err error) {
+	if !errors.Is(err, diskutil.ErrReadOnly) {
+		c.forget()
+	}
+}
+
+func (c *listCache) RepairDisk(ctx context.Context, id string) (string, error) {
+	out, err := c.DiskUtil.RepairDisk(ctx, id)
+	c.mutated(err)
+
+	return out, err
+}
+
+func (c *listCache) RepairVolume(ctx context.Context, id string) (string, error) {
+	out, err := c.DiskUtil.RepairVolume(ctx, id)
+	c.mutated(err)
+
+	return out, err
+}
+
+func (c *listCache) ResizeContainer(ctx context.Context, id string, size string, opts ...types.ResizeOption) (string, error) {
+	out, err := c.DiskUtil.ResizeContainer(ctx, id, size, opts...)
+	c.mutated(err)
+
+	return out, err
+}
+
+func (c *listCache) Mount(ctx context.Context, id string) (string, error) {
+	out, err := c.DiskUtil.Mount(ctx, id)
+	c.mutated(err)
+
+	return out, err
+}
+
+func (c *listCache) Unmount(ctx context.Context, id string, force bool) (string, error) {
+	out, err := c.DiskUtil.Unmount(ctx, id, force)
+	c.mutated(err)
+
+	return out, err
+}
+
+func (c *listCache) EraseVolume(ctx context.Context, id string, format string, name string) (string, error) {
+	out, err := c.DiskUtil.EraseVolume(ctx, id, format, name)
+	c.mutated(err)
+
+	return out, err
+}
+
+func (c *listCache) RenameVolume(ctx context.Context, id string, name string) (string, error) {
+	out, err := c.DiskUtil.RenameVolume(ctx, id, name)
+	c.mutated(err)
+
+	return out, err
+}
+
+func (c *listCache) DeleteSnapshot(ctx context.Context, volumeID string, uuid string) (string, error) {
+	out, err := c.DiskUtil.DeleteSnapshot(ctx, volumeID, uuid)
+	c.mutated(err)
+
+	return out, err
+}
+
+func (c *listCache) AddVolume(ctx context.Context, containerID string, name string, format string) (string, error) {
+	out, err := c.DiskUtil.AddVolume(ctx, containerID, name, format)
+	c.mutated(err)
+
+	return out, err
+}
+
+func (c *listCache) UnlockVolume(ctx context.Context, id string, passphrase string) (string, error) {
+	out, err := c.DiskUtil.UnlockVolume(ctx, id, passphrase)
+	c.mutated(err)
+
+	return out, err
+}
+
+// Type assertion to ensure listCache implements the DiskUtil interface.
+var _ diskutil.DiskUtil = (*listCache)(nil)
+
+// forgetList forgets the partitions cached by the DiskUtil, if it's a listCache, so they're listed again.
+func forgetList(u diskutil.DiskUtil) {
+	if c, ok := u.(*listCache); ok {
+		c.forget()
+	}
+}
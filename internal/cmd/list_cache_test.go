@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	mock_diskutil "github.com/aws/ec2-macos-utils/internal/diskutil/mocks"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// countCalls counts the calls made to the FakeUtil's method.
+func countCalls(fake *diskutil.FakeUtil, method string) int {
+	var n int
+	for _, call := range fake.Calls() {
+		if call.Method == method {
+			n++
+		}
+	}
+
+	return n
+}
+
+func TestListCache(t *testing.T) {
+	ctx := context.Background()
+	fake := diskutil.NewFakeUtil(&types.SystemPartitions{AllDisks: []string{"disk1"}}, nil)
+	cache := newListCache(fake)
+
+	first, err := cache.List(ctx, nil)
+	assert.NoError(t, err)
+	second, err := cache.List(ctx, nil)
+	assert.NoError(t, err)
+
+	assert.True(t, first == second, "should reuse the listed partitions")
+	assert.Equal(t, 1, countCalls(fake, "List"), "should only list the partitions once")
+}
+
+func TestListCache_WithArgs(t *testing.T) {
+	ctx := context.Background()
+	fake := diskutil.NewFakeUtil(&types.SystemPartitions{AllDisks: []string{"disk1"}}, nil)
+	cache := newListCache(fake)
+
+	_, _ = cache.List(ctx, nil)
+	_, _ = cache.List(ctx, []string{"external"})
+
+	assert.Equal(t, 2, countCalls(fake, "List"), "shouldn't reuse the partitions for a filtered list")
+}
+
+func TestListCache_WithMutation(t *testing.T) {
+	ctx := context.Background()
+	fake := diskutil.NewFakeUtil(&types.SystemPartitions{AllDisks: []string{"disk1"}}, nil)
+	cache := newListCache(fake)
+
+	_, _ = cache.List(ctx, nil)
+	_, _ = cache.RepairDisk(ctx, "disk0")
+	_, _ = cache.List(ctx, nil)
+	_, _ = cache.ResizeContainer(ctx, "disk0", "0")
+	_, _ = cache.List(ctx, nil)
+	_, _ = cache.RepairVolume(ctx, "disk1")
+	_, _ = cache.List(ctx, nil)
+
+	assert.Equal(t, 4, countCalls(fake, "List"), "should list the partitions again after each mutation")
+}
+
+func TestListCache_WithDryrun(t *testing.T) {
+	ctx := context.Background()
+	fake := diskutil.NewFakeUtil(&types.SystemPartitions{AllDisks: []string{"disk1"}}, nil)
+	cache := newListCache(diskutil.Dryrun(fake))
+
+	_, _ = cache.List(ctx, nil)
+	_, _ = cache.RepairDisk(ctx, "disk0")
+	_, _ = cache.ResizeContainer(ctx, "disk0", "0")
+	_, _ = cache.List(ctx, nil)
+
+	assert.Equal(t, 1, countCalls(fake, "List"), "should reuse the partitions when mutations are skipped")
+}
+
+func TestListCache_Forget(t *testing.T) {
+	ctx := context.Background()
+	fake := diskutil.NewFakeUtil(&types.SystemPartitions{AllDisks: []string{"disk1"}}, nil)
+	cache := newListCache(fake)
+
+	_, _ = cache.List(ctx, nil)
+	forgetList(cache)
+	_, _ = cache.List(ctx, nil)
+
+	assert.Equal(t, 2, countCalls(fake, "List"), "should list the partitions again once forgotten")
+}
+
+func TestRun_ListsOncePerMutation(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts, disk := waitFixture(2_000_000)
+
+	// The partitions validating the target are reused until the repair, and those listed after the resize are reused
+	// for the report
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	mock.EXPECT().List(ctx, nil).Return(parts, nil).Times(3)
+	mock.EXPECT().Info(ctx, "disk1").Return(disk, nil).Times(2)
+	mock.EXPECT().RepairDisk(ctx, "disk1").Return("", nil)
+	mock.EXPECT().ResizeContainer(ctx, "disk1", "0").Return("", nil)
+
+	err := run(ctx, mock, growContainer{
+		id:     "disk1",
+		report: filepath.Join(t.TempDir(), "report.json"),
+	})
+
+	assert.NoError(t, err, "should be able to grow container and write report")
+}
+
+func TestRun_ListsOnceWithDryrun(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts, disk := waitFixture(2_000_000)
+
+	// Nothing is mutated in a dry run so the partitions are only listed once
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	mock.EXPECT().List(ctx, nil).Return(parts, nil).Times(1)
+	mock.EXPECT().Info(ctx, "disk1").Return(disk, nil).AnyTimes()
+	mock.EXPECT().ResizeLimits(ctx, "disk1").Return(nil, diskutil.ErrReadOnly).AnyTimes()
+
+	err := run(ctx, diskutil.Dryrun(mock), growContainer{
+		id:     "disk1",
+		dryrun: true,
+		report: filepath.Join(t.TempDir(), "report.json"),
+	})
+
+	assert.NoError(t, err, "should be able to preview the grow and write report")
+}
+
+func TestGrowWithWait_ListsEachPoll(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	insufficient, disk := waitFixture(0)
+	sufficient, _ := waitFixture(2_000_000)
+
+	// Even in a dry run, the free space is listed again for each poll
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mock.EXPECT().List(ctx, nil).Return(insufficient, nil),
+		mock.EXPECT().List(ctx, nil).Return(sufficient, nil),
+	)
+	mock.EXPECT().ResizeLimits(ctx, "disk1").Return(nil, diskutil.ErrReadOnly).AnyTimes()
+
+	_, err := growWithWait(ctx, newListCache(diskutil.Dryrun(mock)), disk, growContainer{wait: time.Minute}, time.Millisecond)
+
+	assert.NoError(t, err, "should grow once enough free space appears")
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+	"github.com/aws/ec2-macos-utils/internal/imds"
+)
+
+// imdsTimeout is the maximum duration of a single instance metadata request. IMDS is link-local so it either responds
+// quickly or not at all (e.g. when it's disabled).
+const imdsTimeout = 2 * time.Second
+
+// logInstanceMetadata looks up the instance's metadata from IMDS and logs it alongside the disk's size so that users
+// can tell whether the EBS volume was resized when there isn't enough free space to grow into. IMDS doesn't report
+// volume sizes so the block device mappings identify which volumes to check. Lookups are best-effort: failures are
+// logged at debug level and a nil client skips the lookup entirely.
+func logInstanceMetadata(ctx context.Context, client *imds.Client, disk *types.DiskInfo, freeSpace uint64) {
+	if client == nil {
+		return
+	}
+
+	instanceID, err := client.InstanceID(ctx)
+	if err != nil {
+		logrus.WithError(err).Debug("Unable to look up instance metadata, continuing without it")
+		return
+	}
+
+	fields := logrus.Fields{
+		"instance_id": instanceID,
+		"device_id":   disk.DeviceIdentifier,
+		"disk_size":   humanize.Bytes(disk.TotalSize),
+		"free_space":  humanize.Bytes(freeSpace),
+	}
+	if region, err := client.Region(ctx); err != nil {
+		logrus.WithError(err).Debug("Unable to look up instance region")
+	} else {
+		fields["region"] = region
+	}
+	if mappings, err := client.BlockDeviceMappings(ctx); err != nil {
+		logrus.WithError(err).Debug("Unable to look up instance block device mappings")
+	} else {
+		fields["block_device_mappings"] = mappings
+	}
+
+	logrus.WithFields(fields).Info("Check that the instance's EBS volume was resized (e.g. with aws ec2 describe-volumes)")
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+	"github.com/aws/ec2-macos-utils/internal/imds"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogInstanceMetadata(t *testing.T) {
+	metadata := map[string]string{
+		"/latest/meta-data/instance-id":               "i-0123456789abcdef0",
+		"/latest/meta-data/placement/region":          "us-west-2",
+		"/latest/meta-data/block-device-mapping/":     "root",
+		"/latest/meta-data/block-device-mapping/root": "/dev/sda1",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			w.Write([]byte("token"))
+			return
+		}
+		value, ok := metadata[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(value))
+	}))
+	defer server.Close()
+
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	disk := &types.DiskInfo{DeviceIdentifier: "disk1", TotalSize: 100_000_000_000}
+	logInstanceMetadata(context.Background(), imds.NewWithEndpoint(server.URL, server.Client()), disk, 0)
+
+	entry := hook.LastEntry()
+	if !assert.NotNil(t, entry, "should log the instance metadata") {
+		return
+	}
+	assert.Equal(t, logrus.InfoLevel, entry.Level)
+	assert.Equal(t, "i-0123456789abcdef0", entry.Data["instance_id"])
+	assert.Equal(t, "us-west-2", entry.Data["region"])
+	assert.Equal(t, map[string]string{"root": "/dev/sda1"}, entry.Data["block_device_mappings"])
+	assert.Equal(t, "100 GB", entry.Data["disk_size"], "should log the disk's size alongside the metadata")
+}
+
+func TestLogInstanceMetadata_WithUnreachableIMDS(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	endpoint := server.URL
+	server.Close()
+
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	logInstanceMetadata(context.Background(), imds.NewWithEndpoint(endpoint, nil), &types.DiskInfo{DeviceIdentifier: "disk1"}, 0)
+
+	for _, entry := range hook.AllEntries() {
+		assert.Equal(t, logrus.DebugLevel, entry.Level, "should only log unreachable IMDS at debug level")
+	}
+}
+
+func TestLogInstanceMetadata_WithoutClient(t *testing.T) {
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	logInstanceMetadata(context.Background(), nil, &types.DiskInfo{DeviceIdentifier: "disk1"}, 0)
+
+	assert.Empty(t, hook.AllEntries(), "shouldn't look up metadata without a client")
+}
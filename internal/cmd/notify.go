@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// notifyTimeout is the maximum duration of a single notification request.
+const notifyTimeout = 30 * time.Second
+
+// growSizes are the sizes (in bytes) of a container before and after it was grown.
+type growSizes struct {
+	// old is the size before the grow.
+	old uint64
+	// new is the size after the grow or, in dry-run, the size it would have been grown to.
+	new uint64
+	// resized is true when the container was resized.
+	resized bool
+}
+
+// growResult is the structured result of growing a single container which is sent to the --notify-url and written
+// with --output json.
+type growResult struct {
+	// SchemaVersion is the version of the result's shape (see schemaVersion).
+	SchemaVersion int `json:"schemaVersion"`
+	// DeviceID is the identifier provided to the grow command.
+	DeviceID string `json:"deviceId"`
+	// DryRun is true when the grow didn't make any mutating changes.
+	DryRun bool `json:"dryRun"`
+	// Succeeded is true when the grow completed without error.
+	Succeeded bool `json:"succeeded"`
+	// Resized is true when the container was resized. It's always false in dry-run.
+	Resized bool `json:"resized"`
+	// OldSize is the size (in bytes) of the container before the grow.
+	OldSize uint64 `json:"oldSize"`
+	// NewSize is the size (in bytes) of the container after the grow or, in dry-run, the size it would have been
+	// grown to.
+	NewSize uint64 `json:"newSize"`
+	// BytesGained is the number of bytes the container grew (or would have grown) by.
+	BytesGained uint64 `json:"bytesGained"`
+	// Error describes why the grow failed, if it did.
+	Error string `json:"error,omitempty"`
+	// CompletedAt is the time the grow completed.
+	CompletedAt time.Time `json:"completedAt"`
+}
+
+// newGrowResult creates the result of growing the container with the given identifier from its sizes which failed
+// with err, if not nil.
+func newGrowResult(id string, dryrun bool, sizes growSizes, err error) growResult {
+	result := growResult{
+		SchemaVersion: schemaVersion,
+		DeviceID:      id,
+		DryRun:        dryrun,
+		Succeeded:     err == nil,
+		Resized:       sizes.resized,
+		OldSize:       sizes.old,
+		NewSize:       sizes.new,
+		CompletedAt:   time.Now().UTC(),
+	}
+	if sizes.new > sizes.old {
+		result.BytesGained = sizes.new - sizes.old
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// parseNotifyURL parses and validates a --notify-url. Only file:// and https:// URLs are supported.
+func parseNotifyURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notify url: %w", err)
+	}
+
+	switch u.Scheme {
+	case "file":
+		if u.Path == "" {
+			return nil, errors.New("invalid notify url: file url requires a path")
+		}
+	case "https":
+		if u.Host == "" {
+			return nil, errors.New("invalid notify url: https url requires a host")
+		}
+	default:
+		return nil, fmt.Errorf("invalid notify url: unsupported scheme %q, must be file or https", u.Scheme)
+	}
+
+	return u, nil
+}
+
+// notify sends the result as JSON to the URL: file:// URLs have the result written to the file while https:// URLs have
+// the result posted with client. Notifications are best-effort so failures are logged rather than returned.
+func notify(ctx context.Context, client *http.Client, u *url.URL, result growResult) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		logrus.WithError(err).Warn("Unable to encode grow result for notification")
+		return
+	}
+
+	switch u.Scheme {
+	case "file":
+		err = os.WriteFile(u.Path, data, 0644)
+	default:
+		err = postResult(ctx, client, u.String(), data)
+	}
+	if err != nil {
+		logrus.WithError(err).WithField("url", u.Redacted()).Warn("Unable to send grow result notification")
+		return
+	}
+
+	logrus.WithField("url", u.Redacted()).Debug("Sent grow result notification")
+}
+
+// postResult posts the JSON data to the URL with client.
+func postResult(ctx context.Context, client *http.Client, rawURL string, data []byte) error {
+	if client == nil {
+		return errors.New("no http client")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Drain the body so the connection can be reused
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	mock_diskutil "github.com/aws/ec2-macos-utils/internal/diskutil/mocks"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNotifyURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawURL  string
+		wantErr bool
+	}{
+		{name: "file", rawURL: "file:///var/log/grow.json"},
+		{name: "https", rawURL: "https://example.com/hook"},
+		{name: "http", rawURL: "http://example.com/hook", wantErr: true},
+		{name: "file without path", rawURL: "file://", wantErr: true},
+		{name: "https without host", rawURL: "https:///hook", wantErr: true},
+		{name: "without scheme", rawURL: "/var/log/grow.json", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseNotifyURL(tt.rawURL)
+
+			if tt.wantErr {
+				assert.Error(t, err, "should reject %s", tt.rawURL)
+			} else {
+				assert.NoError(t, err, "should accept %s", tt.rawURL)
+			}
+		})
+	}
+}
+
+func TestNotify_WithHTTPS(t *testing.T) {
+	var got growResult
+	var contentType string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &got)
+	}))
+	defer server.Close()
+
+	u, err := parseNotifyURL(server.URL + "/hook")
+	if !assert.NoError(t, err, "should accept the server url") {
+		return
+	}
+
+	notify(context.Background(), server.Client(), u, newGrowResult("disk1", false, growSizes{}, nil))
+
+	assert.Equal(t, "application/json", contentType, "should post JSON")
+	assert.Equal(t, "disk1", got.DeviceID, "should post the device identifier")
+	assert.True(t, got.Succeeded, "should post the grow outcome")
+	assert.Empty(t, got.Error, "shouldn't post an error on success")
+}
+
+func TestNotify_WithFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "result.json")
+	u, err := parseNotifyURL((&url.URL{Scheme: "file", Path: path}).String())
+	if !assert.NoError(t, err, "should accept the file url") {
+		return
+	}
+
+	notify(context.Background(), nil, u, newGrowResult("disk1", true, growSizes{}, errors.New("error")))
+
+	data, err := os.ReadFile(path)
+	if !assert.NoError(t, err, "should write the result to the file") {
+		return
+	}
+	var got growResult
+	assert.NoError(t, json.Unmarshal(data, &got), "should write valid JSON")
+	assert.Equal(t, "disk1", got.DeviceID, "should write the device identifier")
+	assert.True(t, got.DryRun, "should write the dry run mode")
+	assert.False(t, got.Succeeded, "should write the grow outcome")
+	assert.Equal(t, "error", got.Error, "should write the error")
+}
+
+func TestPostResult_WithErrorStatus(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := postResult(context.Background(), server.Client(), server.URL, []byte("{}"))
+
+	assert.Error(t, err, "should fail with an error status")
+}
+
+func TestRunAndNotify_WithGrowErr(t *testing.T) {
+	var ctx = context.Background()
+	path := filepath.Join(t.TempDir(), "result.json")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	mock.EXPECT().Info(ctx, "/").Return(nil, errors.New("error"))
+
+	err := runAndNotify(ctx, mock, growContainer{
+		id:           "root",
+		notifyTarget: &url.URL{Scheme: "file", Path: path},
+	})
+
+	data, readErr := os.ReadFile(path)
+	var got growResult
+	assert.Error(t, err, "should return the grow error")
+	if assert.NoError(t, readErr, "should notify even when the grow fails") {
+		assert.NoError(t, json.Unmarshal(data, &got), "should write valid JSON")
+		assert.False(t, got.Succeeded, "should notify of the failure")
+	}
+}
+
+// notifyGrowFixture returns a fake diskutil with an APFS container on disk1 which has 2,000,000 bytes of free space to
+// grow into. Resizing the container updates its size.
+func notifyGrowFixture() *diskutil.FakeUtil {
+	const (
+		testDiskID        = "disk1"
+		diskSize   uint64 = 3_000_000
+		partSize   uint64 = 500_000
+	)
+
+	parts := &types.SystemPartitions{
+		AllDisks: []string{testDiskID},
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             diskSize,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
+
+	disk := &types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		TotalSize:         partSize,
+		VirtualOrPhysical: "Physical",
+	}
+
+	fake := diskutil.NewFakeUtil(parts, map[string]*types.DiskInfo{testDiskID: disk})
+	fake.ResizeContainerFunc = func(ctx context.Context, id string, size string, opts ...types.ResizeOption) (string, error) {
+		disk.TotalSize = diskSize - partSize
+		return "", nil
+	}
+
+	return fake
+}
+
+func TestRunAndNotify_WithJSONOutput(t *testing.T) {
+	var out bytes.Buffer
+
+	err := runAndNotify(context.Background(), notifyGrowFixture(), growContainer{
+		id:     "disk1",
+		output: outputJSON,
+		out:    &out,
+	})
+
+	var got growResult
+	assert.NoError(t, err, "should be able to grow the container")
+	if assert.NoError(t, json.Unmarshal(out.Bytes(), &got), "should write the result as JSON") {
+		assert.Equal(t, schemaVersion, got.SchemaVersion, "should include the schema version")
+		assert.Equal(t, "disk1", got.DeviceID, "should include the device identifier")
+		assert.False(t, got.DryRun, "shouldn't be a dry run")
+		assert.True(t, got.Resized, "should have resized the container")
+		assert.Equal(t, uint64(500_000), got.OldSize, "should include the size before the grow")
+		assert.Equal(t, uint64(2_500_000), got.NewSize, "should include the size after the grow")
+		assert.Equal(t, uint64(2_000_000), got.BytesGained, "should include the bytes gained")
+	}
+}
+
+func TestRunAndNotify_WithNothingToGrow(t *testing.T) {
+	var out bytes.Buffer
+	minFree := uint64(10_000_000)
+
+	err := runAndNotify(context.Background(), notifyGrowFixture(), growContainer{
+		id:           "disk1",
+		minFreeSpace: &minFree,
+		output:       outputJSON,
+		out:          &out,
+	})
+
+	var got growResult
+	assert.True(t, isNothingToGrow(err), "should report that there's nothing to grow")
+	if assert.NoError(t, json.Unmarshal(out.Bytes(), &got), "should write the result as JSON") {
+		assert.True(t, got.Succeeded, "having nothing to grow isn't a failure")
+		assert.Empty(t, got.Error, "shouldn't include an error")
+		assert.False(t, got.Resized, "shouldn't have resized the container")
+	}
+}
+
+func TestRunAndNotify_WithJSONOutputDryrun(t *testing.T) {
+	var out bytes.Buffer
+
+	err := runAndNotify(context.Background(), diskutil.Dryrun(notifyGrowFixture()), growContainer{
+		id:     "disk1",
+		dryrun: true,
+		output: outputJSON,
+		out:    &out,
+	})
+
+	var got growResult
+	assert.NoError(t, err, "should be able to dry run the grow")
+	if assert.NoError(t, json.Unmarshal(out.Bytes(), &got), "should write the result as JSON") {
+		assert.True(t, got.DryRun, "should be a dry run")
+		assert.False(t, got.Resized, "shouldn't resize in a dry run")
+		assert.Equal(t, uint64(500_000), got.OldSize, "should include the current size")
+		assert.Equal(t, uint64(2_500_000), got.NewSize, "should include the size it would have grown to")
+		assert.Equal(t, uint64(2_000_000), got.BytesGained, "should include the bytes it would have gained")
+	}
+}
+
+func TestRunAndNotify_WithoutJSONOutput(t *testing.T) {
+	var out bytes.Buffer
+
+	err := runAndNotify(context.Background(), notifyGrowFixture(), growContainer{
+		id:     "disk1",
+		output: outputTable,
+		out:    &out,
+	})
+
+	assert.NoError(t, err, "should be able to grow the container")
+	assert.Empty(t, out.String(), "shouldn't write the result without JSON output")
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+)
+
+// schemaVersion is the version of the shape of every JSON output (e.g. the grow result, list, info, and errors). It's
+// incremented whenever a field is removed or changes meaning so consumers can detect outputs they can't parse. Adding
+// a field doesn't change the version.
+const schemaVersion = 1
+
+// diskOutput is the JSON representation of a single disk, partition, or volume. Sizes of 0 are unknown.
+type diskOutput struct {
+	ID    string `json:"id"`
+	Size  uint64 `json:"size"`
+	Free  uint64 `json:"free,omitempty"`
+	FS    string `json:"fs,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Mount string `json:"mount,omitempty"`
+}
+
+// newDiskOutput creates the JSON representation of the row.
+func newDiskOutput(r diskRow) diskOutput {
+	return diskOutput{
+		ID:    r.id,
+		Size:  r.size,
+		Free:  r.free,
+		FS:    r.fs,
+		Name:  r.name,
+		Mount: r.mount,
+	}
+}
+
+// listOutput is the JSON output of the list command.
+type listOutput struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	Disks         []diskOutput `json:"disks"`
+}
+
+// newListOutput creates the JSON output for each disk, partition, and APFS volume in the system partitions.
+func newListOutput(partitions *types.SystemPartitions) listOutput {
+	out := listOutput{
+		SchemaVersion: schemaVersion,
+		Disks:         []diskOutput{},
+	}
+	for _, row := range partitionRows(partitions) {
+		out.Disks = append(out.Disks, newDiskOutput(row))
+	}
+
+	return out
+}
+
+// infoOutput is the JSON output of the info command.
+type infoOutput struct {
+	SchemaVersion int        `json:"schemaVersion"`
+	Disk          diskOutput `json:"disk"`
+}
+
+// newInfoOutput creates the JSON output for the disk information.
+func newInfoOutput(disk *types.DiskInfo) infoOutput {
+	return infoOutput{
+		SchemaVersion: schemaVersion,
+		Disk:          newDiskOutput(diskInfoRow(disk)),
+	}
+}
+
+// errorOutput is the JSON output of a command which failed.
+type errorOutput struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Error         string `json:"error"`
+}
+
+// outputFlag provides the validated --output of the root command.
+func outputFlag(cmd *cobra.Command) (string, error) {
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return "", err
+	}
+
+	return output, validateOutput(output)
+}
+
+// streamOutputFlag provides the validated --output of the root command for commands which stream their results, where
+// outputNDJSON is also supported.
+func streamOutputFlag(cmd *cobra.Command) (string, error) {
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return "", err
+	}
+	if output == outputNDJSON {
+		return output, nil
+	}
+
+	return output, validateOutput(output)
+}
+
+// isJSONOutput checks if the output mode writes JSON, either as documents or as lines.
+func isJSONOutput(output string) bool {
+	return output == outputJSON || output == outputNDJSON
+}
+
+// resultStream writes the results of a multi-disk run to w as each one completes, so consumers of long batch runs see
+// their progress. With outputJSON, the results are the elements of a single JSON array which is ended by close. With
+// outputNDJSON, each result is a line of JSON. Writes are guarded so results can be written by concurrent runs.
+type resultStream struct {
+	mu      sync.Mutex
+	w       io.Writer
+	ndjson  bool
+	written int
+}
+
+// newResultStream creates a new resultStream writing to w in the JSON output mode.
+func newResultStream(w io.Writer, output string) *resultStream {
+	return &resultStream{w: w, ndjson: output == outputNDJSON}
+}
+
+// write writes the result v as the next element of the stream.
+func (s *resultStream) write(v interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ndjson {
+		return writeJSONLine(s.w, v)
+	}
+
+	data, err := json.MarshalIndent(v, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	separator := ",\n  "
+	if s.written == 0 {
+		separator = "[\n  "
+	}
+	if _, err := fmt.Fprintf(s.w, "%s%s", separator, data); err != nil {
+		return err
+	}
+	s.written++
+
+	return nil
+}
+
+// close ends the JSON array, which is empty when no results were written. Nothing is written for NDJSON since each line
+// stands on its own.
+func (s *resultStream) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ndjson {
+		return nil
+	}
+
+	end := "\n]\n"
+	if s.written == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(s.w, end)
+
+	return err
+}
+
+// writeOutputError writes err as an errorOutput to w when the output is JSON so consumers always get a JSON document.
+// The error is returned as-is for the command to fail with.
+func writeOutputError(w io.Writer, output string, err error) error {
+	if output != outputJSON {
+		return err
+	}
+
+	if werr := writeJSON(w, errorOutput{SchemaVersion: schemaVersion, Error: err.Error()}); werr != nil {
+		logrus.WithError(werr).Warn("Unable to write error output")
+	}
+
+	return err
+}
+
+// writeJSONFile writes v as indented JSON to the file at path.
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// writeJSON writes v as indented JSON to w.
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
+
+// writeJSONLine writes v as a single line of JSON to w (e.g. for NDJSON).
+func writeJSONLine(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
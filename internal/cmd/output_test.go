@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// decodeSchemaVersion decodes the JSON document and returns its schemaVersion field, if present.
+func decodeSchemaVersion(t *testing.T, data []byte) (interface{}, bool) {
+	t.Helper()
+
+	var decoded map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal(data, &decoded), "should be valid JSON") {
+		return nil, false
+	}
+	version, ok := decoded["schemaVersion"]
+
+	return version, ok
+}
+
+// encodeJSON encodes v as JSON for asserting on its fields.
+func encodeJSON(t *testing.T, v interface{}) []byte {
+	t.Helper()
+
+	var out bytes.Buffer
+	assert.NoError(t, writeJSON(&out, v), "should be able to write JSON")
+
+	return out.Bytes()
+}
+
+func TestSchemaVersion(t *testing.T) {
+	partitions := &types.SystemPartitions{
+		AllDisksAndPartitions: []types.DiskPart{
+			{DeviceIdentifier: "disk0", Size: 1_000_000},
+		},
+	}
+	disk := &types.DiskInfo{DeviceIdentifier: "disk1", TotalSize: 1_000_000}
+
+	tests := []struct {
+		name   string
+		output interface{}
+	}{
+		{name: "grow result", output: newGrowResult("disk1", false, growSizes{}, nil)},
+		{name: "failed grow result", output: newGrowResult("disk1", false, growSizes{}, errors.New("error"))},
+		{name: "grow report", output: &growReport{SchemaVersion: schemaVersion, DeviceID: "disk1"}},
+		{name: "list", output: newListOutput(partitions)},
+		{name: "info", output: newInfoOutput(disk)},
+		{name: "preflight result", output: preflightResult{SchemaVersion: schemaVersion}},
+		{name: "verify", output: verifyOutput{SchemaVersion: schemaVersion}},
+		{name: "snapshots", output: newSnapshotsOutput("disk1s1", nil)},
+		{name: "smart", output: newSmartOutput(disk)},
+		{name: "usage", output: newUsageOutput(&diskutil.Usage{MountPoint: "/"})},
+		{name: "error", output: errorOutput{SchemaVersion: schemaVersion, Error: "error"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, ok := decodeSchemaVersion(t, encodeJSON(t, tt.output))
+
+			assert.True(t, ok, "should include the schema version")
+			assert.Equal(t, float64(schemaVersion), version, "should be the current schema version")
+		})
+	}
+}
+
+func TestNewListOutput(t *testing.T) {
+	partitions := &types.SystemPartitions{
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: "disk0",
+				Size:             1_000_000,
+				Content:          "GUID_partition_scheme",
+				Partitions: []types.Partition{
+					{DeviceIdentifier: "disk0s1", Size: 400_000, Content: "EFI", VolumeName: "EFI"},
+				},
+			},
+		},
+	}
+
+	expected := listOutput{
+		SchemaVersion: schemaVersion,
+		Disks: []diskOutput{
+			{ID: "disk0", Size: 1_000_000, Free: 600_000, FS: "GUID_partition_scheme"},
+			{ID: "disk0s1", Size: 400_000, FS: "EFI", Name: "EFI"},
+		},
+	}
+
+	assert.Equal(t, expected, newListOutput(partitions), "should include every disk and partition")
+}
+
+func TestWriteOutputError(t *testing.T) {
+	expectedErr := errors.New("error")
+
+	var table bytes.Buffer
+	err := writeOutputError(&table, outputTable, expectedErr)
+	assert.Equal(t, expectedErr, err, "should return the error as-is")
+	assert.Empty(t, table.String(), "shouldn't write the error for table output")
+
+	var out bytes.Buffer
+	err = writeOutputError(&out, outputJSON, expectedErr)
+	assert.Equal(t, expectedErr, err, "should return the error as-is")
+
+	var decoded errorOutput
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &decoded), "should write valid JSON")
+	assert.Equal(t, errorOutput{SchemaVersion: schemaVersion, Error: "error"}, decoded, "should write the error")
+}
+
+func TestOutputFlag(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", args: nil, want: outputTable},
+		{name: "json", args: []string{"--output", "json"}, want: outputJSON},
+		{name: "ndjson", args: []string{"--output", "ndjson"}, wantErr: true},
+		{name: "unsupported", args: []string{"--output", "yaml"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := rootCommand()
+			sub := &cobra.Command{Use: "sub"}
+			root.AddCommand(sub)
+			if !assert.NoError(t, sub.ParseFlags(tt.args), "should parse the root's flags") {
+				return
+			}
+
+			got, err := outputFlag(sub)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got, "should provide the root's --output")
+		})
+	}
+}
+
+func TestStreamOutputFlag(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", args: nil, want: outputTable},
+		{name: "json", args: []string{"--output", "json"}, want: outputJSON},
+		{name: "ndjson", args: []string{"--output", "ndjson"}, want: outputNDJSON},
+		{name: "unsupported", args: []string{"--output", "yaml"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := rootCommand()
+			sub := &cobra.Command{Use: "sub"}
+			root.AddCommand(sub)
+			if !assert.NoError(t, sub.ParseFlags(tt.args), "should parse the root's flags") {
+				return
+			}
+
+			got, err := streamOutputFlag(sub)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got, "should provide the root's --output")
+		})
+	}
+}
+
+func TestResultStream_JSON(t *testing.T) {
+	var out bytes.Buffer
+	stream := newResultStream(&out, outputJSON)
+
+	assert.NoError(t, stream.write(growResult{DeviceID: "disk1"}))
+	assert.NoError(t, stream.write(growResult{DeviceID: "disk2"}))
+	assert.NoError(t, stream.close())
+
+	var decoded []growResult
+	if assert.NoError(t, json.Unmarshal(out.Bytes(), &decoded), "should write a single JSON array") {
+		assert.Len(t, decoded, 2, "should write every result")
+		assert.Equal(t, "disk1", decoded[0].DeviceID, "should write the results in order")
+		assert.Equal(t, "disk2", decoded[1].DeviceID, "should write the results in order")
+	}
+}
+
+func TestResultStream_JSONWithoutResults(t *testing.T) {
+	var out bytes.Buffer
+	stream := newResultStream(&out, outputJSON)
+
+	assert.NoError(t, stream.close())
+
+	assert.JSONEq(t, "[]", out.String(), "should write an empty array")
+}
+
+func TestResultStream_NDJSON(t *testing.T) {
+	var out bytes.Buffer
+	stream := newResultStream(&out, outputNDJSON)
+
+	assert.NoError(t, stream.write(growResult{DeviceID: "disk1"}))
+	assert.NoError(t, stream.write(growResult{DeviceID: "disk2"}))
+	assert.NoError(t, stream.close())
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if assert.Len(t, lines, 2, "should write a line per result") {
+		for i, want := range []string{"disk1", "disk2"} {
+			var decoded growResult
+			if assert.NoError(t, json.Unmarshal([]byte(lines[i]), &decoded), "each line should be a JSON document") {
+				assert.Equal(t, want, decoded.DeviceID, "should write the results in order")
+			}
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+)
+
+const (
+	// exitPrerequisitesFailed is the exit code used by preflight when any prerequisite check fails.
+	exitPrerequisitesFailed = 2
+	// exitGrowthNeeded is the exit code used by preflight when the prerequisites pass and the container can be grown.
+	exitGrowthNeeded = 3
+)
+
+// prerequisite is a check that must pass before the container can be grown.
+type prerequisite struct {
+	// name describes what's checked.
+	name string
+	// check returns an error when the prerequisite isn't met.
+	check func() error
+}
+
+// preflightResult is the structured result of the preflight command.
+type preflightResult struct {
+	// SchemaVersion is the version of the result's shape (see schemaVersion).
+	SchemaVersion int `json:"schemaVersion"`
+	// Prerequisites are the outcomes of each prerequisite check.
+	Prerequisites []diskutil.Decision `json:"prerequisites"`
+	// PrerequisitesPassed is true when every prerequisite check passed.
+	PrerequisitesPassed bool `json:"prerequisitesPassed"`
+	// Decisions are the outcomes of each check made while planning the grow (see diskutil.PlanGrowContainer).
+	Decisions []diskutil.Decision `json:"decisions,omitempty"`
+	// GrowthNeeded is true when the container can be grown.
+	GrowthNeeded bool `json:"growthNeeded"`
+}
+
+// exitCode encodes the result as the program's exit code: 0 when the prerequisites pass and no growth is needed,
+// exitPrerequisitesFailed when a prerequisite fails, and exitGrowthNeeded when the container can be grown.
+func (r preflightResult) exitCode() int {
+	switch {
+	case !r.PrerequisitesPassed:
+		return exitPrerequisitesFailed
+	case r.GrowthNeeded:
+		return exitGrowthNeeded
+	default:
+		return 0
+	}
+}
+
+// preflightCommand creates a new command which checks the grow prerequisites and if the container can be grown.
+func preflightCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "preflight",
+		Short: "check grow prerequisites and if the container can be grown",
+		Long: strings.TrimSpace(fmt.Sprintf(`
+preflight checks the prerequisites for growing a container
+(e.g. root privileges and diskutil) and plans the grow without
+making any changes. The combined result is written as JSON.
+The exit code is 0 when the prerequisites pass and there's
+nothing to grow, %d when a prerequisite fails, and %d when the
+container can be grown.
+		`, exitPrerequisitesFailed, exitGrowthNeeded)),
+		// The result is written as JSON so errors for the exit code shouldn't also be printed
+		SilenceErrors: true,
+	}
+
+	// Set up the flags to be passed into the command
+	var id string
+	cmd.PersistentFlags().StringVar(&id, "id", "", `container identifier to be checked, "root", or "/"`)
+	cmd.MarkPersistentFlagRequired("id")
+
+	// Set up the command's run function
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		product, err := requireProduct(ctx)
+		if err != nil {
+			return err
+		}
+
+		readiness := diskutil.GrowReadiness(ctx)
+		prerequisites := []prerequisite{
+			{name: "root privileges", check: readinessCheck(readiness.Root, "root privileges required, re-run command with sudo")},
+			{name: "diskutil", check: func() error {
+				_, err := exec.LookPath("diskutil")
+				return err
+			}},
+			{name: "supported release", check: readinessCheck(readiness.ProductSupported, fmt.Sprintf("%s isn't supported", product))},
+		}
+
+		var d diskutil.DiskUtil
+		if readiness.ProductSupported {
+			var err error
+			if d, err = diskutil.ForProduct(product); err != nil {
+				return err
+			}
+		}
+
+		result, err := runPreflight(ctx, prerequisites, func() diskutil.DiskUtil { return d }, growContainer{id: id})
+		if err != nil {
+			logrus.WithError(err).Error("Preflight failed")
+			return writeOutputError(cmd.OutOrStdout(), outputJSON, err)
+		}
+
+		if err := writePreflightResult(cmd.OutOrStdout(), result); err != nil {
+			logrus.WithError(err).Error("Unable to write preflight result")
+			return err
+		}
+
+		if code := result.exitCode(); code != 0 {
+			return ExitCodeError{code: code}
+		}
+
+		return nil
+	}
+
+	return cmd
+}
+
+// readinessCheck creates a prerequisite check from the outcome of a diskutil.GrowReadiness check. The check fails with
+// the message when the outcome isn't ready.
+func readinessCheck(ready bool, message string) func() error {
+	return func() error {
+		if !ready {
+			return errors.New(message)
+		}
+		return nil
+	}
+}
+
+// runPreflight runs each prerequisite check and, if the diskutil controller is available, plans the grow. The
+// controller is fetched after the prerequisites are checked since configuring it is a prerequisite itself.
+func runPreflight(ctx context.Context, prerequisites []prerequisite, utility func() diskutil.DiskUtil, args growContainer) (preflightResult, error) {
+	result := preflightResult{SchemaVersion: schemaVersion, PrerequisitesPassed: true}
+	for _, p := range prerequisites {
+		d := diskutil.Decision{Check: p.name, Passed: true, Detail: "ok"}
+		if err := p.check(); err != nil {
+			d.Passed = false
+			d.Detail = err.Error()
+			result.PrerequisitesPassed = false
+		}
+		result.Prerequisites = append(result.Prerequisites, d)
+	}
+
+	du := utility()
+	if du == nil {
+		return result, nil
+	}
+	du = diskutil.Dryrun(du)
+
+	di, err := getTargetDiskInfo(ctx, du, args.id)
+	if err != nil {
+		return result, fmt.Errorf("cannot check container: %w", err)
+	}
+
+	result.Decisions, err = diskutil.PlanGrowContainer(ctx, du, di, diskutil.GrowOptions{})
+	if err != nil {
+		return result, fmt.Errorf("cannot check container: %w", err)
+	}
+	result.GrowthNeeded = len(result.Decisions) > 0 && allPassed(result.Decisions)
+
+	return result, nil
+}
+
+// allPassed checks if every decision passed.
+func allPassed(decisions []diskutil.Decision) bool {
+	for _, d := range decisions {
+		if !d.Passed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// writePreflightResult writes the result as indented JSON to w.
+func writePreflightResult(w io.Writer, result preflightResult) error {
+	return writeJSON(w, result)
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	mock_diskutil "github.com/aws/ec2-macos-utils/internal/diskutil/mocks"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// preflightDisk returns the system partitions and disk information for an APFS container on disk1 with the given
+// amount of free space.
+func preflightDisk(free uint64) (*types.SystemPartitions, *types.DiskInfo) {
+	const (
+		testDiskID        = "disk1"
+		partSize   uint64 = 500_000
+	)
+
+	parts := &types.SystemPartitions{
+		AllDisks: []string{testDiskID},
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             2*partSize + free,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
+
+	disk := &types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		VirtualOrPhysical: "Physical",
+	}
+
+	return parts, disk
+}
+
+func passingPrerequisites() []prerequisite {
+	return []prerequisite{
+		{name: "first", check: func() error { return nil }},
+		{name: "second", check: func() error { return nil }},
+	}
+}
+
+func TestRunPreflight_WithGrowthNeeded(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts, disk := preflightDisk(2_000_000)
+
+	// No repair or resize is expected since preflight doesn't mutate anything
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mock.EXPECT().List(ctx, nil).Return(parts, nil),
+		mock.EXPECT().Info(ctx, "disk1").Return(disk, nil),
+		mock.EXPECT().List(ctx, nil).Return(parts, nil),
+	)
+
+	result, err := runPreflight(ctx, passingPrerequisites(), func() diskutil.DiskUtil { return mock }, growContainer{id: "disk1"})
+
+	assert.NoError(t, err, "should be able to run preflight with valid data")
+	assert.Equal(t, schemaVersion, result.SchemaVersion, "should include the schema version")
+	assert.True(t, result.PrerequisitesPassed, "prerequisites should pass")
+	assert.Len(t, result.Prerequisites, 2, "should report each prerequisite")
+	assert.True(t, result.GrowthNeeded, "growth should be needed with free space")
+	assert.Equal(t, exitGrowthNeeded, result.exitCode(), "should exit with the growth needed code")
+}
+
+func TestRunPreflight_WithoutFreeSpace(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts, disk := preflightDisk(0)
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mock.EXPECT().List(ctx, nil).Return(parts, nil),
+		mock.EXPECT().Info(ctx, "disk1").Return(disk, nil),
+		mock.EXPECT().List(ctx, nil).Return(parts, nil),
+	)
+
+	result, err := runPreflight(ctx, passingPrerequisites(), func() diskutil.DiskUtil { return mock }, growContainer{id: "disk1"})
+
+	assert.NoError(t, err, "should be able to run preflight without free space")
+	assert.True(t, result.PrerequisitesPassed, "prerequisites should pass")
+	assert.NotEmpty(t, result.Decisions, "should report the planned decisions")
+	assert.False(t, result.GrowthNeeded, "growth shouldn't be needed without free space")
+	assert.Equal(t, 0, result.exitCode(), "should exit successfully")
+}
+
+func TestRunPreflight_WithFailedPrerequisite(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts, disk := preflightDisk(2_000_000)
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mock.EXPECT().List(ctx, nil).Return(parts, nil),
+		mock.EXPECT().Info(ctx, "disk1").Return(disk, nil),
+		mock.EXPECT().List(ctx, nil).Return(parts, nil),
+	)
+
+	prerequisites := []prerequisite{
+		{name: "root privileges", check: func() error { return errors.New("not root") }},
+		{name: "diskutil", check: func() error { return nil }},
+	}
+
+	result, err := runPreflight(ctx, prerequisites, func() diskutil.DiskUtil { return mock }, growContainer{id: "disk1"})
+
+	assert.NoError(t, err, "should be able to run preflight with a failed prerequisite")
+	assert.False(t, result.PrerequisitesPassed, "prerequisites shouldn't pass")
+	assert.Equal(t, diskutil.Decision{Check: "root privileges", Passed: false, Detail: "not root"}, result.Prerequisites[0],
+		"should report the failed prerequisite")
+	assert.True(t, result.GrowthNeeded, "growth should still be assessed")
+	assert.Equal(t, exitPrerequisitesFailed, result.exitCode(), "failed prerequisites should take precedence")
+}
+
+func TestRunPreflight_WithoutUtility(t *testing.T) {
+	var ctx = context.Background()
+
+	prerequisites := []prerequisite{
+		{name: "supported release", check: func() error { return errors.New("unsupported") }},
+	}
+
+	result, err := runPreflight(ctx, prerequisites, func() diskutil.DiskUtil { return nil }, growContainer{id: "disk1"})
+
+	assert.NoError(t, err, "should skip the growth assessment without diskutil")
+	assert.False(t, result.PrerequisitesPassed, "prerequisites shouldn't pass")
+	assert.Empty(t, result.Decisions, "shouldn't plan without diskutil")
+	assert.False(t, result.GrowthNeeded, "growth can't be needed without diskutil")
+	assert.Equal(t, exitPrerequisitesFailed, result.exitCode(), "should exit with the failed prerequisites code")
+}
+
+func TestRunPreflight_WithInfoErr(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	mock.EXPECT().Info(ctx, "/").Return(nil, errors.New("error"))
+
+	_, err := runPreflight(ctx, passingPrerequisites(), func() diskutil.DiskUtil { return mock }, growContainer{id: "root"})
+
+	assert.Error(t, err, "should fail when the container can't be checked")
+}
+
+func TestWritePreflightResult(t *testing.T) {
+	result := preflightResult{
+		Prerequisites:       []diskutil.Decision{{Check: "diskutil", Passed: true, Detail: "ok"}},
+		PrerequisitesPassed: true,
+		GrowthNeeded:        false,
+	}
+
+	var out bytes.Buffer
+	err := writePreflightResult(&out, result)
+
+	var decoded map[string]interface{}
+	assert.NoError(t, err, "should be able to write the result")
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &decoded), "should write valid JSON")
+	assert.Equal(t, true, decoded["prerequisitesPassed"], "should include the prerequisites outcome")
+	assert.Equal(t, false, decoded["growthNeeded"], "should include the growth outcome")
+	assert.NotContains(t, decoded, "decisions", "should omit decisions when there are none")
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/aws/ec2-macos-utils/internal/util"
+)
+
+// exitRebootScheduled is the exit code used by grow when a reboot was scheduled to make the new size visible. The
+// command should be run again once the system has booted.
+const exitRebootScheduled = 4
+
+// rebootDelay is the delay argument given to shutdown when scheduling a reboot. The delay allows the command to exit
+// with exitRebootScheduled before the system goes down.
+const rebootDelay = "+1"
+
+// BootTimeFunc fetches the time the system was last booted.
+type BootTimeFunc func(ctx context.Context) (time.Time, error)
+
+// RebootFunc schedules a reboot of the system.
+type RebootFunc func(ctx context.Context) error
+
+// scheduleReboot schedules a reboot of the system with shutdown(8) after the rebootDelay.
+func scheduleReboot(ctx context.Context) error {
+	out, err := util.ExecuteCommand(ctx, []string{"shutdown", "-r", rebootDelay}, "", nil, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, out.Stderr)
+	}
+
+	return nil
+}
+
+// ResizeNotVisibleError defines an error to distinguish when the volume was resized but the new size isn't visible
+// yet. The error advises how to make the new size visible.
+type ResizeNotVisibleError struct {
+	bootTime  time.Time
+	resizedAt time.Time
+}
+
+func (e ResizeNotVisibleError) Error() string {
+	return resizeAdvice(e.bootTime, e.resizedAt)
+}
+
+// RebootRequired checks if a reboot is required for the new size to be visible.
+func (e ResizeNotVisibleError) RebootRequired() bool {
+	return e.bootTime.Before(e.resizedAt)
+}
+
+// resizeAdvice advises how to make the new size of a volume resized at resizedAt visible given the system was last
+// booted at bootTime. Volumes resized after the last boot require a reboot, otherwise the modification is likely still
+// in progress.
+func resizeAdvice(bootTime, resizedAt time.Time) string {
+	if bootTime.Before(resizedAt) {
+		return fmt.Sprintf("the volume was resized at %s after the last boot at %s, "+
+			"reboot the instance for the new size to be visible",
+			resizedAt.Format(time.RFC3339), bootTime.Format(time.RFC3339))
+	}
+
+	return fmt.Sprintf("the volume was resized at %s before the last boot at %s, "+
+		"wait for the volume modification to complete and try again",
+		resizedAt.Format(time.RFC3339), bootTime.Format(time.RFC3339))
+}
+
+// explainResizeNotVisible checks when the system was last booted relative to when the volume was resized to explain
+// why the new size isn't visible. A ResizeNotVisibleError is returned with the advice.
+func explainResizeNotVisible(ctx context.Context, bootTime BootTimeFunc, resizedAt time.Time) error {
+	booted, err := bootTime(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot determine boot time: %w", err)
+	}
+
+	return ResizeNotVisibleError{bootTime: booted, resizedAt: resizedAt}
+}
+
+// rebootIfRequired schedules a reboot with reboot when err is a ResizeNotVisibleError that requires one. An
+// ExitCodeError with exitRebootScheduled is returned once the reboot is scheduled. Otherwise, err is returned as-is.
+func rebootIfRequired(ctx context.Context, reboot RebootFunc, dryrun bool, err error) error {
+	var notVisibleErr ResizeNotVisibleError
+	if !errors.As(err, &notVisibleErr) || !notVisibleErr.RebootRequired() {
+		return err
+	}
+
+	if dryrun {
+		logrus.WithError(err).Warn("Would have scheduled a reboot for the new size to be visible")
+		return err
+	}
+	if reboot == nil {
+		return fmt.Errorf("cannot schedule reboot: no reboot runner: %w", err)
+	}
+
+	logrus.Info("Scheduling a reboot for the new size to be visible...")
+	if rebootErr := reboot(ctx); rebootErr != nil {
+		return fmt.Errorf("cannot schedule reboot: %v: %w", rebootErr, err)
+	}
+	logrus.Info("Reboot scheduled, run the command again once the system has booted")
+
+	return ExitCodeError{code: exitRebootScheduled, err: fmt.Errorf("reboot scheduled: %w", err)}
+}
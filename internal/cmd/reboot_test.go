@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	mock_diskutil "github.com/aws/ec2-macos-utils/internal/diskutil/mocks"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResizeAdvice(t *testing.T) {
+	resizedAt := time.Date(2023, 10, 11, 16, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		bootTime time.Time
+		want     string
+	}{
+		{
+			name:     "booted before resize",
+			bootTime: resizedAt.Add(-time.Hour),
+			want: "the volume was resized at 2023-10-11T16:00:00Z after the last boot at 2023-10-11T15:00:00Z, " +
+				"reboot the instance for the new size to be visible",
+		},
+		{
+			name:     "booted after resize",
+			bootTime: resizedAt.Add(time.Hour),
+			want: "the volume was resized at 2023-10-11T16:00:00Z before the last boot at 2023-10-11T17:00:00Z, " +
+				"wait for the volume modification to complete and try again",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resizeAdvice(tt.bootTime, resizedAt)
+
+			assert.Equal(t, tt.want, got, "should advise based on the boot time")
+		})
+	}
+}
+
+func TestCheckResizeVisible_WithBootTimeErr(t *testing.T) {
+	bootTime := func(ctx context.Context) (time.Time, error) {
+		return time.Time{}, fmt.Errorf("error")
+	}
+
+	err := explainResizeNotVisible(context.Background(), bootTime, time.Now())
+
+	var notVisibleErr ResizeNotVisibleError
+	assert.Error(t, err, "should fail without boot time")
+	assert.False(t, errors.As(err, &notVisibleErr), "shouldn't advise without boot time")
+}
+
+func TestRun_SinceRebootWithoutFreeSpace(t *testing.T) {
+	const (
+		testDiskID        = "disk1"
+		diskSize   uint64 = 3_000_000
+		partSize   uint64 = 1_500_000
+	)
+	var ctx = context.Background()
+	resizedAt := time.Date(2023, 10, 11, 16, 0, 0, 0, time.UTC)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts := types.SystemPartitions{
+		AllDisks: []string{testDiskID},
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             diskSize,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		VirtualOrPhysical: "Physical",
+	}
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mock.EXPECT().Info(ctx, testDiskID).Return(&disk, nil),
+		mock.EXPECT().RepairDisk(ctx, testDiskID).Return("", nil),
+		mock.EXPECT().List(ctx, nil).Return(&parts, nil),
+	)
+
+	err := run(ctx, mock, growContainer{
+		id:          testDiskID,
+		resizedAt:   resizedAt.Format(time.RFC3339),
+		sinceReboot: true,
+		bootTime: func(ctx context.Context) (time.Time, error) {
+			return resizedAt.Add(-time.Hour), nil
+		},
+	})
+
+	var notVisibleErr ResizeNotVisibleError
+	assert.True(t, errors.As(err, &notVisibleErr), "should explain why there's no free space")
+	assert.True(t, notVisibleErr.RebootRequired(), "should require a reboot when booted before the resize")
+}
+
+func TestSinceReboot_RebootIfNeeded(t *testing.T) {
+	resizedAt := time.Date(2023, 10, 11, 16, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		bootTime       time.Time
+		rebootIfNeeded bool
+		dryrun         bool
+		wantReboot     bool
+	}{
+		{
+			name:           "reboot required with flag",
+			bootTime:       resizedAt.Add(-time.Hour),
+			rebootIfNeeded: true,
+			wantReboot:     true,
+		},
+		{
+			name:     "reboot required without flag",
+			bootTime: resizedAt.Add(-time.Hour),
+		},
+		{
+			name:           "reboot required with flag and dry run",
+			bootTime:       resizedAt.Add(-time.Hour),
+			rebootIfNeeded: true,
+			dryrun:         true,
+		},
+		{
+			name:           "reboot not required with flag",
+			bootTime:       resizedAt.Add(time.Hour),
+			rebootIfNeeded: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rebooted bool
+			err := sinceReboot(context.Background(), growContainer{
+				dryrun:         tt.dryrun,
+				rebootIfNeeded: tt.rebootIfNeeded,
+				resizedAt:      resizedAt.Format(time.RFC3339),
+				bootTime: func(ctx context.Context) (time.Time, error) {
+					return tt.bootTime, nil
+				},
+				reboot: func(ctx context.Context) error {
+					rebooted = true
+					return nil
+				},
+			})
+
+			var notVisibleErr ResizeNotVisibleError
+			var exitErr ExitCodeError
+			assert.True(t, errors.As(err, &notVisibleErr), "should explain why there's no free space")
+			assert.Equal(t, tt.wantReboot, rebooted, "should only reboot when required and requested")
+			assert.Equal(t, tt.wantReboot, errors.As(err, &exitErr), "should only request an exit code when rebooting")
+			if tt.wantReboot {
+				assert.Equal(t, exitRebootScheduled, exitErr.Code(), "should exit with the reboot scheduled code")
+			}
+		})
+	}
+}
+
+func TestSinceReboot_WithRebootErr(t *testing.T) {
+	resizedAt := time.Date(2023, 10, 11, 16, 0, 0, 0, time.UTC)
+
+	err := sinceReboot(context.Background(), growContainer{
+		rebootIfNeeded: true,
+		resizedAt:      resizedAt.Format(time.RFC3339),
+		bootTime: func(ctx context.Context) (time.Time, error) {
+			return resizedAt.Add(-time.Hour), nil
+		},
+		reboot: func(ctx context.Context) error {
+			return errors.New("error")
+		},
+	})
+
+	var notVisibleErr ResizeNotVisibleError
+	var exitErr ExitCodeError
+	assert.Error(t, err, "should fail when the reboot can't be scheduled")
+	assert.True(t, errors.As(err, &notVisibleErr), "should still explain why there's no free space")
+	assert.False(t, errors.As(err, &exitErr), "shouldn't request the reboot scheduled exit code")
+}
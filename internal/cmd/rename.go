@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+)
+
+// renameVolume is a struct for holding all information passed into the rename command.
+type renameVolume struct {
+	dryrun bool
+	id     string
+	name   string
+}
+
+// renameCommand creates a new command which renames volumes (e.g. to label data volumes consistently).
+func renameCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rename",
+		Short: "rename a volume",
+		Long: strings.TrimSpace(`
+rename renames the volume with the given identifier (e.g.
+disk4s1 or /dev/disk4s1) to --name using 'diskutil rename'.
+Names can't be empty or contain path separators ('/' or ':').
+Use --dry-run to check the name without renaming the volume.
+		`),
+	}
+
+	// Set up the flags to be passed into the command
+	renameArgs := renameVolume{}
+	cmd.PersistentFlags().StringVar(&renameArgs.id, "id", "", "volume identifier to be renamed")
+	cmd.PersistentFlags().StringVar(&renameArgs.name, "name", "", "new name of the volume")
+	cmd.PersistentFlags().BoolVar(&renameArgs.dryrun, "dry-run", false, "run command without mutating changes")
+	cmd.MarkPersistentFlagRequired("id")
+	cmd.MarkPersistentFlagRequired("name")
+
+	// Set up the command's pre-run to check for root permissions.
+	// This is necessary since diskutil rename requires root permissions for volumes mounted by the system.
+	cmd.PreRunE = assertRootPrivileges
+
+	// Set up the command's run function
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		product, err := requireProduct(ctx)
+		if err != nil {
+			return err
+		}
+
+		logrus.WithField("product", product).Info("Configuring diskutil for product")
+		d, err := diskutil.ForProduct(product)
+		if err != nil {
+			return err
+		}
+
+		if renameArgs.dryrun {
+			d = diskutil.Dryrun(d)
+		}
+
+		logrus.WithField("args", renameArgs).Debug("Running rename command with args")
+		return runRename(ctx, d, renameArgs)
+	}
+
+	return cmd
+}
+
+// runRename renames the volume with diskutil.RenameVolume. Skipping the rename in a dry run isn't an error.
+func runRename(ctx context.Context, du diskutil.DiskUtil, args renameVolume) error {
+	err := diskutil.RenameVolume(ctx, du, args.id, args.name)
+	if errors.Is(err, diskutil.ErrReadOnly) {
+		logrus.WithError(err).Warn("Would have renamed volume")
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("cannot rename volume: %w", err)
+	}
+	logrus.WithFields(logrus.Fields{"id": args.id, "name": args.name}).Info("Volume renamed")
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunRename(t *testing.T) {
+	fake := diskutil.NewFakeUtil(nil, nil)
+
+	err := runRename(context.Background(), fake, renameVolume{id: "disk5s1", name: "Data"})
+
+	assert.NoError(t, err, "should rename the volume")
+	assert.Contains(t, fake.Calls(), diskutil.FakeCall{Method: "RenameVolume", Args: []string{"disk5s1", "Data"}})
+}
+
+func TestRunRename_WithEmptyName(t *testing.T) {
+	fake := diskutil.NewFakeUtil(nil, nil)
+
+	err := runRename(context.Background(), fake, renameVolume{id: "disk5s1", name: ""})
+
+	assert.Error(t, err, "should reject an empty name")
+	assert.Empty(t, fake.Calls(), "shouldn't call diskutil")
+}
+
+func TestRunRename_WithDryrun(t *testing.T) {
+	fake := diskutil.NewFakeUtil(nil, nil)
+
+	err := runRename(context.Background(), diskutil.Dryrun(fake), renameVolume{dryrun: true, id: "disk5s1", name: "Data"})
+
+	assert.NoError(t, err, "should skip the rename in a dry run")
+	assert.Empty(t, fake.Calls(), "shouldn't rename the volume in a dry run")
+}
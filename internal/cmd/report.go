@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+)
+
+// growReport captures the partition layouts of the system before and after a grow, whether or not it succeeded, for
+// later inspection.
+type growReport struct {
+	// SchemaVersion is the version of the report's shape (see schemaVersion).
+	SchemaVersion int `json:"schemaVersion"`
+	// DeviceID is the identifier provided to the grow command.
+	DeviceID string `json:"deviceId"`
+	// Before is the partition layout of the system before attempting to grow the container.
+	Before *types.SystemPartitions `json:"before"`
+	// After is the partition layout of the system after attempting to grow the container.
+	After *types.SystemPartitions `json:"after"`
+	// Error is the reason the grow failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// writeReport serializes the report as JSON and writes it to the file at path.
+func writeReport(path string, report *growReport) error {
+	if err := writeJSONFile(path, report); err != nil {
+		return fmt.Errorf("cannot write report: %w", err)
+	}
+
+	return nil
+}
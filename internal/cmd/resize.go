@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	"github.com/aws/ec2-macos-utils/internal/util"
+)
+
+// resizeContainer is a struct for holding all information passed into the resize command.
+type resizeContainer struct {
+	dryrun        bool
+	forceInternal bool
+	id            string
+	size          string
+	timeout       time.Duration
+
+	// targetSize is the parsed size.
+	targetSize uint64
+	// out is where command output (as opposed to logs) is written.
+	out io.Writer
+}
+
+// resizeCommand creates a new command which resizes APFS containers to a specific size.
+func resizeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resize",
+		Short: "resize container to a specific size",
+		Long: strings.TrimSpace(`
+resize resizes the container with the given identifier (e.g.
+disk1 or /dev/disk1) to the size given with --size (e.g. 120g),
+which can be smaller than its current size. Containers can't be
+shrunk below the space used by their volumes, and the OS's root
+container keeps room for the running system. Larger sizes are
+grown to like 'grow --size'.
+Containers on the internal disk (disk0) are refused unless
+--force-internal is provided.
+Use --dry-run to print the planned size without resizing.
+Like grow, the resize is bounded by --timeout rather than
+--command-timeout.
+		`),
+	}
+
+	// Set up the flags to be passed into the command
+	resizeArgs := resizeContainer{}
+	cmd.PersistentFlags().StringVar(&resizeArgs.id, "id", "", `container identifier to be resized, "root", or "/"`)
+	cmd.PersistentFlags().StringVar(&resizeArgs.size, "size", "", "size to resize the container to (e.g. 120g, 1.5t)")
+	cmd.PersistentFlags().BoolVar(&resizeArgs.dryrun, "dry-run", false, "run command without mutating changes")
+	cmd.PersistentFlags().BoolVar(&resizeArgs.forceInternal, "force-internal", false, "allow resizing containers on the internal disk (disk0)")
+	cmd.PersistentFlags().DurationVar(&resizeArgs.timeout, "timeout", growDefaultTimeout, "Set the timeout for the command (e.g. 30s, 1m, 1.5h), 0s will disable the timeout")
+	cmd.MarkPersistentFlagRequired("id")
+	cmd.MarkPersistentFlagRequired("size")
+
+	// Set up the command's pre-run to check for root permissions.
+	// This is necessary since diskutil apfs resizeContainer requires root permissions to run.
+	cmd.PreRunE = assertRootPrivileges
+
+	// Set up the command's run function
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		size, err := parseTargetSize(resizeArgs.size)
+		if err != nil {
+			return err
+		}
+		resizeArgs.targetSize = size
+		resizeArgs.out = cmd.OutOrStdout()
+
+		// Like grow, the resize's commands are bounded by its --timeout instead of --command-timeout
+		ctx := util.WithCommandTimeout(cmd.Context(), 0)
+		if resizeArgs.timeout != 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, resizeArgs.timeout)
+			defer cancel()
+		}
+
+		product, err := requireProduct(ctx)
+		if err != nil {
+			return err
+		}
+
+		logrus.WithField("product", product).Info("Configuring diskutil for product")
+		d, err := diskutil.ForProduct(product)
+		if err != nil {
+			return err
+		}
+
+		if resizeArgs.dryrun {
+			d = diskutil.Dryrun(d)
+		}
+
+		logrus.WithField("args", resizeArgs).Debug("Running resize command with args")
+		if err := runResize(ctx, d, resizeArgs); err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				return errors.New("timeout exceeded")
+			}
+
+			return err
+		}
+
+		return nil
+	}
+
+	return cmd
+}
+
+// runResize resolves the target container and resizes it to the target size. Smaller sizes shrink the container with
+// diskutil.ShrinkContainer while larger sizes grow it with diskutil.GrowContainer.
+func runResize(ctx context.Context, du diskutil.DiskUtil, args resizeContainer) error {
+	di, err := getTargetDiskInfo(ctx, du, args.id)
+	if err != nil {
+		return fmt.Errorf("cannot resize container: %w", err)
+	}
+
+	switch {
+	case args.targetSize == di.TotalSize:
+		fmt.Fprintf(args.out, "%s is already %s\n", di.DeviceIdentifier, formatResizeSize(args.targetSize))
+		return nil
+	case args.targetSize < di.TotalSize:
+		err = diskutil.ShrinkContainer(ctx, du, di, args.targetSize, args.forceInternal)
+	default:
+		err = diskutil.GrowContainer(ctx, du, di, diskutil.GrowOptions{TargetSize: args.targetSize, ForceInternal: args.forceInternal})
+	}
+	if err != nil {
+		return fmt.Errorf("cannot resize container: %w", err)
+	}
+
+	verb := "resized"
+	if args.dryrun {
+		verb = "would resize"
+	}
+	fmt.Fprintf(args.out, "%s %s from %s to %s\n", verb, di.DeviceIdentifier,
+		formatResizeSize(di.TotalSize), formatResizeSize(args.targetSize))
+
+	return nil
+}
+
+// formatResizeSize formats a size in bytes with both its human-readable and exact values (e.g. "120 GB (120000000000
+// bytes)").
+func formatResizeSize(size uint64) string {
+	return fmt.Sprintf("%s (%d bytes)", humanize.Bytes(size), size)
+}
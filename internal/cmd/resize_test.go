@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// resizeFixture creates a FakeUtil with the root container (disk2) and separate containers which use 40 GB of their
+// 100 GB: disk4 and disk3, which is on the internal disk.
+func resizeFixture() *diskutil.FakeUtil {
+	const containerSize uint64 = 100_000_000_000
+
+	partitions := &types.SystemPartitions{AllDisks: []string{"disk2", "disk3", "disk4"}}
+	disks := map[string]*types.DiskInfo{
+		"/": {
+			APFSContainerReference: "disk2",
+			ContainerInfo:          types.ContainerInfo{FilesystemType: "apfs"},
+			DeviceIdentifier:       "disk2s1",
+			MountPoint:             "/",
+			WritableMedia:          true,
+		},
+		"disk3": {
+			APFSPhysicalStores: []types.APFSPhysicalStore{{DeviceIdentifier: "disk0s2"}},
+			ContainerInfo: types.ContainerInfo{
+				APFSContainerFree: 60_000_000_000,
+				APFSContainerSize: containerSize,
+				FilesystemType:    "apfs",
+			},
+			DeviceIdentifier: "disk3",
+			TotalSize:        containerSize,
+		},
+		"disk4": {
+			ContainerInfo: types.ContainerInfo{
+				APFSContainerFree: 60_000_000_000,
+				APFSContainerSize: containerSize,
+				FilesystemType:    "apfs",
+			},
+			DeviceIdentifier: "disk4",
+			TotalSize:        containerSize,
+		},
+	}
+
+	return diskutil.NewFakeUtil(partitions, disks)
+}
+
+func TestRunResize_BelowUsedSpace(t *testing.T) {
+	fake := resizeFixture()
+
+	var out bytes.Buffer
+	err := runResize(context.Background(), fake, resizeContainer{id: "disk4", targetSize: 30_000_000_000, out: &out})
+
+	var usedErr diskutil.BelowUsedSpaceError
+	assert.True(t, errors.As(err, &usedErr), "should refuse to shrink below the used space")
+	for _, call := range fake.Calls() {
+		assert.NotEqual(t, "ResizeContainer", call.Method, "shouldn't resize the container")
+	}
+	assert.Empty(t, out.String(), "shouldn't report a resize")
+}
+
+func TestRunResize_Shrink(t *testing.T) {
+	fake := resizeFixture()
+
+	var out bytes.Buffer
+	err := runResize(context.Background(), fake, resizeContainer{id: "disk4", targetSize: 50_000_000_000, out: &out})
+
+	assert.NoError(t, err, "should shrink above the used space")
+	assert.Contains(t, fake.Calls(), diskutil.FakeCall{Method: "ResizeContainer", Args: []string{"disk4", "50000000000B"}},
+		"should resize the container to the exact size")
+	assert.Equal(t, "resized disk4 from 100 GB (100000000000 bytes) to 50 GB (50000000000 bytes)\n", out.String())
+}
+
+func TestRunResize_ShrinkInternalDisk(t *testing.T) {
+	fake := resizeFixture()
+
+	var out bytes.Buffer
+	err := runResize(context.Background(), fake, resizeContainer{id: "disk3", targetSize: 50_000_000_000, out: &out})
+
+	var internalErr diskutil.InternalDiskError
+	assert.True(t, errors.As(err, &internalErr), "should refuse to shrink a container on the internal disk")
+	for _, call := range fake.Calls() {
+		assert.NotEqual(t, "ResizeContainer", call.Method, "shouldn't resize the container")
+	}
+}
+
+func TestRunResize_ShrinkInternalDiskWithForce(t *testing.T) {
+	fake := resizeFixture()
+
+	var out bytes.Buffer
+	err := runResize(context.Background(), fake, resizeContainer{forceInternal: true, id: "disk3", targetSize: 50_000_000_000, out: &out})
+
+	assert.NoError(t, err, "should shrink a container on the internal disk when forced")
+	assert.Contains(t, fake.Calls(), diskutil.FakeCall{Method: "ResizeContainer", Args: []string{"disk3", "50000000000B"}},
+		"should resize the container to the exact size")
+}
+
+func TestRunResize_WithDryrun(t *testing.T) {
+	fake := resizeFixture()
+
+	var out bytes.Buffer
+	err := runResize(context.Background(), diskutil.Dryrun(fake), resizeContainer{dryrun: true, id: "disk4", targetSize: 50_000_000_000, out: &out})
+
+	assert.NoError(t, err, "should plan the shrink in a dry run")
+	for _, call := range fake.Calls() {
+		assert.NotEqual(t, "ResizeContainer", call.Method, "shouldn't resize the container in a dry run")
+	}
+	assert.Equal(t, "would resize disk4 from 100 GB (100000000000 bytes) to 50 GB (50000000000 bytes)\n", out.String(),
+		"should print the planned size")
+}
+
+func TestRunResize_SameSize(t *testing.T) {
+	fake := resizeFixture()
+
+	var out bytes.Buffer
+	err := runResize(context.Background(), fake, resizeContainer{id: "disk4", targetSize: 100_000_000_000, out: &out})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "disk4 is already 100 GB (100000000000 bytes)\n", out.String())
+}
@@ -2,6 +2,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -12,16 +13,44 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/aws/ec2-macos-utils/internal/build"
+	"github.com/aws/ec2-macos-utils/internal/contextual"
+	"github.com/aws/ec2-macos-utils/internal/system"
+	"github.com/aws/ec2-macos-utils/internal/util"
 )
 
 const shortLicenseText = "Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved."
 
+const (
+	// logFormatText is the log format for human-readable text logs.
+	logFormatText = "text"
+	// logFormatJSON is the log format for structured JSON logs (e.g. for shipping to CloudWatch).
+	logFormatJSON = "json"
+)
+
+// defaultCommandTimeout is the default amount of time each read-only diskutil query (e.g. diskutil list) may run for.
+// Commands which change disks (e.g. diskutil apfs resizeContainer) aren't bounded by it.
+const defaultCommandTimeout = time.Minute
+
 // MainCommand provides the main program entrypoint that dispatches to utility subcommands.
 func MainCommand() *cobra.Command {
 	cmd := rootCommand()
 
 	cmds := []*cobra.Command{
 		growContainerCommand(),
+		daemonCommand(),
+		listCommand(),
+		infoCommand(),
+		preflightCommand(),
+		verifyCommand(),
+		snapshotsCommand(),
+		resizeCommand(),
+		eraseCommand(),
+		renameCommand(),
+		addVolumeCommand(),
+		unlockCommand(),
+		smartCommand(),
+		usageCommand(),
+		captureCommand(),
 	}
 	for i := range cmds {
 		cmd.AddCommand(cmds[i])
@@ -49,44 +78,134 @@ help text and usages that accompany them.
 	versionTemplate := "{{.Name}} {{.Version}} [%s]\n\n%s\n"
 	cmd.SetVersionTemplate(fmt.Sprintf(versionTemplate, build.CommitDate, shortLicenseText))
 
-	var verbose bool
+	var verbose, quiet, traceCommands bool
+	var forceRelease, output, logFormat string
+	var commandTimeout time.Duration
 	cmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging output")
+	cmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Only log errors, command output (e.g. JSON) is still written")
+	cmd.PersistentFlags().BoolVar(&traceCommands, "trace-commands", false, "Log each diskutil command run and its duration")
+	cmd.PersistentFlags().StringVar(&output, "output", outputTable, `output format, one of: "table", "json", or "ndjson" (grow only)`)
+	cmd.PersistentFlags().StringVar(&logFormat, "log-format", logFormatText, `log format, one of: "text", "json"`)
+	cmd.PersistentFlags().DurationVar(&commandTimeout, "command-timeout", defaultCommandTimeout, "Set the timeout for each read-only diskutil query, like list and info (e.g. 30s, 1m), 0s will disable the timeout")
+	cmd.PersistentFlags().StringVar(&forceRelease, "force-release", "", "macOS version (e.g. 14.0) to use instead of the identified system version")
 
 	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
-		level := logrus.InfoLevel
-		if verbose {
-			level = logrus.DebugLevel
+		level, err := logLevel(verbose, quiet)
+		if err != nil {
+			return err
+		}
+		if err := setupLogging(level, logFormat); err != nil {
+			return err
 		}
-		setupLogging(level)
 
-		return nil
+		if traceCommands {
+			util.SetCommandObserver(traceCommand)
+		}
+		cmd.SetContext(util.WithCommandTimeout(cmd.Context(), commandTimeout))
+
+		return setupProduct(cmd, forceRelease)
 	}
 
 	return cmd
 }
 
-// setupLogging configures logrus to use the desired timestamp format and log level.
-func setupLogging(level logrus.Level) {
-	Formatter := &logrus.TextFormatter{}
+// logLevel provides the log level selected by the --verbose and --quiet flags, which can't be used together.
+func logLevel(verbose, quiet bool) (logrus.Level, error) {
+	switch {
+	case verbose && quiet:
+		return logrus.InfoLevel, errors.New("--quiet can't be used with --verbose")
+	case verbose:
+		return logrus.DebugLevel, nil
+	case quiet:
+		return logrus.ErrorLevel, nil
+	default:
+		return logrus.InfoLevel, nil
+	}
+}
+
+// setupProduct replaces the identified system's Product in the command's context with the forced release's, if any.
+// The Product isn't required here since not every command uses it (e.g. usage), see requireProduct.
+func setupProduct(cmd *cobra.Command, forceRelease string) error {
+	if forceRelease == "" {
+		return nil
+	}
+
+	ctx := cmd.Context()
+	product, err := system.ProductForVersion(forceRelease)
+	if err != nil {
+		return fmt.Errorf("cannot force release: %w", err)
+	}
+	// The architecture isn't part of the release so keep what was detected for the identified system, if any
+	if identified := contextual.Product(ctx); identified != nil {
+		product.Arch = identified.Arch
+	}
+	logrus.WithField("product", product).Warn("Forcing release instead of the identified system version")
+	cmd.SetContext(contextual.WithProduct(ctx, product))
+
+	return nil
+}
+
+// requireProduct provides the Product in the context for commands which configure diskutil for it. An error with
+// guidance is returned when the system couldn't be identified and no release was forced, wrapping why it couldn't be
+// identified (see contextual.ProductErr) if known.
+func requireProduct(ctx context.Context) (*system.Product, error) {
+	product := contextual.Product(ctx)
+	if product == nil {
+		const guidance = "re-run command with --force-release (e.g. --force-release 14.0) to use a known release"
+		if err := contextual.ProductErr(ctx); err != nil {
+			return nil, fmt.Errorf("unable to identify the macOS version, %s: %w", guidance, err)
+		}
+		return nil, errors.New("unable to identify the macOS version, " + guidance)
+	}
+
+	return product, nil
+}
 
-	// Configure the formatter
-	Formatter.TimestampFormat = time.RFC822
-	Formatter.FullTimestamp = true
+// setupLogging configures logrus to use the desired log format, timestamp format, and log level. Text logs use RFC822
+// timestamps while JSON logs use ISO8601 (RFC3339) timestamps.
+func setupLogging(level logrus.Level, format string) error {
+	var formatter logrus.Formatter
+	switch format {
+	case logFormatText:
+		formatter = &logrus.TextFormatter{
+			TimestampFormat: time.RFC822,
+			FullTimestamp:   true,
+		}
+	case logFormatJSON:
+		formatter = &logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339,
+		}
+	default:
+		return fmt.Errorf("unsupported log format %q, must be %q or %q", format, logFormatText, logFormatJSON)
+	}
 
 	// Set the desired log level
 	logrus.SetLevel(level)
 
-	logrus.SetFormatter(Formatter)
+	logrus.SetFormatter(formatter)
+
+	return nil
 }
 
+// geteuid provides the effective user ID of the process. It's replaced in tests to run as other users.
+var geteuid = os.Geteuid
+
 func hasRootPrivileges() bool {
-	return os.Geteuid() == 0
+	return geteuid() == 0
 }
 
 // assertRootPrivileges checks if the command is running with root permissions.
 // If the command doesn't have root permissions, a help message is logged with
-// an example and an error is returned.
+// an example and an error is returned. Root permissions aren't required when
+// the command's --dry-run or --plan flag is set since nothing is mutated.
 func assertRootPrivileges(cmd *cobra.Command, args []string) error {
+	for _, flag := range []string{"dry-run", "plan"} {
+		if readOnly, err := cmd.Flags().GetBool(flag); err == nil && readOnly {
+			logrus.WithField("flag", flag).Debug("Skipping user permissions check for read-only run")
+			return nil
+		}
+	}
+
 	logrus.Debug("Checking user permissions...")
 	ok := hasRootPrivileges()
 	if !ok {
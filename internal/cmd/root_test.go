@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aws/ec2-macos-utils/internal/contextual"
+	"github.com/aws/ec2-macos-utils/internal/system"
+	"github.com/aws/ec2-macos-utils/internal/util"
+)
+
+func TestSetupProduct_WithoutProduct(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+
+	err := setupProduct(cmd, "")
+
+	assert.NoError(t, err, "shouldn't require a product for every command")
+	assert.Nil(t, contextual.Product(cmd.Context()), "shouldn't provide a product")
+}
+
+func TestRequireProduct(t *testing.T) {
+	product, _ := system.ProductForVersion("13.6")
+
+	actual, err := requireProduct(contextual.WithProduct(context.Background(), product))
+	assert.NoError(t, err, "should provide the identified product")
+	assert.Equal(t, product, actual, "should provide the identified product")
+
+	_, err = requireProduct(context.Background())
+	if assert.Error(t, err, "should fail without a product") {
+		assert.Contains(t, err.Error(), "--force-release", "should suggest forcing a release")
+	}
+
+	scanErr := errors.New("cannot identify system")
+	_, err = requireProduct(contextual.WithProductErr(context.Background(), scanErr))
+	if assert.Error(t, err, "should fail without a product") {
+		assert.Contains(t, err.Error(), "--force-release", "should suggest forcing a release")
+		assert.True(t, errors.Is(err, scanErr), "should report why the system couldn't be identified")
+	}
+}
+
+func TestRootCommand_WithoutProduct(t *testing.T) {
+	formatter, level := logrus.StandardLogger().Formatter, logrus.GetLevel()
+	t.Cleanup(func() {
+		logrus.SetFormatter(formatter)
+		logrus.SetLevel(level)
+	})
+
+	var ran bool
+	root := rootCommand()
+	root.AddCommand(&cobra.Command{Use: "sub", RunE: func(cmd *cobra.Command, args []string) error {
+		ran = true
+		return nil
+	}})
+	root.SetArgs([]string{"sub"})
+	root.SetErr(io.Discard)
+
+	err := root.ExecuteContext(context.Background())
+
+	assert.NoError(t, err, "should run commands which don't use the product")
+	assert.True(t, ran, "should run the subcommand")
+}
+
+func TestSetupProduct_WithProduct(t *testing.T) {
+	product, _ := system.ProductForVersion("13.6")
+	cmd := &cobra.Command{}
+	cmd.SetContext(contextual.WithProduct(context.Background(), product))
+
+	err := setupProduct(cmd, "")
+
+	assert.NoError(t, err, "should use the identified product")
+	assert.Equal(t, product, contextual.Product(cmd.Context()), "should keep the identified product")
+}
+
+func TestSetupProduct_WithForceRelease(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+
+	err := setupProduct(cmd, "14.0")
+
+	assert.NoError(t, err, "should be able to force a known release")
+	if assert.NotNil(t, contextual.Product(cmd.Context()), "should provide the forced product") {
+		assert.Equal(t, system.Sonoma, contextual.Product(cmd.Context()).Release, "should provide the forced release")
+	}
+}
+
+func TestSetupProduct_WithInvalidForceRelease(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+
+	err := setupProduct(cmd, "10.13")
+
+	assert.Error(t, err, "shouldn't be able to force an unknown release")
+	assert.Nil(t, contextual.Product(cmd.Context()), "shouldn't provide a product")
+}
+
+func TestSetupProduct_WithForceReleaseKeepsArch(t *testing.T) {
+	identified, _ := system.ProductForVersion("13.6")
+	identified.Arch = system.ArchARM64
+	cmd := &cobra.Command{}
+	cmd.SetContext(contextual.WithProduct(context.Background(), identified))
+
+	err := setupProduct(cmd, "14.0")
+
+	assert.NoError(t, err, "should be able to force a known release")
+	if assert.NotNil(t, contextual.Product(cmd.Context()), "should provide the forced product") {
+		assert.Equal(t, system.ArchARM64, contextual.Product(cmd.Context()).Arch, "should keep the detected architecture")
+	}
+}
+
+// runRootWithArgs runs a no-op subcommand of the root command with the args so the root's persistent pre-run
+// configures logging. The standard logger's formatter and level are restored after the test.
+func runRootWithArgs(t *testing.T, args ...string) error {
+	t.Helper()
+
+	formatter, level := logrus.StandardLogger().Formatter, logrus.GetLevel()
+	t.Cleanup(func() {
+		logrus.SetFormatter(formatter)
+		logrus.SetLevel(level)
+	})
+
+	product, _ := system.ProductForVersion("13.6")
+	root := rootCommand()
+	root.AddCommand(&cobra.Command{Use: "sub", RunE: func(*cobra.Command, []string) error { return nil }})
+	root.SetArgs(append([]string{"sub"}, args...))
+	root.SetErr(io.Discard)
+
+	return root.ExecuteContext(contextual.WithProduct(context.Background(), product))
+}
+
+func TestRootCommand_LogFormat(t *testing.T) {
+	err := runRootWithArgs(t)
+
+	assert.NoError(t, err)
+	if formatter, ok := logrus.StandardLogger().Formatter.(*logrus.TextFormatter); assert.True(t, ok, "should log text by default") {
+		assert.Equal(t, time.RFC822, formatter.TimestampFormat, "should use RFC822 timestamps")
+	}
+}
+
+func TestRootCommand_LogFormatJSON(t *testing.T) {
+	err := runRootWithArgs(t, "--log-format", "json")
+
+	assert.NoError(t, err)
+	if formatter, ok := logrus.StandardLogger().Formatter.(*logrus.JSONFormatter); assert.True(t, ok, "should log JSON") {
+		assert.Equal(t, time.RFC3339, formatter.TimestampFormat, "should use ISO8601 timestamps")
+	}
+}
+
+func TestRootCommand_UnsupportedLogFormat(t *testing.T) {
+	err := runRootWithArgs(t, "--log-format", "xml")
+
+	assert.Error(t, err, "shouldn't accept an unsupported log format")
+}
+
+func TestRootCommand_LogLevel(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantLevel logrus.Level
+		wantErr   bool
+	}{
+		{name: "default", args: nil, wantLevel: logrus.InfoLevel},
+		{name: "verbose", args: []string{"--verbose"}, wantLevel: logrus.DebugLevel},
+		{name: "quiet", args: []string{"--quiet"}, wantLevel: logrus.ErrorLevel},
+		{name: "quiet shorthand", args: []string{"-q"}, wantLevel: logrus.ErrorLevel},
+		{name: "quiet and verbose", args: []string{"-q", "-v"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runRootWithArgs(t, tt.args...)
+
+			if tt.wantErr {
+				assert.Error(t, err, "shouldn't accept both --quiet and --verbose")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantLevel, logrus.GetLevel(), "should set the log level")
+		})
+	}
+}
+
+// runAsUser replaces the effective user ID for the test.
+func runAsUser(t *testing.T, euid int) {
+	t.Helper()
+
+	t.Cleanup(func() { geteuid = os.Geteuid })
+	geteuid = func() int { return euid }
+}
+
+func TestRootCommand_CommandTimeout(t *testing.T) {
+	product, _ := system.ProductForVersion("13.6")
+	root := rootCommand()
+	root.AddCommand(&cobra.Command{Use: "sub", RunE: func(cmd *cobra.Command, args []string) error {
+		_, err := util.ExecuteCommand(cmd.Context(), []string{"sleep", "5"}, "", nil, nil)
+		return err
+	}})
+	root.SetArgs([]string{"sub", "--command-timeout", "100ms"})
+	root.SetErr(io.Discard)
+
+	err := root.ExecuteContext(contextual.WithProduct(context.Background(), product))
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "should stop commands exceeding the timeout")
+}
+
+func TestAssertRootPrivileges(t *testing.T) {
+	tests := []struct {
+		name    string
+		euid    int
+		args    []string
+		wantErr bool
+	}{
+		{name: "root", euid: 0, args: nil, wantErr: false},
+		{name: "non-root", euid: 501, args: nil, wantErr: true},
+		{name: "root with dry run", euid: 0, args: []string{"--dry-run"}, wantErr: false},
+		{name: "non-root with dry run", euid: 501, args: []string{"--dry-run"}, wantErr: false},
+		{name: "non-root without dry run", euid: 501, args: []string{"--dry-run=false"}, wantErr: true},
+		{name: "non-root with plan", euid: 501, args: []string{"--plan"}, wantErr: false},
+		{name: "non-root without plan", euid: 501, args: []string{"--plan=false"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runAsUser(t, tt.euid)
+			cmd := growContainerCommand()
+			if !assert.NoError(t, cmd.ParseFlags(tt.args), "should parse the flags") {
+				return
+			}
+
+			err := assertRootPrivileges(cmd, nil)
+
+			if tt.wantErr {
+				assert.Error(t, err, "should require root privileges")
+			} else {
+				assert.NoError(t, err, "shouldn't require root privileges")
+			}
+		})
+	}
+}
+
+func TestAssertRootPrivileges_WithoutDryrunFlag(t *testing.T) {
+	runAsUser(t, 501)
+
+	err := assertRootPrivileges(&cobra.Command{}, nil)
+
+	assert.Error(t, err, "should require root privileges for commands without --dry-run")
+}
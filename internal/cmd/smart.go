@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+)
+
+// smartVerified is the SMART status diskutil reports for healthy disks.
+const smartVerified = "Verified"
+
+// smartIndicators are the key wear indicators of a disk as reported by SMART.
+type smartIndicators struct {
+	// PercentageUsed is the estimate of the device's life used (may exceed 100).
+	PercentageUsed int `json:"percentageUsed"`
+	// TemperatureKelvin is the device's composite temperature in Kelvin.
+	TemperatureKelvin int `json:"temperatureKelvin"`
+	// MediaErrors is the number of unrecovered data integrity errors.
+	MediaErrors int `json:"mediaErrors"`
+	// UnsafeShutdowns is the number of times the device lost power without being shut down.
+	UnsafeShutdowns int `json:"unsafeShutdowns"`
+}
+
+// smartOutput is the JSON output of the smart command.
+type smartOutput struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	// Indicators are omitted for disks which don't report them (e.g. EBS volumes).
+	Indicators *smartIndicators `json:"indicators,omitempty"`
+}
+
+// newSmartOutput creates the SMART output for the disk. The counters diskutil reports are split into the low (_0) and
+// high (_1) 64 bits of NVMe's 128-bit counters, only the low bits are used since the high bits are never set in practice.
+func newSmartOutput(disk *types.DiskInfo) smartOutput {
+	out := smartOutput{
+		SchemaVersion: schemaVersion,
+		ID:            disk.DeviceIdentifier,
+		Status:        disk.SMARTStatus,
+	}
+
+	if info := disk.SMARTDeviceSpecificKeysMayVaryNotGuaranteed; info != nil {
+		out.Indicators = &smartIndicators{
+			PercentageUsed:    info.PercentageUsed,
+			TemperatureKelvin: info.Temperature,
+			MediaErrors:       info.MediaErrors0,
+			UnsafeShutdowns:   info.UnsafeShutdowns0,
+		}
+	}
+
+	return out
+}
+
+// smartCommand creates a new command which displays the SMART health status of a disk.
+func smartCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "smart",
+		Short: "display the SMART health status of a disk",
+		Long: strings.TrimSpace(`
+smart displays the SMART status of the disk with the given
+identifier (e.g. disk0 or /dev/disk0) as reported by
+'diskutil', along with its key wear indicators (percentage
+used, temperature, media errors, and unsafe shutdowns) when
+the disk reports them. The string 'root' (or the path '/')
+may be provided for the OS's root volume.
+With --output json, the status is written as JSON instead.
+The command fails when the status isn't Verified.
+		`),
+	}
+
+	// Set up the flags to be passed into the command
+	var id string
+	cmd.PersistentFlags().StringVar(&id, "id", "", `disk identifier, "root", or "/"`)
+	cmd.MarkPersistentFlagRequired("id")
+
+	// Set up the command's run function
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		output, err := outputFlag(cmd)
+		if err != nil {
+			return err
+		}
+
+		disk, err := diskInfo(cmd.Context(), id)
+		if err != nil {
+			return writeOutputError(cmd.OutOrStdout(), output, err)
+		}
+
+		smart := newSmartOutput(disk)
+		if output == outputJSON {
+			err = writeJSON(cmd.OutOrStdout(), smart)
+		} else {
+			err = renderSmart(cmd.OutOrStdout(), smart)
+		}
+		if err != nil {
+			return err
+		}
+
+		return smartStatusErr(smart)
+	}
+
+	return cmd
+}
+
+// renderSmart writes the SMART status and, if available, the wear indicators to w.
+func renderSmart(w io.Writer, smart smartOutput) error {
+	status := smart.Status
+	if status == "" {
+		status = "-"
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "id:\t%s\n", smart.ID)
+	fmt.Fprintf(tw, "status:\t%s\n", status)
+	if i := smart.Indicators; i != nil {
+		fmt.Fprintf(tw, "percentage used:\t%d%%\n", i.PercentageUsed)
+		fmt.Fprintf(tw, "temperature:\t%d K\n", i.TemperatureKelvin)
+		fmt.Fprintf(tw, "media errors:\t%d\n", i.MediaErrors)
+		fmt.Fprintf(tw, "unsafe shutdowns:\t%d\n", i.UnsafeShutdowns)
+	}
+
+	return tw.Flush()
+}
+
+// smartStatusErr provides an error if the SMART status isn't Verified (e.g. "Failing" or "Not Supported").
+func smartStatusErr(smart smartOutput) error {
+	if smart.Status == smartVerified {
+		return nil
+	}
+
+	status := smart.Status
+	if status == "" {
+		status = "unknown"
+	}
+
+	return fmt.Errorf("SMART status of %s is %s, not %s", smart.ID, status, smartVerified)
+}
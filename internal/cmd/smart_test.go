@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bytes"
+	_ "embed"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	//go:embed testdata/smart_disk_info.plist
+	// smartDiskInfo contains the disk information of an NVMe disk which reports SMART wear indicators.
+	smartDiskInfo []byte
+)
+
+// decodeSmartDiskInfo decodes smartDiskInfo like diskutil's output.
+func decodeSmartDiskInfo(t *testing.T) *types.DiskInfo {
+	t.Helper()
+
+	disk, err := (&diskutil.PlistDecoder{}).DecodeDiskInfo(bytes.NewReader(smartDiskInfo))
+	if !assert.NoError(t, err, "should decode the sample disk information") {
+		t.FailNow()
+	}
+
+	return disk
+}
+
+func TestRenderSmart(t *testing.T) {
+	smart := newSmartOutput(decodeSmartDiskInfo(t))
+
+	var out bytes.Buffer
+	err := renderSmart(&out, smart)
+
+	expected := "id:                disk0\n" +
+		"status:            Verified\n" +
+		"percentage used:   3%\n" +
+		"temperature:       309 K\n" +
+		"media errors:      0\n" +
+		"unsafe shutdowns:  17\n"
+	assert.NoError(t, err)
+	assert.Equal(t, expected, out.String(), "should render the status and wear indicators")
+	assert.NoError(t, smartStatusErr(smart), "shouldn't fail for a verified disk")
+}
+
+func TestNewSmartOutput_JSON(t *testing.T) {
+	smart := newSmartOutput(decodeSmartDiskInfo(t))
+
+	expected := `{
+		"schemaVersion": 1,
+		"id": "disk0",
+		"status": "Verified",
+		"indicators": {"percentageUsed": 3, "temperatureKelvin": 309, "mediaErrors": 0, "unsafeShutdowns": 17}
+	}`
+	assert.JSONEq(t, expected, string(encodeJSON(t, smart)))
+}
+
+func TestNewSmartOutput_WithoutIndicators(t *testing.T) {
+	smart := newSmartOutput(&types.DiskInfo{DeviceIdentifier: "disk4", SMARTStatus: "Not Supported"})
+
+	var out bytes.Buffer
+	err := renderSmart(&out, smart)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "id:      disk4\nstatus:  Not Supported\n", out.String(), "should only render the status")
+	assert.Nil(t, smart.Indicators, "should omit the indicators")
+}
+
+func TestSmartStatusErr(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		wantErr bool
+	}{
+		{name: "verified", status: "Verified"},
+		{name: "failing", status: "Failing", wantErr: true},
+		{name: "not supported", status: "Not Supported", wantErr: true},
+		{name: "empty", status: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := smartStatusErr(smartOutput{ID: "disk0", Status: tt.status})
+
+			if tt.wantErr {
+				assert.Error(t, err, "should fail when the status isn't Verified")
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+)
+
+// snapshotOutput is the JSON representation of a single APFS snapshot.
+type snapshotOutput struct {
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
+}
+
+// snapshotsOutput is the JSON output of the snapshots list command.
+type snapshotsOutput struct {
+	SchemaVersion int              `json:"schemaVersion"`
+	VolumeID      string           `json:"volumeId"`
+	Snapshots     []snapshotOutput `json:"snapshots"`
+}
+
+// newSnapshotsOutput creates the JSON output for the volume's snapshots.
+func newSnapshotsOutput(volumeID string, snapshots []types.Snapshot) snapshotsOutput {
+	out := snapshotsOutput{
+		SchemaVersion: schemaVersion,
+		VolumeID:      volumeID,
+		Snapshots:     []snapshotOutput{},
+	}
+	for _, s := range snapshots {
+		out.Snapshots = append(out.Snapshots, snapshotOutput{UUID: s.SnapshotUUID, Name: s.SnapshotName})
+	}
+
+	return out
+}
+
+// snapshotsCommand creates a new command which lists and deletes the APFS snapshots of a volume.
+func snapshotsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshots",
+		Short: "list and delete APFS snapshots",
+		Long: strings.TrimSpace(`
+snapshots lists and deletes the APFS snapshots of a volume
+(e.g. Time Machine local snapshots) using 'diskutil apfs'.
+Deleting snapshots reclaims the space they consume, which can
+be done before growing the container.
+		`),
+	}
+
+	cmd.AddCommand(snapshotsListCommand(), snapshotsDeleteCommand())
+
+	return cmd
+}
+
+// snapshotsListCommand creates a new command which lists the APFS snapshots of a volume.
+func snapshotsListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list a volume's APFS snapshots",
+		Long: strings.TrimSpace(`
+list displays the UUID and name of each APFS snapshot of the
+volume with the given identifier (e.g. disk1s1 or /dev/disk1s1)
+or mount point (e.g. /System/Volumes/Data).
+With --output json, the snapshots are written as JSON instead.
+		`),
+	}
+
+	// Set up the flags to be passed into the command
+	var id string
+	cmd.PersistentFlags().StringVar(&id, "id", "", "volume identifier or mount point")
+	cmd.MarkPersistentFlagRequired("id")
+
+	// Set up the command's run function
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		output, err := outputFlag(cmd)
+		if err != nil {
+			return err
+		}
+
+		d, err := snapshotsDiskUtil(cmd.Context())
+		if err != nil {
+			return writeOutputError(cmd.OutOrStdout(), output, err)
+		}
+
+		snapshots, err := d.ListSnapshots(cmd.Context(), id)
+		if err != nil {
+			return writeOutputError(cmd.OutOrStdout(), output, fmt.Errorf("cannot list snapshots: %w", err))
+		}
+
+		if output == outputJSON {
+			return writeJSON(cmd.OutOrStdout(), newSnapshotsOutput(id, snapshots))
+		}
+
+		return renderSnapshots(cmd.OutOrStdout(), snapshots)
+	}
+
+	return cmd
+}
+
+// snapshotsDeleteCommand creates a new command which deletes an APFS snapshot of a volume.
+func snapshotsDeleteCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "delete a volume's APFS snapshot",
+		Long: strings.TrimSpace(`
+delete removes the APFS snapshot with the given UUID (as shown
+by 'snapshots list') from the volume with the given identifier
+(e.g. disk1s1 or /dev/disk1s1) or mount point.
+		`),
+	}
+
+	// Set up the flags to be passed into the command
+	var id, uuid string
+	var dryrun bool
+	cmd.PersistentFlags().StringVar(&id, "id", "", "volume identifier or mount point")
+	cmd.PersistentFlags().StringVar(&uuid, "uuid", "", "UUID of the snapshot to be deleted")
+	cmd.PersistentFlags().BoolVar(&dryrun, "dry-run", false, "run command without mutating changes")
+	cmd.MarkPersistentFlagRequired("id")
+	cmd.MarkPersistentFlagRequired("uuid")
+
+	// Set up the command's pre-run to check for root permissions.
+	// This is necessary since diskutil apfs deleteSnapshot requires root permissions to run.
+	cmd.PreRunE = assertRootPrivileges
+
+	// Set up the command's run function
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		d, err := snapshotsDiskUtil(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		if dryrun {
+			d = diskutil.Dryrun(d)
+		}
+
+		return deleteSnapshot(cmd.Context(), d, id, uuid)
+	}
+
+	return cmd
+}
+
+// snapshotsDiskUtil configures diskutil for the product in the context.
+func snapshotsDiskUtil(ctx context.Context) (diskutil.DiskUtil, error) {
+	product, err := requireProduct(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.WithField("product", product).Debug("Configuring diskutil for product")
+	return diskutil.ForProduct(product)
+}
+
+// deleteSnapshot deletes the snapshot with the UUID from the volume. Skipping the deletion in a dry run isn't an error.
+func deleteSnapshot(ctx context.Context, du diskutil.DiskUtil, volumeID, uuid string) error {
+	if strings.TrimSpace(uuid) == "" {
+		return errors.New("empty snapshot uuid")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"volume_id": volumeID,
+		"uuid":      uuid,
+	}).Info("Deleting snapshot...")
+	out, err := du.DeleteSnapshot(ctx, volumeID, uuid)
+	logrus.WithField("out", out).Debug("DeleteSnapshot output")
+	if errors.Is(err, diskutil.ErrReadOnly) {
+		logrus.WithError(err).Warn("Would have deleted snapshot")
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("cannot delete snapshot: %w", err)
+	}
+	logrus.Info("Snapshot deleted")
+
+	return nil
+}
+
+// renderSnapshots writes the UUID and name of each snapshot as a table to w.
+func renderSnapshots(w io.Writer, snapshots []types.Snapshot) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "UUID\tNAME")
+	for _, s := range snapshots {
+		fmt.Fprintf(tw, "%s\t%s\n", s.SnapshotUUID, formatTableString(s.SnapshotName))
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	mock_diskutil "github.com/aws/ec2-macos-utils/internal/diskutil/mocks"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteSnapshot(t *testing.T) {
+	const (
+		volumeID = "disk1s1"
+		uuid     = "4B2E0C19-6E4F-4A43-9C63-5D2C0B7A5F01"
+	)
+
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{name: "deleted"},
+		{name: "dry run", err: fmt.Errorf("skip delete snapshot: %w", diskutil.ErrReadOnly)},
+		{name: "failed", err: errors.New("error"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_diskutil.NewMockDiskUtil(ctrl)
+			mock.EXPECT().DeleteSnapshot(ctx, volumeID, uuid).Return("", tt.err)
+
+			err := deleteSnapshot(ctx, mock, volumeID, uuid)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestDeleteSnapshot_WithoutUUID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// No DeleteSnapshot is expected without a UUID
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+
+	err := deleteSnapshot(context.Background(), mock, "disk1s1", " ")
+
+	assert.Error(t, err, "shouldn't delete a snapshot without a UUID")
+}
+
+func TestRenderSnapshots(t *testing.T) {
+	snapshots := []types.Snapshot{
+		{SnapshotUUID: "4B2E0C19-6E4F-4A43-9C63-5D2C0B7A5F01", SnapshotName: "com.apple.TimeMachine.2023-10-11-160000.local"},
+		{SnapshotUUID: "9A6F3D2E-1B7C-4E8D-8F90-A1B2C3D4E5F6"},
+	}
+
+	var out bytes.Buffer
+	err := renderSnapshots(&out, snapshots)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "UUID                                  NAME\n"+
+		"4B2E0C19-6E4F-4A43-9C63-5D2C0B7A5F01  com.apple.TimeMachine.2023-10-11-160000.local\n"+
+		"9A6F3D2E-1B7C-4E8D-8F90-A1B2C3D4E5F6  -\n", out.String())
+}
+
+func TestNewSnapshotsOutput(t *testing.T) {
+	out := newSnapshotsOutput("disk1s1", nil)
+
+	data, err := json.Marshal(out)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"schemaVersion": 1, "volumeId": "disk1s1", "snapshots": []}`, string(data),
+		"should write an empty list without snapshots")
+}
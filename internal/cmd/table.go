@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+)
+
+const (
+	// outputTable is the --output mode which renders disks as a table.
+	outputTable = "table"
+	// outputJSON is the --output mode which writes disks as JSON (see schemaVersion).
+	outputJSON = "json"
+	// outputNDJSON is the --output mode which writes each result as a line of JSON, only supported by grow.
+	outputNDJSON = "ndjson"
+
+	// defaultColumns are the table columns rendered when no columns are requested.
+	defaultColumns = "id,size,free,fs"
+)
+
+// diskRow holds the values of a single disk, partition, or volume to be rendered in a table. Sizes of 0 are rendered
+// as unknown.
+type diskRow struct {
+	id    string
+	size  uint64
+	free  uint64
+	fs    string
+	name  string
+	mount string
+}
+
+// tableColumn describes a column which can be selected for a table.
+type tableColumn struct {
+	// header is the column's title.
+	header string
+	// value formats the column's value for the row.
+	value func(r diskRow) string
+}
+
+// tableColumns are all the columns which can be selected for a table, keyed by the name used to select them.
+var tableColumns = map[string]tableColumn{
+	"id":    {header: "ID", value: func(r diskRow) string { return r.id }},
+	"size":  {header: "SIZE", value: func(r diskRow) string { return formatTableSize(r.size) }},
+	"free":  {header: "FREE", value: func(r diskRow) string { return formatTableSize(r.free) }},
+	"fs":    {header: "FS", value: func(r diskRow) string { return formatTableString(r.fs) }},
+	"name":  {header: "NAME", value: func(r diskRow) string { return formatTableString(r.name) }},
+	"mount": {header: "MOUNT", value: func(r diskRow) string { return formatTableString(r.mount) }},
+}
+
+// tableColumnNames lists the names of tableColumns in the order they're documented.
+var tableColumnNames = []string{"id", "size", "free", "fs", "name", "mount"}
+
+// validateOutput checks that the output mode is supported.
+func validateOutput(output string) error {
+	if output != outputTable && output != outputJSON {
+		return fmt.Errorf("unsupported output %q, must be %q or %q", output, outputTable, outputJSON)
+	}
+
+	return nil
+}
+
+// parseColumns parses a comma-separated list of column names (e.g. "id,size"), validating each against the known
+// tableColumns. The defaultColumns are used when the list is empty.
+func parseColumns(spec string) ([]string, error) {
+	if strings.TrimSpace(spec) == "" {
+		spec = defaultColumns
+	}
+
+	var columns []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := tableColumns[name]; !ok {
+			return nil, fmt.Errorf("unknown column %q, must be one of: %s", name, strings.Join(tableColumnNames, ", "))
+		}
+		columns = append(columns, name)
+	}
+
+	return columns, nil
+}
+
+// renderTable writes the rows as a table with the given columns to w.
+func renderTable(w io.Writer, columns []string, rows []diskRow) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	headers := make([]string, len(columns))
+	for i, name := range columns {
+		headers[i] = tableColumns[name].header
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+
+	for _, row := range rows {
+		values := make([]string, len(columns))
+		for i, name := range columns {
+			values[i] = tableColumns[name].value(row)
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// formatTableSize formats a size in bytes for a table.
+func formatTableSize(size uint64) string {
+	if size == 0 {
+		return "-"
+	}
+
+	return humanize.Bytes(size)
+}
+
+// formatTableString formats a possibly empty string for a table.
+func formatTableString(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+// partitionRows creates a row for each disk, partition, and APFS volume in the system partitions.
+func partitionRows(partitions *types.SystemPartitions) []diskRow {
+	var rows []diskRow
+	for _, disk := range partitions.AllDisksAndPartitions {
+		row := diskRow{
+			id:   disk.DeviceIdentifier,
+			size: disk.Size,
+			fs:   disk.Content,
+		}
+		if len(disk.Partitions) > 0 || len(disk.APFSVolumes) > 0 {
+			row.free, _ = partitions.AvailableDiskSpace(disk.DeviceIdentifier)
+		}
+		rows = append(rows, row)
+
+		for _, part := range disk.Partitions {
+			rows = append(rows, diskRow{
+				id:   part.DeviceIdentifier,
+				size: part.Size,
+				fs:   part.Content,
+				name: part.VolumeName,
+			})
+		}
+
+		for _, volume := range disk.APFSVolumes {
+			rows = append(rows, diskRow{
+				id:    volume.DeviceIdentifier,
+				size:  volume.Size,
+				fs:    "apfs",
+				name:  volume.VolumeName,
+				mount: volume.MountPoint,
+			})
+		}
+	}
+
+	return rows
+}
+
+// diskInfoRow creates a row for the disk information. The free space of APFS containers is used when available.
+func diskInfoRow(disk *types.DiskInfo) diskRow {
+	row := diskRow{
+		id:    disk.DeviceIdentifier,
+		size:  disk.TotalSize,
+		free:  disk.FreeSpace,
+		fs:    disk.FilesystemType,
+		name:  disk.VolumeName,
+		mount: disk.MountPoint,
+	}
+	if disk.APFSContainerSize > 0 {
+		row.free = disk.APFSContainerFree
+	}
+	if row.fs == "" {
+		row.fs = disk.Content
+	}
+
+	return row
+}
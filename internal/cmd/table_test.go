@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "without columns",
+			spec: "",
+			want: []string{"id", "size", "free", "fs"},
+		},
+		{
+			name: "with selected columns",
+			spec: "id, Name,mount",
+			want: []string{"id", "name", "mount"},
+		},
+		{
+			name:    "with unknown column",
+			spec:    "id,uuid",
+			wantErr: true,
+		},
+		{
+			name:    "with empty column",
+			spec:    "id,,size",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseColumns(tt.spec)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got, "should parse expected columns")
+		})
+	}
+}
+
+func TestValidateOutput(t *testing.T) {
+	assert.NoError(t, validateOutput("table"), "should support table output")
+	assert.NoError(t, validateOutput("json"), "should support json output")
+	assert.Error(t, validateOutput("yaml"), "shouldn't support unknown output")
+}
+
+func TestRenderTable(t *testing.T) {
+	rows := []diskRow{
+		{id: "disk0", size: 3_000_000, free: 1_000_000, fs: "GUID_partition_scheme"},
+		{id: "disk2s1", size: 500_000, fs: "apfs", name: "Macintosh HD", mount: "/"},
+	}
+
+	var out bytes.Buffer
+	err := renderTable(&out, []string{"id", "free", "name"}, rows)
+
+	expectedOut := strings.Join([]string{
+		"ID       FREE    NAME",
+		"disk0    1.0 MB  -",
+		"disk2s1  -       Macintosh HD",
+	}, "\n") + "\n"
+
+	assert.NoError(t, err, "should be able to render table")
+	assert.Equal(t, expectedOut, out.String(), "should render selected columns")
+}
+
+func TestPartitionRows(t *testing.T) {
+	partitions := &types.SystemPartitions{
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: "disk0",
+				Content:          "GUID_partition_scheme",
+				Size:             3_000_000,
+				Partitions: []types.Partition{
+					{DeviceIdentifier: "disk0s1", Content: "EFI", Size: 500_000},
+				},
+			},
+			{
+				DeviceIdentifier: "disk2",
+				Content:          "Apple_APFS_Container",
+				Size:             2_000_000,
+				APFSVolumes: []types.APFSVolume{
+					{DeviceIdentifier: "disk2s1", VolumeName: "Macintosh HD", MountPoint: "/", Size: 1_000_000},
+				},
+			},
+		},
+	}
+
+	expectedRows := []diskRow{
+		{id: "disk0", size: 3_000_000, free: 2_500_000, fs: "GUID_partition_scheme"},
+		{id: "disk0s1", size: 500_000, fs: "EFI"},
+		{id: "disk2", size: 2_000_000, free: 1_000_000, fs: "Apple_APFS_Container"},
+		{id: "disk2s1", size: 1_000_000, fs: "apfs", name: "Macintosh HD", mount: "/"},
+	}
+
+	actualRows := partitionRows(partitions)
+
+	assert.Equal(t, expectedRows, actualRows, "should have a row for each disk, partition, and volume")
+}
+
+func TestDiskInfoRow(t *testing.T) {
+	disk := &types.DiskInfo{
+		ContainerInfo: types.ContainerInfo{
+			APFSContainerFree: 1_000_000,
+			APFSContainerSize: 3_000_000,
+		},
+		Content:          "Apple_APFS_Container",
+		DeviceIdentifier: "disk2",
+		TotalSize:        3_000_000,
+	}
+
+	expectedRow := diskRow{id: "disk2", size: 3_000_000, free: 1_000_000, fs: "Apple_APFS_Container"}
+
+	actualRow := diskInfoRow(disk)
+
+	assert.Equal(t, expectedRow, actualRow, "should use the container's free space")
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// traceCommand logs each diskutil command that was run, and how long it took, at info level. It's used as the
+// util.CommandObserver for --trace-commands. Commands given a passphrase (e.g. when unlocking an encrypted volume) are
+// never traced.
+func traceCommand(argv []string, elapsed time.Duration, err error) {
+	if len(argv) == 0 || filepath.Base(argv[0]) != "diskutil" || hasPassphrase(argv) {
+		return
+	}
+
+	entry := logrus.WithFields(logrus.Fields{
+		"argv":    strings.Join(argv, " "),
+		"elapsed": elapsed,
+	})
+	if err != nil {
+		entry = entry.WithError(err)
+	}
+	entry.Info("Ran diskutil command")
+}
+
+// hasPassphrase checks if the argv includes a passphrase argument or is for unlocking a volume.
+func hasPassphrase(argv []string) bool {
+	for _, arg := range argv {
+		lower := strings.ToLower(arg)
+		if strings.Contains(lower, "passphrase") || lower == "unlockvolume" {
+			return true
+		}
+	}
+
+	return false
+}
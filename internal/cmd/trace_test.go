@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		argv      []string
+		err       error
+		wantTrace string
+	}{
+		{
+			name:      "list",
+			argv:      []string{"diskutil", "list", "-plist"},
+			wantTrace: "diskutil list -plist",
+		},
+		{
+			name:      "resize with error",
+			argv:      []string{"diskutil", "apfs", "resizeContainer", "disk1", "0"},
+			err:       errors.New("exit status 1"),
+			wantTrace: "diskutil apfs resizeContainer disk1 0",
+		},
+		{
+			name: "other command",
+			argv: []string{"/usr/bin/yes"},
+		},
+		{
+			name: "unlock with passphrase",
+			argv: []string{"diskutil", "apfs", "unlockVolume", "disk2s1", "-passphrase", "secret"},
+		},
+		{
+			name: "unlock with stdin passphrase",
+			argv: []string{"diskutil", "apfs", "unlockVolume", "disk2s1", "-stdinpassphrase"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := test.NewGlobal()
+			defer hook.Reset()
+
+			traceCommand(tt.argv, time.Second, tt.err)
+
+			if tt.wantTrace == "" {
+				assert.Empty(t, hook.AllEntries(), "shouldn't trace command")
+				return
+			}
+			entry := hook.LastEntry()
+			if !assert.NotNil(t, entry, "should trace command") {
+				return
+			}
+			assert.Equal(t, logrus.InfoLevel, entry.Level, "should trace at info level")
+			assert.Equal(t, tt.wantTrace, entry.Data["argv"], "should trace the command's argv")
+			assert.Equal(t, time.Second, entry.Data["elapsed"], "should trace the command's duration")
+			assert.Equal(t, tt.err, entry.Data[logrus.ErrorKey], "should trace the command's error")
+		})
+	}
+}
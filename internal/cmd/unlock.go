@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+)
+
+// unlockVolume is a struct for holding all information passed into the unlock command. The passphrase is deliberately
+// not held here since the args are logged.
+type unlockVolume struct {
+	dryrun        bool
+	id            string
+	passphraseEnv string
+}
+
+// unlockCommand creates a new command which unlocks encrypted APFS volumes.
+func unlockCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "unlock",
+		Short: "unlock an encrypted volume",
+		Long: strings.TrimSpace(`
+unlock unlocks the encrypted APFS volume with the given
+identifier (e.g. disk4s1 or /dev/disk4s1) using
+'diskutil apfs unlockVolume'. The passphrase is read from the
+first line of stdin, or from the environment variable named by
+--passphrase-env, and is given to diskutil on stdin so it
+never appears in the process table or logs.
+Use --dry-run to check the passphrase is provided without
+unlocking the volume.
+		`),
+	}
+
+	// Set up the flags to be passed into the command
+	unlockArgs := unlockVolume{}
+	cmd.PersistentFlags().StringVar(&unlockArgs.id, "id", "", "volume identifier to be unlocked")
+	cmd.PersistentFlags().StringVar(&unlockArgs.passphraseEnv, "passphrase-env", "", "environment variable to read the passphrase from instead of stdin")
+	cmd.PersistentFlags().BoolVar(&unlockArgs.dryrun, "dry-run", false, "run command without mutating changes")
+	cmd.MarkPersistentFlagRequired("id")
+
+	// Set up the command's pre-run to check for root permissions.
+	// This is necessary since diskutil apfs unlockVolume requires root permissions to run.
+	cmd.PreRunE = assertRootPrivileges
+
+	// Set up the command's run function
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		passphrase, err := readPassphrase(cmd.InOrStdin(), unlockArgs.passphraseEnv)
+		if err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		product, err := requireProduct(ctx)
+		if err != nil {
+			return err
+		}
+
+		logrus.WithField("product", product).Info("Configuring diskutil for product")
+		d, err := diskutil.ForProduct(product)
+		if err != nil {
+			return err
+		}
+
+		if unlockArgs.dryrun {
+			d = diskutil.Dryrun(d)
+		}
+
+		logrus.WithField("args", unlockArgs).Debug("Running unlock command with args")
+		return runUnlock(ctx, d, unlockArgs, passphrase)
+	}
+
+	return cmd
+}
+
+// readPassphrase reads the passphrase from the environment variable named env or, if no variable is named, from the
+// first line of r. An error is returned if the passphrase is empty.
+func readPassphrase(r io.Reader, env string) (string, error) {
+	if env != "" {
+		passphrase, ok := os.LookupEnv(env)
+		if !ok || passphrase == "" {
+			return "", fmt.Errorf("no passphrase in environment variable %s", env)
+		}
+
+		return passphrase, nil
+	}
+
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("cannot read passphrase: %w", err)
+	}
+	passphrase := strings.TrimRight(line, "\r\n")
+	if passphrase == "" {
+		return "", errors.New("no passphrase provided on stdin")
+	}
+
+	return passphrase, nil
+}
+
+// runUnlock unlocks the volume with diskutil.UnlockVolume. Skipping the unlock in a dry run isn't an error.
+func runUnlock(ctx context.Context, du diskutil.DiskUtil, args unlockVolume, passphrase string) error {
+	err := diskutil.UnlockVolume(ctx, du, args.id, passphrase)
+	if errors.Is(err, diskutil.ErrReadOnly) {
+		logrus.WithError(err).Warn("Would have unlocked volume")
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("cannot unlock volume: %w", err)
+	}
+	logrus.WithField("id", args.id).Info("Volume unlocked")
+
+	return nil
+}
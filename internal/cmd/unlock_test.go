@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const testPassphrase = "correct horse battery staple"
+
+func TestReadPassphrase(t *testing.T) {
+	t.Setenv("TEST_UNLOCK_PASSPHRASE", testPassphrase)
+	t.Setenv("TEST_UNLOCK_EMPTY", "")
+
+	tests := []struct {
+		name    string
+		in      string
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{name: "stdin", in: testPassphrase + "\n", want: testPassphrase},
+		{name: "stdin without newline", in: testPassphrase, want: testPassphrase},
+		{name: "stdin with crlf", in: testPassphrase + "\r\nignored\n", want: testPassphrase},
+		{name: "empty stdin", in: "", wantErr: true},
+		{name: "env", in: "ignored\n", env: "TEST_UNLOCK_PASSPHRASE", want: testPassphrase},
+		{name: "empty env", env: "TEST_UNLOCK_EMPTY", wantErr: true},
+		{name: "unset env", env: "TEST_UNLOCK_UNSET", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readPassphrase(strings.NewReader(tt.in), tt.env)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got, "should read the passphrase")
+		})
+	}
+}
+
+func TestRunUnlock(t *testing.T) {
+	var actualPassphrase string
+	fake := diskutil.NewFakeUtil(nil, nil)
+	fake.UnlockVolumeFunc = func(ctx context.Context, id string, passphrase string) (string, error) {
+		actualPassphrase = passphrase
+
+		return "", nil
+	}
+
+	err := runUnlock(context.Background(), fake, unlockVolume{id: "disk5s1"}, testPassphrase)
+
+	assert.NoError(t, err, "should unlock the volume")
+	assert.Equal(t, testPassphrase, actualPassphrase, "should unlock with the passphrase")
+}
+
+func TestRunUnlock_WithDryrun(t *testing.T) {
+	fake := diskutil.NewFakeUtil(nil, nil)
+
+	err := runUnlock(context.Background(), diskutil.Dryrun(fake), unlockVolume{dryrun: true, id: "disk5s1"}, testPassphrase)
+
+	assert.NoError(t, err, "should skip the unlock in a dry run")
+	assert.Empty(t, fake.Calls(), "shouldn't unlock the volume in a dry run")
+}
+
+func TestRunUnlock_DoesNotLogPassphrase(t *testing.T) {
+	level := logrus.GetLevel()
+	defer logrus.SetLevel(level)
+	logrus.SetLevel(logrus.TraceLevel)
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	fake := diskutil.NewFakeUtil(nil, nil)
+
+	err := runUnlock(context.Background(), fake, unlockVolume{id: "disk5s1"}, testPassphrase)
+
+	assert.NoError(t, err, "should unlock the volume")
+	for _, entry := range hook.AllEntries() {
+		line, err := entry.String()
+		if assert.NoError(t, err) {
+			assert.NotContains(t, line, testPassphrase, "shouldn't log the passphrase")
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+)
+
+// usageOutput is the JSON output of the usage command. Sizes are in bytes.
+type usageOutput struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	MountPoint    string `json:"mountPoint"`
+	BlockSize     uint64 `json:"blockSize"`
+	Size          uint64 `json:"size"`
+	Used          uint64 `json:"used"`
+	Free          uint64 `json:"free"`
+	Available     uint64 `json:"available"`
+}
+
+// newUsageOutput creates the usage output for the file system's usage.
+func newUsageOutput(usage *diskutil.Usage) usageOutput {
+	return usageOutput{
+		SchemaVersion: schemaVersion,
+		MountPoint:    usage.MountPoint,
+		BlockSize:     usage.BlockSize,
+		Size:          usage.TotalBytes,
+		Used:          usage.UsedBytes(),
+		Free:          usage.FreeBytes,
+		Available:     usage.AvailableBytes,
+	}
+}
+
+// usageCommand creates a new command which displays the file system's usage of a mounted volume.
+func usageCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "usage",
+		Short: "display the file system usage of a mounted volume",
+		Long: strings.TrimSpace(`
+usage displays the size, used, free, and available space of
+the volume mounted at --mount-point (the root volume by
+default) as reported by the file system, like df. Unlike
+the allocation of partitions reported by 'diskutil', this is
+the space users see in Finder. Space reserved for root is
+free but not available.
+With --output json, the usage is written as JSON instead.
+		`),
+	}
+
+	// Set up the flags to be passed into the command
+	var mountPoint string
+	cmd.PersistentFlags().StringVar(&mountPoint, "mount-point", "/", "mount point (or any path) of the volume")
+
+	// Set up the command's run function
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		output, err := outputFlag(cmd)
+		if err != nil {
+			return err
+		}
+
+		usage, err := diskutil.UsageOf(cmd.Context(), mountPoint)
+		if err != nil {
+			return writeOutputError(cmd.OutOrStdout(), output, err)
+		}
+
+		if output == outputJSON {
+			return writeJSON(cmd.OutOrStdout(), newUsageOutput(usage))
+		}
+
+		return renderUsage(cmd.OutOrStdout(), newUsageOutput(usage))
+	}
+
+	return cmd
+}
+
+// renderUsage writes the usage to w along with the percentage of the file system's size which is used.
+func renderUsage(w io.Writer, usage usageOutput) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "mount point:\t%s\n", usage.MountPoint)
+	fmt.Fprintf(tw, "block size:\t%d\n", usage.BlockSize)
+	fmt.Fprintf(tw, "size:\t%s\n", humanize.Bytes(usage.Size))
+	fmt.Fprintf(tw, "used:\t%s (%s%%)\n", humanize.Bytes(usage.Used), humanize.Ftoa(usedPercent(usage)))
+	fmt.Fprintf(tw, "free:\t%s\n", humanize.Bytes(usage.Free))
+	fmt.Fprintf(tw, "available:\t%s\n", humanize.Bytes(usage.Available))
+
+	return tw.Flush()
+}
+
+// usedPercent provides the percentage of the file system's size which is used, rounded to one decimal place. It's 0
+// when the size is unknown.
+func usedPercent(usage usageOutput) float64 {
+	if usage.Size == 0 {
+		return 0
+	}
+
+	return math.Round(float64(usage.Used)/float64(usage.Size)*1000) / 10
+}
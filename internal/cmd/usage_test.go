@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUsageOutput(t *testing.T) {
+	usage := &diskutil.Usage{
+		MountPoint:     "/",
+		BlockSize:      4096,
+		TotalBytes:     100_000_000_000,
+		FreeBytes:      40_000_000_000,
+		AvailableBytes: 39_000_000_000,
+	}
+
+	expected := usageOutput{
+		SchemaVersion: schemaVersion,
+		MountPoint:    "/",
+		BlockSize:     4096,
+		Size:          100_000_000_000,
+		Used:          60_000_000_000,
+		Free:          40_000_000_000,
+		Available:     39_000_000_000,
+	}
+
+	assert.Equal(t, expected, newUsageOutput(usage), "should include the used space")
+}
+
+func TestRenderUsage(t *testing.T) {
+	var out bytes.Buffer
+
+	err := renderUsage(&out, usageOutput{
+		MountPoint: "/",
+		BlockSize:  4096,
+		Size:       100_000_000_000,
+		Used:       60_500_000_000,
+		Free:       39_500_000_000,
+		Available:  39_000_000_000,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "mount point:  /\n"+
+		"block size:   4096\n"+
+		"size:         100 GB\n"+
+		"used:         60 GB (60.5%)\n"+
+		"free:         40 GB\n"+
+		"available:    39 GB\n", out.String(), "should render the usage")
+}
+
+func TestUsedPercent(t *testing.T) {
+	assert.Equal(t, 12.3, usedPercent(usageOutput{Size: 1000, Used: 123}), "should round to one decimal place")
+	assert.Equal(t, float64(0), usedPercent(usageOutput{Used: 123}), "should be 0 without a size")
+}
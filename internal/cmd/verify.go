@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/identifier"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+)
+
+// verifyCheck is the outcome of verifying a single disk or volume with diskutil.
+type verifyCheck struct {
+	// Check describes what was verified (e.g. "partition map").
+	Check string `json:"check"`
+	// ID is the device identifier which was verified.
+	ID string `json:"id"`
+	// OK is true when diskutil found no problems.
+	OK bool `json:"ok"`
+	// Findings is diskutil's report of the problems it found.
+	Findings string `json:"findings,omitempty"`
+}
+
+// verifyOutput is the JSON output of the verify command.
+type verifyOutput struct {
+	SchemaVersion int           `json:"schemaVersion"`
+	Checks        []verifyCheck `json:"checks"`
+}
+
+// verifyCommand creates a new command which verifies a container's disk and volume without changing them.
+func verifyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "verify a container's disk and volume",
+		Long: strings.TrimSpace(`
+verify checks the partition map of the physical disk backing
+the container with the given identifier (e.g. disk1 or
+/dev/disk1) and the container itself using 'diskutil
+verifyDisk' and 'diskutil verifyVolume'. Nothing is changed,
+so it's safe to run before growing. The string 'root' (or the
+path '/') may be provided for the OS's root container. The
+command fails when diskutil finds problems.
+		`),
+	}
+
+	// Set up the flags to be passed into the command
+	var id string
+	cmd.PersistentFlags().StringVar(&id, "id", "", `container identifier to be verified, "root", or "/"`)
+	cmd.MarkPersistentFlagRequired("id")
+
+	// Set up the command's run function
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		output, err := outputFlag(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		product, err := requireProduct(ctx)
+		if err != nil {
+			return err
+		}
+
+		logrus.WithField("product", product).Debug("Configuring diskutil for product")
+		d, err := diskutil.ForProduct(product)
+		if err != nil {
+			return err
+		}
+
+		// Verifying never changes the disk so diskutil is always configured as a dry run
+		checks, err := runVerify(ctx, diskutil.Dryrun(d), id)
+		if err != nil {
+			return writeOutputError(cmd.OutOrStdout(), output, err)
+		}
+
+		if output == outputJSON {
+			err = writeJSON(cmd.OutOrStdout(), verifyOutput{SchemaVersion: schemaVersion, Checks: checks})
+		} else {
+			err = writeVerifyChecks(cmd.OutOrStdout(), checks)
+		}
+		if err != nil {
+			return err
+		}
+
+		return verifyChecksErr(checks)
+	}
+
+	return cmd
+}
+
+// runVerify verifies the partition map of the disk backing the target and the target's volume. An error is only
+// returned if the target can't be resolved, problems found by diskutil are reported in the checks instead.
+func runVerify(ctx context.Context, du diskutil.DiskUtil, target string) ([]verifyCheck, error) {
+	di, err := getVerifyDiskInfo(ctx, du, target)
+	if err != nil {
+		return nil, fmt.Errorf("cannot verify disk: %w", err)
+	}
+
+	diskID := verifyDiskID(di)
+	logrus.WithField("device_id", diskID).Info("Verifying disk...")
+	diskOut, diskErr := du.VerifyDisk(ctx, diskID)
+
+	logrus.WithField("device_id", di.DeviceIdentifier).Info("Verifying volume...")
+	volumeOut, volumeErr := du.VerifyVolume(ctx, di.DeviceIdentifier)
+
+	return []verifyCheck{
+		newVerifyCheck("partition map", diskID, diskOut, diskErr),
+		newVerifyCheck("volume", di.DeviceIdentifier, volumeOut, volumeErr),
+	}, nil
+}
+
+// getVerifyDiskInfo resolves the target like getTargetDiskInfo except that a read-only root is allowed since it can
+// still be verified.
+func getVerifyDiskInfo(ctx context.Context, du diskutil.DiskUtil, target string) (*types.DiskInfo, error) {
+	if strings.EqualFold("root", target) {
+		return du.Info(ctx, "/")
+	}
+
+	return getTargetDiskInfo(ctx, du, target)
+}
+
+// verifyDiskID provides the identifier of the disk whose partition map should be verified. This is the physical store
+// for APFS containers and volumes, otherwise it's the whole disk.
+func verifyDiskID(di *types.DiskInfo) string {
+	if id, err := di.ParentDeviceID(); err == nil {
+		return id
+	}
+
+	if di.ParentWholeDisk != "" {
+		return di.ParentWholeDisk
+	}
+
+	return identifier.ParseDiskID(di.DeviceIdentifier)
+}
+
+// newVerifyCheck creates the outcome of a verification from diskutil's output and error. The output is reported as
+// the findings when the verification failed, falling back to the error when diskutil didn't report anything.
+func newVerifyCheck(check, id, out string, err error) verifyCheck {
+	if err == nil {
+		return verifyCheck{Check: check, ID: id, OK: true}
+	}
+
+	findings := strings.TrimSpace(out)
+	if findings == "" {
+		findings = err.Error()
+	}
+
+	return verifyCheck{Check: check, ID: id, Findings: findings}
+}
+
+// writeVerifyChecks writes whether each check is OK to w, followed by diskutil's indented findings when it's not.
+func writeVerifyChecks(w io.Writer, checks []verifyCheck) error {
+	for _, c := range checks {
+		if c.OK {
+			if _, err := fmt.Fprintf(w, "%s (%s): OK\n", c.Check, c.ID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "%s (%s): problems found\n", c.Check, c.ID); err != nil {
+			return err
+		}
+		for _, line := range strings.Split(c.Findings, "\n") {
+			if _, err := fmt.Fprintf(w, "  %s\n", line); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// verifyChecksErr provides an error naming the checks which found problems, if any.
+func verifyChecksErr(checks []verifyCheck) error {
+	var failed []string
+	for _, c := range checks {
+		if !c.OK {
+			failed = append(failed, fmt.Sprintf("%s (%s)", c.Check, c.ID))
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("verification found problems with %s", strings.Join(failed, ", "))
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	mock_diskutil "github.com/aws/ec2-macos-utils/internal/diskutil/mocks"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunVerify(t *testing.T) {
+	container := &types.DiskInfo{
+		DeviceIdentifier:   "disk1",
+		APFSPhysicalStores: []types.APFSPhysicalStore{{DeviceIdentifier: "disk0s2"}},
+	}
+	parts := &types.SystemPartitions{AllDisks: []string{"disk0", "disk1"}}
+	findings := "Checking the container superblock\nerror: container superblock is corrupt\n"
+
+	tests := []struct {
+		name       string
+		id         string
+		diskErr    error
+		volumeOut  string
+		volumeErr  error
+		wantChecks []verifyCheck
+	}{
+		{
+			name: "ok",
+			id:   "disk1",
+			wantChecks: []verifyCheck{
+				{Check: "partition map", ID: "disk0", OK: true},
+				{Check: "volume", ID: "disk1", OK: true},
+			},
+		},
+		{
+			name:      "volume with findings",
+			id:        "disk1",
+			volumeOut: findings,
+			volumeErr: errors.New("exit status 1"),
+			wantChecks: []verifyCheck{
+				{Check: "partition map", ID: "disk0", OK: true},
+				{Check: "volume", ID: "disk1", Findings: "Checking the container superblock\nerror: container superblock is corrupt"},
+			},
+		},
+		{
+			name:    "disk without findings",
+			id:      "disk1",
+			diskErr: errors.New("exit status 1"),
+			wantChecks: []verifyCheck{
+				{Check: "partition map", ID: "disk0", Findings: "exit status 1"},
+				{Check: "volume", ID: "disk1", OK: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_diskutil.NewMockDiskUtil(ctrl)
+			gomock.InOrder(
+				mock.EXPECT().List(ctx, nil).Return(parts, nil),
+				mock.EXPECT().Info(ctx, tt.id).Return(container, nil),
+				mock.EXPECT().VerifyDisk(ctx, "disk0").Return("", tt.diskErr),
+				mock.EXPECT().VerifyVolume(ctx, "disk1").Return(tt.volumeOut, tt.volumeErr),
+			)
+
+			checks, err := runVerify(ctx, mock, tt.id)
+
+			assert.NoError(t, err, "should verify the container")
+			assert.Equal(t, tt.wantChecks, checks)
+		})
+	}
+}
+
+func TestRunVerify_WithReadOnlyRoot(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	root := &types.DiskInfo{
+		DeviceIdentifier: "disk3s1s1",
+		ParentWholeDisk:  "disk3",
+		WritableMedia:    false,
+	}
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mock.EXPECT().Info(ctx, "/").Return(root, nil),
+		mock.EXPECT().VerifyDisk(ctx, "disk3").Return("", nil),
+		mock.EXPECT().VerifyVolume(ctx, "disk3s1s1").Return("", nil),
+	)
+
+	checks, err := runVerify(ctx, mock, "root")
+
+	assert.NoError(t, err, "should verify a read-only root")
+	assert.Len(t, checks, 2)
+}
+
+func TestRunVerify_WithInfoErr(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mock_diskutil.NewMockDiskUtil(ctrl)
+	mock.EXPECT().Info(ctx, "/").Return(nil, errors.New("error"))
+
+	checks, err := runVerify(ctx, mock, "root")
+
+	assert.Error(t, err, "should fail to resolve the target")
+	assert.Nil(t, checks)
+}
+
+func TestWriteVerifyChecks(t *testing.T) {
+	checks := []verifyCheck{
+		{Check: "partition map", ID: "disk0", OK: true},
+		{Check: "volume", ID: "disk1", Findings: "first\nsecond"},
+	}
+
+	var out bytes.Buffer
+	err := writeVerifyChecks(&out, checks)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "partition map (disk0): OK\nvolume (disk1): problems found\n  first\n  second\n", out.String())
+}
+
+func TestVerifyChecksErr(t *testing.T) {
+	ok := verifyCheck{Check: "partition map", ID: "disk0", OK: true}
+	failed := verifyCheck{Check: "volume", ID: "disk1", Findings: "corrupt"}
+
+	assert.NoError(t, verifyChecksErr([]verifyCheck{ok}), "shouldn't fail when every check is OK")
+
+	err := verifyChecksErr([]verifyCheck{ok, failed})
+	if assert.Error(t, err, "should fail when a check found problems") {
+		assert.Contains(t, err.Error(), "volume (disk1)", "should name the failed check")
+		assert.NotContains(t, err.Error(), "disk0", "shouldn't name the passed check")
+	}
+}
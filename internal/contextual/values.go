@@ -9,12 +9,19 @@ import (
 // productKey is used to set and retrieve context held values for Product.
 var productKey = struct{}{}
 
-// WithProduct extends the context to provide a Product.
+// productErrKey is used to set and retrieve context held values for ProductErr.
+var productErrKey = struct{ name string }{"productErr"}
+
+// WithProduct extends the context to provide a Product. A nil Product isn't provided so the context is returned as-is.
 func WithProduct(ctx context.Context, product *system.Product) context.Context {
+	if product == nil {
+		return ctx
+	}
+
 	return context.WithValue(ctx, productKey, product)
 }
 
-// Product fetches the system's Product provided in ctx.
+// Product fetches the system's Product provided in ctx. If no Product was provided, nil is returned.
 func Product(ctx context.Context) *system.Product {
 	if val := ctx.Value(productKey); val != nil {
 		if v, ok := val.(*system.Product); ok {
@@ -25,3 +32,26 @@ func Product(ctx context.Context) *system.Product {
 
 	return nil
 }
+
+// WithProductErr extends the context to provide the error identifying the system's Product, which commands requiring
+// a Product can report once logging is set up. A nil error isn't provided so the context is returned as-is.
+func WithProductErr(ctx context.Context, err error) context.Context {
+	if err == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, productErrKey, err)
+}
+
+// ProductErr fetches the error identifying the system's Product provided in ctx. If no error was provided, nil is
+// returned.
+func ProductErr(ctx context.Context) error {
+	if val := ctx.Value(productErrKey); val != nil {
+		if v, ok := val.(error); ok {
+			return v
+		}
+		panic("incoherent context")
+	}
+
+	return nil
+}
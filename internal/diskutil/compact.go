@@ -0,0 +1,173 @@
+package diskutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// ErrTruncatedPlist indicates that binary plist data ended before its trailer, which happens when diskutil's output is
+// cut short (e.g. by a timeout or a partial capture).
+var ErrTruncatedPlist = errors.New("binary plist is truncated")
+
+// valueTags are the plist elements whose character data is their value. Whitespace following the opening tag of one
+// of these elements may be (part of) the value so it must be kept.
+var valueTags = [][]byte{
+	[]byte("<string"),
+	[]byte("<key"),
+	[]byte("<data"),
+	[]byte("<date"),
+	[]byte("<integer"),
+	[]byte("<real"),
+	[]byte("<![CDATA["),
+}
+
+// binaryPlistMagic is the header which starts binary plist data.
+var binaryPlistMagic = []byte("bplist00")
+
+// binaryPlistTrailerSize is the size of the trailer which ends binary plist data and locates its offset table.
+const binaryPlistTrailerSize = 32
+
+// utf8BOM is the UTF-8 byte order mark which some diskutil output (and captures of it) is prefixed with.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// compactPlistReader reads all the plist data from the reader, trims any byte order mark or whitespace before the XML
+// (see trimPlistPrefix), and removes the whitespace between XML elements (e.g. indentation) since each run of
+// whitespace is decoded as an extra token by the plist decoder. Data that isn't XML is returned unchanged, except that
+// binary plists are checked for truncation (see checkBinaryPlist).
+func compactPlistReader(reader io.ReadSeeker) (io.ReadSeeker, error) {
+	// Size the buffer up front rather than growing it while reading
+	start, err := reader.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	end, err := reader.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := reader.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, end-start)
+	if _, err := io.ReadFull(reader, raw); err != nil {
+		return nil, err
+	}
+
+	if err := checkBinaryPlist(raw); err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(compactXMLPlist(trimPlistPrefix(raw))), nil
+}
+
+// checkBinaryPlist checks that binary plist data (data starting with binaryPlistMagic) is long enough to hold its
+// trailer and the offset table the trailer points to. The plist decoder reports truncated data with errors about its
+// internal structure, so ErrTruncatedPlist is returned instead. Other data isn't checked.
+func checkBinaryPlist(raw []byte) error {
+	if !bytes.HasPrefix(raw, binaryPlistMagic) {
+		return nil
+	}
+
+	if len(raw) < len(binaryPlistMagic)+binaryPlistTrailerSize {
+		return ErrTruncatedPlist
+	}
+
+	// The trailer ends with the size of each offset, the number of objects, the top object, and the offset table's
+	// position (see CFBinaryPList.c)
+	trailerStart := uint64(len(raw) - binaryPlistTrailerSize)
+	trailer := raw[trailerStart:]
+	offsetSize := uint64(trailer[6])
+	numObjects := binary.BigEndian.Uint64(trailer[8:16])
+	offsetTable := binary.BigEndian.Uint64(trailer[24:32])
+
+	if offsetSize == 0 || numObjects == 0 || offsetTable < uint64(len(binaryPlistMagic)) || offsetTable > trailerStart ||
+		numObjects > (trailerStart-offsetTable)/offsetSize {
+		return ErrTruncatedPlist
+	}
+
+	return nil
+}
+
+// trimPlistPrefix trims a leading UTF-8 byte order mark and whitespace from raw plist data up to the "<?xml" or
+// "<plist" token. Data that doesn't start with either token once trimmed is returned unchanged.
+func trimPlistPrefix(raw []byte) []byte {
+	trimmed := bytes.TrimLeftFunc(bytes.TrimPrefix(raw, utf8BOM), func(r rune) bool {
+		return r < utf8.RuneSelf && isXMLSpace(byte(r))
+	})
+	if !isXMLPlist(trimmed) {
+		return raw
+	}
+
+	return trimmed
+}
+
+// isXMLPlist checks if the data starts with the "<?xml" or "<plist" token.
+func isXMLPlist(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("<?xml")) || bytes.HasPrefix(data, []byte("<plist"))
+}
+
+// compactXMLPlist removes the whitespace between XML elements in raw plist data. The data is compacted in place and
+// the compacted slice is returned. Data that isn't XML is returned unchanged.
+func compactXMLPlist(raw []byte) []byte {
+	if !isXMLPlist(bytes.TrimSpace(raw)) {
+		return raw
+	}
+
+	// Since the compacted data is never longer than what's been read, it's safe to write it into the same slice.
+	out := raw[:0]
+	for i := 0; i < len(raw); {
+		if raw[i] != '<' {
+			out = append(out, raw[i])
+			i++
+			continue
+		}
+
+		end := bytes.IndexByte(raw[i:], '>')
+		if end < 0 {
+			return append(out, raw[i:]...)
+		}
+		// The tag must be checked before it's copied since copying may overwrite it
+		tag := raw[i : i+end+1]
+		value := isValueTag(tag)
+		out = append(out, tag...)
+		i += end + 1
+
+		if value {
+			continue
+		}
+
+		// Skip whitespace that's only followed by another element
+		j := i
+		for j < len(raw) && isXMLSpace(raw[j]) {
+			j++
+		}
+		if j < len(raw) && raw[j] == '<' {
+			i = j
+		}
+	}
+
+	return out
+}
+
+// isValueTag checks if the tag opens an element whose character data is its value (see valueTags).
+func isValueTag(tag []byte) bool {
+	if bytes.HasSuffix(tag, []byte("/>")) {
+		return false
+	}
+
+	for _, prefix := range valueTags {
+		if bytes.HasPrefix(tag, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isXMLSpace checks if the byte is whitespace as defined by XML.
+func isXMLSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
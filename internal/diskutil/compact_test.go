@@ -0,0 +1,153 @@
+package diskutil
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"howett.net/plist"
+)
+
+func TestCompactXMLPlist(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{
+			name: "without input",
+			raw:  "",
+			want: "",
+		},
+		{
+			name: "without xml",
+			raw:  "{ AllDisks = ( disk0 ); }",
+			want: "{ AllDisks = ( disk0 ); }",
+		},
+		{
+			name: "with indentation",
+			raw:  "<?xml version=\"1.0\"?>\n<plist version=\"1.0\">\n<dict>\n\t<key>Size</key>\n\t<integer>1</integer>\n</dict>\n</plist>\n",
+			want: "<?xml version=\"1.0\"?><plist version=\"1.0\"><dict><key>Size</key><integer>1</integer></dict></plist>\n",
+		},
+		{
+			name: "with whitespace values",
+			raw:  "<plist>\n<array>\n\t<string>  </string>\n\t<string> Macintosh HD </string>\n\t<true/>\n\t<string/>\n</array>\n</plist>",
+			want: "<plist><array><string>  </string><string> Macintosh HD </string><true/><string/></array></plist>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := compactXMLPlist([]byte(tt.raw))
+
+			assert.Equal(t, tt.want, string(got), "should only remove whitespace between elements")
+		})
+	}
+}
+
+func TestTrimPlistPrefix(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{
+			name: "without prefix",
+			raw:  "<?xml version=\"1.0\"?><plist/>",
+			want: "<?xml version=\"1.0\"?><plist/>",
+		},
+		{
+			name: "with BOM",
+			raw:  "\xef\xbb\xbf<?xml version=\"1.0\"?><plist/>",
+			want: "<?xml version=\"1.0\"?><plist/>",
+		},
+		{
+			name: "with leading whitespace",
+			raw:  "\n \t<plist/>",
+			want: "<plist/>",
+		},
+		{
+			name: "with BOM and leading whitespace",
+			raw:  "\xef\xbb\xbf\r\n<plist/>",
+			want: "<plist/>",
+		},
+		{
+			name: "without xml",
+			raw:  "\xef\xbb\xbf { AllDisks = ( disk0 ); }",
+			want: "\xef\xbb\xbf { AllDisks = ( disk0 ); }",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := trimPlistPrefix([]byte(tt.raw))
+
+			assert.Equal(t, tt.want, string(got), "should only trim the prefix of XML plists")
+		})
+	}
+}
+
+func TestCompactPlistReader_DecodesSameAsOriginal(t *testing.T) {
+	fixtures := map[string]string{
+		"list":            decoderList,
+		"disk info":       decoderDiskInfo,
+		"container info":  decoderContainerInfo,
+		"BOM disk info":   decoderBOMDiskInfo,
+		"whitespace list": decoderWhitespaceList,
+	}
+	for name, raw := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			var want, got map[string]interface{}
+			_, err := plist.Unmarshal([]byte(raw), &want)
+			assert.NoError(t, err, "should be able to decode original data")
+
+			compacted, err := compactPlistReader(strings.NewReader(raw))
+			assert.NoError(t, err, "should be able to compact data")
+			err = plist.NewDecoder(compacted).Decode(&got)
+			assert.NoError(t, err, "should be able to decode compacted data")
+
+			assert.Equal(t, want, got, "compacted data should decode the same as the original")
+		})
+	}
+}
+
+func TestCompactPlistReader_FromOffset(t *testing.T) {
+	reader := strings.NewReader("ignored<plist>\n<string>disk0</string>\n</plist>")
+	_, _ = reader.Seek(int64(len("ignored")), 0)
+
+	compacted, err := compactPlistReader(reader)
+	assert.NoError(t, err, "should be able to compact data")
+
+	var got string
+	err = plist.NewDecoder(compacted).Decode(&got)
+	assert.NoError(t, err, "should be able to decode compacted data")
+	assert.Equal(t, "disk0", got, "should only read data from the reader's offset")
+}
+
+func TestCheckBinaryPlist(t *testing.T) {
+	complete := []byte(decoderBinaryDiskInfo)
+
+	tests := []struct {
+		name    string
+		raw     []byte
+		wantErr bool
+	}{
+		{name: "without input", raw: nil},
+		{name: "xml", raw: []byte(decoderDiskInfo)},
+		{name: "complete", raw: complete},
+		{name: "only magic", raw: complete[:8], wantErr: true},
+		{name: "without trailer", raw: complete[:len(complete)-binaryPlistTrailerSize], wantErr: true},
+		{name: "missing last byte", raw: complete[:len(complete)-1], wantErr: true},
+		{name: "first half", raw: complete[:len(complete)/2], wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkBinaryPlist(tt.raw)
+
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ErrTruncatedPlist), "should report truncated binary plist data")
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
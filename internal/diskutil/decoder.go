@@ -1,15 +1,19 @@
 package diskutil
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"strconv"
 
 	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
 
 	"howett.net/plist"
 )
 
-// Decoder outlines the functionality necessary for decoding plist output from the macOS diskutil command.
+// Decoder outlines the functionality necessary for decoding plist (or JSON) output from the macOS diskutil command.
 type Decoder interface {
 	// DecodeSystemPartitions takes an io.ReadSeeker for the raw plist data of all disks and partition information
 	// and decodes it into a new types.SystemPartitions struct.
@@ -18,19 +22,59 @@ type Decoder interface {
 	// DecodeDiskInfo takes an io.ReadSeeker for the raw plist data of disk information and decodes it into
 	// a new types.DiskInfo struct.
 	DecodeDiskInfo(reader io.ReadSeeker) (*types.DiskInfo, error)
+
+	// DecodeResizeLimits takes an io.ReadSeeker for the raw plist data of a container's resize limits and decodes it
+	// into a new types.ResizeLimits struct.
+	DecodeResizeLimits(reader io.ReadSeeker) (*types.ResizeLimits, error)
+
+	// DecodeSnapshots takes an io.ReadSeeker for the raw plist data of a volume's snapshots and decodes it into a
+	// slice of types.Snapshot structs.
+	DecodeSnapshots(reader io.ReadSeeker) ([]types.Snapshot, error)
+}
+
+// DecodeSystemPartitionsFile opens the file at the path (e.g. a capture of diskutil's output) and decodes its raw plist
+// data of all disks and partition information with the Decoder.
+func DecodeSystemPartitionsFile(dec Decoder, path string) (*types.SystemPartitions, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening list: %w", err)
+	}
+	defer f.Close()
+
+	return dec.DecodeSystemPartitions(f)
+}
+
+// DecodeDiskInfoFile opens the file at the path (e.g. a capture of diskutil's output) and decodes its raw plist data of
+// disk information with the Decoder.
+func DecodeDiskInfoFile(dec Decoder, path string) (*types.DiskInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening disk info: %w", err)
+	}
+	defer f.Close()
+
+	return dec.DecodeDiskInfo(f)
 }
 
 // PlistDecoder provides the plist Decoder implementation.
-type PlistDecoder struct{}
+type PlistDecoder struct {
+	// Strict enables validation of the decoded data (e.g. that physical store identifiers are well-formed) so that
+	// malformed output is reported when decoding rather than when the data is used.
+	Strict bool
+}
 
 // DecodeSystemPartitions assumes the io.ReadSeeker it's given contains raw plist data and attempts to decode that.
 func (d *PlistDecoder) DecodeSystemPartitions(reader io.ReadSeeker) (*types.SystemPartitions, error) {
-	// Set up a new SystemPartitions and create a decoder from the reader
+	// Set up a new SystemPartitions and create a decoder from the compacted data
 	partitions := &types.SystemPartitions{}
-	decoder := plist.NewDecoder(reader)
+	compacted, err := compactPlistReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error reading list: %w", err)
+	}
+	decoder := plist.NewDecoder(compacted)
 
 	// Decode the plist output from diskutil into a SystemPartitions struct for easier access
-	err := decoder.Decode(partitions)
+	err = decoder.Decode(partitions)
 	if err != nil {
 		return nil, fmt.Errorf("error decoding list: %w", err)
 	}
@@ -40,15 +84,180 @@ func (d *PlistDecoder) DecodeSystemPartitions(reader io.ReadSeeker) (*types.Syst
 
 // DecodeDiskInfo assumes the io.ReadSeeker it's given contains raw plist data and attempts to decode that.
 func (d *PlistDecoder) DecodeDiskInfo(reader io.ReadSeeker) (*types.DiskInfo, error) {
-	// Set up a new DiskInfo and create a decoder from the reader
+	// Set up a new DiskInfo and create a decoder from the compacted data
 	disk := &types.DiskInfo{}
-	decoder := plist.NewDecoder(reader)
+	compacted, err := compactPlistReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error reading disk info: %w", err)
+	}
+	decoder := plist.NewDecoder(compacted)
 
 	// Decode the plist output from diskutil into a DiskInfo struct for easier access
-	err := decoder.Decode(disk)
+	err = decoder.Decode(disk)
 	if err != nil {
 		return nil, fmt.Errorf("error decoding disk info: %w", err)
 	}
 
+	if d.Strict {
+		if err := disk.ValidatePhysicalStores(); err != nil {
+			return nil, fmt.Errorf("error decoding disk info: %w", err)
+		}
+	}
+
 	return disk, nil
 }
+
+// DecodeResizeLimits assumes the io.ReadSeeker it's given contains raw plist data and attempts to decode that.
+func (d *PlistDecoder) DecodeResizeLimits(reader io.ReadSeeker) (*types.ResizeLimits, error) {
+	// Set up a new ResizeLimits and create a decoder from the compacted data
+	limits := &types.ResizeLimits{}
+	compacted, err := compactPlistReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error reading resize limits: %w", err)
+	}
+	decoder := plist.NewDecoder(compacted)
+
+	// Decode the plist output from diskutil into a ResizeLimits struct for easier access
+	err = decoder.Decode(limits)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding resize limits: %w", err)
+	}
+
+	return limits, nil
+}
+
+// DecodeSnapshots assumes the io.ReadSeeker it's given contains raw plist data and attempts to decode that.
+func (d *PlistDecoder) DecodeSnapshots(reader io.ReadSeeker) ([]types.Snapshot, error) {
+	// Set up the list of snapshots and create a decoder from the compacted data
+	var list struct {
+		Snapshots []types.Snapshot `plist:"Snapshots"`
+	}
+	compacted, err := compactPlistReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshots: %w", err)
+	}
+	decoder := plist.NewDecoder(compacted)
+
+	// Decode the plist output from diskutil into the Snapshot structs for easier access
+	err = decoder.Decode(&list)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding snapshots: %w", err)
+	}
+
+	return list.Snapshots, nil
+}
+
+// JSONDecoder provides the Decoder implementation for diskutil's JSON output (diskutil's -json arg). diskutil's JSON
+// uses the same keys as its plists so the JSON is converted to a plist and decoded with the types' plist tags.
+type JSONDecoder struct {
+	// Strict enables validation of the decoded data (e.g. that physical store identifiers are well-formed) so that
+	// malformed output is reported when decoding rather than when the data is used.
+	Strict bool
+}
+
+// DecodeSystemPartitions assumes the io.ReadSeeker it's given contains raw JSON data and attempts to decode that.
+func (d *JSONDecoder) DecodeSystemPartitions(reader io.ReadSeeker) (*types.SystemPartitions, error) {
+	partitions := &types.SystemPartitions{}
+	if err := decodeJSON(reader, partitions); err != nil {
+		return nil, fmt.Errorf("error decoding list: %w", err)
+	}
+
+	return partitions, nil
+}
+
+// DecodeDiskInfo assumes the io.ReadSeeker it's given contains raw JSON data and attempts to decode that.
+func (d *JSONDecoder) DecodeDiskInfo(reader io.ReadSeeker) (*types.DiskInfo, error) {
+	disk := &types.DiskInfo{}
+	if err := decodeJSON(reader, disk); err != nil {
+		return nil, fmt.Errorf("error decoding disk info: %w", err)
+	}
+
+	if d.Strict {
+		if err := disk.ValidatePhysicalStores(); err != nil {
+			return nil, fmt.Errorf("error decoding disk info: %w", err)
+		}
+	}
+
+	return disk, nil
+}
+
+// DecodeResizeLimits assumes the io.ReadSeeker it's given contains raw JSON data and attempts to decode that.
+func (d *JSONDecoder) DecodeResizeLimits(reader io.ReadSeeker) (*types.ResizeLimits, error) {
+	limits := &types.ResizeLimits{}
+	if err := decodeJSON(reader, limits); err != nil {
+		return nil, fmt.Errorf("error decoding resize limits: %w", err)
+	}
+
+	return limits, nil
+}
+
+// DecodeSnapshots assumes the io.ReadSeeker it's given contains raw JSON data and attempts to decode that.
+func (d *JSONDecoder) DecodeSnapshots(reader io.ReadSeeker) ([]types.Snapshot, error) {
+	var list struct {
+		Snapshots []types.Snapshot `plist:"Snapshots"`
+	}
+	if err := decodeJSON(reader, &list); err != nil {
+		return nil, fmt.Errorf("error decoding snapshots: %w", err)
+	}
+
+	return list.Snapshots, nil
+}
+
+// decodeJSON decodes the raw JSON data into v by converting it to a binary plist, so v's plist tags are used for the
+// JSON's keys.
+func decodeJSON(reader io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return err
+	}
+	if _, ok := raw.(map[string]interface{}); !ok {
+		return errors.New("expected a JSON object")
+	}
+
+	data, err := plist.Marshal(plistValue(raw), plist.BinaryFormat)
+	if err != nil {
+		return err
+	}
+	_, err = plist.Unmarshal(data, v)
+
+	return err
+}
+
+// plistValue converts the value decoded from JSON into one which can be encoded as a plist. Numbers are kept as
+// integers when possible so they decode into the types' integer fields, and nulls are dropped since plists can't
+// represent them.
+func plistValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if value == nil {
+				delete(v, key)
+				continue
+			}
+			v[key] = plistValue(value)
+		}
+		return v
+	case []interface{}:
+		values := make([]interface{}, 0, len(v))
+		for _, value := range v {
+			if value != nil {
+				values = append(values, plistValue(value))
+			}
+		}
+		return values
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return u
+		}
+		f, _ := v.Float64()
+		return f
+	default:
+		return v
+	}
+}
@@ -0,0 +1,114 @@
+package diskutil
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"howett.net/plist"
+)
+
+const (
+	// benchDisks is the number of disks in the large list fixture.
+	benchDisks = 16
+	// benchVolumes is the number of APFS volumes in each disk of the large list fixture.
+	benchVolumes = 32
+)
+
+// largeListPlist generates "diskutil list -plist" output for a system with many disks and volumes.
+func largeListPlist(b *testing.B) string {
+	parts := types.SystemPartitions{}
+	for i := 0; i < benchDisks; i++ {
+		diskID := fmt.Sprintf("disk%d", i)
+		disk := types.DiskPart{
+			APFSPhysicalStores: []types.APFSPhysicalStoreID{{DeviceIdentifier: diskID + "s2"}},
+			Content:            "Apple_APFS_Container",
+			DeviceIdentifier:   diskID,
+			Size:               500_000_000_000,
+		}
+		for j := 0; j < benchVolumes; j++ {
+			volumeID := fmt.Sprintf("%ss%d", diskID, j+1)
+			disk.APFSVolumes = append(disk.APFSVolumes, types.APFSVolume{
+				DeviceIdentifier: volumeID,
+				DiskUUID:         "AAAAAAAA-BBBB-CCCC-DDDD-FFFFFFFFFFFF",
+				MountPoint:       "/Volumes/" + volumeID,
+				MountedSnapshots: []types.Snapshot{{SnapshotUUID: "AAAAAAAA-BBBB-CCCC-DDDD-FFFFFFFFFFFF"}},
+				Size:             1_000_000_000,
+				VolumeName:       "Volume " + volumeID,
+				VolumeUUID:       "AAAAAAAA-BBBB-CCCC-DDDD-FFFFFFFFFFFF",
+			})
+			parts.VolumesFromDisks = append(parts.VolumesFromDisks, "Volume "+volumeID)
+		}
+		parts.AllDisks = append(parts.AllDisks, diskID)
+		parts.AllDisksAndPartitions = append(parts.AllDisksAndPartitions, disk)
+		parts.WholeDisks = append(parts.WholeDisks, diskID)
+	}
+
+	out, err := plist.MarshalIndent(parts, plist.XMLFormat, "\t")
+	if err != nil {
+		b.Fatalf("unable to generate list fixture: %v", err)
+	}
+
+	return string(out)
+}
+
+// largeDiskInfoPlist generates "diskutil info -plist" output for a disk with many physical stores and SMART data.
+func largeDiskInfoPlist(b *testing.B) string {
+	disk := types.DiskInfo{
+		ContainerInfo: types.ContainerInfo{
+			APFSContainerFree: 100_000_000_000,
+			APFSContainerSize: 500_000_000_000,
+			FilesystemType:    "apfs",
+		},
+		APFSContainerReference: "disk2",
+		DeviceIdentifier:       "disk2s5",
+		MountPoint:             "/",
+		ParentWholeDisk:        "disk2",
+		SMARTDeviceSpecificKeysMayVaryNotGuaranteed: &types.SmartDeviceInfo{},
+		TotalSize:         500_000_000_000,
+		VirtualOrPhysical: "Virtual",
+		VolumeName:        "Macintosh HD",
+	}
+	for i := 0; i < benchVolumes; i++ {
+		disk.APFSPhysicalStores = append(disk.APFSPhysicalStores, types.APFSPhysicalStore{
+			DeviceIdentifier: fmt.Sprintf("disk%ds2", i),
+		})
+	}
+
+	out, err := plist.MarshalIndent(disk, plist.XMLFormat, "\t")
+	if err != nil {
+		b.Fatalf("unable to generate disk info fixture: %v", err)
+	}
+
+	return string(out)
+}
+
+func Benchmark_DecodeSystemPartitions(b *testing.B) {
+	raw := largeListPlist(b)
+	d := &PlistDecoder{}
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(raw)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := d.DecodeSystemPartitions(strings.NewReader(raw)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark_DecodeDiskInfo(b *testing.B) {
+	raw := largeDiskInfoPlist(b)
+	d := &PlistDecoder{}
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(raw)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := d.DecodeDiskInfo(strings.NewReader(raw)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
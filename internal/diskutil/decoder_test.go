@@ -2,6 +2,9 @@ package diskutil
 
 import (
 	_ "embed"
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -27,6 +30,14 @@ var (
 	// decoderContainerInfo contains a container plist file that is properly formatted (but is also sparse).
 	decoderContainerInfo string
 
+	//go:embed testdata/decoder/malformed_store_info.plist
+	// decoderMalformedStoreInfo contains a container plist file with a malformed physical store identifier.
+	decoderMalformedStoreInfo string
+
+	//go:embed testdata/decoder/locked_container_info.plist
+	// decoderLockedContainerInfo contains a container plist file for a container that's locked by FileVault.
+	decoderLockedContainerInfo string
+
 	//go:embed testdata/decoder/broken_list.plist
 	// decoderBrokenList contains a container plist file that is missing the plist header.
 	decoderBrokenList string
@@ -34,6 +45,50 @@ var (
 	//go:embed testdata/decoder/list.plist
 	// decoderList contains a container plist file that is properly formatted (but is also sparse).
 	decoderList string
+
+	//go:embed testdata/decoder/bom_disk_info.plist
+	// decoderBOMDiskInfo contains the disk plist file prefixed with a UTF-8 byte order mark.
+	decoderBOMDiskInfo string
+
+	//go:embed testdata/decoder/whitespace_list.plist
+	// decoderWhitespaceList contains the list plist file prefixed with whitespace.
+	decoderWhitespaceList string
+
+	//go:embed testdata/decoder/resize_limits.plist
+	// decoderResizeLimits contains a resize limits plist file that is properly formatted.
+	decoderResizeLimits string
+
+	//go:embed testdata/decoder/snapshots.plist
+	// decoderSnapshots contains a snapshot list plist file with two Time Machine local snapshots.
+	decoderSnapshots string
+
+	//go:embed testdata/decoder/binary_disk_info.plist
+	// decoderBinaryDiskInfo contains the disk plist file in the binary format.
+	decoderBinaryDiskInfo string
+
+	//go:embed testdata/decoder/binary_list.plist
+	// decoderBinaryList contains the list plist file in the binary format.
+	decoderBinaryList string
+
+	//go:embed testdata/decoder/truncated_binary_disk_info.plist
+	// decoderTruncatedBinaryDiskInfo contains the first half of the binary disk plist file.
+	decoderTruncatedBinaryDiskInfo string
+
+	//go:embed testdata/decoder/disk_info.json
+	// decoderJSONDiskInfo contains the disk information as JSON, including a null value.
+	decoderJSONDiskInfo string
+
+	//go:embed testdata/decoder/list.json
+	// decoderJSONList contains the list plist file's data as JSON.
+	decoderJSONList string
+
+	//go:embed testdata/decoder/resize_limits.json
+	// decoderJSONResizeLimits contains the resize limits plist file's data as JSON.
+	decoderJSONResizeLimits string
+
+	//go:embed testdata/decoder/snapshots.json
+	// decoderJSONSnapshots contains the snapshot list plist file's data as JSON.
+	decoderJSONSnapshots string
 )
 
 func TestPlistDecoder_DecodeDiskInfo_WithoutInput(t *testing.T) {
@@ -128,6 +183,37 @@ func TestPlistDecoder_DecodeDiskInfo_ContainerSuccess(t *testing.T) {
 	assert.ObjectsAreEqualValues(expectedDisk, actualDisk)
 }
 
+func TestPlistDecoder_DecodeDiskInfo_WithMalformedPhysicalStore(t *testing.T) {
+	d := &PlistDecoder{}
+	reader := strings.NewReader(decoderMalformedStoreInfo)
+
+	actualDisk, err := d.DecodeDiskInfo(reader)
+
+	assert.NoError(t, err, "should tolerate malformed physical stores by default")
+	assert.Len(t, actualDisk.APFSPhysicalStores, 2, "should decode all physical stores")
+}
+
+func TestPlistDecoder_DecodeDiskInfo_StrictWithMalformedPhysicalStore(t *testing.T) {
+	d := &PlistDecoder{Strict: true}
+	reader := strings.NewReader(decoderMalformedStoreInfo)
+
+	actualDisk, err := d.DecodeDiskInfo(reader)
+
+	assert.Error(t, err, "shouldn't be able to strictly decode malformed physical stores")
+	assert.Contains(t, err.Error(), `"not-a-disk"`, "should list the malformed physical store")
+	assert.NotContains(t, err.Error(), `"disk0s2"`, "shouldn't list well-formed physical stores")
+	assert.Nil(t, actualDisk, "should get nil since decode failed")
+}
+
+func TestPlistDecoder_DecodeDiskInfo_StrictContainerSuccess(t *testing.T) {
+	d := &PlistDecoder{Strict: true}
+	reader := strings.NewReader(decoderContainerInfo)
+
+	_, err := d.DecodeDiskInfo(reader)
+
+	assert.NoError(t, err, "should be able to strictly decode valid container plist data")
+}
+
 func TestPlistDecoder_DecodeSystemPartitions_WithoutInput(t *testing.T) {
 	d := &PlistDecoder{}
 	reader := strings.NewReader("")
@@ -207,3 +293,255 @@ func TestPlistDecoder_DecodeSystemPartitions_Success(t *testing.T) {
 	assert.NoError(t, err, "should be able to decode valid list plist data")
 	assert.ObjectsAreEqualValues(wantParts, gotParts)
 }
+
+func TestPlistDecoder_DecodeResizeLimits_WithoutPlistInput(t *testing.T) {
+	d := &PlistDecoder{}
+	reader := strings.NewReader("this is not a plist")
+
+	actualLimits, err := d.DecodeResizeLimits(reader)
+
+	assert.Error(t, err, "shouldn't be able to decode non-plist input")
+	assert.Nil(t, actualLimits, "should get nil since decode failed")
+}
+
+func TestPlistDecoder_DecodeResizeLimits_Success(t *testing.T) {
+	d := &PlistDecoder{}
+	reader := strings.NewReader(decoderResizeLimits)
+
+	expectedLimits := &types.ResizeLimits{
+		CurrentSize: 99_648_233_472,
+		MaximumSize: 121_122_037_760,
+		MinimumSize: 28_034_662_400,
+	}
+
+	actualLimits, err := d.DecodeResizeLimits(reader)
+
+	assert.NoError(t, err, "should be able to decode valid resize limits")
+	assert.Equal(t, expectedLimits, actualLimits, "should have decoded expected limits")
+}
+
+func TestPlistDecoder_DecodeSnapshots_WithoutPlistInput(t *testing.T) {
+	d := &PlistDecoder{}
+	reader := strings.NewReader("this is not a plist")
+
+	actualSnapshots, err := d.DecodeSnapshots(reader)
+
+	assert.Error(t, err, "shouldn't be able to decode non-plist input")
+	assert.Nil(t, actualSnapshots, "should get nil since decode failed")
+}
+
+func TestPlistDecoder_DecodeSnapshots_Success(t *testing.T) {
+	d := &PlistDecoder{}
+	reader := strings.NewReader(decoderSnapshots)
+
+	expectedSnapshots := []types.Snapshot{
+		{
+			SnapshotName: "com.apple.TimeMachine.2023-10-11-160000.local",
+			SnapshotUUID: "4B2E0C19-6E4F-4A43-9C63-5D2C0B7A5F01",
+		},
+		{
+			SnapshotName: "com.apple.TimeMachine.2023-10-11-170000.local",
+			SnapshotUUID: "9A6F3D2E-1B7C-4E8D-8F90-A1B2C3D4E5F6",
+		},
+	}
+
+	actualSnapshots, err := d.DecodeSnapshots(reader)
+
+	assert.NoError(t, err, "should be able to decode valid snapshots")
+	assert.Equal(t, expectedSnapshots, actualSnapshots, "should have decoded expected snapshots")
+}
+
+func TestPlistDecoder_DecodeDiskInfo_WithBOM(t *testing.T) {
+	d := &PlistDecoder{}
+
+	expectedDisk, err := d.DecodeDiskInfo(strings.NewReader(decoderDiskInfo))
+	if !assert.NoError(t, err, "should be able to decode disk info without BOM") {
+		return
+	}
+
+	actualDisk, err := d.DecodeDiskInfo(strings.NewReader(decoderBOMDiskInfo))
+
+	assert.NoError(t, err, "should be able to decode disk info prefixed with a BOM")
+	assert.Equal(t, expectedDisk, actualDisk, "should decode the same as without the BOM")
+}
+
+func TestPlistDecoder_DecodeSystemPartitions_WithLeadingWhitespace(t *testing.T) {
+	d := &PlistDecoder{}
+
+	expectedParts, err := d.DecodeSystemPartitions(strings.NewReader(decoderList))
+	if !assert.NoError(t, err, "should be able to decode list without leading whitespace") {
+		return
+	}
+
+	actualParts, err := d.DecodeSystemPartitions(strings.NewReader(decoderWhitespaceList))
+
+	assert.NoError(t, err, "should be able to decode list prefixed with whitespace")
+	assert.Equal(t, expectedParts, actualParts, "should decode the same as without the whitespace")
+}
+
+func TestPlistDecoder_DecodeDiskInfo_WithBinaryPlist(t *testing.T) {
+	d := &PlistDecoder{}
+
+	expectedDisk, err := d.DecodeDiskInfo(strings.NewReader(decoderDiskInfo))
+	if !assert.NoError(t, err, "should be able to decode XML disk info") {
+		return
+	}
+
+	actualDisk, err := d.DecodeDiskInfo(strings.NewReader(decoderBinaryDiskInfo))
+
+	assert.NoError(t, err, "should be able to decode binary disk info")
+	assert.Equal(t, expectedDisk, actualDisk, "should decode the same as the XML format")
+}
+
+func TestPlistDecoder_DecodeSystemPartitions_WithBinaryPlist(t *testing.T) {
+	d := &PlistDecoder{}
+
+	expectedParts, err := d.DecodeSystemPartitions(strings.NewReader(decoderList))
+	if !assert.NoError(t, err, "should be able to decode XML list") {
+		return
+	}
+
+	actualParts, err := d.DecodeSystemPartitions(strings.NewReader(decoderBinaryList))
+
+	assert.NoError(t, err, "should be able to decode binary list")
+	assert.Equal(t, expectedParts, actualParts, "should decode the same as the XML format")
+}
+
+func TestPlistDecoder_DecodeDiskInfo_WithTruncatedBinaryPlist(t *testing.T) {
+	d := &PlistDecoder{}
+
+	actualDisk, err := d.DecodeDiskInfo(strings.NewReader(decoderTruncatedBinaryDiskInfo))
+
+	assert.True(t, errors.Is(err, ErrTruncatedPlist), "should report that the binary plist is truncated")
+	assert.Nil(t, actualDisk, "should get nil since decode failed")
+}
+
+// writeTempPlist writes the plist data to a file in a temporary directory and returns its path.
+func writeTempPlist(t *testing.T, data string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "capture.plist")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("unable to write plist: %v", err)
+	}
+
+	return path
+}
+
+func TestDecodeDiskInfoFile(t *testing.T) {
+	d := &PlistDecoder{}
+
+	expectedDisk, err := d.DecodeDiskInfo(strings.NewReader(decoderDiskInfo))
+	if !assert.NoError(t, err, "should be able to decode disk info") {
+		return
+	}
+
+	actualDisk, err := DecodeDiskInfoFile(d, writeTempPlist(t, decoderDiskInfo))
+
+	assert.NoError(t, err, "should be able to decode disk info from a file")
+	assert.Equal(t, expectedDisk, actualDisk, "should decode the same as from a reader")
+}
+
+func TestDecodeSystemPartitionsFile(t *testing.T) {
+	d := &PlistDecoder{}
+
+	expectedParts, err := d.DecodeSystemPartitions(strings.NewReader(decoderList))
+	if !assert.NoError(t, err, "should be able to decode list") {
+		return
+	}
+
+	actualParts, err := DecodeSystemPartitionsFile(d, writeTempPlist(t, decoderList))
+
+	assert.NoError(t, err, "should be able to decode list from a file")
+	assert.Equal(t, expectedParts, actualParts, "should decode the same as from a reader")
+}
+
+func TestDecodeFile_WithoutFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.plist")
+
+	disk, diskErr := DecodeDiskInfoFile(&PlistDecoder{}, path)
+	parts, partsErr := DecodeSystemPartitionsFile(&PlistDecoder{}, path)
+
+	assert.True(t, errors.Is(diskErr, os.ErrNotExist), "should fail to decode disk info from a missing file")
+	assert.Nil(t, disk)
+	assert.True(t, errors.Is(partsErr, os.ErrNotExist), "should fail to decode list from a missing file")
+	assert.Nil(t, parts)
+}
+
+func TestJSONDecoder_DecodeDiskInfo_Success(t *testing.T) {
+	d := &JSONDecoder{Strict: true}
+	reader := strings.NewReader(decoderJSONDiskInfo)
+
+	expectedDisk := &types.DiskInfo{
+		APFSContainerReference:                      "disk2",
+		APFSPhysicalStores:                          []types.APFSPhysicalStore{{DeviceIdentifier: "disk0s2"}},
+		SMARTDeviceSpecificKeysMayVaryNotGuaranteed: &types.SmartDeviceInfo{AvailableSpare: 100},
+		TotalSize: 121_122_037_760,
+	}
+
+	actualDisk, err := d.DecodeDiskInfo(reader)
+
+	assert.NoError(t, err, "should be able to decode valid disk JSON data")
+	assert.Equal(t, expectedDisk, actualDisk, "should decode the JSON keys with the plist tags")
+}
+
+func TestJSONDecoder_DecodeDiskInfo_WithoutJSONInput(t *testing.T) {
+	d := &JSONDecoder{}
+
+	for _, input := range []string{"", decoderDiskInfo, "[]"} {
+		actualDisk, err := d.DecodeDiskInfo(strings.NewReader(input))
+
+		assert.Error(t, err, "shouldn't be able to decode non-JSON object input")
+		assert.Nil(t, actualDisk, "should get nil since decode failed")
+	}
+}
+
+func TestJSONDecoder_DecodeDiskInfo_StrictWithMalformedPhysicalStore(t *testing.T) {
+	d := &JSONDecoder{Strict: true}
+	reader := strings.NewReader(`{"APFSPhysicalStores": [{"APFSPhysicalStore": "not-a-disk"}]}`)
+
+	actualDisk, err := d.DecodeDiskInfo(reader)
+
+	assert.Error(t, err, "should validate the physical stores when strict")
+	assert.Nil(t, actualDisk, "should get nil since validation failed")
+}
+
+func TestJSONDecoder_DecodeSystemPartitions_Success(t *testing.T) {
+	expectedParts, err := (&PlistDecoder{}).DecodeSystemPartitions(strings.NewReader(decoderList))
+	if !assert.NoError(t, err, "should be able to decode list plist data") {
+		return
+	}
+
+	actualParts, err := (&JSONDecoder{}).DecodeSystemPartitions(strings.NewReader(decoderJSONList))
+
+	assert.NoError(t, err, "should be able to decode valid list JSON data")
+	assert.Equal(t, expectedParts, actualParts, "should decode the same as the plist")
+}
+
+func TestJSONDecoder_DecodeResizeLimits_Success(t *testing.T) {
+	d := &JSONDecoder{}
+	reader := strings.NewReader(decoderJSONResizeLimits)
+
+	expectedLimits := &types.ResizeLimits{
+		CurrentSize: 99_648_233_472,
+		MaximumSize: 121_122_037_760,
+		MinimumSize: 28_034_662_400,
+	}
+
+	actualLimits, err := d.DecodeResizeLimits(reader)
+
+	assert.NoError(t, err, "should be able to decode valid resize limits")
+	assert.Equal(t, expectedLimits, actualLimits, "should have decoded expected limits")
+}
+
+func TestJSONDecoder_DecodeSnapshots_Success(t *testing.T) {
+	expectedSnapshots, err := (&PlistDecoder{}).DecodeSnapshots(strings.NewReader(decoderSnapshots))
+	if !assert.NoError(t, err, "should be able to decode snapshots plist data") {
+		return
+	}
+
+	actualSnapshots, err := (&JSONDecoder{}).DecodeSnapshots(strings.NewReader(decoderJSONSnapshots))
+
+	assert.NoError(t, err, "should be able to decode valid snapshots JSON data")
+	assert.Equal(t, expectedSnapshots, actualSnapshots, "should decode the same as the plist")
+}
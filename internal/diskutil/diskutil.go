@@ -7,30 +7,235 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os/exec"
 	"strings"
+	"sync"
 
 	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
 	"github.com/aws/ec2-macos-utils/internal/system"
+	"github.com/aws/ec2-macos-utils/internal/util"
 
 	"github.com/Masterminds/semver"
+	"github.com/sirupsen/logrus"
 )
 
 const (
-	// minimumGrowFreeSpace defines the minimum amount of free space (in bytes) required to attempt running
-	// diskutil's resize command.
-	minimumGrowFreeSpace = 1000000
+	// DefaultMinFreeSpace defines the default minimum amount of free space (in bytes) required to attempt running
+	// diskutil's resize command (see GrowOptions.MinFreeSpace).
+	DefaultMinFreeSpace = 1000000
+
+	// internalDiskID is the device identifier of the whole disk that's typically the internal boot media on EC2 Mac
+	// instances. Growing should target the EBS volume instead.
+	internalDiskID = "disk0"
 )
 
 // ErrReadOnly identifies errors due to dry-run not being able to continue without mutating changes.
 var ErrReadOnly = errors.New("read-only mode")
 
+// ErrDiskutilNotFound identifies errors due to the diskutil command not resolving on the PATH (e.g. when not running on
+// macOS or on a stripped image).
+var ErrDiskutilNotFound = errors.New("diskutil not found on PATH")
+
 // FreeSpaceError defines an error to distinguish when there's not enough space to grow the specified container.
 type FreeSpaceError struct {
 	freeSpaceBytes uint64
+	minimumBytes   uint64
 }
 
 func (e FreeSpaceError) Error() string {
-	return fmt.Sprintf("%d bytes available", e.freeSpaceBytes)
+	if e.minimumBytes == 0 {
+		return fmt.Sprintf("%d bytes available", e.freeSpaceBytes)
+	}
+
+	return fmt.Sprintf("%d bytes available, less than the configured minimum of %d bytes", e.freeSpaceBytes, e.minimumBytes)
+}
+
+// FreeSpaceBytes returns the amount of free space (in bytes) that was available when the error occurred.
+func (e FreeSpaceError) FreeSpaceBytes() uint64 {
+	return e.freeSpaceBytes
+}
+
+// MinimumBytes returns the minimum amount of free space (in bytes) that was required when the error occurred. A
+// MinimumBytes of 0 indicates the error wasn't due to the minimum (e.g. a target size exceeded the free space).
+func (e FreeSpaceError) MinimumBytes() uint64 {
+	return e.minimumBytes
+}
+
+// DiskNotFoundError defines an error to distinguish when diskutil can't find the disk for a device identifier (e.g.
+// the disk was detached).
+type DiskNotFoundError struct {
+	deviceID string
+	err      error
+}
+
+func (e DiskNotFoundError) Error() string {
+	return fmt.Sprintf("disk %s not found", e.deviceID)
+}
+
+// Unwrap returns the DiskUtilError of the diskutil command which reported the disk wasn't found, if any.
+func (e DiskNotFoundError) Unwrap() error {
+	return e.err
+}
+
+// DeviceID returns the device identifier of the disk which wasn't found.
+func (e DiskNotFoundError) DeviceID() string {
+	return e.deviceID
+}
+
+// DiskUtilError defines an error to distinguish diskutil's failures by the exit code and stderr of the command (e.g. to
+// tell "resource busy" from "not permitted"). They're read from the util.CommandError it wraps.
+type DiskUtilError struct {
+	err error
+}
+
+// newDiskUtilError creates a DiskUtilError for err, the error of the diskutil command which failed. When diskutil's
+// stderr reports that there's no disk for a device identifier, a DiskNotFoundError wrapping the DiskUtilError is
+// returned instead.
+func newDiskUtilError(err error) error {
+	diskutilErr := DiskUtilError{err: err}
+
+	if match := diskNotFoundExp.FindStringSubmatch(diskutilErr.Stderr()); match != nil {
+		return DiskNotFoundError{deviceID: normalizeDeviceNode(match[1]), err: diskutilErr}
+	}
+
+	return diskutilErr
+}
+
+func (e DiskUtilError) Error() string {
+	err := e.err
+	if e.Is(ErrDiskutilNotFound) {
+		err = ErrDiskutilNotFound
+	}
+
+	return fmt.Sprintf("exit code %d, stderr: [%s]: %v", e.ExitCode(), e.Stderr(), err)
+}
+
+func (e DiskUtilError) Unwrap() error {
+	return e.err
+}
+
+// Is reports the command's error as ErrDiskutilNotFound when it couldn't be started since diskutil doesn't resolve on
+// the PATH.
+func (e DiskUtilError) Is(target error) bool {
+	return target == ErrDiskutilNotFound && errors.Is(e.err, exec.ErrNotFound)
+}
+
+// ExitCode returns the exit code of the diskutil command, or -1 if it didn't exit on its own.
+func (e DiskUtilError) ExitCode() int {
+	var cmdErr util.CommandError
+	if !errors.As(e.err, &cmdErr) {
+		return -1
+	}
+
+	return cmdErr.ExitCode()
+}
+
+// Stderr returns the stderr of the diskutil command.
+func (e DiskUtilError) Stderr() string {
+	var cmdErr util.CommandError
+	if !errors.As(e.err, &cmdErr) {
+		return ""
+	}
+
+	return cmdErr.Stderr()
+}
+
+// InternalDiskError defines an error to distinguish when a mutating operation targets the internal disk without being
+// forced.
+type InternalDiskError struct {
+	deviceID string
+}
+
+func (e InternalDiskError) Error() string {
+	return fmt.Sprintf("refusing to modify internal disk %s without force", e.deviceID)
+}
+
+// LockedContainerError defines an error to distinguish when a container can't be resized because it's locked (e.g. by
+// FileVault).
+type LockedContainerError struct {
+	deviceID string
+}
+
+func (e LockedContainerError) Error() string {
+	return fmt.Sprintf("container %s is locked, unlock it (e.g. with diskutil apfs unlockVolume) before resizing", e.deviceID)
+}
+
+// ReadOnlyRootError defines an error to distinguish when the disk backing the root filesystem is read-only and can't
+// be repaired or resized.
+type ReadOnlyRootError struct {
+	deviceID string
+}
+
+func (e ReadOnlyRootError) Error() string {
+	return fmt.Sprintf("root device %s is read-only, boot the instance normally (not into recovery) "+
+		"and make sure the volume is writable before growing", e.deviceID)
+}
+
+// CheckWritableRoot checks that the disk backing the root filesystem can be written to. The root volume itself is
+// always mounted read-only on releases with a sealed system volume (Big Sur and later), so the writability of its
+// media is checked instead. A ReadOnlyRootError is returned if the media is read-only.
+func CheckWritableRoot(root *types.DiskInfo) error {
+	if root == nil {
+		return errors.New("no root disk information")
+	}
+
+	if !root.WritableMedia {
+		return ReadOnlyRootError{deviceID: root.DeviceIdentifier}
+	}
+
+	return nil
+}
+
+// RootDiskInfo fetches the disk information for the root filesystem and checks that it's writable (see
+// CheckWritableRoot) so repairs and resizes don't fail confusingly (e.g. when booted into a recovery-like state).
+func RootDiskInfo(ctx context.Context, u DiskUtil) (*types.DiskInfo, error) {
+	root, err := u.Info(ctx, "/")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := CheckWritableRoot(root); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// RootContainerInfo fetches the disk information for the root filesystem like RootDiskInfo, then resolves the APFS
+// container of its volume group (see VolumeGroupContainer) so the container is what gets resized.
+func RootContainerInfo(ctx context.Context, u DiskUtil) (*types.DiskInfo, error) {
+	root, err := RootDiskInfo(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	return VolumeGroupContainer(ctx, u, root)
+}
+
+// VolumeGroupContainer resolves the APFS container of a volume which is a member of a volume group (e.g. the System and
+// Data volumes the root filesystem is split into on Catalina and later). Resolving "/" can land on either member (or
+// a snapshot of the System volume) but they all share the group's APFSVolumeGroupID and reference the same container,
+// so the container's disk information is fetched with the APFSContainerReference. Disks which aren't members of a
+// volume group are returned as-is.
+func VolumeGroupContainer(ctx context.Context, u DiskUtil, disk *types.DiskInfo) (*types.DiskInfo, error) {
+	if disk == nil || disk.APFSVolumeGroupID == "" {
+		return disk, nil
+	}
+	if disk.APFSContainerReference == "" {
+		return nil, fmt.Errorf("volume %s of group %s doesn't reference its container", disk.DeviceIdentifier, disk.APFSVolumeGroupID)
+	}
+
+	container, err := u.Info(ctx, disk.APFSContainerReference)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch container of volume group %s: %w", disk.APFSVolumeGroupID, err)
+	}
+	logrus.WithFields(logrus.Fields{
+		"device_id":       disk.DeviceIdentifier,
+		"volume_group_id": disk.APFSVolumeGroupID,
+		"container_id":    container.DeviceIdentifier,
+	}).Info("Resolved volume group to its container")
+
+	return container, nil
 }
 
 // DiskUtil outlines the functionality necessary for wrapping macOS's diskutil tool.
@@ -42,40 +247,143 @@ type DiskUtil interface {
 	// List fetches all disk and partition information for the system.
 	// This output will be filtered based on the args provided.
 	List(ctx context.Context, args []string) (*types.SystemPartitions, error)
+	// Mount mounts the volume for the specified device identifier and returns its mount point.
+	Mount(ctx context.Context, id string) (string, error)
+	// Unmount unmounts the volume for the specified device identifier, forcefully if force is true.
+	Unmount(ctx context.Context, id string, force bool) (string, error)
 	// RepairDisk attempts to repair the disk for the specified device identifier.
 	// This process requires root access.
 	RepairDisk(ctx context.Context, id string) (string, error)
+	// RepairVolume attempts to repair the volume (or APFS container) for the specified device identifier.
+	// This process requires root access.
+	RepairVolume(ctx context.Context, id string) (string, error)
+	// VerifyDisk verifies the partition map of the disk for the specified device identifier without changing it.
+	VerifyDisk(ctx context.Context, id string) (string, error)
+	// VerifyVolume verifies the file system of the volume for the specified device identifier without changing it.
+	VerifyVolume(ctx context.Context, id string) (string, error)
+	// EraseVolume erases the volume for the specified device identifier, reformatting it with the given format and
+	// name. This process requires root access.
+	EraseVolume(ctx context.Context, id string, format string, name string) (string, error)
+	// RenameVolume renames the volume for the specified device identifier.
+	RenameVolume(ctx context.Context, id string, name string) (string, error)
 }
 
 // APFS outlines the functionality necessary for wrapping diskutil's "apfs" verb.
 type APFS interface {
 	// ResizeContainer attempts to grow the APFS container with the given device identifier
 	// to the specified size. If the given size is 0, ResizeContainer will attempt to grow
-	// the disk to its maximum size.
-	ResizeContainer(ctx context.Context, id string, size string) (string, error)
+	// the disk to its maximum size. Any options are appended to the command in order.
+	ResizeContainer(ctx context.Context, id string, size string, opts ...types.ResizeOption) (string, error)
+	// ResizeLimits fetches the sizes the APFS container with the given device identifier can be resized to.
+	ResizeLimits(ctx context.Context, id string) (*types.ResizeLimits, error)
+	// ListSnapshots fetches the snapshots of the APFS volume with the given device identifier.
+	ListSnapshots(ctx context.Context, volumeID string) ([]types.Snapshot, error)
+	// DeleteSnapshot deletes the snapshot with the given UUID from the APFS volume with the given device identifier.
+	// This process requires root access.
+	DeleteSnapshot(ctx context.Context, volumeID string, uuid string) (string, error)
+	// AddVolume adds a new volume with the given name and format to the APFS container with the given device
+	// identifier. This process requires root access.
+	AddVolume(ctx context.Context, containerID string, name string, format string) (string, error)
+	// UnlockVolume unlocks the encrypted APFS volume with the given device identifier using the passphrase. This
+	// process requires root access.
+	UnlockVolume(ctx context.Context, id string, passphrase string) (string, error)
 }
 
 // readonlyWrapper provides a typed implementation for DiskUtil that substitutes mutating
-// methods with dryrun alternatives.
+// methods with dryrun alternatives. The diskutil command each skipped method would have run is recorded so the
+// changes can be previewed (see PlannedCommands).
 type readonlyWrapper struct {
 	// impl is the DiskUtil implementation that should have mutating methods substituted for dryrun methods.
 	impl DiskUtil
+
+	mu      sync.Mutex
+	planned [][]string
+}
+
+// PlannedCommands provides the diskutil commands (e.g. ["diskutil", "repairDisk", "disk0"]) which were skipped, in
+// the order they would have run.
+func (r *readonlyWrapper) PlannedCommands() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	planned := make([][]string, len(r.planned))
+	copy(planned, r.planned)
+
+	return planned
+}
+
+// skip records the command as planned and provides the ErrReadOnly for skipping the operation.
+func (r *readonlyWrapper) skip(operation string, command []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.planned = append(r.planned, command)
+
+	return fmt.Errorf("skip %s: %w", operation, ErrReadOnly)
 }
 
-func (r readonlyWrapper) ResizeContainer(ctx context.Context, id string, size string) (string, error) {
-	return "", fmt.Errorf("skip resize container: %w", ErrReadOnly)
+func (r *readonlyWrapper) ResizeContainer(ctx context.Context, id string, size string, opts ...types.ResizeOption) (string, error) {
+	return "", r.skip("resize container", resizeContainerCommand(id, size, opts))
 }
 
-func (r readonlyWrapper) Info(ctx context.Context, id string) (*types.DiskInfo, error) {
+func (r *readonlyWrapper) Info(ctx context.Context, id string) (*types.DiskInfo, error) {
 	return r.impl.Info(ctx, id)
 }
 
-func (r readonlyWrapper) List(ctx context.Context, args []string) (*types.SystemPartitions, error) {
+func (r *readonlyWrapper) List(ctx context.Context, args []string) (*types.SystemPartitions, error) {
 	return r.impl.List(ctx, args)
 }
 
-func (r readonlyWrapper) RepairDisk(ctx context.Context, id string) (string, error) {
-	return "", fmt.Errorf("skip repair disk: %w", ErrReadOnly)
+func (r *readonlyWrapper) ResizeLimits(ctx context.Context, id string) (*types.ResizeLimits, error) {
+	return r.impl.ResizeLimits(ctx, id)
+}
+
+func (r *readonlyWrapper) ListSnapshots(ctx context.Context, volumeID string) ([]types.Snapshot, error) {
+	return r.impl.ListSnapshots(ctx, volumeID)
+}
+
+func (r *readonlyWrapper) DeleteSnapshot(ctx context.Context, volumeID string, uuid string) (string, error) {
+	return "", r.skip("delete snapshot", deleteSnapshotCommand(volumeID, uuid))
+}
+
+func (r *readonlyWrapper) AddVolume(ctx context.Context, containerID string, name string, format string) (string, error) {
+	return "", r.skip("add volume", addVolumeCommand(containerID, name, format))
+}
+
+func (r *readonlyWrapper) UnlockVolume(ctx context.Context, id string, passphrase string) (string, error) {
+	return "", r.skip("unlock volume", unlockVolumeCommand(id))
+}
+
+func (r *readonlyWrapper) Mount(ctx context.Context, id string) (string, error) {
+	return "", r.skip("mount", mountCommand(id))
+}
+
+func (r *readonlyWrapper) Unmount(ctx context.Context, id string, force bool) (string, error) {
+	return "", r.skip("unmount", unmountCommand(id, force))
+}
+
+func (r *readonlyWrapper) RepairDisk(ctx context.Context, id string) (string, error) {
+	return "", r.skip("repair disk", repairDiskCommand(id))
+}
+
+func (r *readonlyWrapper) RepairVolume(ctx context.Context, id string) (string, error) {
+	return "", r.skip("repair volume", repairVolumeCommand(id))
+}
+
+func (r *readonlyWrapper) VerifyDisk(ctx context.Context, id string) (string, error) {
+	return r.impl.VerifyDisk(ctx, id)
+}
+
+func (r *readonlyWrapper) VerifyVolume(ctx context.Context, id string) (string, error) {
+	return r.impl.VerifyVolume(ctx, id)
+}
+
+func (r *readonlyWrapper) EraseVolume(ctx context.Context, id string, format string, name string) (string, error) {
+	return "", r.skip("erase volume", eraseVolumeCommand(id, format, name))
+}
+
+func (r *readonlyWrapper) RenameVolume(ctx context.Context, id string, name string) (string, error) {
+	return "", r.skip("rename volume", renameVolumeCommand(id, name))
 }
 
 // Type assertion to ensure readonlyWrapper implements the DiskUtil interface.
@@ -83,96 +391,125 @@ var _ DiskUtil = (*readonlyWrapper)(nil)
 
 // Dryrun takes a DiskUtil implementation and wraps the mutating methods with dryrun alternatives.
 func Dryrun(impl DiskUtil) *readonlyWrapper {
-	return &readonlyWrapper{impl}
+	return &readonlyWrapper{impl: impl}
 }
 
-// ForProduct creates a new diskutil controller for the given product.
+// jsonReleases are the releases where diskutil's JSON output is more reliable than its plist output, so it's requested
+// with -json and decoded with the JSONDecoder instead. Every supported release currently uses plists.
+var jsonReleases = map[system.Release]bool{}
+
+// ForProduct creates a new diskutil controller for the given product which decodes diskutil's plist output, or its
+// JSON output for releases in jsonReleases.
 func ForProduct(p *system.Product) (DiskUtil, error) {
+	if jsonReleases[p.Release] {
+		return forProduct(p, &JSONDecoder{}, OutputJSON)
+	}
+
+	return ForProductWithDecoder(p, &PlistDecoder{})
+}
+
+// ForProductWithDecoder creates a new diskutil controller for the given product which decodes diskutil's plist output
+// with the given Decoder.
+func ForProductWithDecoder(p *system.Product, dec Decoder) (DiskUtil, error) {
+	return forProduct(p, dec, OutputPlist)
+}
+
+// forProduct creates a new diskutil controller for the given product which requests diskutil's output in the format
+// and decodes it with the given Decoder.
+func forProduct(p *system.Product, dec Decoder, format OutputFormat) (DiskUtil, error) {
+	if dec == nil {
+		return nil, errors.New("decoder required")
+	}
+
 	switch p.Release {
 	case system.Mojave:
-		return newMojave(p.Version)
+		return newMojave(p.Version, dec, format)
 	case system.Catalina:
-		return newCatalina(p.Version)
+		return newCatalina(p.Version, dec, format)
 	case system.BigSur:
-		return newBigSur(p.Version)
+		return newBigSur(p.Version, dec, format)
 	case system.Monterey:
-		return newMonterey(p.Version)
+		return newMonterey(p.Version, dec, format)
 	case system.Ventura:
-		return newVentura(p.Version)
+		return newVentura(p.Version, dec, format)
 	case system.Sonoma:
-		return newSonoma(p.Version)
+		return newSonoma(p.Version, dec, format)
 	case system.Sequoia:
-		return newSequoia(p.Version)
+		return newSequoia(p.Version, dec, format)
+	case system.Latest:
+		logrus.WithField("version", p.Version.String()).Warn("Newer macOS release than supported, " +
+			"configuring diskutil for the newest supported release (Sequoia)")
+		return newSequoia(p.Version, dec, format)
 	default:
 		return nil, errors.New("unknown release")
 	}
 }
 
-// newMojave configures the DiskUtil for the specified Mojave version.
-func newMojave(version semver.Version) (*diskutilMojave, error) {
+// newMojave configures the DiskUtil for the specified Mojave version, requesting diskutil's output in the given format.
+func newMojave(version semver.Version, dec Decoder, format OutputFormat) (*diskutilMojave, error) {
 	du := &diskutilMojave{
-		embeddedDiskutil: &DiskUtilityCmd{},
-		dec:              &PlistDecoder{},
+		embeddedDiskutil: &DiskUtilityCmd{Format: format},
+		dec:              dec,
 	}
 
 	return du, nil
 }
 
-// newCatalina configures the DiskUtil for the specified Catalina version.
-func newCatalina(version semver.Version) (*diskutilCatalina, error) {
+// newCatalina configures the DiskUtil for the specified Catalina version, requesting diskutil's output in the given format.
+func newCatalina(version semver.Version, dec Decoder, format OutputFormat) (*diskutilCatalina, error) {
 	du := &diskutilCatalina{
-		embeddedDiskutil: &DiskUtilityCmd{},
-		dec:              &PlistDecoder{},
+		embeddedDiskutil: &DiskUtilityCmd{Format: format},
+		dec:              dec,
 	}
 
 	return du, nil
 }
 
-// newBigSur configures the DiskUtil for the specified Big Sur version.
-func newBigSur(version semver.Version) (*diskutilBigSur, error) {
+// newBigSur configures the DiskUtil for the specified Big Sur version, requesting diskutil's output in the given format.
+func newBigSur(version semver.Version, dec Decoder, format OutputFormat) (*diskutilBigSur, error) {
 	du := &diskutilBigSur{
-		embeddedDiskutil: &DiskUtilityCmd{},
-		dec:              &PlistDecoder{},
+		embeddedDiskutil: &DiskUtilityCmd{Format: format},
+		dec:              dec,
 	}
 
 	return du, nil
 }
 
-// newMonterey configures the DiskUtil for the specified Monterey version.
-func newMonterey(version semver.Version) (*diskutilMonterey, error) {
+// newMonterey configures the DiskUtil for the specified Monterey version, requesting diskutil's output in the given format.
+func newMonterey(version semver.Version, dec Decoder, format OutputFormat) (*diskutilMonterey, error) {
 	du := &diskutilMonterey{
-		embeddedDiskutil: &DiskUtilityCmd{},
-		dec:              &PlistDecoder{},
+		embeddedDiskutil: &DiskUtilityCmd{Format: format},
+		dec:              dec,
 	}
 
 	return du, nil
 }
 
-// newVentura configures the DiskUtil for the specified Ventura version.
-func newVentura(version semver.Version) (*diskutilMonterey, error) {
+// newVentura configures the DiskUtil for the specified Ventura version, requesting diskutil's output in the given format.
+func newVentura(version semver.Version, dec Decoder, format OutputFormat) (*diskutilMonterey, error) {
 	du := &diskutilMonterey{
-		embeddedDiskutil: &DiskUtilityCmd{},
-		dec:              &PlistDecoder{},
+		embeddedDiskutil: &DiskUtilityCmd{Format: format},
+		dec:              dec,
 	}
 
 	return du, nil
 }
 
-// newSonoma configures the DiskUtil for the specified Sonoma version.
-func newSonoma(version semver.Version) (*diskutilSonoma, error) {
+// newSonoma configures the DiskUtil for the specified Sonoma version, requesting diskutil's output in the given format.
+func newSonoma(version semver.Version, dec Decoder, format OutputFormat) (*diskutilSonoma, error) {
 	du := &diskutilSonoma{
-		embeddedDiskutil: &DiskUtilityCmd{},
-		dec:              &PlistDecoder{},
+		embeddedDiskutil: &DiskUtilityCmd{Format: format},
+		dec:              dec,
 	}
 
 	return du, nil
 }
 
-// newSequoia configures the DiskUtil for the specified Sequoia version.
-func newSequoia(version semver.Version) (*diskutilSonoma, error) {
+// newSequoia configures the DiskUtil for the specified Sequoia version, requesting diskutil's output in the given format.
+func newSequoia(version semver.Version, dec Decoder, format OutputFormat) (*diskutilSonoma, error) {
 	du := &diskutilSonoma{
-		embeddedDiskutil: &DiskUtilityCmd{},
-		dec:              &PlistDecoder{},
+		embeddedDiskutil: &DiskUtilityCmd{Format: format},
+		dec:              dec,
 	}
 
 	return du, nil
@@ -234,6 +571,18 @@ func (d *diskutilMojave) Info(ctx context.Context, id string) (*types.DiskInfo,
 	return disk, nil
 }
 
+// ResizeLimits utilizes the UtilImpl.ResizeLimits method to fetch the raw resize limits output from diskutil and
+// returns the decoded output in a ResizeLimits struct.
+func (d *diskutilMojave) ResizeLimits(ctx context.Context, id string) (*types.ResizeLimits, error) {
+	return resizeLimits(ctx, d.embeddedDiskutil, d.dec, id)
+}
+
+// ListSnapshots utilizes the UtilImpl.ListSnapshots method to fetch the raw snapshot list from diskutil and returns the
+// decoded snapshots.
+func (d *diskutilMojave) ListSnapshots(ctx context.Context, volumeID string) ([]types.Snapshot, error) {
+	return listSnapshots(ctx, d.embeddedDiskutil, d.dec, volumeID)
+}
+
 // diskutilCatalina wraps all the functionality necessary for interacting with macOS's diskutil in GoLang.
 type diskutilCatalina struct {
 	// embeddedDiskutil provides the diskutil implementation to prevent manual wiring between UtilImpl and DiskUtil.
@@ -255,6 +604,18 @@ func (d *diskutilCatalina) Info(ctx context.Context, id string) (*types.DiskInfo
 	return info(ctx, d.embeddedDiskutil, d.dec, id)
 }
 
+// ResizeLimits utilizes the UtilImpl.ResizeLimits method to fetch the raw resize limits output from diskutil and
+// returns the decoded output in a ResizeLimits struct.
+func (d *diskutilCatalina) ResizeLimits(ctx context.Context, id string) (*types.ResizeLimits, error) {
+	return resizeLimits(ctx, d.embeddedDiskutil, d.dec, id)
+}
+
+// ListSnapshots utilizes the UtilImpl.ListSnapshots method to fetch the raw snapshot list from diskutil and returns the
+// decoded snapshots.
+func (d *diskutilCatalina) ListSnapshots(ctx context.Context, volumeID string) ([]types.Snapshot, error) {
+	return listSnapshots(ctx, d.embeddedDiskutil, d.dec, volumeID)
+}
+
 // diskutilBigSur wraps all the functionality necessary for interacting with macOS's diskutil in GoLang.
 type diskutilBigSur struct {
 	// embeddedDiskutil provides the diskutil implementation to prevent manual wiring between UtilImpl and DiskUtil.
@@ -276,6 +637,18 @@ func (d *diskutilBigSur) Info(ctx context.Context, id string) (*types.DiskInfo,
 	return info(ctx, d.embeddedDiskutil, d.dec, id)
 }
 
+// ResizeLimits utilizes the UtilImpl.ResizeLimits method to fetch the raw resize limits output from diskutil and
+// returns the decoded output in a ResizeLimits struct.
+func (d *diskutilBigSur) ResizeLimits(ctx context.Context, id string) (*types.ResizeLimits, error) {
+	return resizeLimits(ctx, d.embeddedDiskutil, d.dec, id)
+}
+
+// ListSnapshots utilizes the UtilImpl.ListSnapshots method to fetch the raw snapshot list from diskutil and returns the
+// decoded snapshots.
+func (d *diskutilBigSur) ListSnapshots(ctx context.Context, volumeID string) ([]types.Snapshot, error) {
+	return listSnapshots(ctx, d.embeddedDiskutil, d.dec, volumeID)
+}
+
 // diskutilMonterey wraps all the functionality necessary for interacting with macOS's diskutil in GoLang.
 type diskutilMonterey struct {
 	// embeddedDiskutil provides the diskutil implementation to prevent manual wiring between UtilImpl and DiskUtil.
@@ -297,6 +670,18 @@ func (d *diskutilMonterey) Info(ctx context.Context, id string) (*types.DiskInfo
 	return info(ctx, d.embeddedDiskutil, d.dec, id)
 }
 
+// ResizeLimits utilizes the UtilImpl.ResizeLimits method to fetch the raw resize limits output from diskutil and
+// returns the decoded output in a ResizeLimits struct.
+func (d *diskutilMonterey) ResizeLimits(ctx context.Context, id string) (*types.ResizeLimits, error) {
+	return resizeLimits(ctx, d.embeddedDiskutil, d.dec, id)
+}
+
+// ListSnapshots utilizes the UtilImpl.ListSnapshots method to fetch the raw snapshot list from diskutil and returns the
+// decoded snapshots.
+func (d *diskutilMonterey) ListSnapshots(ctx context.Context, volumeID string) ([]types.Snapshot, error) {
+	return listSnapshots(ctx, d.embeddedDiskutil, d.dec, volumeID)
+}
+
 // diskutilVentura wraps all the functionality necessary for interacting with macOS's diskutil in GoLang.
 type diskutilVentura struct {
 	// embeddedDiskutil provides the diskutil implementation to prevent manual wiring between UtilImpl and DiskUtil.
@@ -318,6 +703,18 @@ func (d *diskutilVentura) Info(ctx context.Context, id string) (*types.DiskInfo,
 	return info(ctx, d.embeddedDiskutil, d.dec, id)
 }
 
+// ResizeLimits utilizes the UtilImpl.ResizeLimits method to fetch the raw resize limits output from diskutil and
+// returns the decoded output in a ResizeLimits struct.
+func (d *diskutilVentura) ResizeLimits(ctx context.Context, id string) (*types.ResizeLimits, error) {
+	return resizeLimits(ctx, d.embeddedDiskutil, d.dec, id)
+}
+
+// ListSnapshots utilizes the UtilImpl.ListSnapshots method to fetch the raw snapshot list from diskutil and returns the
+// decoded snapshots.
+func (d *diskutilVentura) ListSnapshots(ctx context.Context, volumeID string) ([]types.Snapshot, error) {
+	return listSnapshots(ctx, d.embeddedDiskutil, d.dec, volumeID)
+}
+
 // diskutilSonoma wraps all the functionality necessary for interacting with macOS's diskutil in GoLang.
 type diskutilSonoma struct {
 	// embeddedDiskutil provides the diskutil implementation to prevent manual wiring between UtilImpl and DiskUtil.
@@ -339,6 +736,18 @@ func (d *diskutilSonoma) Info(ctx context.Context, id string) (*types.DiskInfo,
 	return info(ctx, d.embeddedDiskutil, d.dec, id)
 }
 
+// ResizeLimits utilizes the UtilImpl.ResizeLimits method to fetch the raw resize limits output from diskutil and
+// returns the decoded output in a ResizeLimits struct.
+func (d *diskutilSonoma) ResizeLimits(ctx context.Context, id string) (*types.ResizeLimits, error) {
+	return resizeLimits(ctx, d.embeddedDiskutil, d.dec, id)
+}
+
+// ListSnapshots utilizes the UtilImpl.ListSnapshots method to fetch the raw snapshot list from diskutil and returns the
+// decoded snapshots.
+func (d *diskutilSonoma) ListSnapshots(ctx context.Context, volumeID string) ([]types.Snapshot, error) {
+	return listSnapshots(ctx, d.embeddedDiskutil, d.dec, volumeID)
+}
+
 // info is a wrapper that fetches the raw diskutil info data and decodes it into a usable types.DiskInfo struct.
 func info(ctx context.Context, util UtilImpl, decoder Decoder, id string) (*types.DiskInfo, error) {
 	// Fetch the raw disk information from the util
@@ -378,3 +787,45 @@ func list(ctx context.Context, util UtilImpl, decoder Decoder, args []string) (*
 
 	return partitions, nil
 }
+
+// resizeLimits is a wrapper that fetches the raw diskutil resize limits data and decodes it into a usable
+// types.ResizeLimits struct.
+func resizeLimits(ctx context.Context, util UtilImpl, decoder Decoder, id string) (*types.ResizeLimits, error) {
+	// Fetch the raw resize limits from the util
+	rawLimits, err := util.ResizeLimits(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a reader for the raw data
+	reader := strings.NewReader(rawLimits)
+
+	// Decode the raw data into a more usable ResizeLimits struct
+	limits, err := decoder.DecodeResizeLimits(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return limits, nil
+}
+
+// listSnapshots is a wrapper that fetches the raw diskutil snapshot list and decodes it into usable types.Snapshot
+// structs.
+func listSnapshots(ctx context.Context, util UtilImpl, decoder Decoder, volumeID string) ([]types.Snapshot, error) {
+	// Fetch the raw snapshot list from the util
+	rawSnapshots, err := util.ListSnapshots(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a reader for the raw data
+	reader := strings.NewReader(rawSnapshots)
+
+	// Decode the raw data into more usable Snapshot structs
+	snapshots, err := decoder.DecodeSnapshots(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
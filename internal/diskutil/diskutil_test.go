@@ -1,9 +1,16 @@
 package diskutil
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"testing"
 
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+	"github.com/aws/ec2-macos-utils/internal/system"
+
+	"github.com/Masterminds/semver"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -20,3 +27,451 @@ func TestMinimumGrowSpaceError_Error(t *testing.T) {
 
 	assert.Equal(t, expectedErrorMessage, actualErrorMessage, "expected message to include metadata")
 }
+
+func TestFreeSpaceError_ErrorWithMinimum(t *testing.T) {
+	e := FreeSpaceError{
+		freeSpaceBytes: 500_000,
+		minimumBytes:   1_000_000,
+	}
+
+	assert.Equal(t, "500000 bytes available, less than the configured minimum of 1000000 bytes", e.Error(),
+		"expected message to include the configured minimum")
+}
+
+func TestFreeSpaceError_FreeSpaceBytes(t *testing.T) {
+	const expectedSize uint64 = 500_000
+
+	e := FreeSpaceError{
+		freeSpaceBytes: expectedSize,
+	}
+
+	actualSize := e.FreeSpaceBytes()
+
+	assert.Equal(t, expectedSize, actualSize, "expected available bytes to be readable")
+}
+
+func TestDiskNotFoundError(t *testing.T) {
+	e := DiskNotFoundError{deviceID: "disk5"}
+
+	assert.Equal(t, "disk disk5 not found", e.Error(), "expected message to include the device id")
+	assert.Equal(t, "disk5", e.DeviceID(), "expected device id to be readable")
+}
+
+func TestCheckWritableRoot(t *testing.T) {
+	tests := []struct {
+		name    string
+		root    *types.DiskInfo
+		wantErr bool
+	}{
+		{
+			name:    "without root",
+			root:    nil,
+			wantErr: true,
+		},
+		{
+			name: "read-only media",
+			root: &types.DiskInfo{
+				DeviceIdentifier: "disk1s5s1",
+				WritableMedia:    false,
+			},
+			wantErr: true,
+		},
+		{
+			name: "writable media with read-only volume",
+			root: &types.DiskInfo{
+				DeviceIdentifier: "disk1s5s1",
+				WritableMedia:    true,
+				WritableVolume:   false,
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckWritableRoot(tt.root)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestReadOnlyRootError(t *testing.T) {
+	err := CheckWritableRoot(&types.DiskInfo{DeviceIdentifier: "disk1s5s1"})
+
+	var readOnlyErr ReadOnlyRootError
+	assert.True(t, errors.As(err, &readOnlyErr), "should get ReadOnlyRootError")
+	assert.Contains(t, readOnlyErr.Error(), "disk1s5s1", "expected message to include the device")
+}
+
+// volumeGroupFixture creates a FakeUtil for Catalina and later where "/" resolves to a snapshot of the System volume,
+// disk3s1s1, which is in the same volume group as the Data volume, disk3s5, in the container disk3.
+func volumeGroupFixture() *FakeUtil {
+	const groupID = "A3F4E1F2-2A7C-4B1E-9C4D-5E6F7A8B9C0D"
+
+	return NewFakeUtil(nil, map[string]*types.DiskInfo{
+		"/": {
+			APFSContainerReference: "disk3",
+			ContainerInfo:          types.ContainerInfo{APFSVolumeGroupID: groupID, FilesystemType: "apfs"},
+			DeviceIdentifier:       "disk3s1s1",
+			WritableMedia:          true,
+		},
+		"disk3s5": {
+			APFSContainerReference: "disk3",
+			ContainerInfo:          types.ContainerInfo{APFSVolumeGroupID: groupID, FilesystemType: "apfs"},
+			DeviceIdentifier:       "disk3s5",
+			WritableMedia:          true,
+		},
+		"disk3": {
+			APFSPhysicalStores: []types.APFSPhysicalStore{{DeviceIdentifier: "disk0s2"}},
+			ContainerInfo:      types.ContainerInfo{FilesystemType: "apfs"},
+			DeviceIdentifier:   "disk3",
+			WritableMedia:      true,
+		},
+	})
+}
+
+func TestRootContainerInfo_WithVolumeGroup(t *testing.T) {
+	fake := volumeGroupFixture()
+
+	container, err := RootContainerInfo(context.Background(), fake)
+
+	assert.NoError(t, err, "should resolve the root's container")
+	if assert.NotNil(t, container) {
+		assert.Equal(t, "disk3", container.DeviceIdentifier, "should resolve to the container rather than a member volume")
+	}
+}
+
+func TestVolumeGroupContainer(t *testing.T) {
+	fake := volumeGroupFixture()
+
+	data, _ := fake.Info(context.Background(), "disk3s5")
+	container, err := VolumeGroupContainer(context.Background(), fake, data)
+
+	assert.NoError(t, err, "should resolve the Data volume's container")
+	if assert.NotNil(t, container) {
+		assert.Equal(t, "disk3", container.DeviceIdentifier, "should resolve every member to the same container")
+	}
+}
+
+func TestVolumeGroupContainer_WithoutVolumeGroup(t *testing.T) {
+	fake := NewFakeUtil(nil, nil)
+	disk := &types.DiskInfo{APFSContainerReference: "disk1", DeviceIdentifier: "disk1s1"}
+
+	actual, err := VolumeGroupContainer(context.Background(), fake, disk)
+
+	assert.NoError(t, err)
+	assert.True(t, disk == actual, "should return disks outside of a volume group as-is")
+	assert.Empty(t, fake.Calls(), "shouldn't fetch any disk information")
+}
+
+func TestVolumeGroupContainer_WithoutContainerReference(t *testing.T) {
+	disk := &types.DiskInfo{
+		ContainerInfo:    types.ContainerInfo{APFSVolumeGroupID: "A3F4E1F2-2A7C-4B1E-9C4D-5E6F7A8B9C0D"},
+		DeviceIdentifier: "disk3s5",
+	}
+
+	_, err := VolumeGroupContainer(context.Background(), NewFakeUtil(nil, nil), disk)
+
+	assert.Error(t, err, "should fail when the container can't be resolved")
+}
+
+// fakeDecoder is a Decoder that records the raw data it's given and returns fixed results.
+type fakeDecoder struct {
+	raw []string
+}
+
+func (d *fakeDecoder) DecodeSystemPartitions(reader io.ReadSeeker) (*types.SystemPartitions, error) {
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	d.raw = append(d.raw, string(raw))
+
+	return &types.SystemPartitions{AllDisks: []string{"disk1"}}, nil
+}
+
+func (d *fakeDecoder) DecodeDiskInfo(reader io.ReadSeeker) (*types.DiskInfo, error) {
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	d.raw = append(d.raw, string(raw))
+
+	return &types.DiskInfo{DeviceIdentifier: "disk1"}, nil
+}
+
+func (d *fakeDecoder) DecodeResizeLimits(reader io.ReadSeeker) (*types.ResizeLimits, error) {
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	d.raw = append(d.raw, string(raw))
+
+	return &types.ResizeLimits{}, nil
+}
+
+func (d *fakeDecoder) DecodeSnapshots(reader io.ReadSeeker) ([]types.Snapshot, error) {
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	d.raw = append(d.raw, string(raw))
+
+	return []types.Snapshot{{SnapshotUUID: "uuid"}}, nil
+}
+
+// fakeUtilImpl is a UtilImpl that returns fixed raw output without running diskutil.
+type fakeUtilImpl struct{}
+
+func (fakeUtilImpl) Info(ctx context.Context, id string) (string, error) {
+	return "info " + id, nil
+}
+
+func (fakeUtilImpl) List(ctx context.Context, args []string) (string, error) {
+	return "list", nil
+}
+
+func (fakeUtilImpl) Mount(ctx context.Context, id string) (string, error) {
+	return "/Volumes/" + id, nil
+}
+
+func (fakeUtilImpl) Unmount(ctx context.Context, id string, force bool) (string, error) {
+	return "", nil
+}
+
+func (fakeUtilImpl) RepairDisk(ctx context.Context, id string) (string, error) {
+	return "", nil
+}
+
+func (fakeUtilImpl) VerifyDisk(ctx context.Context, id string) (string, error) {
+	return "", nil
+}
+
+func (fakeUtilImpl) RepairVolume(ctx context.Context, id string) (string, error) {
+	return "", nil
+}
+
+func (fakeUtilImpl) VerifyVolume(ctx context.Context, id string) (string, error) {
+	return "", nil
+}
+
+func (fakeUtilImpl) EraseVolume(ctx context.Context, id string, format string, name string) (string, error) {
+	return "", nil
+}
+
+func (fakeUtilImpl) RenameVolume(ctx context.Context, id string, name string) (string, error) {
+	return "", nil
+}
+
+func (fakeUtilImpl) ResizeContainer(ctx context.Context, id string, size string, opts ...types.ResizeOption) (string, error) {
+	return "", nil
+}
+
+func (fakeUtilImpl) ResizeLimits(ctx context.Context, id string) (string, error) {
+	return "limits " + id, nil
+}
+
+func (fakeUtilImpl) ListSnapshots(ctx context.Context, volumeID string) (string, error) {
+	return "snapshots " + volumeID, nil
+}
+
+func (fakeUtilImpl) AddVolume(ctx context.Context, containerID string, name string, format string) (string, error) {
+	return "", nil
+}
+
+func (fakeUtilImpl) UnlockVolume(ctx context.Context, id string, passphrase string) (string, error) {
+	return "", nil
+}
+
+func (fakeUtilImpl) DeleteSnapshot(ctx context.Context, volumeID string, uuid string) (string, error) {
+	return "", nil
+}
+
+func TestForProductWithDecoder(t *testing.T) {
+	product := &system.Product{Release: system.Monterey, Version: *semver.MustParse("12.6.0")}
+	dec := &fakeDecoder{}
+
+	du, err := ForProductWithDecoder(product, dec)
+	assert.NoError(t, err, "should be able to configure diskutil with a decoder")
+
+	monterey, ok := du.(*diskutilMonterey)
+	if !assert.True(t, ok, "should configure diskutil for Monterey") {
+		return
+	}
+	monterey.embeddedDiskutil = fakeUtilImpl{}
+
+	disk, err := du.Info(context.Background(), "disk1")
+	assert.NoError(t, err, "should be able to get info")
+	assert.Equal(t, "disk1", disk.DeviceIdentifier, "should get info from the decoder")
+
+	partitions, err := du.List(context.Background(), nil)
+	assert.NoError(t, err, "should be able to list")
+	assert.Equal(t, []string{"disk1"}, partitions.AllDisks, "should get partitions from the decoder")
+
+	snapshots, err := du.ListSnapshots(context.Background(), "disk1s1")
+	assert.NoError(t, err, "should be able to list snapshots")
+	assert.Equal(t, []types.Snapshot{{SnapshotUUID: "uuid"}}, snapshots, "should get snapshots from the decoder")
+
+	assert.Equal(t, []string{"info disk1", "list", "snapshots disk1s1"}, dec.raw, "should decode raw output with the given decoder")
+}
+
+func TestForProductWithDecoder_WithLatest(t *testing.T) {
+	product := &system.Product{Release: system.Latest, Version: *semver.MustParse("26.0.0")}
+
+	du, err := ForProductWithDecoder(product, &fakeDecoder{})
+	assert.NoError(t, err, "should be able to configure diskutil for a future release")
+
+	_, ok := du.(*diskutilSonoma)
+	assert.True(t, ok, "should configure diskutil for the newest supported release")
+}
+
+func TestForProductWithDecoder_WithoutDecoder(t *testing.T) {
+	product := &system.Product{Release: system.Monterey, Version: *semver.MustParse("12.6.0")}
+
+	_, err := ForProductWithDecoder(product, nil)
+
+	assert.Error(t, err, "shouldn't be able to configure diskutil without a decoder")
+}
+
+func TestForProduct(t *testing.T) {
+	product := &system.Product{Release: system.Sonoma, Version: *semver.MustParse("14.4.0")}
+
+	du, err := ForProduct(product)
+	assert.NoError(t, err, "should be able to configure diskutil")
+
+	sonoma, ok := du.(*diskutilSonoma)
+	if !assert.True(t, ok, "should configure diskutil for Sonoma") {
+		return
+	}
+	assert.IsType(t, &PlistDecoder{}, sonoma.dec, "should decode plists by default")
+	assert.Equal(t, &DiskUtilityCmd{Format: OutputPlist}, sonoma.embeddedDiskutil, "should request plists by default")
+}
+
+func TestForProduct_WithJSONRelease(t *testing.T) {
+	jsonReleases[system.Sonoma] = true
+	defer delete(jsonReleases, system.Sonoma)
+	product := &system.Product{Release: system.Sonoma, Version: *semver.MustParse("14.4.0")}
+
+	du, err := ForProduct(product)
+	assert.NoError(t, err, "should be able to configure diskutil")
+
+	sonoma, ok := du.(*diskutilSonoma)
+	if !assert.True(t, ok, "should configure diskutil for Sonoma") {
+		return
+	}
+	assert.IsType(t, &JSONDecoder{}, sonoma.dec, "should decode JSON for the release")
+	assert.Equal(t, &DiskUtilityCmd{Format: OutputJSON}, sonoma.embeddedDiskutil, "should request JSON for the release")
+}
+
+func TestDryrun_Unmount(t *testing.T) {
+	fake := NewFakeUtil(nil, nil)
+
+	_, err := Dryrun(fake).Unmount(context.Background(), "disk2s1", false)
+
+	assert.True(t, errors.Is(err, ErrReadOnly), "should skip unmounting in a dry run")
+	assert.Empty(t, fake.Calls(), "shouldn't unmount the volume")
+}
+
+func TestDryrun_Mount(t *testing.T) {
+	fake := NewFakeUtil(nil, nil)
+	du := Dryrun(fake)
+
+	_, err := du.Mount(context.Background(), "disk2s1")
+
+	assert.True(t, errors.Is(err, ErrReadOnly), "should skip mounting in a dry run")
+	assert.Empty(t, fake.Calls(), "shouldn't mount the volume")
+	assert.Equal(t, [][]string{mountCommand("disk2s1")}, du.PlannedCommands(), "should record the skipped mount")
+}
+
+func TestDryrun_PlannedCommands(t *testing.T) {
+	fake := NewFakeUtil(nil, nil)
+	du := Dryrun(fake)
+
+	du.Unmount(context.Background(), "/dev/disk2s1", true)
+	du.RenameVolume(context.Background(), "disk5s1", "Data")
+	du.UnlockVolume(context.Background(), "disk5s1", "passphrase")
+	planned := du.PlannedCommands()
+
+	assert.Equal(t, [][]string{
+		unmountCommand("/dev/disk2s1", true),
+		renameVolumeCommand("disk5s1", "Data"),
+		{"diskutil", "apfs", "unlockVolume", "disk5s1", "-stdinpassphrase"},
+	}, planned, "should record the skipped commands in order without the passphrase")
+
+	planned[0] = nil
+	assert.NotNil(t, du.PlannedCommands()[0], "should provide a copy of the planned commands")
+	assert.Empty(t, fake.Calls(), "shouldn't run any of the commands")
+}
+
+func TestDryrun_Verify(t *testing.T) {
+	fake := NewFakeUtil(nil, nil)
+	du := Dryrun(fake)
+
+	_, diskErr := du.VerifyDisk(context.Background(), "disk0")
+	_, volumeErr := du.VerifyVolume(context.Background(), "disk1")
+
+	assert.NoError(t, diskErr, "should verify the disk in a dry run")
+	assert.NoError(t, volumeErr, "should verify the volume in a dry run")
+	assert.Equal(t, []FakeCall{
+		{Method: "VerifyDisk", Args: []string{"disk0"}},
+		{Method: "VerifyVolume", Args: []string{"disk1"}},
+	}, fake.Calls(), "should verify since verifying doesn't change the disk")
+}
+
+func TestDryrun_DeleteSnapshot(t *testing.T) {
+	fake := NewFakeUtil(nil, nil)
+
+	_, err := Dryrun(fake).DeleteSnapshot(context.Background(), "disk1s1", "4B2E0C19-6E4F-4A43-9C63-5D2C0B7A5F01")
+
+	assert.True(t, errors.Is(err, ErrReadOnly), "should skip deleting the snapshot in a dry run")
+	assert.Empty(t, fake.Calls(), "shouldn't delete the snapshot")
+}
+
+func TestDryrun_EraseVolume(t *testing.T) {
+	fake := NewFakeUtil(nil, nil)
+
+	_, err := Dryrun(fake).EraseVolume(context.Background(), "disk5s1", "APFS", "Scratch")
+
+	assert.True(t, errors.Is(err, ErrReadOnly), "should skip erasing the volume in a dry run")
+	assert.Empty(t, fake.Calls(), "shouldn't erase the volume")
+}
+
+func TestDryrun_RepairVolume(t *testing.T) {
+	fake := NewFakeUtil(nil, nil)
+
+	_, err := Dryrun(fake).RepairVolume(context.Background(), "disk2")
+
+	assert.True(t, errors.Is(err, ErrReadOnly), "should skip repairing the volume in a dry run")
+	assert.Empty(t, fake.Calls(), "shouldn't repair the volume")
+}
+
+func TestDryrun_RenameVolume(t *testing.T) {
+	fake := NewFakeUtil(nil, nil)
+
+	_, err := Dryrun(fake).RenameVolume(context.Background(), "disk5s1", "Data")
+
+	assert.True(t, errors.Is(err, ErrReadOnly), "should skip renaming the volume in a dry run")
+	assert.Empty(t, fake.Calls(), "shouldn't rename the volume")
+}
+
+func TestDryrun_AddVolume(t *testing.T) {
+	fake := NewFakeUtil(nil, nil)
+
+	_, err := Dryrun(fake).AddVolume(context.Background(), "disk5", "Data", "APFS")
+
+	assert.True(t, errors.Is(err, ErrReadOnly), "should skip adding the volume in a dry run")
+	assert.Empty(t, fake.Calls(), "shouldn't add the volume")
+}
+
+func TestDryrun_UnlockVolume(t *testing.T) {
+	fake := NewFakeUtil(nil, nil)
+
+	_, err := Dryrun(fake).UnlockVolume(context.Background(), "disk5s1", "passphrase")
+
+	assert.True(t, errors.Is(err, ErrReadOnly), "should skip unlocking the volume in a dry run")
+	assert.Empty(t, fake.Calls(), "shouldn't unlock the volume")
+}
@@ -0,0 +1,169 @@
+// Package diskutiltest provides helpers for tests which mock diskutil.DiskUtil, so the common sequences of diskutil
+// calls (e.g. growing a container) don't need to be spelled out with gomock in every test. It's only meant to be
+// imported by tests.
+package diskutiltest
+
+import (
+	"bytes"
+	_ "embed"
+	"io"
+	"os"
+	"testing"
+
+	mock_diskutil "github.com/aws/ec2-macos-utils/internal/diskutil/mocks"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/golang/mock/gomock"
+	"howett.net/plist"
+)
+
+var (
+	//go:embed testdata/grow/list.plist
+	// growList contains the disks and partitions of disk1, which has 2,000,000 bytes of free space to grow into.
+	growList []byte
+
+	//go:embed testdata/grow/info.plist
+	// growInfo contains the information of disk1, an APFS container on a physical disk.
+	growInfo []byte
+)
+
+// GrowFixture decodes the disks and partitions and the disk information of a container (disk1) which can be grown.
+// The test fails if they can't be decoded.
+func GrowFixture(t testing.TB) (*types.SystemPartitions, *types.DiskInfo) {
+	t.Helper()
+
+	parts := &types.SystemPartitions{}
+	decode(t, "grow list", bytes.NewReader(growList), parts)
+	disk := &types.DiskInfo{}
+	decode(t, "grow info", bytes.NewReader(growInfo), disk)
+
+	return parts, disk
+}
+
+// Scenario builds the ordered expectations of a mock DiskUtil. Each method adds the calls for a step and returns the
+// Scenario so steps can be chained, then Build orders the calls and provides the mock:
+//
+//	mock := diskutiltest.NewScenario(ctrl).Grow("disk1", parts, disk).Build()
+//
+// The context of each call isn't matched.
+type Scenario struct {
+	mock  *mock_diskutil.MockDiskUtil
+	calls []*gomock.Call
+}
+
+// NewScenario creates a new Scenario for a mock DiskUtil controlled by ctrl.
+func NewScenario(ctrl *gomock.Controller) *Scenario {
+	return &Scenario{mock: mock_diskutil.NewMockDiskUtil(ctrl)}
+}
+
+// List expects the disks and partitions to be listed, providing parts.
+func (s *Scenario) List(parts *types.SystemPartitions) *Scenario {
+	return s.add(s.mock.EXPECT().List(gomock.Any(), nil).Return(parts, nil))
+}
+
+// ListErr expects the disks and partitions to be listed, failing with err.
+func (s *Scenario) ListErr(err error) *Scenario {
+	return s.add(s.mock.EXPECT().List(gomock.Any(), nil).Return(nil, err))
+}
+
+// Info expects the information of the disk with the identifier to be fetched, providing disk.
+func (s *Scenario) Info(id string, disk *types.DiskInfo) *Scenario {
+	return s.add(s.mock.EXPECT().Info(gomock.Any(), id).Return(disk, nil))
+}
+
+// InfoErr expects the information of the disk with the identifier to be fetched, failing with err.
+func (s *Scenario) InfoErr(id string, err error) *Scenario {
+	return s.add(s.mock.EXPECT().Info(gomock.Any(), id).Return(nil, err))
+}
+
+// Repair expects the disk with the identifier to be repaired successfully.
+func (s *Scenario) Repair(id string) *Scenario {
+	return s.add(s.mock.EXPECT().RepairDisk(gomock.Any(), id).Return("", nil))
+}
+
+// Resize expects the container with the identifier to be resized to the size (e.g. "0" for its maximum size) without
+// any options, providing diskutil's output.
+func (s *Scenario) Resize(id, size, output string) *Scenario {
+	return s.add(s.mock.EXPECT().ResizeContainer(gomock.Any(), id, size).Return(output, nil))
+}
+
+// GrowContainer expects the container with the identifier to be grown to its maximum size the way
+// diskutil.GrowContainer does for a physical container: the container's disk is repaired and listed again before the
+// container is resized.
+func (s *Scenario) GrowContainer(id string, parts *types.SystemPartitions, disk *types.DiskInfo) *Scenario {
+	return s.Repair(disk.ParentWholeDisk).
+		List(parts).
+		Resize(id, "0", "")
+}
+
+// Grow expects the container with the identifier to be grown to its maximum size the way the grow command does: the
+// disks are listed and the container's information is fetched, the container is grown (see GrowContainer), then the
+// disks are listed and the information is fetched again for the new size. The same parts and disk are provided before
+// and after the resize.
+func (s *Scenario) Grow(id string, parts *types.SystemPartitions, disk *types.DiskInfo) *Scenario {
+	return s.List(parts).
+		Info(id, disk).
+		GrowContainer(id, parts, disk).
+		List(parts).
+		Info(id, disk)
+}
+
+// Build orders the expected calls and provides the mock DiskUtil.
+func (s *Scenario) Build() *mock_diskutil.MockDiskUtil {
+	if len(s.calls) > 1 {
+		gomock.InOrder(s.calls...)
+	}
+
+	return s.mock
+}
+
+// add appends the call to the scenario.
+func (s *Scenario) add(call *gomock.Call) *Scenario {
+	s.calls = append(s.calls, call)
+
+	return s
+}
+
+// SystemPartitionsFile decodes the raw plist data of all disks and partitions (e.g. a capture of 'diskutil list
+// -plist') from the file at the path. The test fails if it can't be decoded.
+func SystemPartitionsFile(t testing.TB, path string) *types.SystemPartitions {
+	t.Helper()
+
+	parts := &types.SystemPartitions{}
+	decodeFile(t, path, parts)
+
+	return parts
+}
+
+// DiskInfoFile decodes the raw plist data of disk information (e.g. a capture of 'diskutil info -plist') from the
+// file at the path. The test fails if it can't be decoded.
+func DiskInfoFile(t testing.TB, path string) *types.DiskInfo {
+	t.Helper()
+
+	disk := &types.DiskInfo{}
+	decodeFile(t, path, disk)
+
+	return disk
+}
+
+// decodeFile decodes the plist file at the path into v, failing the test if it can't be.
+func decodeFile(t testing.TB, path string, v interface{}) {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unable to open fixture: %v", err)
+	}
+	defer f.Close()
+
+	decode(t, path, f, v)
+}
+
+// decode decodes the named plist fixture from the reader into v, failing the test if it can't be.
+func decode(t testing.TB, name string, r io.ReadSeeker, v interface{}) {
+	t.Helper()
+
+	if err := plist.NewDecoder(r).Decode(v); err != nil {
+		t.Fatalf("unable to decode fixture %s: %v", name, err)
+	}
+}
@@ -0,0 +1,68 @@
+package diskutiltest
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrowFixture(t *testing.T) {
+	parts, disk := GrowFixture(t)
+
+	assert.Equal(t, []string{"disk1"}, parts.AllDisks, "should decode the listed disks")
+	if assert.Len(t, parts.AllDisksAndPartitions, 1, "should decode the disk's partitions") {
+		assert.Equal(t, uint64(3_000_000), parts.AllDisksAndPartitions[0].Size, "should decode the disk's size")
+		assert.Len(t, parts.AllDisksAndPartitions[0].Partitions, 2, "should decode the partitions")
+	}
+	assert.Equal(t, "disk1", disk.DeviceIdentifier, "should decode the container's identifier")
+	assert.Equal(t, "disk1", disk.ParentWholeDisk, "should decode the container's disk")
+	assert.Equal(t, "apfs", disk.FilesystemType, "should decode the container's information")
+	assert.Equal(t, []types.APFSPhysicalStore{{DeviceIdentifier: "disk1"}}, disk.APFSPhysicalStores, "should decode the physical stores")
+}
+
+func TestFiles(t *testing.T) {
+	parts := SystemPartitionsFile(t, filepath.Join("testdata", "grow", "list.plist"))
+	disk := DiskInfoFile(t, filepath.Join("testdata", "grow", "info.plist"))
+
+	wantParts, wantDisk := GrowFixture(t)
+	assert.Equal(t, wantParts, parts, "should decode the list from the file")
+	assert.Equal(t, wantDisk, disk, "should decode the disk information from the file")
+}
+
+func TestScenario_GrowContainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts, disk := GrowFixture(t)
+	mock := NewScenario(ctrl).GrowContainer("disk1", parts, disk).Build()
+
+	// The calls are made by the grow itself, so the scenario must match its order
+	res, err := diskutil.GrowContainerWithResult(context.Background(), mock, disk, diskutil.GrowOptions{})
+
+	assert.NoError(t, err, "should grow the container")
+	assert.Equal(t, "disk1", res.DeviceID, "should grow the fixture's container")
+	assert.Equal(t, uint64(2_000_000), res.FreeSpace, "should find the fixture's free space")
+	assert.True(t, res.Resized, "should resize the container")
+}
+
+func TestScenario_WithErrors(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	listErr, infoErr := errors.New("list error"), errors.New("info error")
+	mock := NewScenario(ctrl).ListErr(listErr).InfoErr("disk1", infoErr).Build()
+
+	_, gotListErr := mock.List(ctx, nil)
+	_, gotInfoErr := mock.Info(ctx, "disk1")
+
+	assert.Equal(t, listErr, gotListErr, "should fail to list")
+	assert.Equal(t, infoErr, gotInfoErr, "should fail to fetch the information")
+}
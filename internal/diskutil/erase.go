@@ -0,0 +1,113 @@
+package diskutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil/identifier"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RootDiskError defines an error to distinguish when a destructive operation targets a volume on the disk holding
+// the running system's root filesystem.
+type RootDiskError struct {
+	deviceID string
+	diskID   string
+}
+
+func (e RootDiskError) Error() string {
+	return fmt.Sprintf("refusing to erase %s since it's on %s which holds the root filesystem", e.deviceID, e.diskID)
+}
+
+// DeviceID returns the device identifier of the volume which was refused.
+func (e RootDiskError) DeviceID() string {
+	return e.deviceID
+}
+
+// EraseVolume erases the volume with the given device identifier, reformatting it with the given format and name, by
+// performing the following operations:
+//  1. Fetch the types.DiskInfo for the volume and for the root filesystem (via the mount point "/").
+//  2. Verify that the volume isn't on the same whole disk as the root filesystem.
+//  3. Erase the volume with DiskUtil.EraseVolume.
+//
+// A RootDiskError is returned if the volume is on the root filesystem's disk. In a dry run (see Dryrun) the erase is
+// skipped and the wrapped ErrReadOnly is returned.
+func EraseVolume(ctx context.Context, u DiskUtil, id, format, name string) error {
+	if strings.TrimSpace(id) == "" {
+		return errors.New("empty volume identifier")
+	}
+	if strings.TrimSpace(format) == "" {
+		return errors.New("empty volume format")
+	}
+	if strings.TrimSpace(name) == "" {
+		return errors.New("empty volume name")
+	}
+
+	volume, err := u.Info(ctx, id)
+	if err != nil {
+		return fmt.Errorf("cannot fetch volume information: %w", err)
+	}
+
+	root, err := u.Info(ctx, "/")
+	if err != nil {
+		return fmt.Errorf("cannot determine root filesystem's disk: %w", err)
+	}
+
+	logrus.WithField("device_id", volume.DeviceIdentifier).Info("Checking that volume isn't on the root disk...")
+	if err := checkNotRootDisk(volume, root); err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"device_id": volume.DeviceIdentifier,
+		"format":    format,
+		"name":      name,
+	}).Info("Erasing volume...")
+	out, err := u.EraseVolume(ctx, volume.DeviceIdentifier, format, name)
+	logrus.WithField("out", out).Debug("EraseVolume output")
+	if err != nil {
+		return fmt.Errorf("unable to erase volume: %w", err)
+	}
+
+	return nil
+}
+
+// checkNotRootDisk checks that the volume doesn't share a whole disk with the root filesystem. Both the parent whole
+// disk and, for APFS, the whole disk of the physical store are compared since the root's parent whole disk is the
+// synthesized container rather than the physical disk. A RootDiskError is returned if they're shared.
+func checkNotRootDisk(volume, root *types.DiskInfo) error {
+	rootDisks := wholeDisks(root)
+	for _, disk := range wholeDisks(volume) {
+		for _, rootDisk := range rootDisks {
+			if disk == rootDisk {
+				return RootDiskError{deviceID: volume.DeviceIdentifier, diskID: disk}
+			}
+		}
+	}
+
+	return nil
+}
+
+// wholeDisks provides the identifiers of the whole disks backing the disk: its parent whole disk and, for APFS, the
+// whole disk of its physical store. The disk's own identifier is used if it has no parent whole disk.
+func wholeDisks(disk *types.DiskInfo) []string {
+	var disks []string
+
+	parent := disk.ParentWholeDisk
+	if parent == "" {
+		parent = disk.DeviceIdentifier
+	}
+	if id := identifier.ParseDiskID(parent); id != "" {
+		disks = append(disks, id)
+	}
+
+	if id, err := disk.ParentDeviceID(); err == nil {
+		disks = append(disks, id)
+	}
+
+	return disks
+}
@@ -0,0 +1,118 @@
+package diskutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// eraseFixture creates a FakeUtil with the root filesystem on the container disk3 (backed by disk0) and a scratch
+// volume on the container disk5 (backed by disk4).
+func eraseFixture() *FakeUtil {
+	return NewFakeUtil(nil, map[string]*types.DiskInfo{
+		"/": {
+			APFSPhysicalStores: []types.APFSPhysicalStore{{DeviceIdentifier: "disk0s2"}},
+			DeviceIdentifier:   "disk3s1s1",
+			MountPoint:         "/",
+			ParentWholeDisk:    "disk3",
+		},
+		"disk3s5": {
+			APFSPhysicalStores: []types.APFSPhysicalStore{{DeviceIdentifier: "disk0s2"}},
+			DeviceIdentifier:   "disk3s5",
+			ParentWholeDisk:    "disk3",
+		},
+		"disk0s2": {
+			DeviceIdentifier: "disk0s2",
+			ParentWholeDisk:  "disk0",
+		},
+		"disk5s1": {
+			APFSPhysicalStores: []types.APFSPhysicalStore{{DeviceIdentifier: "disk4s2"}},
+			DeviceIdentifier:   "disk5s1",
+			ParentWholeDisk:    "disk5",
+		},
+	})
+}
+
+// erased reports whether the fake was asked to erase a volume.
+func erased(fake *FakeUtil) bool {
+	for _, call := range fake.Calls() {
+		if call.Method == "EraseVolume" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestEraseVolume(t *testing.T) {
+	fake := eraseFixture()
+
+	err := EraseVolume(context.Background(), fake, "disk5s1", "APFS", "Scratch")
+
+	assert.NoError(t, err, "should erase a volume off the root disk")
+	assert.Contains(t, fake.Calls(), FakeCall{Method: "EraseVolume", Args: []string{"disk5s1", "APFS", "Scratch"}},
+		"should erase the volume with the format and name")
+}
+
+func TestEraseVolume_OnRootDisk(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		wantDisk string
+	}{
+		{name: "root volume", id: "/", wantDisk: "disk3"},
+		{name: "volume in root container", id: "disk3s5", wantDisk: "disk3"},
+		{name: "root physical store", id: "disk0s2", wantDisk: "disk0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := eraseFixture()
+
+			err := EraseVolume(context.Background(), fake, tt.id, "APFS", "Scratch")
+
+			var rootErr RootDiskError
+			if assert.True(t, errors.As(err, &rootErr), "should refuse to erase a volume on the root disk") {
+				assert.Equal(t, tt.wantDisk, rootErr.diskID, "should name the shared disk")
+			}
+			assert.False(t, erased(fake), "shouldn't erase the volume")
+		})
+	}
+}
+
+func TestEraseVolume_WithDryrun(t *testing.T) {
+	fake := eraseFixture()
+
+	err := EraseVolume(context.Background(), Dryrun(fake), "disk5s1", "APFS", "Scratch")
+
+	assert.True(t, errors.Is(err, ErrReadOnly), "should skip erasing in a dry run")
+	assert.False(t, erased(fake), "shouldn't erase the volume")
+}
+
+func TestEraseVolume_WithInfoErr(t *testing.T) {
+	fake := eraseFixture()
+
+	err := EraseVolume(context.Background(), fake, "disk9s1", "APFS", "Scratch")
+
+	assert.Error(t, err, "should fail without the volume's information")
+	assert.False(t, erased(fake), "shouldn't erase the volume")
+}
+
+func TestEraseVolume_WithoutName(t *testing.T) {
+	fake := eraseFixture()
+
+	err := EraseVolume(context.Background(), fake, "disk5s1", "APFS", " ")
+
+	assert.Error(t, err, "should require a name")
+	assert.Empty(t, fake.Calls(), "shouldn't call diskutil")
+}
+
+func TestRootDiskError_Error(t *testing.T) {
+	err := RootDiskError{deviceID: "disk3s5", diskID: "disk3"}
+
+	assert.Equal(t, "refusing to erase disk3s5 since it's on disk3 which holds the root filesystem", err.Error())
+	assert.Equal(t, "disk3s5", err.DeviceID())
+}
@@ -0,0 +1,275 @@
+package diskutil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+)
+
+// FakeCall records a single call made to a FakeUtil.
+type FakeCall struct {
+	// Method is the name of the DiskUtil method that was called (e.g. "RepairDisk").
+	Method string
+	// Args are the string arguments the method was called with (e.g. the device identifier).
+	Args []string
+}
+
+// FakeUtil is an in-memory DiskUtil seeded with fixed partitions and disk information so that consumers can exercise
+// the diskutil flows (e.g. GrowContainer) without running diskutil or wiring mocks. Every call is recorded. The
+// mutating methods succeed without output unless their behavior is replaced.
+type FakeUtil struct {
+	// UnmountFunc, if set, replaces the behavior of Unmount.
+	UnmountFunc func(ctx context.Context, id string, force bool) (string, error)
+	// RepairDiskFunc, if set, replaces the behavior of RepairDisk.
+	RepairDiskFunc func(ctx context.Context, id string) (string, error)
+	// RepairVolumeFunc, if set, replaces the behavior of RepairVolume.
+	RepairVolumeFunc func(ctx context.Context, id string) (string, error)
+	// VerifyDiskFunc, if set, replaces the behavior of VerifyDisk.
+	VerifyDiskFunc func(ctx context.Context, id string) (string, error)
+	// VerifyVolumeFunc, if set, replaces the behavior of VerifyVolume.
+	VerifyVolumeFunc func(ctx context.Context, id string) (string, error)
+	// EraseVolumeFunc, if set, replaces the behavior of EraseVolume.
+	EraseVolumeFunc func(ctx context.Context, id string, format string, name string) (string, error)
+	// RenameVolumeFunc, if set, replaces the behavior of RenameVolume.
+	RenameVolumeFunc func(ctx context.Context, id string, name string) (string, error)
+	// ResizeContainerFunc, if set, replaces the behavior of ResizeContainer.
+	ResizeContainerFunc func(ctx context.Context, id string, size string, opts ...types.ResizeOption) (string, error)
+	// ResizeLimitsFunc, if set, replaces the behavior of ResizeLimits which otherwise reports that the limits are
+	// unavailable.
+	ResizeLimitsFunc func(ctx context.Context, id string) (*types.ResizeLimits, error)
+	// ListSnapshotsFunc, if set, replaces the behavior of ListSnapshots which otherwise lists no snapshots.
+	ListSnapshotsFunc func(ctx context.Context, volumeID string) ([]types.Snapshot, error)
+	// DeleteSnapshotFunc, if set, replaces the behavior of DeleteSnapshot.
+	DeleteSnapshotFunc func(ctx context.Context, volumeID string, uuid string) (string, error)
+	// AddVolumeFunc, if set, replaces the behavior of AddVolume.
+	AddVolumeFunc func(ctx context.Context, containerID string, name string, format string) (string, error)
+	// UnlockVolumeFunc, if set, replaces the behavior of UnlockVolume.
+	UnlockVolumeFunc func(ctx context.Context, id string, passphrase string) (string, error)
+
+	mu         sync.Mutex
+	calls      []FakeCall
+	partitions *types.SystemPartitions
+	disks      map[string]*types.DiskInfo
+}
+
+// NewFakeUtil creates a new FakeUtil which lists the partitions and provides the information of the disks, keyed by
+// their device identifier (or mount point).
+func NewFakeUtil(partitions *types.SystemPartitions, disks map[string]*types.DiskInfo) *FakeUtil {
+	return &FakeUtil{
+		partitions: partitions,
+		disks:      disks,
+	}
+}
+
+// Calls returns a copy of the calls made to the FakeUtil in the order they were made.
+func (f *FakeUtil) Calls() []FakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	calls := make([]FakeCall, len(f.calls))
+	copy(calls, f.calls)
+
+	return calls
+}
+
+// record records a call to the method with the given arguments.
+func (f *FakeUtil) record(method string, args ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, FakeCall{Method: method, Args: args})
+}
+
+// Info provides the seeded information for the disk with the given identifier. An error is returned if no disk was
+// seeded with the identifier.
+func (f *FakeUtil) Info(ctx context.Context, id string) (*types.DiskInfo, error) {
+	f.record("Info", id)
+
+	for key, disk := range f.disks {
+		if strings.EqualFold(key, id) {
+			return disk, nil
+		}
+	}
+
+	return nil, fmt.Errorf("fake: no disk information for %s", id)
+}
+
+// List provides the seeded partitions. An error is returned if no partitions were seeded.
+func (f *FakeUtil) List(ctx context.Context, args []string) (*types.SystemPartitions, error) {
+	f.record("List", args...)
+
+	if f.partitions == nil {
+		return nil, fmt.Errorf("fake: no partitions")
+	}
+
+	return f.partitions, nil
+}
+
+// Mount provides the mount point of the seeded disk information for the given identifier. An error is returned if no
+// disk was seeded with the identifier.
+func (f *FakeUtil) Mount(ctx context.Context, id string) (string, error) {
+	f.record("Mount", id)
+
+	for key, disk := range f.disks {
+		if strings.EqualFold(key, id) {
+			return disk.MountPoint, nil
+		}
+	}
+
+	return "", fmt.Errorf("fake: no disk information for %s", id)
+}
+
+// Unmount calls UnmountFunc if it's set. Otherwise, the unmount succeeds without output.
+func (f *FakeUtil) Unmount(ctx context.Context, id string, force bool) (string, error) {
+	f.record("Unmount", id, fmt.Sprint(force))
+
+	if f.UnmountFunc != nil {
+		return f.UnmountFunc(ctx, id, force)
+	}
+
+	return "", nil
+}
+
+// RepairDisk calls RepairDiskFunc if it's set. Otherwise, the repair succeeds without output.
+func (f *FakeUtil) RepairDisk(ctx context.Context, id string) (string, error) {
+	f.record("RepairDisk", id)
+
+	if f.RepairDiskFunc != nil {
+		return f.RepairDiskFunc(ctx, id)
+	}
+
+	return "", nil
+}
+
+// RepairVolume calls RepairVolumeFunc if it's set. Otherwise, the repair succeeds without output.
+func (f *FakeUtil) RepairVolume(ctx context.Context, id string) (string, error) {
+	f.record("RepairVolume", id)
+
+	if f.RepairVolumeFunc != nil {
+		return f.RepairVolumeFunc(ctx, id)
+	}
+
+	return "", nil
+}
+
+// VerifyDisk calls VerifyDiskFunc if it's set. Otherwise, the verification passes without output.
+func (f *FakeUtil) VerifyDisk(ctx context.Context, id string) (string, error) {
+	f.record("VerifyDisk", id)
+
+	if f.VerifyDiskFunc != nil {
+		return f.VerifyDiskFunc(ctx, id)
+	}
+
+	return "", nil
+}
+
+// VerifyVolume calls VerifyVolumeFunc if it's set. Otherwise, the verification passes without output.
+func (f *FakeUtil) VerifyVolume(ctx context.Context, id string) (string, error) {
+	f.record("VerifyVolume", id)
+
+	if f.VerifyVolumeFunc != nil {
+		return f.VerifyVolumeFunc(ctx, id)
+	}
+
+	return "", nil
+}
+
+// EraseVolume calls EraseVolumeFunc if it's set. Otherwise, the erase succeeds without output.
+func (f *FakeUtil) EraseVolume(ctx context.Context, id string, format string, name string) (string, error) {
+	f.record("EraseVolume", id, format, name)
+
+	if f.EraseVolumeFunc != nil {
+		return f.EraseVolumeFunc(ctx, id, format, name)
+	}
+
+	return "", nil
+}
+
+// RenameVolume calls RenameVolumeFunc if it's set. Otherwise, the rename succeeds without output.
+func (f *FakeUtil) RenameVolume(ctx context.Context, id string, name string) (string, error) {
+	f.record("RenameVolume", id, name)
+
+	if f.RenameVolumeFunc != nil {
+		return f.RenameVolumeFunc(ctx, id, name)
+	}
+
+	return "", nil
+}
+
+// ResizeContainer calls ResizeContainerFunc if it's set. Otherwise, the resize succeeds without output. Any options
+// are recorded after the size.
+func (f *FakeUtil) ResizeContainer(ctx context.Context, id string, size string, opts ...types.ResizeOption) (string, error) {
+	args := []string{id, size}
+	for _, opt := range opts {
+		args = append(args, string(opt))
+	}
+	f.record("ResizeContainer", args...)
+
+	if f.ResizeContainerFunc != nil {
+		return f.ResizeContainerFunc(ctx, id, size, opts...)
+	}
+
+	return "", nil
+}
+
+// ResizeLimits calls ResizeLimitsFunc if it's set. Otherwise, an error is returned since the limits are unavailable.
+func (f *FakeUtil) ResizeLimits(ctx context.Context, id string) (*types.ResizeLimits, error) {
+	f.record("ResizeLimits", id)
+
+	if f.ResizeLimitsFunc != nil {
+		return f.ResizeLimitsFunc(ctx, id)
+	}
+
+	return nil, fmt.Errorf("fake: no resize limits for %s", id)
+}
+
+// ListSnapshots calls ListSnapshotsFunc if it's set. Otherwise, no snapshots are listed.
+func (f *FakeUtil) ListSnapshots(ctx context.Context, volumeID string) ([]types.Snapshot, error) {
+	f.record("ListSnapshots", volumeID)
+
+	if f.ListSnapshotsFunc != nil {
+		return f.ListSnapshotsFunc(ctx, volumeID)
+	}
+
+	return nil, nil
+}
+
+// DeleteSnapshot calls DeleteSnapshotFunc if it's set. Otherwise, the deletion succeeds without output.
+func (f *FakeUtil) DeleteSnapshot(ctx context.Context, volumeID string, uuid string) (string, error) {
+	f.record("DeleteSnapshot", volumeID, uuid)
+
+	if f.DeleteSnapshotFunc != nil {
+		return f.DeleteSnapshotFunc(ctx, volumeID, uuid)
+	}
+
+	return "", nil
+}
+
+// AddVolume calls AddVolumeFunc if it's set. Otherwise, the volume is added without output.
+func (f *FakeUtil) AddVolume(ctx context.Context, containerID string, name string, format string) (string, error) {
+	f.record("AddVolume", containerID, name, format)
+
+	if f.AddVolumeFunc != nil {
+		return f.AddVolumeFunc(ctx, containerID, name, format)
+	}
+
+	return "", nil
+}
+
+// UnlockVolume calls UnlockVolumeFunc if it's set. Otherwise, the unlock succeeds without output. The passphrase isn't
+// recorded.
+func (f *FakeUtil) UnlockVolume(ctx context.Context, id string, passphrase string) (string, error) {
+	f.record("UnlockVolume", id)
+
+	if f.UnlockVolumeFunc != nil {
+		return f.UnlockVolumeFunc(ctx, id, passphrase)
+	}
+
+	return "", nil
+}
+
+// Type assertion to ensure FakeUtil implements the DiskUtil interface.
+var _ DiskUtil = (*FakeUtil)(nil)
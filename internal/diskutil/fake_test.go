@@ -0,0 +1,234 @@
+package diskutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeGrowFixture returns the partitions and disk information for an APFS container on disk1 with free space.
+func fakeGrowFixture() (*types.SystemPartitions, *types.DiskInfo) {
+	const (
+		testDiskID        = "disk1"
+		diskSize   uint64 = 3_000_000
+		partSize   uint64 = 500_000
+	)
+
+	parts := &types.SystemPartitions{
+		AllDisks: []string{testDiskID},
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             diskSize,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
+
+	disk := &types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		VirtualOrPhysical: "Physical",
+	}
+
+	return parts, disk
+}
+
+func TestFakeUtil_GrowContainer(t *testing.T) {
+	parts, disk := fakeGrowFixture()
+	fake := NewFakeUtil(parts, map[string]*types.DiskInfo{"disk1": disk})
+
+	err := GrowContainer(context.Background(), fake, disk, GrowOptions{})
+
+	expectedCalls := []FakeCall{
+		{Method: "RepairDisk", Args: []string{"disk1"}},
+		{Method: "List"},
+		{Method: "ResizeContainer", Args: []string{"disk1", "0"}},
+	}
+
+	assert.NoError(t, err, "should be able to grow container with the fake")
+	assert.Equal(t, expectedCalls, fake.Calls(), "should record each call made by the grow")
+}
+
+func TestFakeUtil_GrowContainerWithResizeOptions(t *testing.T) {
+	parts, disk := fakeGrowFixture()
+	fake := NewFakeUtil(parts, map[string]*types.DiskInfo{"disk1": disk})
+	opts := GrowOptions{ResizeOptions: []types.ResizeOption{types.ResizeNoEFIUpdate, types.ResizeDontAutoMount}}
+
+	err := GrowContainer(context.Background(), fake, disk, opts)
+
+	assert.NoError(t, err, "should be able to grow container with the fake")
+	assert.Contains(t, fake.Calls(), FakeCall{Method: "ResizeContainer", Args: []string{"disk1", "0", "-noEFIUpdate", "-dontAutoMount"}},
+		"should pass the resize options in order")
+}
+
+func TestFakeUtil_GrowContainerWithResizeErr(t *testing.T) {
+	parts, disk := fakeGrowFixture()
+	fake := NewFakeUtil(parts, map[string]*types.DiskInfo{"disk1": disk})
+	fake.ResizeContainerFunc = func(ctx context.Context, id string, size string, opts ...types.ResizeOption) (string, error) {
+		return "", errors.New("error")
+	}
+
+	err := GrowContainer(context.Background(), fake, disk, GrowOptions{})
+
+	assert.Error(t, err, "should fail with the configured resize error")
+}
+
+func TestFakeUtil_GrowContainerWithRepairErr(t *testing.T) {
+	parts, disk := fakeGrowFixture()
+	fake := NewFakeUtil(parts, map[string]*types.DiskInfo{"disk1": disk})
+	fake.RepairDiskFunc = func(ctx context.Context, id string) (string, error) {
+		return "", errors.New("error")
+	}
+
+	err := GrowContainer(context.Background(), fake, disk, GrowOptions{})
+
+	assert.Error(t, err, "should fail with the configured repair error")
+	assert.Len(t, fake.Calls(), 1, "shouldn't continue after the repair fails")
+}
+
+func TestFakeUtil_Info(t *testing.T) {
+	_, disk := fakeGrowFixture()
+	fake := NewFakeUtil(nil, map[string]*types.DiskInfo{"disk1": disk})
+
+	got, err := fake.Info(context.Background(), "DISK1")
+	assert.NoError(t, err, "should find the seeded disk")
+	assert.Equal(t, disk, got, "should provide the seeded disk")
+
+	_, err = fake.Info(context.Background(), "disk2")
+	assert.Error(t, err, "shouldn't find a disk that wasn't seeded")
+
+	_, err = fake.List(context.Background(), nil)
+	assert.Error(t, err, "shouldn't list without seeded partitions")
+}
+
+func TestFakeUtil_GrowContainerWithTargetSize(t *testing.T) {
+	parts, disk := fakeGrowFixture()
+	fake := NewFakeUtil(parts, map[string]*types.DiskInfo{"disk1": disk})
+
+	err := GrowContainer(context.Background(), fake, disk, GrowOptions{TargetSize: 1_500_000})
+
+	expectedCalls := []FakeCall{
+		{Method: "RepairDisk", Args: []string{"disk1"}},
+		{Method: "List"},
+		{Method: "ResizeContainer", Args: []string{"disk1", "1500000B"}},
+	}
+
+	assert.NoError(t, err, "should be able to grow container to the target size")
+	assert.Equal(t, expectedCalls, fake.Calls(), "should resize to the target size")
+}
+
+func TestFakeUtil_GrowContainerWithTargetSizeOverFreeSpace(t *testing.T) {
+	parts, disk := fakeGrowFixture()
+	fake := NewFakeUtil(parts, map[string]*types.DiskInfo{"disk1": disk})
+
+	err := GrowContainer(context.Background(), fake, disk, GrowOptions{TargetSize: 2_500_000})
+
+	var freeSpaceErr FreeSpaceError
+	assert.Error(t, err, "shouldn't grow past the free space")
+	assert.True(t, errors.As(err, &freeSpaceErr), "should fail with a FreeSpaceError")
+	assert.Len(t, fake.Calls(), 2, "shouldn't resize the container")
+}
+
+func TestFakeUtil_GrowContainerWithResult(t *testing.T) {
+	parts, disk := fakeGrowFixture()
+	disk.TotalSize = 500_000
+	fake := NewFakeUtil(parts, map[string]*types.DiskInfo{"disk1": disk})
+
+	result, err := GrowContainerWithResult(context.Background(), fake, disk, GrowOptions{})
+
+	assert.NoError(t, err, "should be able to grow container with the fake")
+	assert.Equal(t, GrowResult{DeviceID: "disk1", FreeSpace: 2_000_000, PreviousSize: 500_000, Resized: true}, result, "should describe the resize")
+}
+
+func TestFakeUtil_GrowContainerWithResultDryrun(t *testing.T) {
+	parts, disk := fakeGrowFixture()
+	disk.TotalSize = 500_000
+	fake := NewFakeUtil(parts, map[string]*types.DiskInfo{"disk1": disk})
+
+	result, err := GrowContainerWithResult(context.Background(), Dryrun(fake), disk, GrowOptions{})
+
+	assert.NoError(t, err, "should be able to dry run the grow with the fake")
+	assert.Equal(t, GrowResult{DeviceID: "disk1", FreeSpace: 2_000_000, PreviousSize: 500_000, Size: 2_500_000}, result, "should describe the predicted resize")
+}
+
+func TestFakeUtil_GrowContainerWithResultDryrunPlannedCommands(t *testing.T) {
+	parts, disk := fakeGrowFixture()
+	fake := NewFakeUtil(parts, map[string]*types.DiskInfo{"disk1": disk})
+	du := Dryrun(fake)
+
+	_, err := GrowContainerWithResult(context.Background(), du, disk, GrowOptions{ResizeOptions: []types.ResizeOption{types.ResizeNoEFIUpdate}})
+
+	assert.NoError(t, err, "should be able to dry run the grow with the fake")
+	assert.Equal(t, [][]string{
+		{"diskutil", "repairDisk", "disk1"},
+		{"diskutil", "apfs", "resizeContainer", "disk1", "0", "-noEFIUpdate"},
+	}, du.PlannedCommands(), "should record the repair and resize commands which were skipped")
+	for _, call := range fake.Calls() {
+		assert.NotContains(t, []string{"RepairDisk", "ResizeContainer"}, call.Method, "shouldn't run mutating commands")
+	}
+}
+
+func TestFakeUtil_GrowContainerWithFreeSpaceSource(t *testing.T) {
+	const infoFree uint64 = 0
+
+	tests := []struct {
+		name        string
+		source      FreeSpaceSource
+		wantResized bool
+	}{
+		// The partitions have free space (see fakeGrowFixture) while diskutil's info reports none
+		{name: "default", source: "", wantResized: true},
+		{name: "partitions", source: FreeSpaceFromPartitions, wantResized: true},
+		{name: "info", source: FreeSpaceFromInfo, wantResized: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, disk := fakeGrowFixture()
+			disk.FreeSpace = infoFree
+			fake := NewFakeUtil(parts, map[string]*types.DiskInfo{"disk1": disk})
+
+			result, err := GrowContainerWithResult(context.Background(), fake, disk, GrowOptions{FreeSpaceSource: tt.source})
+
+			assert.Equal(t, tt.wantResized, result.Resized, "should decide the grow from the selected source")
+			if tt.wantResized {
+				assert.NoError(t, err)
+				return
+			}
+			var freeSpaceErr FreeSpaceError
+			assert.True(t, errors.As(err, &freeSpaceErr), "should fail without free space from the selected source")
+		})
+	}
+}
+
+func TestFakeUtil_GrowContainerWithInfoFreeSpace(t *testing.T) {
+	parts, disk := fakeGrowFixture()
+	// The partitions have no free space while diskutil's info reports some
+	parts.AllDisksAndPartitions[0].Size = 1_000_000
+	disk.FreeSpace = 2_000_000
+	fake := NewFakeUtil(parts, map[string]*types.DiskInfo{"disk1": disk})
+
+	err := GrowContainer(context.Background(), fake, disk, GrowOptions{FreeSpaceSource: FreeSpaceFromInfo})
+
+	expectedCalls := []FakeCall{
+		{Method: "RepairDisk", Args: []string{"disk1"}},
+		{Method: "Info", Args: []string{"disk1"}},
+		{Method: "ResizeContainer", Args: []string{"disk1", "0"}},
+	}
+
+	assert.NoError(t, err, "should grow with the free space from diskutil's info")
+	assert.Equal(t, expectedCalls, fake.Calls(), "should fetch the free space from diskutil's info")
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
 
@@ -11,20 +14,189 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// GrowContainer grows a container to its maximum size by performing the following operations:
-//  1. Verify that the given types.DiskInfo is an APFS container that can be resized.
+// repairRetryDelay is the delay before the first retry of a failed repair. The delay is doubled for each further retry.
+var repairRetryDelay = time.Second
+
+// FreeSpaceSource identifies where the amount of free space used to decide how to grow a container comes from. The
+// sources can diverge (e.g. when the kernel hasn't picked up a resized disk).
+type FreeSpaceSource string
+
+const (
+	// FreeSpaceFromPartitions calculates the free space as the disk's size less the sizes of its partitions (see
+	// types.SystemPartitions.AvailableDiskSpace). It's the default source.
+	FreeSpaceFromPartitions FreeSpaceSource = "partitions"
+	// FreeSpaceFromInfo uses the free space diskutil reports in the disk's information (see types.DiskInfo).
+	FreeSpaceFromInfo FreeSpaceSource = "info"
+)
+
+// ParseFreeSpaceSource parses the name of a FreeSpaceSource (e.g. "partitions"). An empty name is the default source,
+// FreeSpaceFromPartitions.
+func ParseFreeSpaceSource(name string) (FreeSpaceSource, error) {
+	switch source := FreeSpaceSource(strings.ToLower(name)); source {
+	case "":
+		return FreeSpaceFromPartitions, nil
+	case FreeSpaceFromPartitions, FreeSpaceFromInfo:
+		return source, nil
+	default:
+		return "", fmt.Errorf("unknown free space source %q, must be %q or %q", name, FreeSpaceFromPartitions, FreeSpaceFromInfo)
+	}
+}
+
+// RepairStrategy selects what is repaired before growing a container.
+type RepairStrategy string
+
+const (
+	// RepairParentDisk repairs the container's parent whole disk with DiskUtil.RepairDisk, which forces the kernel to
+	// get the latest GPT information (e.g. after an EBS volume was resized). It's the default strategy.
+	RepairParentDisk RepairStrategy = "disk"
+	// RepairContainerVolume only repairs the container with DiskUtil.RepairVolume. It's faster, but the GPT isn't
+	// refreshed so it only suits disks whose new size is already visible (e.g. after a reboot).
+	RepairContainerVolume RepairStrategy = "volume"
+)
+
+// ParseRepairStrategy parses the name of a RepairStrategy (e.g. "disk"). An empty name is the default strategy,
+// RepairParentDisk.
+func ParseRepairStrategy(name string) (RepairStrategy, error) {
+	switch strategy := RepairStrategy(strings.ToLower(name)); strategy {
+	case "":
+		return RepairParentDisk, nil
+	case RepairParentDisk, RepairContainerVolume:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown repair strategy %q, must be %q or %q", name, RepairParentDisk, RepairContainerVolume)
+	}
+}
+
+// GrowOptions configures how GrowContainer resizes a container.
+type GrowOptions struct {
+	// ID identifies the container to grow with Grow: a device identifier (e.g. disk1), a device node (e.g.
+	// /dev/disk1), a mount point, or "root" (or "/") for the OS's root volume. GrowContainer doesn't use it since the
+	// container is provided.
+	ID string
+	// DryRun skips the mutating changes made by Grow (see Dryrun).
+	DryRun bool
+	// Timeout limits how long Grow can take. A Timeout of 0 disables the limit.
+	Timeout time.Duration
+	// MaxGrowBytes caps the number of bytes a container can grow by in a single operation. When the projected growth
+	// exceeds the cap, the container is resized to its current size plus the cap instead of its maximum size. A
+	// MaxGrowBytes of 0 disables the cap.
+	MaxGrowBytes uint64
+	// TargetSize is the absolute size (in bytes) to grow the container to instead of its maximum size. The target can't
+	// exceed the container's current size plus the free space on its disk. A TargetSize of 0 grows to the maximum size.
+	TargetSize uint64
+	// MinFreeSpace is the minimum amount of free space (in bytes) required to grow the container. A nil MinFreeSpace
+	// uses DefaultMinFreeSpace and a MinFreeSpace of 0 disables the check.
+	MinFreeSpace *uint64
+	// FreeSpaceSource selects where the free space deciding the grow comes from. The zero value uses
+	// FreeSpaceFromPartitions.
+	FreeSpaceSource FreeSpaceSource
+	// RepairRetries is the number of times a failed repair of the parent disk is retried with exponential backoff
+	// (e.g. when the kernel hasn't picked up the resized disk's GPT yet). A RepairRetries of 0 doesn't retry.
+	RepairRetries uint
+	// RepairStrategy selects what is repaired before growing. The zero value uses RepairParentDisk.
+	RepairStrategy RepairStrategy
+	// ForceInternal allows containers on the internal disk (see internalDiskID) to be resized.
+	ForceInternal bool
+	// Force attempts the resize even when the container doesn't look like it can be APFS resized (see
+	// canAPFSResize), e.g. when its ContainerInfo didn't decode fully.
+	Force bool
+	// ResizeOptions are the trailing flags passed to DiskUtil.ResizeContainer, in order.
+	ResizeOptions []types.ResizeOption
+}
+
+// minFreeSpace provides the minimum amount of free space (in bytes) required to grow, defaulting to
+// DefaultMinFreeSpace.
+func (o GrowOptions) minFreeSpace() uint64 {
+	if o.MinFreeSpace == nil {
+		return DefaultMinFreeSpace
+	}
+
+	return *o.MinFreeSpace
+}
+
+// GrowResult describes the resize made (or, when skipped in dry-run, the resize which would have been made) by
+// GrowContainerWithResult.
+type GrowResult struct {
+	// DeviceID is the identifier of the device which was (or would have been) resized. This is the container's
+	// physical disk when the container isn't a physical device.
+	DeviceID string
+	// FreeSpace is the amount of free space (in bytes) found on the disk before resizing.
+	FreeSpace uint64
+	// PreviousSize is the size (in bytes) of the container before it was grown.
+	PreviousSize uint64
+	// Size is the size (in bytes) of the container after it was grown as reported by diskutil or, when the resize was
+	// skipped in dry-run, the predicted size. A Size of 0 is unknown.
+	Size uint64
+	// Resized is true when the container was resized.
+	Resized bool
+	// Output is diskutil's output from resizing the container.
+	Output string
+}
+
+// GrowContainer grows a container to its maximum size. See GrowContainerWithResult for the operations performed.
+func GrowContainer(ctx context.Context, u DiskUtil, container *types.DiskInfo, opts GrowOptions) error {
+	_, err := GrowContainerWithResult(ctx, u, container, opts)
+
+	return err
+}
+
+// Grow grows the container identified by GrowOptions.ID for programs which don't use the command line. The container's
+// information is fetched (see RootContainerInfo for the root volume) and it's grown with GrowContainerWithResult within
+// GrowOptions.Timeout, skipping mutating changes when GrowOptions.DryRun is set. The result is returned even when the
+// grow fails part way (e.g. without enough free space, see FreeSpaceError).
+func Grow(ctx context.Context, u DiskUtil, opts GrowOptions) (*GrowResult, error) {
+	if strings.TrimSpace(opts.ID) == "" {
+		return nil, errors.New("empty container identifier")
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	if opts.DryRun {
+		u = Dryrun(u)
+	}
+
+	var container *types.DiskInfo
+	var err error
+	if strings.EqualFold(opts.ID, "root") || opts.ID == "/" {
+		container, err = RootContainerInfo(ctx, u)
+	} else {
+		container, err = u.Info(ctx, opts.ID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch container information: %w", err)
+	}
+
+	result, err := GrowContainerWithResult(ctx, u, container, opts)
+
+	return &result, err
+}
+
+// GrowContainerWithResult grows a container to its maximum size by performing the following operations:
+//  1. Verify that the given types.DiskInfo is an unlocked APFS container that can be resized (see GrowOptions.Force).
 //  2. Fetch the types.DiskInfo for the underlying physical disk (if the container isn't a physical device).
-//  3. Repair the parent disk to force the kernel to get the latest GPT information for the disk.
+//  3. Repair the parent disk to force the kernel to get the latest GPT information for the disk (or only the
+//     container, see GrowOptions.RepairStrategy).
 //  4. Check if there's enough free space on the disk to perform an APFS.ResizeContainer.
-//  5. Resize the container to its maximum size.
-func GrowContainer(ctx context.Context, u DiskUtil, container *types.DiskInfo) error {
+//  5. Check that the requested target size (if any, see GrowOptions.TargetSize) fits in the free space.
+//  6. Resize the container to its maximum size (or the target or capped size, see GrowOptions).
+//
+// The GrowResult describes the resize, which is only made when no error is returned. The fields known before an error
+// (e.g. the free space found when there isn't enough of it) are still set.
+func GrowContainerWithResult(ctx context.Context, u DiskUtil, container *types.DiskInfo, opts GrowOptions) (GrowResult, error) {
 	if container == nil {
-		return fmt.Errorf("unable to resize nil container")
+		return GrowResult{}, fmt.Errorf("unable to resize nil container")
 	}
+	result := GrowResult{PreviousSize: container.TotalSize}
 
 	logrus.WithField("device_id", container.DeviceIdentifier).Info("Checking if device can be APFS resized...")
-	if err := canAPFSResize(container); err != nil {
-		return fmt.Errorf("unable to resize container: %w", err)
+	if err := checkAPFSResize(container, opts.Force); err != nil {
+		return result, fmt.Errorf("unable to resize container: %w", err)
+	}
+	if err := checkUnlocked(container); err != nil {
+		return result, fmt.Errorf("unable to resize container: %w", err)
 	}
 	logrus.Info("Device can be resized")
 
@@ -35,50 +207,232 @@ func GrowContainer(ctx context.Context, u DiskUtil, container *types.DiskInfo) e
 	if !phy.IsPhysical() {
 		parent, err := u.Info(ctx, phy.ParentWholeDisk)
 		if err != nil {
-			return fmt.Errorf("unable to determine physical disk: %w", err)
+			return result, fmt.Errorf("unable to determine physical disk: %w", err)
 		}
 		// using the parent disk of provided disk (probably a container)
 		phy = parent
 	}
+	result.DeviceID = phy.DeviceIdentifier
+
+	// Refuse to mutate the internal boot media unless explicitly allowed. Errors resolving the parent disk are left to
+	// be reported by the repair.
+	if parentDiskID, err := phy.ParentDeviceID(); err == nil {
+		if err := checkInternalDisk(parentDiskID, opts.ForceInternal); err != nil {
+			return result, fmt.Errorf("unable to resize container: %w", err)
+		}
+	}
 
 	// Capture any free space on a resized disk
-	logrus.Info("Repairing the parent disk...")
-	_, err := repairParentDisk(ctx, u, phy)
-	if err != nil {
-		return fmt.Errorf("cannot update free space on disk: %w", err)
+	if opts.RepairStrategy == RepairContainerVolume {
+		logrus.Info("Repairing the container...")
+		_, err := repairContainer(ctx, u, container, opts.RepairRetries)
+		if err != nil {
+			return result, fmt.Errorf("cannot repair container: %w", err)
+		}
+		logrus.Info("Successfully repaired the container")
+	} else {
+		logrus.Info("Repairing the parent disk...")
+		_, err := repairParentDisk(ctx, u, phy, opts.RepairRetries)
+		if err != nil {
+			return result, fmt.Errorf("cannot update free space on disk: %w", err)
+		}
+		logrus.Info("Successfully repaired the parent disk")
 	}
-	logrus.Info("Successfully repaired the parent disk")
 
 	// Minimum free space to resize required - bail if we don't have enough.
 	logrus.WithField("device_id", phy.DeviceIdentifier).Info("Fetching amount of free space on device...")
-	totalFree, err := getDiskFreeSpace(ctx, u, phy)
+	totalFree, diskSize, err := getDiskSpace(ctx, u, phy, opts.FreeSpaceSource)
 	if err != nil {
-		return fmt.Errorf("cannot determine available space on disk: %w", err)
+		return result, fmt.Errorf("cannot determine available space on disk: %w", err)
 	}
 	logrus.WithField("freed_bytes", humanize.Bytes(totalFree)).Trace("updated free space on disk")
-	if totalFree < minimumGrowFreeSpace {
+	result.FreeSpace = totalFree
+	if err := checkFreeSpace(totalFree, opts.minFreeSpace()); err != nil {
 		logrus.WithFields(logrus.Fields{
 			"total_free":       humanize.Bytes(totalFree),
-			"required_minimum": humanize.Bytes(minimumGrowFreeSpace),
+			"free_pct":         freePercent(totalFree, diskSize),
+			"required_minimum": humanize.Bytes(opts.minFreeSpace()),
 		}).Warn("Available free space does not meet required minimum to grow")
-		return fmt.Errorf("not enough space to resize container: %w", FreeSpaceError{totalFree})
+		return result, fmt.Errorf("not enough space to resize container: %w", err)
+	}
+	if err := checkTargetSize(container, totalFree, opts.TargetSize); err != nil {
+		return result, fmt.Errorf("unable to resize container: %w", err)
+	}
+
+	// Grow to the maximum size unless a target size was requested or the projected growth exceeds the configured cap, in
+	// which case the container is grown to an absolute target instead.
+	target := growTarget(container, totalFree, opts)
+	if target != 0 && opts.TargetSize == 0 {
+		logrus.WithFields(logrus.Fields{
+			"projected_growth": humanize.Bytes(totalFree),
+			"max_grow":         humanize.Bytes(opts.MaxGrowBytes),
+		}).Info("Projected growth exceeds maximum allowed growth, capping resize target")
 	}
 
 	logrus.WithFields(logrus.Fields{
-		"device_id":  phy.DeviceIdentifier,
-		"free_space": humanize.Bytes(totalFree),
-	}).Info("Resizing container to maximum size...")
-	out, err := u.ResizeContainer(ctx, phy.DeviceIdentifier, "0")
+		"device_id":   phy.DeviceIdentifier,
+		"free_space":  humanize.Bytes(totalFree),
+		"free_pct":    freePercent(totalFree, diskSize),
+		"target_size": describeResizeTarget(target),
+	}).Info("Resizing container...")
+	out, err := u.ResizeContainer(ctx, phy.DeviceIdentifier, resizeTarget(target), opts.ResizeOptions...)
 	logrus.WithField("out", out).Debug("Resize output")
+	result.Output = out
 	if errors.Is(err, ErrReadOnly) {
-		logrus.WithError(err).Warn("Would have resized container to max size")
+		size, source := predictResizeSize(ctx, u, phy.DeviceIdentifier, container, totalFree, target)
+		logrus.WithError(err).WithField("source", source).Warnf("Would have resized container to %s", humanize.Bytes(size))
+		result.Size = size
 	} else if err != nil {
-		return err
+		return result, err
+	} else if resized := ParseResizeOutput(out); resized.NoOp {
+		logrus.WithField("size", humanize.Bytes(resized.Size)).Info("Container already at maximum size")
+		result.Size = resized.Size
+	} else {
+		result.Resized = true
+		result.Size = resized.Size
+		if resized.Size != 0 {
+			logrus.WithField("new_size", humanize.Bytes(resized.Size)).Info("Container resized")
+		}
+	}
+
+	return result, nil
+}
+
+// IsInternalDisk checks if the given whole disk is the internal disk, which is refused unless
+// GrowOptions.ForceInternal is set.
+func IsInternalDisk(id string) bool {
+	return strings.EqualFold(id, internalDiskID)
+}
+
+// checkInternalDisk checks that the given whole disk isn't the internal disk unless forced. An InternalDiskError is
+// returned if it is.
+func checkInternalDisk(id string, force bool) error {
+	if !IsInternalDisk(id) {
+		return nil
+	}
+
+	if force {
+		logrus.WithField("device_id", id).Warn("Forcing mutating changes to the internal disk")
+		return nil
+	}
+
+	return InternalDiskError{deviceID: id}
+}
+
+// checkAPFSResize checks that the disk can be APFS resized (see canAPFSResize) unless forced.
+func checkAPFSResize(disk *types.DiskInfo, force bool) error {
+	err := canAPFSResize(disk)
+	if err != nil && force && disk != nil {
+		logrus.WithError(err).WithField("device_id", disk.DeviceIdentifier).
+			Warn("FORCING resize of a container which doesn't look like it can be APFS resized, diskutil may fail or misbehave")
+		return nil
+	}
+
+	return err
+}
+
+// checkFreeSpace checks that the amount of free space meets the minimum required to grow a container. A minimum of 0
+// disables the check. A FreeSpaceError is returned if there isn't enough free space.
+func checkFreeSpace(totalFree, minimum uint64) error {
+	if totalFree < minimum {
+		return FreeSpaceError{freeSpaceBytes: totalFree, minimumBytes: minimum}
 	}
 
 	return nil
 }
 
+// checkTargetSize checks that the requested target size (in bytes) grows the container and fits in the free space on
+// its disk. A target of 0 (the maximum size) always fits. A FreeSpaceError is wrapped if the target exceeds the space
+// available.
+func checkTargetSize(container *types.DiskInfo, totalFree, target uint64) error {
+	if target == 0 {
+		return nil
+	}
+
+	if target <= container.TotalSize {
+		return fmt.Errorf("target size %s must be larger than the current size %s",
+			humanize.Bytes(target), humanize.Bytes(container.TotalSize))
+	}
+
+	if available := container.TotalSize + totalFree; target > available {
+		return fmt.Errorf("target size %s exceeds the %s available: %w",
+			humanize.Bytes(target), humanize.Bytes(available), FreeSpaceError{freeSpaceBytes: totalFree})
+	}
+
+	return nil
+}
+
+// growTarget determines the absolute size (in bytes) the container should be resized to given the amount of free
+// space on its disk. A target of 0 indicates the container should be resized to its maximum size.
+func growTarget(container *types.DiskInfo, totalFree uint64, opts GrowOptions) uint64 {
+	if opts.TargetSize > 0 {
+		return opts.TargetSize
+	}
+
+	if opts.MaxGrowBytes > 0 && totalFree > opts.MaxGrowBytes {
+		return container.TotalSize + opts.MaxGrowBytes
+	}
+
+	return 0
+}
+
+// resizeTarget converts an absolute target size (in bytes) into the size argument expected by APFS.ResizeContainer.
+// A target of 0 is passed through as "0" which resizes the container to its maximum size.
+func resizeTarget(size uint64) string {
+	if size == 0 {
+		return "0"
+	}
+
+	return fmt.Sprintf("%dB", size)
+}
+
+const (
+	// predictionTarget is the source of predicted resize sizes which were requested as an absolute target.
+	predictionTarget = "target"
+	// predictionLimits is the source of predicted resize sizes which were reported by diskutil's resize limits.
+	predictionLimits = "diskutil limits"
+	// predictionProjection is the source of predicted resize sizes which were projected from the free space.
+	predictionProjection = "projection"
+)
+
+// predictResizeSize predicts the size (in bytes) the container would be resized to for a preview (e.g. a dry run).
+// Absolute targets are used as-is. Otherwise, the maximum size reported by diskutil's resize limits for the physical
+// store is preferred since that's exactly what diskutil would target. If the limits aren't available, the size is
+// projected from the container's current size and the disk's free space instead. The source of the prediction is
+// returned along with the size.
+func predictResizeSize(ctx context.Context, u DiskUtil, id string, container *types.DiskInfo, totalFree, target uint64) (uint64, string) {
+	if target != 0 {
+		return target, predictionTarget
+	}
+
+	limits, err := u.ResizeLimits(ctx, id)
+	if err != nil {
+		logrus.WithError(err).Debug("Unable to fetch resize limits, projecting the resized size instead")
+		limits = nil
+	}
+
+	return predictMaxSize(limits, container, totalFree)
+}
+
+// predictMaxSize predicts the maximum size (in bytes) the container can be resized to, preferring the limits reported
+// by diskutil when they're available over a projection from the free space.
+func predictMaxSize(limits *types.ResizeLimits, container *types.DiskInfo, totalFree uint64) (uint64, string) {
+	if limits != nil && limits.MaximumSize > 0 {
+		return limits.MaximumSize, predictionLimits
+	}
+
+	return container.TotalSize + totalFree, predictionProjection
+}
+
+// describeResizeTarget provides a human-readable description of the target size for logging.
+func describeResizeTarget(size uint64) string {
+	if size == 0 {
+		return "max size"
+	}
+
+	return humanize.Bytes(size)
+}
+
 // canAPFSResize does some basic checking on a types.DiskInfo to see if it matches the criteria necessary for
 // APFS.ResizeContainer to succeed. It checks that the types.ContainerInfo is not empty and that the
 // types.ContainerInfo's FilesystemType is "apfs".
@@ -102,40 +456,149 @@ func canAPFSResize(disk *types.DiskInfo) error {
 	return errors.New("disk is not apfs")
 }
 
+// checkUnlocked checks that the disk isn't locked (e.g. by FileVault) since locked containers can't be resized. A
+// LockedContainerError is returned if it is.
+func checkUnlocked(disk *types.DiskInfo) error {
+	if disk.Locked {
+		return LockedContainerError{deviceID: disk.DeviceIdentifier}
+	}
+
+	return nil
+}
+
 // getDiskFreeSpace calculates the amount of free space a disk has available by summing the sizes of each partition
-// and then subtracting that from the total size. See types.SystemPartitions for more information.
-func getDiskFreeSpace(ctx context.Context, util DiskUtil, disk *types.DiskInfo) (uint64, error) {
+// and then subtracting that from the total size. See types.SystemPartitions for more information. With
+// FreeSpaceFromInfo, the free space diskutil reports for the parent disk is used instead.
+func getDiskFreeSpace(ctx context.Context, util DiskUtil, disk *types.DiskInfo, source FreeSpaceSource) (uint64, error) {
+	free, _, err := getDiskSpace(ctx, util, disk, source)
+
+	return free, err
+}
+
+// getDiskSpace calculates the amount of free space like getDiskFreeSpace, along with the size of the parent disk the
+// free space is on (from the same source). The size is 0 when it isn't reported.
+func getDiskSpace(ctx context.Context, util DiskUtil, disk *types.DiskInfo, source FreeSpaceSource) (free uint64, size uint64, err error) {
+	if source == FreeSpaceFromInfo {
+		return getInfoSpace(ctx, util, disk)
+	}
+
+	// The list mustn't be filtered since filtering omits the partition information the free space is calculated from
 	partitions, err := util.List(ctx, nil)
 	if err != nil {
-		return 0, err
+		return 0, 0, err
 	}
 
 	parentDiskID, err := disk.ParentDeviceID()
 	if err != nil {
-		return 0, err
+		return 0, 0, err
+	}
+
+	free, err = partitions.AvailableDiskSpace(parentDiskID)
+	if err != nil {
+		return 0, 0, err
+	}
+	if parent, ok := partitions.FindDisk(parentDiskID); ok {
+		size = parent.Size
 	}
 
-	return partitions.AvailableDiskSpace(parentDiskID)
+	return free, size, nil
+}
+
+// getInfoSpace fetches the free space and size diskutil reports for the disk's parent. The information is fetched
+// again, rather than using the given disk's, so that it reflects any repair made since.
+func getInfoSpace(ctx context.Context, util DiskUtil, disk *types.DiskInfo) (free uint64, size uint64, err error) {
+	parentDiskID, err := disk.ParentDeviceID()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	parent, err := util.Info(ctx, parentDiskID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return parent.FreeSpace, parent.TotalSize, nil
+}
+
+// freePercent calculates the free space as a percentage of the disk's size, rounded to one decimal place. A size of 0
+// is unknown so the percentage is 0.
+func freePercent(free, size uint64) float64 {
+	if size == 0 {
+		return 0
+	}
+
+	return math.Round(float64(free)/float64(size)*1000) / 10
 }
 
 // repairParentDisk attempts to find and repair the parent device for the given disk in order to update the current
-// amount of free space available.
-func repairParentDisk(ctx context.Context, utility DiskUtil, disk *types.DiskInfo) (message string, err error) {
+// amount of free space available. A failed repair is retried up to the given number of times, doubling the delay
+// (starting at repairRetryDelay) between each attempt, unless the context is done first.
+func repairParentDisk(ctx context.Context, utility DiskUtil, disk *types.DiskInfo, retries uint) (message string, err error) {
 	// Get the device identifier for the parent disk
 	parentDiskID, err := disk.ParentDeviceID()
 	if err != nil {
 		return fmt.Sprintf("failed to get the parent disk ID for container [%s]", disk.DeviceIdentifier), err
 	}
 
-	// Attempt to repair the container's parent disk
-	logrus.WithField("parent_id", parentDiskID).Info("Repairing parent disk...")
-	out, err := utility.RepairDisk(ctx, parentDiskID)
-	logrus.WithField("out", out).Debug("RepairDisk output")
-	if errors.Is(err, ErrReadOnly) {
-		logrus.WithError(err).Warn("Would have repaired parent disk")
-	} else if err != nil {
+	return retryRepair(ctx, "parent disk", retries, func() (string, error) {
+		// Attempt to repair the container's parent disk
+		logrus.WithField("parent_id", parentDiskID).Info("Repairing parent disk...")
+		out, err := utility.RepairDisk(ctx, parentDiskID)
+		logrus.WithField("out", out).Debug("RepairDisk output")
+
+		return out, err
+	})
+}
+
+// repairContainer attempts to repair the given container with DiskUtil.RepairVolume. Unlike repairParentDisk, the
+// partition map isn't repaired. A failed repair is retried like repairParentDisk.
+func repairContainer(ctx context.Context, utility DiskUtil, container *types.DiskInfo, retries uint) (string, error) {
+	return retryRepair(ctx, "container", retries, func() (string, error) {
+		logrus.WithField("device_id", container.DeviceIdentifier).Info("Repairing container...")
+		out, err := utility.RepairVolume(ctx, container.DeviceIdentifier)
+		logrus.WithField("out", out).Debug("RepairVolume output")
+
 		return out, err
+	})
+}
+
+// retryRepair calls repair, retrying up to the given number of times when it fails, doubling the delay (starting at
+// repairRetryDelay) between each attempt, unless the context is done first. A repair skipped in a dry run (see
+// ErrReadOnly) succeeds. The target describes what is repaired (e.g. "parent disk").
+func retryRepair(ctx context.Context, target string, retries uint, repair func() (string, error)) (string, error) {
+	delay := repairRetryDelay
+	for attempt := uint(0); ; attempt++ {
+		out, err := repair()
+		switch {
+		case errors.Is(err, ErrReadOnly):
+			logrus.WithError(err).Warnf("Would have repaired %s", target)
+			return out, nil
+		case err == nil:
+			return out, nil
+		case attempt >= retries:
+			return out, err
+		}
+
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"attempt":  attempt + 1,
+			"retry_in": delay,
+		}).Warnf("Failed to repair %s, retrying...", target)
+		if ctxErr := sleepContext(ctx, delay); ctxErr != nil {
+			return out, fmt.Errorf("stopped retrying repair after error [%v]: %w", err, ctxErr)
+		}
+		delay *= 2
 	}
+}
+
+// sleepContext waits for the duration to elapse. The context's error is returned if it's done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
 
-	return out, nil
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
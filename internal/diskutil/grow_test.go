@@ -2,15 +2,19 @@ package diskutil
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"testing"
+	"time"
 
 	mock_diskutil "github.com/aws/ec2-macos-utils/internal/diskutil/mocks"
 	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
 
 	"github.com/golang/mock/gomock"
 	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -24,7 +28,7 @@ func TestGrowContainer_WithoutContainer(t *testing.T) {
 
 	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
 
-	err := GrowContainer(context.Background(), mockUtility, nil)
+	err := GrowContainer(context.Background(), mockUtility, nil, GrowOptions{})
 
 	assert.Error(t, err, "shouldn't be able to grow container with nil container")
 }
@@ -37,7 +41,7 @@ func TestGrowContainer_WithEmptyContainer(t *testing.T) {
 
 	disk := types.DiskInfo{}
 
-	err := GrowContainer(context.Background(), mockUtility, &disk)
+	err := GrowContainer(context.Background(), mockUtility, &disk, GrowOptions{})
 
 	assert.Error(t, err, "shouldn't be able to grow container with empty container")
 }
@@ -60,7 +64,7 @@ func TestGrowContainer_WithInfoErr(t *testing.T) {
 		VirtualOrPhysical: "Virtual",
 	}
 
-	err := GrowContainer(context.Background(), mockUtility, &disk)
+	err := GrowContainer(context.Background(), mockUtility, &disk, GrowOptions{})
 
 	assert.Error(t, err, "shouldn't be able to grow container with info error")
 }
@@ -86,7 +90,7 @@ func TestGrowContainer_WithRepairDiskErr(t *testing.T) {
 		VirtualOrPhysical: "Physical",
 	}
 
-	err := GrowContainer(context.Background(), mockUtility, &disk)
+	err := GrowContainer(context.Background(), mockUtility, &disk, GrowOptions{})
 
 	assert.Error(t, err, "shouldn't be able to grow container with repair disk error")
 }
@@ -115,7 +119,7 @@ func TestGrowContainer_WithListError(t *testing.T) {
 		VirtualOrPhysical: "Physical",
 	}
 
-	err := GrowContainer(context.Background(), mockUtility, &disk)
+	err := GrowContainer(context.Background(), mockUtility, &disk, GrowOptions{})
 
 	assert.Error(t, err, "shouldn't be able to grow container with list error")
 }
@@ -165,12 +169,14 @@ func TestGrowContainer_WithoutFreeSpace(t *testing.T) {
 		VirtualOrPhysical: "Physical",
 	}
 
-	expectedErr := fmt.Errorf("not enough space to resize container: %w", FreeSpaceError{expectedFreeSpace})
+	expectedErr := fmt.Errorf("not enough space to resize container: %w", FreeSpaceError{freeSpaceBytes: expectedFreeSpace, minimumBytes: DefaultMinFreeSpace})
 
-	actualErr := GrowContainer(context.Background(), mockUtility, &disk)
+	result, actualErr := GrowContainerWithResult(context.Background(), mockUtility, &disk, GrowOptions{})
 
 	assert.Error(t, actualErr, "shouldn't be able to grow container without free space")
 	assert.Equal(t, expectedErr, actualErr, "should get FreeSpaceError since there's no free space")
+	assert.Equal(t, uint64(expectedFreeSpace), result.FreeSpace, "should report the free space found")
+	assert.False(t, result.Resized, "shouldn't report a resize")
 }
 
 func TestGrowContainer_WithResizeContainerError(t *testing.T) {
@@ -218,7 +224,7 @@ func TestGrowContainer_WithResizeContainerError(t *testing.T) {
 		VirtualOrPhysical: "Physical",
 	}
 
-	err := GrowContainer(context.Background(), mockUtility, &disk)
+	err := GrowContainer(context.Background(), mockUtility, &disk, GrowOptions{})
 
 	assert.Error(t, err, "shouldn't be able to grow container with resize container error")
 }
@@ -253,7 +259,7 @@ func TestGrowContainer_Success(t *testing.T) {
 	gomock.InOrder(
 		mockUtility.EXPECT().RepairDisk(ctx, testDiskID).Return("", nil),
 		mockUtility.EXPECT().List(ctx, nil).Return(&parts, nil),
-		mockUtility.EXPECT().ResizeContainer(ctx, testDiskID, "0").Return("", nil),
+		mockUtility.EXPECT().ResizeContainer(ctx, testDiskID, "0").Return(resizeGrow, nil),
 	)
 
 	disk := types.DiskInfo{
@@ -265,156 +271,95 @@ func TestGrowContainer_Success(t *testing.T) {
 		},
 		DeviceIdentifier:  testDiskID,
 		ParentWholeDisk:   testDiskID,
+		TotalSize:         partSize,
 		VirtualOrPhysical: "Physical",
 	}
 
-	err := GrowContainer(context.Background(), mockUtility, &disk)
-
-	assert.NoError(t, err, "should be able to grow container")
-}
-
-func TestCanAPFSResize(t *testing.T) {
-	type args struct {
-		container *types.DiskInfo
-	}
-	tests := []struct {
-		name    string
-		args    args
-		wantErr bool
-	}{
-		{
-			name: "WithoutContainer",
-			args: args{
-				container: nil,
-			},
-			wantErr: true,
-		},
-		{
-			name: "WithEmptyContainer",
-			args: args{
-				container: &types.DiskInfo{
-					ContainerInfo: types.ContainerInfo{},
-				},
-			},
-			wantErr: true,
-		},
-		{
-			name: "WithoutAPFSFilesystem",
-			args: args{
-				container: &types.DiskInfo{
-					ContainerInfo: types.ContainerInfo{
-						FilesystemType: "not apfs",
-					},
-				},
-			},
-			wantErr: true,
-		},
-		{
-			name: "WithoutAPFSReference",
-			args: args{
-				container: &types.DiskInfo{},
-			},
-			wantErr: true,
-		},
-		{
-			name: "WithoutAPFSPhysicalStores",
-			args: args{
-				container: &types.DiskInfo{
-					APFSContainerReference: "disk1",
-				},
-			},
-			wantErr: true,
-		},
-		{
-			name: "SuccessAPFSFilesystem",
-			args: args{
-				container: &types.DiskInfo{
-					ContainerInfo: types.ContainerInfo{
-						FilesystemType: "apfs",
-					},
-				},
-			},
-			wantErr: false,
-		},
-		{
-			name: "SuccessAPFSContainer",
-			args: args{
-				container: &types.DiskInfo{
-					APFSContainerReference: "disk1",
-					APFSPhysicalStores: []types.APFSPhysicalStore{
-						{DeviceIdentifier: "disk0"},
-					},
-				},
-			},
-			wantErr: false,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := canAPFSResize(tt.args.container)
+	result, err := GrowContainerWithResult(context.Background(), mockUtility, &disk, GrowOptions{})
 
-			if tt.wantErr {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-			}
-		})
+	expected := GrowResult{
+		DeviceID:     testDiskID,
+		FreeSpace:    diskSize - 2*partSize,
+		PreviousSize: partSize,
+		Size:         121_122_037_760,
+		Resized:      true,
+		Output:       resizeGrow,
 	}
+	assert.NoError(t, err, "should be able to grow container")
+	assert.Equal(t, expected, result, "should describe the resize")
 }
 
-func TestGetDiskFreeSpace_WithListErr(t *testing.T) {
-	const expectedSize uint64 = 0
+func TestGrowContainer_AlreadyAtMaximum(t *testing.T) {
+	const (
+		testDiskID = "disk1"
+		// total disk size
+		diskSize uint64 = 3_000_000
+		// individual partition space occupied
+		partSize uint64 = 500_000
+	)
 	var ctx = context.Background()
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
-	mockUtility.EXPECT().List(ctx, nil).Return(nil, fmt.Errorf("error"))
-
-	disk := types.DiskInfo{}
-
-	actual, err := getDiskFreeSpace(context.Background(), mockUtility, &disk)
-
-	assert.Error(t, err, "shouldn't be able to get free space with list error")
-	assert.Equal(t, expectedSize, actual, "shouldn't get size due to list error")
-}
-
-func TestGetDiskFreeSpace_WithNilSystemPartitions(t *testing.T) {
-	const expectedSize uint64 = 0
-	var ctx = context.Background()
-
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
+	parts := types.SystemPartitions{
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             diskSize,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
 
 	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
-	mockUtility.EXPECT().List(ctx, nil).Return(nil, nil)
+	gomock.InOrder(
+		mockUtility.EXPECT().RepairDisk(ctx, testDiskID).Return("", nil),
+		mockUtility.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mockUtility.EXPECT().ResizeContainer(ctx, testDiskID, "0").Return(resizeAlreadyWholeDisk, nil),
+	)
 
-	disk := types.DiskInfo{}
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		VirtualOrPhysical: "Physical",
+	}
 
-	actual, err := getDiskFreeSpace(context.Background(), mockUtility, &disk)
+	hook := test.NewGlobal()
+	defer hook.Reset()
 
-	assert.Error(t, err, "shouldn't be able to get free space for nil partitions")
-	assert.Equal(t, expectedSize, actual, "shouldn't get size due to nil partitions")
+	err := GrowContainer(context.Background(), mockUtility, &disk, GrowOptions{})
+
+	assert.NoError(t, err, "should treat an already whole disk as success")
+	if assert.NotNil(t, hook.LastEntry(), "should log the resize outcome") {
+		assert.Equal(t, "Container already at maximum size", hook.LastEntry().Message, "should log that nothing was resized")
+	}
 }
 
-func TestGetDiskFreeSpace_WithoutFreeSpace(t *testing.T) {
+func TestGrowContainer_UnderMaxGrowBytes(t *testing.T) {
 	const (
 		testDiskID = "disk1"
 		// total disk size
-		diskSize uint64 = 1_000_000
+		diskSize uint64 = 3_000_000
 		// individual partition space occupied
 		partSize uint64 = 500_000
-		// should see: diskSize - (2 * partSize)
-		expectedFreeSpace uint64 = 0
+		// cap on growth which is larger than the free space (2_000_000)
+		maxGrowBytes uint64 = 2_500_000
 	)
 	var ctx = context.Background()
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
-
 	parts := types.SystemPartitions{
 		AllDisksAndPartitions: []types.DiskPart{
 			{
@@ -427,37 +372,49 @@ func TestGetDiskFreeSpace_WithoutFreeSpace(t *testing.T) {
 			},
 		},
 	}
-	mockUtility.EXPECT().List(ctx, nil).Return(&parts, nil)
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mockUtility.EXPECT().RepairDisk(ctx, testDiskID).Return("", nil),
+		mockUtility.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mockUtility.EXPECT().ResizeContainer(ctx, testDiskID, "0").Return("", nil),
+	)
 
 	disk := types.DiskInfo{
 		APFSPhysicalStores: []types.APFSPhysicalStore{
 			{DeviceIdentifier: testDiskID},
 		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		TotalSize:         partSize,
+		VirtualOrPhysical: "Physical",
 	}
 
-	actual, err := getDiskFreeSpace(context.Background(), mockUtility, &disk)
+	err := GrowContainer(context.Background(), mockUtility, &disk, GrowOptions{MaxGrowBytes: maxGrowBytes})
 
-	assert.NoError(t, err, "should be able to calculate free space with valid data")
-	assert.Equal(t, expectedFreeSpace, actual, "should have calculated free space based on partitions")
+	assert.NoError(t, err, "should be able to grow container to max size when under the cap")
 }
 
-func TestGetDiskFreeSpace_FreeSpace(t *testing.T) {
+func TestGrowContainer_OverMaxGrowBytes(t *testing.T) {
 	const (
 		testDiskID = "disk1"
 		// total disk size
-		diskSize uint64 = 2_000_000
+		diskSize uint64 = 3_000_000
 		// individual partition space occupied
 		partSize uint64 = 500_000
-		// should see: diskSize - (2 * partSize)
-		expectedFreeSpace uint64 = 1_000_000
+		// cap on growth which is smaller than the free space (2_000_000)
+		maxGrowBytes uint64 = 1_500_000
+		// should see: partSize + maxGrowBytes
+		expectedTarget = "2000000B"
 	)
 	var ctx = context.Background()
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
-
 	parts := types.SystemPartitions{
 		AllDisksAndPartitions: []types.DiskPart{
 			{
@@ -470,79 +427,1218 @@ func TestGetDiskFreeSpace_FreeSpace(t *testing.T) {
 			},
 		},
 	}
-	mockUtility.EXPECT().List(ctx, nil).Return(&parts, nil)
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mockUtility.EXPECT().RepairDisk(ctx, testDiskID).Return("", nil),
+		mockUtility.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mockUtility.EXPECT().ResizeContainer(ctx, testDiskID, expectedTarget).Return("", nil),
+	)
 
 	disk := types.DiskInfo{
 		APFSPhysicalStores: []types.APFSPhysicalStore{
 			{DeviceIdentifier: testDiskID},
 		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		TotalSize:         partSize,
+		VirtualOrPhysical: "Physical",
 	}
 
-	actual, err := getDiskFreeSpace(context.Background(), mockUtility, &disk)
+	err := GrowContainer(context.Background(), mockUtility, &disk, GrowOptions{MaxGrowBytes: maxGrowBytes})
 
-	assert.NoError(t, err, "should be able to calculate free space with valid data")
-	assert.Equal(t, expectedFreeSpace, actual, "should have calculated free space based on partitions")
-}
-
-func TestRepairParentDisk_WithoutDiskInfo(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
-
-	disk := types.DiskInfo{}
-	expectedMessage := fmt.Sprintf("failed to get the parent disk ID for container [%s]", disk.DeviceIdentifier)
-
-	actualMessage, err := repairParentDisk(context.Background(), mockUtility, &disk)
-
-	assert.Error(t, err, "shouldn't be able to repair disk without disk info")
-	assert.Equal(t, expectedMessage, actualMessage, "should see error message for device")
+	assert.NoError(t, err, "should be able to grow container to capped size when over the cap")
 }
 
-func TestRepairParentDisk_WithRepairDiskErr(t *testing.T) {
+func TestGrowContainer_WithInternalDisk(t *testing.T) {
 	const testDiskID = "disk0"
-	var ctx = context.Background()
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
-	mockUtility.EXPECT().RepairDisk(ctx, testDiskID).Return("error", fmt.Errorf("error"))
 
 	disk := types.DiskInfo{
 		APFSPhysicalStores: []types.APFSPhysicalStore{
 			{DeviceIdentifier: testDiskID},
 		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		VirtualOrPhysical: "Physical",
 	}
-	expectedMessage := "error"
 
-	actualMessage, err := repairParentDisk(context.Background(), mockUtility, &disk)
+	err := GrowContainer(context.Background(), mockUtility, &disk, GrowOptions{})
 
-	assert.Error(t, err, "shouldn't be able to repair parent disk with repair disk error")
-	assert.Equal(t, expectedMessage, actualMessage, "should see error message for device")
+	var internalErr InternalDiskError
+	assert.True(t, errors.As(err, &internalErr), "should refuse to grow container on the internal disk")
 }
 
-func TestRepairParentDisk_Success(t *testing.T) {
+func TestGrowContainer_WithForcedInternalDisk(t *testing.T) {
 	const (
-		testDiskID      = "disk0"
-		expectedMessage = ""
+		testDiskID = "disk0"
+		// total disk size
+		diskSize uint64 = 3_000_000
+		// individual partition space occupied
+		partSize uint64 = 500_000
 	)
 	var ctx = context.Background()
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
+	parts := types.SystemPartitions{
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             diskSize,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
+
 	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
-	mockUtility.EXPECT().RepairDisk(ctx, testDiskID).Return("", nil)
+	gomock.InOrder(
+		mockUtility.EXPECT().RepairDisk(ctx, testDiskID).Return("", nil),
+		mockUtility.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mockUtility.EXPECT().ResizeContainer(ctx, testDiskID, "0").Return("", nil),
+	)
 
 	disk := types.DiskInfo{
 		APFSPhysicalStores: []types.APFSPhysicalStore{
 			{DeviceIdentifier: testDiskID},
 		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		VirtualOrPhysical: "Physical",
 	}
 
-	actualMessage, err := repairParentDisk(context.Background(), mockUtility, &disk)
+	err := GrowContainer(ctx, mockUtility, &disk, GrowOptions{ForceInternal: true})
 
-	assert.NoError(t, err, "should be able to repair parent with valid data")
-	assert.Equal(t, expectedMessage, actualMessage, "should see expected message")
+	assert.NoError(t, err, "should be able to grow container on the internal disk when forced")
+}
+
+func TestGrowContainer_WithForce(t *testing.T) {
+	const (
+		testDiskID = "disk1"
+		// total disk size
+		diskSize uint64 = 3_000_000
+		// individual partition space occupied
+		partSize uint64 = 500_000
+	)
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts := types.SystemPartitions{
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             diskSize,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mockUtility.EXPECT().RepairDisk(ctx, testDiskID).Return("", nil),
+		mockUtility.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mockUtility.EXPECT().ResizeContainer(ctx, testDiskID, "0").Return("", nil),
+	)
+
+	// The container's ContainerInfo didn't decode, so canAPFSResize rejects it
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		VirtualOrPhysical: "Physical",
+	}
+	if !assert.Error(t, canAPFSResize(&disk), "fixture shouldn't look like it can be APFS resized") {
+		return
+	}
+
+	err := GrowContainer(ctx, mockUtility, &disk, GrowOptions{Force: true})
+
+	assert.NoError(t, err, "should attempt to grow container when forced")
+}
+
+func TestGrowContainer_WithRepairContainerVolume(t *testing.T) {
+	const (
+		testContainerID = "disk2"
+		testDiskID      = "disk1"
+		// total disk size
+		diskSize uint64 = 3_000_000
+		// individual partition space occupied
+		partSize uint64 = 500_000
+	)
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts := types.SystemPartitions{
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             diskSize,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
+
+	container := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID + "s2"},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testContainerID,
+		ParentWholeDisk:   testDiskID,
+		VirtualOrPhysical: "Virtual",
+	}
+	phy := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID + "s2"},
+		},
+		DeviceIdentifier:  testDiskID + "s2",
+		ParentWholeDisk:   testDiskID,
+		VirtualOrPhysical: "Physical",
+	}
+
+	// Only the container is repaired rather than its parent disk
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mockUtility.EXPECT().Info(ctx, testDiskID).Return(&phy, nil),
+		mockUtility.EXPECT().RepairVolume(ctx, testContainerID).Return("", nil),
+		mockUtility.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mockUtility.EXPECT().ResizeContainer(ctx, testDiskID+"s2", "0").Return("", nil),
+	)
+
+	err := GrowContainer(ctx, mockUtility, &container, GrowOptions{RepairStrategy: RepairContainerVolume})
+
+	assert.NoError(t, err, "should be able to grow container after repairing it")
+}
+
+func TestGrowContainer_WithoutForce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+
+	disk := types.DiskInfo{
+		DeviceIdentifier:  "disk1",
+		VirtualOrPhysical: "Physical",
+	}
+
+	err := GrowContainer(context.Background(), mockUtility, &disk, GrowOptions{})
+
+	assert.Error(t, err, "should refuse to grow container which can't be APFS resized")
+}
+
+func TestGrowContainer_WithLockedContainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+
+	disk, err := (&PlistDecoder{}).DecodeDiskInfo(strings.NewReader(decoderLockedContainerInfo))
+	if !assert.NoError(t, err, "should be able to decode locked container fixture") {
+		return
+	}
+
+	err = GrowContainer(context.Background(), mockUtility, disk, GrowOptions{})
+
+	var lockedErr LockedContainerError
+	assert.True(t, errors.As(err, &lockedErr), "should refuse to grow locked container before resizing")
+	assert.Contains(t, lockedErr.Error(), "unlock", "should suggest unlocking the container")
+}
+
+func TestCheckUnlocked(t *testing.T) {
+	tests := []struct {
+		name    string
+		disk    *types.DiskInfo
+		wantErr bool
+	}{
+		{
+			name: "unlocked container",
+			disk: &types.DiskInfo{
+				ContainerInfo: types.ContainerInfo{
+					FileVault: true,
+				},
+				DeviceIdentifier: "disk2",
+			},
+			wantErr: false,
+		},
+		{
+			name: "locked container",
+			disk: &types.DiskInfo{
+				ContainerInfo: types.ContainerInfo{
+					FileVault: true,
+					Locked:    true,
+				},
+				DeviceIdentifier: "disk2",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkUnlocked(tt.disk)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCanAPFSResize(t *testing.T) {
+	type args struct {
+		container *types.DiskInfo
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "WithoutContainer",
+			args: args{
+				container: nil,
+			},
+			wantErr: true,
+		},
+		{
+			name: "WithEmptyContainer",
+			args: args{
+				container: &types.DiskInfo{
+					ContainerInfo: types.ContainerInfo{},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "WithoutAPFSFilesystem",
+			args: args{
+				container: &types.DiskInfo{
+					ContainerInfo: types.ContainerInfo{
+						FilesystemType: "not apfs",
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "WithoutAPFSReference",
+			args: args{
+				container: &types.DiskInfo{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "WithoutAPFSPhysicalStores",
+			args: args{
+				container: &types.DiskInfo{
+					APFSContainerReference: "disk1",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "SuccessAPFSFilesystem",
+			args: args{
+				container: &types.DiskInfo{
+					ContainerInfo: types.ContainerInfo{
+						FilesystemType: "apfs",
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "SuccessAPFSContainer",
+			args: args{
+				container: &types.DiskInfo{
+					APFSContainerReference: "disk1",
+					APFSPhysicalStores: []types.APFSPhysicalStore{
+						{DeviceIdentifier: "disk0"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := canAPFSResize(tt.args.container)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGetDiskFreeSpace_WithListErr(t *testing.T) {
+	const expectedSize uint64 = 0
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	mockUtility.EXPECT().List(ctx, nil).Return(nil, fmt.Errorf("error"))
+
+	disk := types.DiskInfo{}
+
+	actual, err := getDiskFreeSpace(context.Background(), mockUtility, &disk, FreeSpaceFromPartitions)
+
+	assert.Error(t, err, "shouldn't be able to get free space with list error")
+	assert.Equal(t, expectedSize, actual, "shouldn't get size due to list error")
+}
+
+func TestGetDiskFreeSpace_WithNilSystemPartitions(t *testing.T) {
+	const expectedSize uint64 = 0
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	mockUtility.EXPECT().List(ctx, nil).Return(nil, nil)
+
+	disk := types.DiskInfo{}
+
+	actual, err := getDiskFreeSpace(context.Background(), mockUtility, &disk, FreeSpaceFromPartitions)
+
+	assert.Error(t, err, "shouldn't be able to get free space for nil partitions")
+	assert.Equal(t, expectedSize, actual, "shouldn't get size due to nil partitions")
+}
+
+func TestGetDiskFreeSpace_WithoutFreeSpace(t *testing.T) {
+	const (
+		testDiskID = "disk1"
+		// total disk size
+		diskSize uint64 = 1_000_000
+		// individual partition space occupied
+		partSize uint64 = 500_000
+		// should see: diskSize - (2 * partSize)
+		expectedFreeSpace uint64 = 0
+	)
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+
+	parts := types.SystemPartitions{
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             diskSize,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
+	mockUtility.EXPECT().List(ctx, nil).Return(&parts, nil)
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+	}
+
+	actual, err := getDiskFreeSpace(context.Background(), mockUtility, &disk, FreeSpaceFromPartitions)
+
+	assert.NoError(t, err, "should be able to calculate free space with valid data")
+	assert.Equal(t, expectedFreeSpace, actual, "should have calculated free space based on partitions")
+}
+
+func TestGetDiskFreeSpace_FreeSpace(t *testing.T) {
+	const (
+		testDiskID = "disk1"
+		// total disk size
+		diskSize uint64 = 2_000_000
+		// individual partition space occupied
+		partSize uint64 = 500_000
+		// should see: diskSize - (2 * partSize)
+		expectedFreeSpace uint64 = 1_000_000
+	)
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+
+	parts := types.SystemPartitions{
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             diskSize,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
+	mockUtility.EXPECT().List(ctx, nil).Return(&parts, nil)
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+	}
+
+	actual, err := getDiskFreeSpace(context.Background(), mockUtility, &disk, FreeSpaceFromPartitions)
+
+	assert.NoError(t, err, "should be able to calculate free space with valid data")
+	assert.Equal(t, expectedFreeSpace, actual, "should have calculated free space based on partitions")
+}
+
+func TestRepairParentDisk_WithoutDiskInfo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+
+	disk := types.DiskInfo{}
+	expectedMessage := fmt.Sprintf("failed to get the parent disk ID for container [%s]", disk.DeviceIdentifier)
+
+	actualMessage, err := repairParentDisk(context.Background(), mockUtility, &disk, 0)
+
+	assert.Error(t, err, "shouldn't be able to repair disk without disk info")
+	assert.Equal(t, expectedMessage, actualMessage, "should see error message for device")
+}
+
+func TestRepairParentDisk_WithRepairDiskErr(t *testing.T) {
+	const testDiskID = "disk0"
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	mockUtility.EXPECT().RepairDisk(ctx, testDiskID).Return("error", fmt.Errorf("error"))
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+	}
+	expectedMessage := "error"
+
+	actualMessage, err := repairParentDisk(context.Background(), mockUtility, &disk, 0)
+
+	assert.Error(t, err, "shouldn't be able to repair parent disk with repair disk error")
+	assert.Equal(t, expectedMessage, actualMessage, "should see error message for device")
+}
+
+func TestRepairParentDisk_Success(t *testing.T) {
+	const (
+		testDiskID      = "disk0"
+		expectedMessage = ""
+	)
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	mockUtility.EXPECT().RepairDisk(ctx, testDiskID).Return("", nil)
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+	}
+
+	actualMessage, err := repairParentDisk(context.Background(), mockUtility, &disk, 0)
+
+	assert.NoError(t, err, "should be able to repair parent with valid data")
+	assert.Equal(t, expectedMessage, actualMessage, "should see expected message")
+}
+
+func TestRepairParentDisk_WithRetries(t *testing.T) {
+	const testDiskID = "disk0"
+	var ctx = context.Background()
+
+	defer func(delay time.Duration) { repairRetryDelay = delay }(repairRetryDelay)
+	repairRetryDelay = time.Millisecond
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mockUtility.EXPECT().RepairDisk(ctx, testDiskID).Return("", fmt.Errorf("error")),
+		mockUtility.EXPECT().RepairDisk(ctx, testDiskID).Return("", fmt.Errorf("error")),
+		mockUtility.EXPECT().RepairDisk(ctx, testDiskID).Return("repaired", nil),
+	)
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+	}
+
+	actualMessage, err := repairParentDisk(ctx, mockUtility, &disk, 3)
+
+	assert.NoError(t, err, "should repair the parent disk once the transient failures pass")
+	assert.Equal(t, "repaired", actualMessage, "should see the message of the successful repair")
+}
+
+func TestRepairParentDisk_WithRetriesExhausted(t *testing.T) {
+	const testDiskID = "disk0"
+	var ctx = context.Background()
+
+	defer func(delay time.Duration) { repairRetryDelay = delay }(repairRetryDelay)
+	repairRetryDelay = time.Millisecond
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	mockUtility.EXPECT().RepairDisk(ctx, testDiskID).Return("", fmt.Errorf("error")).Times(3)
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+	}
+
+	_, err := repairParentDisk(ctx, mockUtility, &disk, 2)
+
+	assert.Error(t, err, "should fail once the retries are exhausted")
+}
+
+func TestRepairParentDisk_WithCancelledContext(t *testing.T) {
+	const testDiskID = "disk0"
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Cancel while the first repair fails so the retry isn't attempted
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	mockUtility.EXPECT().RepairDisk(ctx, testDiskID).DoAndReturn(func(context.Context, string) (string, error) {
+		cancel()
+		return "", fmt.Errorf("error")
+	})
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+	}
+
+	_, err := repairParentDisk(ctx, mockUtility, &disk, 3)
+
+	assert.True(t, errors.Is(err, context.Canceled), "should stop retrying when the context is done")
+}
+
+func TestRepairParentDisk_WithReadOnly(t *testing.T) {
+	const testDiskID = "disk0"
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	mockUtility.EXPECT().RepairDisk(ctx, testDiskID).Return("", fmt.Errorf("skip repair disk: %w", ErrReadOnly))
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+	}
+
+	_, err := repairParentDisk(ctx, mockUtility, &disk, 3)
+
+	assert.NoError(t, err, "shouldn't retry or fail a dry run")
+}
+
+func TestPredictMaxSize(t *testing.T) {
+	const (
+		containerSize uint64 = 100_000_000
+		totalFree     uint64 = 20_000_000
+	)
+	container := &types.DiskInfo{TotalSize: containerSize}
+
+	tests := []struct {
+		name       string
+		limits     *types.ResizeLimits
+		wantSize   uint64
+		wantSource string
+	}{
+		{
+			name:       "without limits",
+			limits:     nil,
+			wantSize:   containerSize + totalFree,
+			wantSource: predictionProjection,
+		},
+		{
+			name:       "with empty limits",
+			limits:     &types.ResizeLimits{},
+			wantSize:   containerSize + totalFree,
+			wantSource: predictionProjection,
+		},
+		{
+			name:       "with limits",
+			limits:     &types.ResizeLimits{MaximumSize: 119_000_000},
+			wantSize:   119_000_000,
+			wantSource: predictionLimits,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size, source := predictMaxSize(tt.limits, container, totalFree)
+
+			assert.Equal(t, tt.wantSize, size, "should predict the expected size")
+			assert.Equal(t, tt.wantSource, source, "should predict from the expected source")
+		})
+	}
+}
+
+func TestRepairContainer_WithRetries(t *testing.T) {
+	const testContainerID = "disk2"
+	var ctx = context.Background()
+
+	defer func(delay time.Duration) { repairRetryDelay = delay }(repairRetryDelay)
+	repairRetryDelay = time.Millisecond
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mockUtility.EXPECT().RepairVolume(ctx, testContainerID).Return("", fmt.Errorf("error")),
+		mockUtility.EXPECT().RepairVolume(ctx, testContainerID).Return("repaired", nil),
+	)
+
+	actualMessage, err := repairContainer(ctx, mockUtility, &types.DiskInfo{DeviceIdentifier: testContainerID}, 3)
+
+	assert.NoError(t, err, "should repair the container once the transient failure passes")
+	assert.Equal(t, "repaired", actualMessage, "should see the message of the successful repair")
+}
+
+func TestRepairContainer_WithReadOnly(t *testing.T) {
+	fake := NewFakeUtil(nil, nil)
+
+	_, err := repairContainer(context.Background(), Dryrun(fake), &types.DiskInfo{DeviceIdentifier: "disk2"}, 0)
+
+	assert.NoError(t, err, "should skip repairing the container in a dry run")
+	assert.Empty(t, fake.Calls(), "shouldn't repair the container")
+}
+
+func TestParseRepairStrategy(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    RepairStrategy
+		wantErr bool
+	}{
+		{name: "", want: RepairParentDisk},
+		{name: "disk", want: RepairParentDisk},
+		{name: "Volume", want: RepairContainerVolume},
+		{name: "partition", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRepairStrategy(tt.name)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseFreeSpaceSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    FreeSpaceSource
+		wantErr bool
+	}{
+		{name: "", want: FreeSpaceFromPartitions},
+		{name: "partitions", want: FreeSpaceFromPartitions},
+		{name: "Info", want: FreeSpaceFromInfo},
+		{name: "gpt", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFreeSpaceSource(tt.name)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCheckFreeSpace(t *testing.T) {
+	tests := []struct {
+		name      string
+		totalFree uint64
+		minimum   uint64
+		wantErr   bool
+	}{
+		{name: "default minimum met", totalFree: DefaultMinFreeSpace, minimum: DefaultMinFreeSpace},
+		{name: "default minimum not met", totalFree: DefaultMinFreeSpace - 1, minimum: DefaultMinFreeSpace, wantErr: true},
+		{name: "higher minimum not met", totalFree: 5_000_000, minimum: 10_000_000, wantErr: true},
+		{name: "disabled without free space", totalFree: 0, minimum: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkFreeSpace(tt.totalFree, tt.minimum)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+
+			var freeSpaceErr FreeSpaceError
+			if assert.True(t, errors.As(err, &freeSpaceErr), "should be a FreeSpaceError") {
+				assert.Equal(t, tt.totalFree, freeSpaceErr.FreeSpaceBytes())
+				assert.Equal(t, tt.minimum, freeSpaceErr.MinimumBytes(), "should include the configured minimum")
+			}
+		})
+	}
+}
+
+func TestGrowContainer_WithMinFreeSpaceDisabled(t *testing.T) {
+	const (
+		testDiskID = "disk1"
+		// total disk size
+		diskSize uint64 = 1_500_000
+		// individual partition space occupied, leaving less than the default minimum free
+		partSize uint64 = 500_000
+	)
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts := types.SystemPartitions{
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             diskSize,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mockUtility.EXPECT().RepairDisk(ctx, testDiskID).Return("", nil),
+		mockUtility.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mockUtility.EXPECT().ResizeContainer(ctx, testDiskID, "0").Return("", nil),
+	)
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		VirtualOrPhysical: "Physical",
+	}
+
+	var minFree uint64
+	err := GrowContainer(ctx, mockUtility, &disk, GrowOptions{MinFreeSpace: &minFree})
+
+	assert.NoError(t, err, "should grow with less than the default minimum when the check is disabled")
+}
+
+func TestCheckTargetSize(t *testing.T) {
+	const (
+		containerSize uint64 = 100_000_000
+		totalFree     uint64 = 20_000_000
+	)
+	container := &types.DiskInfo{TotalSize: containerSize}
+
+	tests := []struct {
+		name          string
+		target        uint64
+		wantErr       bool
+		wantFreeSpace bool
+	}{
+		{name: "maximum size", target: 0},
+		{name: "within free space", target: containerSize + totalFree/2},
+		{name: "all free space", target: containerSize + totalFree},
+		{name: "current size", target: containerSize, wantErr: true},
+		{name: "over free space", target: containerSize + totalFree + 1, wantErr: true, wantFreeSpace: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkTargetSize(container, totalFree, tt.target)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+
+			var freeSpaceErr FreeSpaceError
+			assert.Error(t, err)
+			assert.Equal(t, tt.wantFreeSpace, errors.As(err, &freeSpaceErr), "should only be a FreeSpaceError when over")
+		})
+	}
+}
+
+func TestPredictResizeSize(t *testing.T) {
+	const (
+		testDiskID           = "disk1"
+		containerSize uint64 = 100_000_000
+		totalFree     uint64 = 20_000_000
+		maximumSize   uint64 = 119_000_000
+	)
+	var ctx = context.Background()
+	container := &types.DiskInfo{TotalSize: containerSize}
+
+	tests := []struct {
+		name       string
+		target     uint64
+		limits     *types.ResizeLimits
+		limitsErr  error
+		wantSize   uint64
+		wantSource string
+	}{
+		{
+			name:       "with limits",
+			limits:     &types.ResizeLimits{MaximumSize: maximumSize},
+			wantSize:   maximumSize,
+			wantSource: predictionLimits,
+		},
+		{
+			name:       "with limits error",
+			limitsErr:  fmt.Errorf("error"),
+			wantSize:   containerSize + totalFree,
+			wantSource: predictionProjection,
+		},
+		{
+			name:       "with target",
+			target:     110_000_000,
+			wantSize:   110_000_000,
+			wantSource: predictionTarget,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+			if tt.target == 0 {
+				mockUtility.EXPECT().ResizeLimits(ctx, testDiskID).Return(tt.limits, tt.limitsErr)
+			}
+
+			size, source := predictResizeSize(ctx, mockUtility, testDiskID, container, totalFree, tt.target)
+
+			assert.Equal(t, tt.wantSize, size, "should predict the expected size")
+			assert.Equal(t, tt.wantSource, source, "should predict from the expected source")
+		})
+	}
+}
+
+func TestGrowContainer_DryrunWithLimits(t *testing.T) {
+	const (
+		testDiskID = "disk1"
+		// total disk size
+		diskSize uint64 = 3_000_000
+		// individual partition space occupied
+		partSize uint64 = 500_000
+		// maximum size reported by diskutil
+		maximumSize uint64 = 2_900_000
+	)
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts := types.SystemPartitions{
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             diskSize,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
+
+	// The dry run skips the repair and resize but still queries the limits
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mockUtility.EXPECT().List(ctx, nil).Return(&parts, nil),
+		mockUtility.EXPECT().ResizeLimits(ctx, testDiskID).Return(&types.ResizeLimits{MaximumSize: maximumSize}, nil),
+	)
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		VirtualOrPhysical: "Physical",
+	}
+
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	err := GrowContainer(ctx, Dryrun(mockUtility), &disk, GrowOptions{})
+
+	assert.NoError(t, err, "should be able to dry run grow container")
+	if assert.NotNil(t, hook.LastEntry(), "should log the predicted size") {
+		assert.Equal(t, "Would have resized container to 2.9 MB", hook.LastEntry().Message, "should predict the size from the limits")
+		assert.Equal(t, predictionLimits, hook.LastEntry().Data["source"], "should log the source of the prediction")
+	}
+}
+
+// growFixture creates the partitions and disk information of the APFS container disk1, whose disk has 2,000,000 bytes
+// of free space to grow into.
+func growFixture() (*types.SystemPartitions, *types.DiskInfo) {
+	const (
+		testDiskID        = "disk1"
+		diskSize   uint64 = 3_000_000
+		partSize   uint64 = 500_000
+	)
+
+	parts := &types.SystemPartitions{
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             diskSize,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
+
+	disk := &types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		TotalSize:         partSize,
+		VirtualOrPhysical: "Physical",
+		WritableMedia:     true,
+	}
+
+	return parts, disk
+}
+
+func TestGrow(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts, disk := growFixture()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mockUtility.EXPECT().Info(ctx, "/dev/disk1").Return(disk, nil),
+		mockUtility.EXPECT().RepairDisk(ctx, "disk1").Return("", nil),
+		mockUtility.EXPECT().List(ctx, nil).Return(parts, nil),
+		mockUtility.EXPECT().ResizeContainer(ctx, "disk1", "0").Return(resizeGrow, nil),
+	)
+
+	result, err := Grow(ctx, mockUtility, GrowOptions{ID: "/dev/disk1"})
+
+	assert.NoError(t, err, "should be able to grow the container")
+	if assert.NotNil(t, result) {
+		assert.True(t, result.Resized, "should resize the container")
+		assert.Equal(t, uint64(2_000_000), result.FreeSpace, "should describe the resize")
+	}
+}
+
+func TestGrow_WithRoot(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, disk := growFixture()
+	disk.WritableMedia = false
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	mockUtility.EXPECT().Info(ctx, "/").Return(disk, nil)
+
+	result, err := Grow(ctx, mockUtility, GrowOptions{ID: "root"})
+
+	var readOnlyErr ReadOnlyRootError
+	assert.True(t, errors.As(err, &readOnlyErr), "should refuse to grow a read-only root")
+	assert.Nil(t, result, "shouldn't describe a resize")
+}
+
+func TestGrow_WithDryRun(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts, disk := growFixture()
+
+	// The dry run skips the repair and resize but still queries the limits
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mockUtility.EXPECT().Info(ctx, "disk1").Return(disk, nil),
+		mockUtility.EXPECT().List(ctx, nil).Return(parts, nil),
+		mockUtility.EXPECT().ResizeLimits(ctx, "disk1").Return(&types.ResizeLimits{MaximumSize: 2_500_000}, nil),
+	)
+
+	result, err := Grow(ctx, mockUtility, GrowOptions{ID: "disk1", DryRun: true})
+
+	assert.NoError(t, err, "should be able to preview the grow")
+	if assert.NotNil(t, result) {
+		assert.False(t, result.Resized, "shouldn't resize the container")
+		assert.Equal(t, uint64(2_500_000), result.Size, "should predict the size")
+	}
+}
+
+func TestGrow_WithTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	mockUtility.EXPECT().Info(gomock.Any(), "disk1").DoAndReturn(func(ctx context.Context, id string) (*types.DiskInfo, error) {
+		_, ok := ctx.Deadline()
+		assert.True(t, ok, "should limit the grow to the timeout")
+
+		return nil, fmt.Errorf("error")
+	})
+
+	_, err := Grow(context.Background(), mockUtility, GrowOptions{ID: "disk1", Timeout: time.Minute})
+
+	assert.Error(t, err, "should fail without the container's information")
+}
+
+func TestGrow_WithoutID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+
+	_, err := Grow(context.Background(), mockUtility, GrowOptions{})
+
+	assert.Error(t, err, "should require the container's identifier")
+}
+
+func TestFreePercent(t *testing.T) {
+	tests := []struct {
+		name string
+		free uint64
+		size uint64
+		want float64
+	}{
+		{name: "without size", free: 1_000, size: 0, want: 0},
+		{name: "without free space", free: 0, size: 1_000_000, want: 0},
+		{name: "rounded", free: 1, size: 3, want: 33.3},
+		{name: "whole disk", free: 1_000_000, size: 1_000_000, want: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, freePercent(tt.free, tt.size), "should calculate the percentage of the disk")
+		})
+	}
+}
+
+func TestGrowContainer_LogsFreePercent(t *testing.T) {
+	const testDiskID = "disk1"
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 24,000 of the disk's 2,000,000 bytes are free, which is less than the default minimum
+	parts := types.SystemPartitions{
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             2_000_000,
+				Partitions:       []types.Partition{{Size: 1_976_000}},
+			},
+		},
+	}
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mockUtility.EXPECT().RepairDisk(ctx, testDiskID).Return("", nil),
+		mockUtility.EXPECT().List(ctx, nil).Return(&parts, nil),
+	)
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		VirtualOrPhysical: "Physical",
+	}
+
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	err := GrowContainer(ctx, mockUtility, &disk, GrowOptions{})
+
+	var freeSpaceErr FreeSpaceError
+	assert.True(t, errors.As(err, &freeSpaceErr), "should decline without enough free space")
+	if assert.NotNil(t, hook.LastEntry(), "should log the declined grow") {
+		assert.Equal(t, 1.2, hook.LastEntry().Data["free_pct"], "should log the free space as a percentage of the disk")
+	}
 }
@@ -5,13 +5,36 @@ import (
 	"strings"
 )
 
-// diskIDExp is the regexp expression for device identifiers.
-var diskIDExp = regexp.MustCompile("disk[0-9]+")
+// diskIDExp is the regexp expression for device identifiers. Identifiers are matched case-insensitively since
+// operators occasionally type them in uppercase (e.g. "DISK1").
+var diskIDExp = regexp.MustCompile("(?i)disk[0-9]+")
 
-// ParseDiskID parses a supported disk identifier from a string.
+// fullDiskIDExp is the regexp expression for device identifiers including any slices (e.g. "disk2s1"). Like
+// diskIDExp, identifiers are matched case-insensitively.
+var fullDiskIDExp = regexp.MustCompile("(?i)disk[0-9]+(s[0-9]+)*")
+
+// deviceIDExp is the regexp expression for complete device identifiers of disks and their slices (e.g. "disk0s2").
+var deviceIDExp = regexp.MustCompile("^disk[0-9]+(s[0-9]+)*$")
+
+// ParseDiskID parses a supported disk identifier from a string. The identifier is normalized to lowercase (e.g.
+// "DISK1" is parsed as "disk1").
 func ParseDiskID(s string) string {
 	if strings.TrimSpace(s) == "" {
 		return ""
 	}
-	return diskIDExp.FindString(s)
+	return strings.ToLower(diskIDExp.FindString(s))
+}
+
+// ParseFullDiskID parses a supported device identifier from a string like ParseDiskID, but preserves any slices (e.g.
+// "/dev/disk2s1" is parsed as "disk2s1" rather than "disk2").
+func ParseFullDiskID(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return ""
+	}
+	return strings.ToLower(fullDiskIDExp.FindString(s))
+}
+
+// IsDeviceID checks if the string is exactly a device identifier for a disk or one of its slices.
+func IsDeviceID(s string) bool {
+	return deviceIDExp.MatchString(s)
 }
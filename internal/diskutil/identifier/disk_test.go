@@ -43,6 +43,27 @@ func TestParseDiskID(t *testing.T) {
 			},
 			want: "disk1",
 		},
+		{
+			name: "with uppercase device id",
+			args: args{
+				s: "DISK1",
+			},
+			want: "disk1",
+		},
+		{
+			name: "with mixed-case device id",
+			args: args{
+				s: "Disk1",
+			},
+			want: "disk1",
+		},
+		{
+			name: "with mixed-case full device id",
+			args: args{
+				s: "/dev/dIsK12",
+			},
+			want: "disk12",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -52,3 +73,49 @@ func TestParseDiskID(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFullDiskID(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{name: "with empty input", s: "", want: ""},
+		{name: "without device id", s: "this is not a device identifier", want: ""},
+		{name: "with disk", s: "disk2", want: "disk2"},
+		{name: "with slice", s: "disk2s1", want: "disk2s1"},
+		{name: "with snapshot slice", s: "disk1s5s1", want: "disk1s5s1"},
+		{name: "with slice device node", s: "/dev/disk2s1", want: "disk2s1"},
+		{name: "with uppercase slice", s: "DISK2S1", want: "disk2s1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseFullDiskID(tt.s)
+
+			assert.Equal(t, tt.want, got, "parsed id should preserve the slice")
+		})
+	}
+}
+
+func TestIsDeviceID(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want bool
+	}{
+		{name: "with empty input", s: "", want: false},
+		{name: "with disk", s: "disk1", want: true},
+		{name: "with slice", s: "disk0s2", want: true},
+		{name: "with snapshot slice", s: "disk1s5s1", want: true},
+		{name: "with device node", s: "/dev/disk1", want: false},
+		{name: "with trailing text", s: "disk0s2 (Apple_APFS)", want: false},
+		{name: "without device id", s: "this is not a device identifier", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := IsDeviceID(tt.s)
+
+			assert.Equal(t, tt.want, got, "should match expected device identifier check")
+		})
+	}
+}
@@ -35,6 +35,51 @@ func (m *MockDiskUtil) EXPECT() *MockDiskUtilMockRecorder {
 	return m.recorder
 }
 
+// AddVolume mocks base method.
+func (m *MockDiskUtil) AddVolume(arg0 context.Context, arg1, arg2, arg3 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddVolume", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddVolume indicates an expected call of AddVolume.
+func (mr *MockDiskUtilMockRecorder) AddVolume(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddVolume", reflect.TypeOf((*MockDiskUtil)(nil).AddVolume), arg0, arg1, arg2, arg3)
+}
+
+// DeleteSnapshot mocks base method.
+func (m *MockDiskUtil) DeleteSnapshot(arg0 context.Context, arg1, arg2 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSnapshot", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteSnapshot indicates an expected call of DeleteSnapshot.
+func (mr *MockDiskUtilMockRecorder) DeleteSnapshot(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSnapshot", reflect.TypeOf((*MockDiskUtil)(nil).DeleteSnapshot), arg0, arg1, arg2)
+}
+
+// EraseVolume mocks base method.
+func (m *MockDiskUtil) EraseVolume(arg0 context.Context, arg1, arg2, arg3 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseVolume", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EraseVolume indicates an expected call of EraseVolume.
+func (mr *MockDiskUtilMockRecorder) EraseVolume(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseVolume", reflect.TypeOf((*MockDiskUtil)(nil).EraseVolume), arg0, arg1, arg2, arg3)
+}
+
 // Info mocks base method.
 func (m *MockDiskUtil) Info(arg0 context.Context, arg1 string) (*types.DiskInfo, error) {
 	m.ctrl.T.Helper()
@@ -65,6 +110,51 @@ func (mr *MockDiskUtilMockRecorder) List(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockDiskUtil)(nil).List), arg0, arg1)
 }
 
+// ListSnapshots mocks base method.
+func (m *MockDiskUtil) ListSnapshots(arg0 context.Context, arg1 string) ([]types.Snapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSnapshots", arg0, arg1)
+	ret0, _ := ret[0].([]types.Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSnapshots indicates an expected call of ListSnapshots.
+func (mr *MockDiskUtilMockRecorder) ListSnapshots(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSnapshots", reflect.TypeOf((*MockDiskUtil)(nil).ListSnapshots), arg0, arg1)
+}
+
+// Mount mocks base method.
+func (m *MockDiskUtil) Mount(arg0 context.Context, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Mount", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Mount indicates an expected call of Mount.
+func (mr *MockDiskUtilMockRecorder) Mount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Mount", reflect.TypeOf((*MockDiskUtil)(nil).Mount), arg0, arg1)
+}
+
+// RenameVolume mocks base method.
+func (m *MockDiskUtil) RenameVolume(arg0 context.Context, arg1, arg2 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameVolume", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenameVolume indicates an expected call of RenameVolume.
+func (mr *MockDiskUtilMockRecorder) RenameVolume(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameVolume", reflect.TypeOf((*MockDiskUtil)(nil).RenameVolume), arg0, arg1, arg2)
+}
+
 // RepairDisk mocks base method.
 func (m *MockDiskUtil) RepairDisk(arg0 context.Context, arg1 string) (string, error) {
 	m.ctrl.T.Helper()
@@ -80,17 +170,112 @@ func (mr *MockDiskUtilMockRecorder) RepairDisk(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepairDisk", reflect.TypeOf((*MockDiskUtil)(nil).RepairDisk), arg0, arg1)
 }
 
+// RepairVolume mocks base method.
+func (m *MockDiskUtil) RepairVolume(arg0 context.Context, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RepairVolume", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RepairVolume indicates an expected call of RepairVolume.
+func (mr *MockDiskUtilMockRecorder) RepairVolume(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepairVolume", reflect.TypeOf((*MockDiskUtil)(nil).RepairVolume), arg0, arg1)
+}
+
 // ResizeContainer mocks base method.
-func (m *MockDiskUtil) ResizeContainer(arg0 context.Context, arg1, arg2 string) (string, error) {
+func (m *MockDiskUtil) ResizeContainer(arg0 context.Context, arg1, arg2 string, arg3 ...types.ResizeOption) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ResizeContainer", arg0, arg1, arg2)
+	varargs := []interface{}{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ResizeContainer", varargs...)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ResizeContainer indicates an expected call of ResizeContainer.
-func (mr *MockDiskUtilMockRecorder) ResizeContainer(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockDiskUtilMockRecorder) ResizeContainer(arg0, arg1, arg2 interface{}, arg3 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResizeContainer", reflect.TypeOf((*MockDiskUtil)(nil).ResizeContainer), varargs...)
+}
+
+// ResizeLimits mocks base method.
+func (m *MockDiskUtil) ResizeLimits(arg0 context.Context, arg1 string) (*types.ResizeLimits, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResizeLimits", arg0, arg1)
+	ret0, _ := ret[0].(*types.ResizeLimits)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResizeLimits indicates an expected call of ResizeLimits.
+func (mr *MockDiskUtilMockRecorder) ResizeLimits(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResizeLimits", reflect.TypeOf((*MockDiskUtil)(nil).ResizeLimits), arg0, arg1)
+}
+
+// UnlockVolume mocks base method.
+func (m *MockDiskUtil) UnlockVolume(arg0 context.Context, arg1, arg2 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockVolume", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnlockVolume indicates an expected call of UnlockVolume.
+func (mr *MockDiskUtilMockRecorder) UnlockVolume(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockVolume", reflect.TypeOf((*MockDiskUtil)(nil).UnlockVolume), arg0, arg1, arg2)
+}
+
+// Unmount mocks base method.
+func (m *MockDiskUtil) Unmount(arg0 context.Context, arg1 string, arg2 bool) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unmount", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Unmount indicates an expected call of Unmount.
+func (mr *MockDiskUtilMockRecorder) Unmount(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unmount", reflect.TypeOf((*MockDiskUtil)(nil).Unmount), arg0, arg1, arg2)
+}
+
+// VerifyDisk mocks base method.
+func (m *MockDiskUtil) VerifyDisk(arg0 context.Context, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyDisk", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyDisk indicates an expected call of VerifyDisk.
+func (mr *MockDiskUtilMockRecorder) VerifyDisk(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyDisk", reflect.TypeOf((*MockDiskUtil)(nil).VerifyDisk), arg0, arg1)
+}
+
+// VerifyVolume mocks base method.
+func (m *MockDiskUtil) VerifyVolume(arg0 context.Context, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyVolume", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyVolume indicates an expected call of VerifyVolume.
+func (mr *MockDiskUtilMockRecorder) VerifyVolume(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResizeContainer", reflect.TypeOf((*MockDiskUtil)(nil).ResizeContainer), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyVolume", reflect.TypeOf((*MockDiskUtil)(nil).VerifyVolume), arg0, arg1)
 }
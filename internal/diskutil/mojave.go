@@ -4,29 +4,85 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"sync"
 
 	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
 	"github.com/aws/ec2-macos-utils/internal/util"
 )
 
-// updatePhysicalStores provides separate functionality for fetching APFS physical stores for SystemPartitions.
+// cLocaleEnv is the environment which forces diskutil's human-readable output to be in English (the C locale) so that
+// it can be parsed regardless of the system's locale.
+var cLocaleEnv = []string{"LANG=C", "LC_ALL=C"}
+
+// executeCommand runs the command for fetching physical stores. It's replaced in tests to observe the invocation.
+var executeCommand = util.ExecuteCommand
+
+// maxPhysicalStoreFetches limits how many diskutil commands fetch physical stores at once.
+const maxPhysicalStoreFetches = 4
+
+// updatePhysicalStores provides separate functionality for fetching APFS physical stores for SystemPartitions. The
+// physical stores are fetched concurrently (up to maxPhysicalStoreFetches at once) and the first error cancels the
+// remaining fetches.
 func updatePhysicalStores(ctx context.Context, partitions *types.SystemPartitions) error {
-	// Independently update all APFS disks' physical stores
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Each physical store is fetched into the slot of its disk/partition so that they're added in order
+	physicalStoreIds := make([]string, len(partitions.AllDisksAndPartitions))
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, maxPhysicalStoreFetches)
 	for i, part := range partitions.AllDisksAndPartitions {
 		// Only do the update if the disk/partition is APFS
-		if isAPFSVolume(part) {
+		if !isAPFSVolume(part) {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
 			// Fetch the physical store for the disk/partition
-			physicalStoreId, err := fetchPhysicalStore(ctx, part.DeviceIdentifier)
+			physicalStoreId, err := fetchPhysicalStore(ctx, id)
 			if err != nil {
-				return err
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
 			}
+			physicalStoreIds[i] = physicalStoreId
+		}(i, part.DeviceIdentifier)
+	}
+	wg.Wait()
 
-			// Create a new physical store from the output
-			physicalStore := types.APFSPhysicalStoreID{physicalStoreId}
+	if firstErr != nil {
+		return firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
-			// Add the physical store to the DiskInfo
-			partitions.AllDisksAndPartitions[i].APFSPhysicalStores = append(part.APFSPhysicalStores, physicalStore)
+	for i, physicalStoreId := range physicalStoreIds {
+		if physicalStoreId == "" {
+			continue
 		}
+
+		// Create a new physical store from the output
+		physicalStore := types.APFSPhysicalStoreID{DeviceIdentifier: physicalStoreId}
+
+		// Add the physical store to the DiskInfo
+		part := &partitions.AllDisksAndPartitions[i]
+		part.APFSPhysicalStores = append(part.APFSPhysicalStores, physicalStore)
 	}
 
 	return nil
@@ -45,10 +101,10 @@ func fetchPhysicalStore(ctx context.Context, id string) (string, error) {
 	//   * list - specifies the diskutil 'list' verb for a specific device ID and returns the human-readable output
 	cmdPhysicalStore := []string{"diskutil", "list", id}
 
-	// Execute the command to parse output from diskutil list
-	out, err := util.ExecuteCommand(ctx, cmdPhysicalStore, "", nil, nil)
+	// Execute the command to parse output from diskutil list, which is localized unless the C locale is forced
+	out, err := executeCommand(ctx, cmdPhysicalStore, "", cLocaleEnv, nil)
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", out.Stderr, err)
+		return "", fmt.Errorf("diskutil: failed to run diskutil command to fetch the physical store: %w", newDiskUtilError(err))
 	}
 
 	return parsePhysicalStoreId(out.Stdout)
@@ -77,7 +133,7 @@ func updatePhysicalStore(ctx context.Context, disk *types.DiskInfo) error {
 			return err
 		}
 
-		physicalStore := types.APFSPhysicalStore{physicalStoreId}
+		physicalStore := types.APFSPhysicalStore{DeviceIdentifier: physicalStoreId}
 
 		disk.APFSPhysicalStores = append(disk.APFSPhysicalStores, physicalStore)
 	}
@@ -0,0 +1,134 @@
+package diskutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+	"github.com/aws/ec2-macos-utils/internal/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// physicalStoreListOutput is the human-readable output of diskutil list for an APFS container on Mojave.
+const physicalStoreListOutput = `/dev/disk2 (synthesized):
+   #:                       TYPE NAME                    SIZE       IDENTIFIER
+   0:      APFS Container Scheme -                      +60.0 GB    disk2
+                                 Physical Store disk0s2
+   1:                APFS Volume Macintosh HD            10.9 GB    disk2s1
+`
+
+func TestFetchPhysicalStore(t *testing.T) {
+	var actualArgv, actualEnv []string
+	t.Cleanup(func() { executeCommand = util.ExecuteCommand })
+	executeCommand = func(ctx context.Context, c []string, runAsUser string, envVars []string, stdin io.ReadCloser) (util.CommandOutput, error) {
+		actualArgv, actualEnv = c, envVars
+
+		return util.CommandOutput{Stdout: physicalStoreListOutput}, nil
+	}
+
+	id, err := fetchPhysicalStore(context.Background(), "disk2")
+
+	assert.NoError(t, err, "should be able to fetch the physical store")
+	assert.Equal(t, "disk0s2", id, "should parse the physical store")
+	assert.Equal(t, []string{"diskutil", "list", "disk2"}, actualArgv, "should list the disk")
+	assert.Contains(t, actualEnv, "LANG=C", "should force the C locale")
+	assert.Contains(t, actualEnv, "LC_ALL=C", "should force the C locale")
+}
+
+// physicalStoreOutput creates the human-readable output of diskutil list for an APFS container with the physical store.
+func physicalStoreOutput(id, physicalStoreId string) string {
+	return fmt.Sprintf("/dev/%s (synthesized):\n   0:      APFS Container Scheme -   +60.0 GB    %s\n"+
+		"                                 Physical Store %s\n", id, id, physicalStoreId)
+}
+
+func TestUpdatePhysicalStores(t *testing.T) {
+	physicalStores := map[string]string{
+		"disk2": "disk0s2",
+		"disk3": "disk1s2",
+		"disk4": "disk5s2",
+		"disk6": "disk7s2",
+		"disk8": "disk9s2",
+	}
+	t.Cleanup(func() { executeCommand = util.ExecuteCommand })
+	executeCommand = func(ctx context.Context, c []string, runAsUser string, envVars []string, stdin io.ReadCloser) (util.CommandOutput, error) {
+		id := c[len(c)-1]
+
+		return util.CommandOutput{Stdout: physicalStoreOutput(id, physicalStores[id])}, nil
+	}
+
+	partitions := types.SystemPartitions{
+		AllDisksAndPartitions: []types.DiskPart{
+			{DeviceIdentifier: "disk0"},
+			{DeviceIdentifier: "disk2", APFSVolumes: []types.APFSVolume{}},
+			{DeviceIdentifier: "disk3", APFSVolumes: []types.APFSVolume{}},
+			{DeviceIdentifier: "disk4", APFSVolumes: []types.APFSVolume{}},
+			{DeviceIdentifier: "disk5"},
+			{DeviceIdentifier: "disk6", APFSVolumes: []types.APFSVolume{}},
+			{DeviceIdentifier: "disk8", APFSVolumes: []types.APFSVolume{}},
+		},
+	}
+
+	err := updatePhysicalStores(context.Background(), &partitions)
+	if !assert.NoError(t, err, "should be able to update the physical stores") {
+		return
+	}
+
+	for _, part := range partitions.AllDisksAndPartitions {
+		physicalStoreId, ok := physicalStores[part.DeviceIdentifier]
+		if !ok {
+			assert.Empty(t, part.APFSPhysicalStores, "shouldn't add a physical store to %s which isn't APFS", part.DeviceIdentifier)
+			continue
+		}
+		assert.Equal(t, []types.APFSPhysicalStoreID{{DeviceIdentifier: physicalStoreId}}, part.APFSPhysicalStores,
+			"should add the physical store of %s", part.DeviceIdentifier)
+	}
+}
+
+func TestUpdatePhysicalStores_WithErr(t *testing.T) {
+	t.Cleanup(func() { executeCommand = util.ExecuteCommand })
+	executeCommand = func(ctx context.Context, c []string, runAsUser string, envVars []string, stdin io.ReadCloser) (util.CommandOutput, error) {
+		id := c[len(c)-1]
+		if id == "disk3" {
+			return util.ExecuteCommand(ctx, []string{"sh", "-c", "echo 'Unable to find disk for disk3' >&2; exit 1"}, "", nil, nil)
+		}
+
+		// The remaining fetches only finish once they're cancelled by the failed fetch
+		select {
+		case <-ctx.Done():
+			return util.CommandOutput{ExitCode: -1}, ctx.Err()
+		case <-time.After(5 * time.Second):
+			return util.CommandOutput{Stdout: physicalStoreOutput(id, "disk0s2")}, nil
+		}
+	}
+
+	partitions := types.SystemPartitions{
+		AllDisksAndPartitions: []types.DiskPart{
+			{DeviceIdentifier: "disk2", APFSVolumes: []types.APFSVolume{}},
+			{DeviceIdentifier: "disk3", APFSVolumes: []types.APFSVolume{}},
+			{DeviceIdentifier: "disk4", APFSVolumes: []types.APFSVolume{}},
+		},
+	}
+
+	start := time.Now()
+	err := updatePhysicalStores(context.Background(), &partitions)
+
+	var diskutilErr DiskUtilError
+	if assert.True(t, errors.As(err, &diskutilErr), "should surface the failed fetch's error") {
+		assert.Contains(t, diskutilErr.Stderr(), "disk3", "should be the failed fetch's error")
+	}
+	assert.True(t, time.Since(start) < 5*time.Second, "should cancel the remaining fetches")
+	for _, part := range partitions.AllDisksAndPartitions {
+		assert.Empty(t, part.APFSPhysicalStores, "shouldn't add physical stores when a fetch fails")
+	}
+}
+
+func TestParsePhysicalStoreId_WithoutPhysicalStore(t *testing.T) {
+	_, err := parsePhysicalStoreId("/dev/disk0 (internal, physical):\n")
+
+	assert.Error(t, err, "shouldn't find a physical store")
+}
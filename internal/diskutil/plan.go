@@ -0,0 +1,201 @@
+package diskutil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/dustin/go-humanize"
+)
+
+// Decision records the outcome of a single check made while deciding how to grow a container.
+type Decision struct {
+	// Check describes what was checked.
+	Check string `json:"check"`
+	// Passed is true when the check succeeded.
+	Passed bool `json:"passed"`
+	// Detail provides the reasoning behind the outcome of the check.
+	Detail string `json:"detail"`
+}
+
+func (d Decision) String() string {
+	mark := "✓"
+	if !d.Passed {
+		mark = "✗"
+	}
+
+	return fmt.Sprintf("%s %s: %s", mark, d.Check, d.Detail)
+}
+
+// PlanGrowContainer walks the same checks as GrowContainer without mutating anything and returns the Decision made
+// at each step. Planning stops at the first check that fails since GrowContainer wouldn't continue past it either.
+//
+// Since the parent disk isn't repaired while planning, the amount of free space may not reflect recent changes to the
+// size of the disk that the kernel hasn't picked up yet.
+func PlanGrowContainer(ctx context.Context, u DiskUtil, container *types.DiskInfo, opts GrowOptions) ([]Decision, error) {
+	if container == nil {
+		return nil, fmt.Errorf("unable to plan for nil container")
+	}
+
+	decisions := []Decision{apfsResizeDecision(container, opts.Force)}
+	if !decisions[0].Passed {
+		return decisions, nil
+	}
+
+	phy := container
+	if !phy.IsPhysical() {
+		parent, err := u.Info(ctx, phy.ParentWholeDisk)
+		if err != nil {
+			return decisions, fmt.Errorf("unable to determine physical disk: %w", err)
+		}
+		phy = parent
+	}
+
+	parentDiskID, err := phy.ParentDeviceID()
+	decisions = append(decisions, parentDiskDecision(parentDiskID, err))
+	if err != nil {
+		return decisions, nil
+	}
+
+	decisions = append(decisions, internalDiskDecision(parentDiskID, opts.ForceInternal))
+	if !decisions[len(decisions)-1].Passed {
+		return decisions, nil
+	}
+
+	decisions = append(decisions, repairDecision(container, parentDiskID, opts.RepairStrategy))
+
+	totalFree, err := getDiskFreeSpace(ctx, u, phy, opts.FreeSpaceSource)
+	if err != nil {
+		return decisions, fmt.Errorf("cannot determine available space on disk: %w", err)
+	}
+	decisions = append(decisions, freeSpaceDecision(totalFree, opts.minFreeSpace()))
+	if !decisions[len(decisions)-1].Passed {
+		return decisions, nil
+	}
+
+	if opts.TargetSize != 0 {
+		decisions = append(decisions, targetSizeDecision(container, totalFree, opts.TargetSize))
+		if !decisions[len(decisions)-1].Passed {
+			return decisions, nil
+		}
+	}
+
+	decisions = append(decisions, resizeDecision(phy.DeviceIdentifier, growTarget(container, totalFree, opts)))
+
+	return decisions, nil
+}
+
+// apfsResizeDecision decides if the container can be APFS resized, or if resizing it is forced.
+func apfsResizeDecision(container *types.DiskInfo, force bool) Decision {
+	d := Decision{Check: "container is APFS"}
+	apfsErr := canAPFSResize(container)
+	if apfsErr != nil && !force {
+		d.Detail = apfsErr.Error()
+		return d
+	}
+	if err := checkUnlocked(container); err != nil {
+		d.Detail = err.Error()
+		return d
+	}
+
+	d.Passed = true
+	if apfsErr != nil {
+		d.Detail = fmt.Sprintf("%s but resizing %s is forced", apfsErr, container.DeviceIdentifier)
+	} else {
+		d.Detail = fmt.Sprintf("%s can be resized", container.DeviceIdentifier)
+	}
+
+	return d
+}
+
+// repairDecision describes the repair made before growing with the strategy.
+func repairDecision(container *types.DiskInfo, parentDiskID string, strategy RepairStrategy) Decision {
+	if strategy == RepairContainerVolume {
+		return Decision{
+			Check:  "repair container",
+			Passed: true,
+			Detail: fmt.Sprintf("would repair %s without updating the free space of %s", container.DeviceIdentifier, parentDiskID),
+		}
+	}
+
+	return Decision{
+		Check:  "repair parent disk",
+		Passed: true,
+		Detail: fmt.Sprintf("would repair %s to update its free space", parentDiskID),
+	}
+}
+
+// parentDiskDecision decides if the parent disk was resolved from the container's physical store.
+func parentDiskDecision(parentDiskID string, err error) Decision {
+	d := Decision{Check: "parent disk"}
+	if err != nil {
+		d.Detail = err.Error()
+		return d
+	}
+
+	d.Passed = true
+	d.Detail = fmt.Sprintf("parent is %s", parentDiskID)
+
+	return d
+}
+
+// internalDiskDecision decides if the parent disk may be modified given it might be the internal disk.
+func internalDiskDecision(parentDiskID string, force bool) Decision {
+	d := Decision{Check: "internal disk"}
+	if err := checkInternalDisk(parentDiskID, force); err != nil {
+		d.Detail = err.Error()
+		return d
+	}
+
+	d.Passed = true
+	if strings.EqualFold(parentDiskID, internalDiskID) {
+		d.Detail = fmt.Sprintf("%s is the internal disk but modifying it is forced", parentDiskID)
+	} else {
+		d.Detail = fmt.Sprintf("%s is not the internal disk", parentDiskID)
+	}
+
+	return d
+}
+
+// freeSpaceDecision decides if there's enough free space to grow given the minimum required.
+func freeSpaceDecision(totalFree, minimum uint64) Decision {
+	d := Decision{Check: "free space"}
+	if err := checkFreeSpace(totalFree, minimum); err != nil {
+		d.Detail = fmt.Sprintf("%s < %s", humanize.Bytes(totalFree), humanize.Bytes(minimum))
+		return d
+	}
+
+	d.Passed = true
+	if minimum == 0 {
+		d.Detail = fmt.Sprintf("%s, minimum check disabled", humanize.Bytes(totalFree))
+	} else {
+		d.Detail = fmt.Sprintf("%s ≥ %s", humanize.Bytes(totalFree), humanize.Bytes(minimum))
+	}
+
+	return d
+}
+
+// targetSizeDecision decides if the requested target size fits in the free space.
+func targetSizeDecision(container *types.DiskInfo, totalFree, target uint64) Decision {
+	d := Decision{Check: "target size"}
+	if err := checkTargetSize(container, totalFree, target); err != nil {
+		d.Detail = err.Error()
+		return d
+	}
+
+	d.Passed = true
+	d.Detail = fmt.Sprintf("%s ≤ %s", humanize.Bytes(target), humanize.Bytes(container.TotalSize+totalFree))
+
+	return d
+}
+
+// resizeDecision describes the resize that would be performed.
+func resizeDecision(id string, target uint64) Decision {
+	return Decision{
+		Check:  "resize container",
+		Passed: true,
+		Detail: fmt.Sprintf("would resize %s to %s", id, describeResizeTarget(target)),
+	}
+}
@@ -0,0 +1,258 @@
+package diskutil
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	mock_diskutil "github.com/aws/ec2-macos-utils/internal/diskutil/mocks"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanGrowContainer_WithoutContainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+
+	decisions, err := PlanGrowContainer(context.Background(), mockUtility, nil, GrowOptions{})
+
+	assert.Error(t, err, "shouldn't be able to plan with nil container")
+	assert.Nil(t, decisions, "shouldn't have any decisions without a container")
+}
+
+func TestPlanGrowContainer_WithEmptyContainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+
+	disk := types.DiskInfo{}
+
+	decisions, err := PlanGrowContainer(context.Background(), mockUtility, &disk, GrowOptions{})
+
+	assert.NoError(t, err, "should be able to plan for an empty container")
+	assert.Len(t, decisions, 1, "should stop planning after the failed APFS check")
+	assert.Equal(t, "container is APFS", decisions[0].Check)
+	assert.False(t, decisions[0].Passed, "empty container isn't APFS")
+}
+
+func TestPlanGrowContainer_WithForce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	mockUtility.EXPECT().Info(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("error"))
+
+	disk := types.DiskInfo{DeviceIdentifier: "disk2", ParentWholeDisk: "disk1"}
+
+	decisions, err := PlanGrowContainer(context.Background(), mockUtility, &disk, GrowOptions{Force: true})
+
+	assert.Error(t, err, "should fail to resolve the physical disk")
+	if assert.Len(t, decisions, 1, "should continue planning past the forced APFS check") {
+		assert.True(t, decisions[0].Passed, "forced APFS check should pass")
+		assert.Contains(t, decisions[0].Detail, "forced")
+	}
+}
+
+func TestPlanGrowContainer_WithListErr(t *testing.T) {
+	const testDiskID = "disk1"
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	mockUtility.EXPECT().List(ctx, nil).Return(nil, fmt.Errorf("error"))
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		VirtualOrPhysical: "Physical",
+	}
+
+	decisions, err := PlanGrowContainer(ctx, mockUtility, &disk, GrowOptions{})
+
+	assert.Error(t, err, "shouldn't be able to plan with list error")
+	assert.Len(t, decisions, 4, "should keep the decisions made before the error")
+}
+
+func TestPlanGrowContainer_WithoutFreeSpace(t *testing.T) {
+	const (
+		testDiskID = "disk1"
+		// total disk size
+		diskSize uint64 = 1_000_000
+		// individual partition space occupied
+		partSize uint64 = 500_000
+	)
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts := types.SystemPartitions{
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             diskSize,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	mockUtility.EXPECT().List(ctx, nil).Return(&parts, nil)
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		VirtualOrPhysical: "Physical",
+	}
+
+	decisions, err := PlanGrowContainer(ctx, mockUtility, &disk, GrowOptions{})
+
+	assert.NoError(t, err, "should be able to plan without free space")
+	assert.Len(t, decisions, 5, "should stop planning after the failed free space check")
+	assert.Equal(t, "free space", decisions[4].Check)
+	assert.False(t, decisions[4].Passed, "should fail the free space check")
+}
+
+func TestPlanGrowContainer_Success(t *testing.T) {
+	const (
+		testDiskID = "disk1"
+		// total disk size
+		diskSize uint64 = 3_000_000
+		// individual partition space occupied
+		partSize uint64 = 500_000
+	)
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parts := types.SystemPartitions{
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: testDiskID,
+				Size:             diskSize,
+				Partitions: []types.Partition{
+					{Size: partSize},
+					{Size: partSize},
+				},
+			},
+		},
+	}
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	mockUtility.EXPECT().List(ctx, nil).Return(&parts, nil)
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		VirtualOrPhysical: "Physical",
+	}
+
+	expectedDecisions := []Decision{
+		{Check: "container is APFS", Passed: true, Detail: "disk1 can be resized"},
+		{Check: "parent disk", Passed: true, Detail: "parent is disk1"},
+		{Check: "internal disk", Passed: true, Detail: "disk1 is not the internal disk"},
+		{Check: "repair parent disk", Passed: true, Detail: "would repair disk1 to update its free space"},
+		{Check: "free space", Passed: true, Detail: "2.0 MB ≥ 1.0 MB"},
+		{Check: "resize container", Passed: true, Detail: "would resize disk1 to max size"},
+	}
+
+	actualDecisions, err := PlanGrowContainer(ctx, mockUtility, &disk, GrowOptions{})
+
+	assert.NoError(t, err, "should be able to plan with valid data")
+	assert.Equal(t, expectedDecisions, actualDecisions, "should have a decision for each check")
+}
+
+func TestPlanGrowContainer_WithInternalDisk(t *testing.T) {
+	const testDiskID = "disk0"
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{
+			{DeviceIdentifier: testDiskID},
+		},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier:  testDiskID,
+		ParentWholeDisk:   testDiskID,
+		VirtualOrPhysical: "Physical",
+	}
+
+	expectedDecisions := []Decision{
+		{Check: "container is APFS", Passed: true, Detail: "disk0 can be resized"},
+		{Check: "parent disk", Passed: true, Detail: "parent is disk0"},
+		{Check: "internal disk", Passed: false, Detail: "refusing to modify internal disk disk0 without force"},
+	}
+
+	actualDecisions, err := PlanGrowContainer(ctx, mockUtility, &disk, GrowOptions{})
+
+	assert.NoError(t, err, "should be able to plan for the internal disk")
+	assert.Equal(t, expectedDecisions, actualDecisions, "should stop planning at the internal disk check")
+}
+
+func TestPlanGrowContainer_WithLockedContainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+
+	disk := types.DiskInfo{
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+			Locked:         true,
+		},
+		DeviceIdentifier: "disk2",
+	}
+
+	decisions, err := PlanGrowContainer(context.Background(), mockUtility, &disk, GrowOptions{})
+
+	assert.NoError(t, err, "should be able to plan for a locked container")
+	assert.Len(t, decisions, 1, "should stop planning at the locked container")
+	assert.False(t, decisions[0].Passed, "should fail the APFS check for a locked container")
+}
+
+func TestRepairDecision(t *testing.T) {
+	container := &types.DiskInfo{DeviceIdentifier: "disk2"}
+
+	d := repairDecision(container, "disk1", RepairParentDisk)
+	assert.Equal(t, "repair parent disk", d.Check)
+	assert.Contains(t, d.Detail, "disk1", "should repair the parent disk by default")
+
+	d = repairDecision(container, "disk1", RepairContainerVolume)
+	assert.Equal(t, "repair container", d.Check)
+	assert.Contains(t, d.Detail, "would repair disk2", "should only repair the container")
+}
@@ -0,0 +1,107 @@
+package diskutil
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/aws/ec2-macos-utils/internal/contextual"
+	"github.com/aws/ec2-macos-utils/internal/system"
+)
+
+// Readiness summarizes if the system is ready to grow its root container.
+type Readiness struct {
+	// ProductSupported is true when diskutil can be configured for the product provided in the context.
+	ProductSupported bool
+	// Root is true when running with root privileges, which are required to repair the parent disk.
+	Root bool
+	// RootResizable is true when the root container is APFS and unlocked so it can be resized.
+	RootResizable bool
+	// FreeSpaceBytes is the free space on the root container's disk. The free space isn't updated with a repair, so
+	// space added since the disk was last repaired isn't included.
+	FreeSpaceBytes uint64
+	// Problems explain each check that isn't ready.
+	Problems []string
+}
+
+// Ready checks if every check is ready, including that there's enough free space to grow the root container.
+func (r Readiness) Ready() bool {
+	return r.ProductSupported && r.Root && r.RootResizable && checkFreeSpace(r.FreeSpaceBytes, DefaultMinFreeSpace) == nil
+}
+
+// readinessEnv provides the environment checked for readiness so it can be replaced in tests.
+type readinessEnv struct {
+	// isRoot checks if running with root privileges.
+	isRoot func() bool
+	// forProduct configures the diskutil controller for the product.
+	forProduct func(p *system.Product) (DiskUtil, error)
+}
+
+// GrowReadiness checks if the system is ready to grow its root container: the product provided in the context must be
+// supported, the command must be running as root, the root container must be APFS and resizable, and there must be
+// enough free space. The checks don't make any changes.
+func GrowReadiness(ctx context.Context) Readiness {
+	return growReadiness(ctx, readinessEnv{
+		isRoot:     func() bool { return os.Geteuid() == 0 },
+		forProduct: ForProduct,
+	})
+}
+
+// growReadiness checks readiness (see GrowReadiness) in the given environment.
+func growReadiness(ctx context.Context, env readinessEnv) Readiness {
+	var r Readiness
+
+	r.Root = env.isRoot()
+	if !r.Root {
+		r.Problems = append(r.Problems, "root privileges required")
+	}
+
+	product := contextual.Product(ctx)
+	if product == nil {
+		r.Problems = append(r.Problems, "no product detected")
+		return r
+	}
+	u, err := env.forProduct(product)
+	if err != nil {
+		r.Problems = append(r.Problems, fmt.Sprintf("%s isn't supported: %v", product, err))
+		return r
+	}
+	r.ProductSupported = true
+
+	root, err := u.Info(ctx, "/")
+	if err != nil {
+		r.Problems = append(r.Problems, fmt.Sprintf("cannot fetch root container: %v", err))
+		return r
+	}
+	if err := canAPFSResize(root); err != nil {
+		r.Problems = append(r.Problems, fmt.Sprintf("root container can't be resized: %v", err))
+		return r
+	}
+	if err := checkUnlocked(root); err != nil {
+		r.Problems = append(r.Problems, fmt.Sprintf("root container can't be resized: %v", err))
+		return r
+	}
+	r.RootResizable = true
+
+	phy := root
+	if !phy.IsPhysical() {
+		phy, err = u.Info(ctx, root.ParentWholeDisk)
+		if err != nil {
+			r.Problems = append(r.Problems, fmt.Sprintf("cannot determine physical disk: %v", err))
+			return r
+		}
+	}
+	r.FreeSpaceBytes, err = getDiskFreeSpace(ctx, u, phy, FreeSpaceFromPartitions)
+	if err != nil {
+		r.Problems = append(r.Problems, fmt.Sprintf("cannot determine free space: %v", err))
+		return r
+	}
+	if err := checkFreeSpace(r.FreeSpaceBytes, DefaultMinFreeSpace); err != nil {
+		r.Problems = append(r.Problems, fmt.Sprintf("not enough free space: %s < %s",
+			humanize.Bytes(r.FreeSpaceBytes), humanize.Bytes(DefaultMinFreeSpace)))
+	}
+
+	return r
+}
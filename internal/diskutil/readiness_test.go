@@ -0,0 +1,84 @@
+package diskutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/contextual"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+	"github.com/aws/ec2-macos-utils/internal/system"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readinessContext provides a supported product in the context.
+func readinessContext(t *testing.T) context.Context {
+	product, err := system.ProductForVersion("13.6")
+	if !assert.NoError(t, err, "should be able to create product") {
+		t.FailNow()
+	}
+
+	return contextual.WithProduct(context.Background(), product)
+}
+
+func TestGrowReadiness_Ready(t *testing.T) {
+	parts, disk := fakeGrowFixture()
+	fake := NewFakeUtil(parts, map[string]*types.DiskInfo{"/": disk})
+
+	r := growReadiness(readinessContext(t), readinessEnv{
+		isRoot:     func() bool { return true },
+		forProduct: func(p *system.Product) (DiskUtil, error) { return fake, nil },
+	})
+
+	assert.True(t, r.Ready(), "should be ready")
+	assert.True(t, r.ProductSupported, "product should be supported")
+	assert.True(t, r.Root, "should be running as root")
+	assert.True(t, r.RootResizable, "root container should be resizable")
+	assert.Equal(t, uint64(2_000_000), r.FreeSpaceBytes, "should report the free space")
+	assert.Empty(t, r.Problems, "shouldn't report problems")
+}
+
+func TestGrowReadiness_NotReady(t *testing.T) {
+	parts, disk := fakeGrowFixture()
+	disk.Locked = true
+	fake := NewFakeUtil(parts, map[string]*types.DiskInfo{"/": disk})
+
+	r := growReadiness(readinessContext(t), readinessEnv{
+		isRoot:     func() bool { return false },
+		forProduct: func(p *system.Product) (DiskUtil, error) { return fake, nil },
+	})
+
+	assert.False(t, r.Ready(), "shouldn't be ready")
+	assert.True(t, r.ProductSupported, "product should be supported")
+	assert.False(t, r.Root, "shouldn't be running as root")
+	assert.False(t, r.RootResizable, "locked root container shouldn't be resizable")
+	assert.Len(t, r.Problems, 2, "should report each problem")
+}
+
+func TestGrowReadiness_WithUnsupportedProduct(t *testing.T) {
+	r := growReadiness(readinessContext(t), readinessEnv{
+		isRoot:     func() bool { return true },
+		forProduct: func(p *system.Product) (DiskUtil, error) { return nil, errors.New("unknown release") },
+	})
+
+	assert.False(t, r.Ready(), "shouldn't be ready")
+	assert.False(t, r.ProductSupported, "product shouldn't be supported")
+	assert.Len(t, r.Problems, 1, "should report the unsupported product")
+}
+
+func TestGrowReadiness_WithoutFreeSpace(t *testing.T) {
+	parts, disk := fakeGrowFixture()
+	parts.AllDisksAndPartitions[0].Size = 1_000_000
+	fake := NewFakeUtil(parts, map[string]*types.DiskInfo{"/": disk})
+
+	r := growReadiness(readinessContext(t), readinessEnv{
+		isRoot:     func() bool { return true },
+		forProduct: func(p *system.Product) (DiskUtil, error) { return fake, nil },
+	})
+
+	assert.False(t, r.Ready(), "shouldn't be ready without free space")
+	assert.True(t, r.RootResizable, "root container should be resizable")
+	assert.Zero(t, r.FreeSpaceBytes, "should report no free space")
+	assert.Len(t, r.Problems, 1, "should report the missing free space")
+}
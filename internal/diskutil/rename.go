@@ -0,0 +1,52 @@
+package diskutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxVolumeNameBytes is the longest volume name (in bytes) supported by APFS.
+const maxVolumeNameBytes = 255
+
+// RenameVolume renames the volume with the given device identifier after validating the new name with
+// validateVolumeName. In a dry run (see Dryrun) the rename is skipped and the wrapped ErrReadOnly is returned.
+func RenameVolume(ctx context.Context, u DiskUtil, id, name string) error {
+	if strings.TrimSpace(id) == "" {
+		return errors.New("empty volume identifier")
+	}
+	if err := validateVolumeName(name); err != nil {
+		return fmt.Errorf("invalid volume name: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"device_id": id,
+		"name":      name,
+	}).Info("Renaming volume...")
+	out, err := u.RenameVolume(ctx, id, name)
+	logrus.WithField("out", out).Debug("RenameVolume output")
+	if err != nil {
+		return fmt.Errorf("unable to rename volume: %w", err)
+	}
+
+	return nil
+}
+
+// validateVolumeName checks that the name can be used for a volume: it must not be empty, must not contain path
+// separators ("/" or the legacy ":"), and must fit within maxVolumeNameBytes.
+func validateVolumeName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("empty volume name")
+	}
+	if strings.ContainsAny(name, "/:") {
+		return fmt.Errorf("%q contains a path separator", name)
+	}
+	if len(name) > maxVolumeNameBytes {
+		return fmt.Errorf("%q is longer than %d bytes", name, maxVolumeNameBytes)
+	}
+
+	return nil
+}
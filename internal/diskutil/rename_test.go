@@ -0,0 +1,63 @@
+package diskutil
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenameVolume(t *testing.T) {
+	fake := NewFakeUtil(nil, nil)
+
+	err := RenameVolume(context.Background(), fake, "disk5s1", "Data")
+
+	assert.NoError(t, err, "should rename the volume")
+	assert.Equal(t, []FakeCall{{Method: "RenameVolume", Args: []string{"disk5s1", "Data"}}}, fake.Calls())
+}
+
+func TestRenameVolume_WithInvalidName(t *testing.T) {
+	tests := []struct {
+		name       string
+		volumeName string
+	}{
+		{name: "empty", volumeName: ""},
+		{name: "whitespace", volumeName: "  "},
+		{name: "slash", volumeName: "Data/Scratch"},
+		{name: "colon", volumeName: "Data:Scratch"},
+		{name: "too long", volumeName: strings.Repeat("a", maxVolumeNameBytes+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := NewFakeUtil(nil, nil)
+
+			err := RenameVolume(context.Background(), fake, "disk5s1", tt.volumeName)
+
+			assert.Error(t, err, "should reject the name")
+			assert.Empty(t, fake.Calls(), "shouldn't call diskutil")
+		})
+	}
+}
+
+func TestRenameVolume_WithRenameErr(t *testing.T) {
+	expectedErr := errors.New("error")
+	fake := NewFakeUtil(nil, nil)
+	fake.RenameVolumeFunc = func(ctx context.Context, id string, name string) (string, error) {
+		return "", expectedErr
+	}
+
+	err := RenameVolume(context.Background(), fake, "disk5s1", "Data")
+
+	assert.True(t, errors.Is(err, expectedErr), "should fail with the rename's error")
+}
+
+func TestRenameVolume_WithDryrun(t *testing.T) {
+	fake := NewFakeUtil(nil, nil)
+
+	err := RenameVolume(context.Background(), Dryrun(fake), "disk5s1", "Data")
+
+	assert.True(t, errors.Is(err, ErrReadOnly), "should skip the rename in a dry run")
+	assert.Empty(t, fake.Calls(), "shouldn't rename the volume")
+}
@@ -0,0 +1,58 @@
+package diskutil
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// resizeSizeExp is the regexp expression for sizes (in bytes) reported by diskutil while resizing a container (e.g.
+// "the new size is 121,122,037,760 bytes"). Digit grouping separators vary by locale so any of the common separators
+// are accepted between digits.
+var resizeSizeExp = regexp.MustCompile(`(?i)([0-9][0-9.,' \x{00a0}\x{202f}]*[0-9]|[0-9])\s*bytes`)
+
+// resizeNoOpExp is the regexp expression for the benign message diskutil reports when a container's physical store
+// already fills the whole disk so there's nothing to resize.
+var resizeNoOpExp = regexp.MustCompile(`(?i)size change is zero|already a whole disk`)
+
+// ResizeResult captures the outcome of an APFS.ResizeContainer as reported by diskutil.
+type ResizeResult struct {
+	// Size is the final size (in bytes) reported by diskutil for the container's physical store. A Size of 0
+	// indicates that the size couldn't be determined from the output.
+	Size uint64
+	// NoOp is true when diskutil reported that the container was already at its maximum size so nothing was resized.
+	NoOp bool
+}
+
+// ParseResizeOutput parses the output of diskutil's resizeContainer verb into a ResizeResult. The last size reported
+// in the output is used as the final size since diskutil reports the planned sizes before the completed resize.
+// Progress lines (e.g. "[ 0%..10%..100% ]") don't report sizes in bytes and are ignored.
+func ParseResizeOutput(out string) ResizeResult {
+	result := ResizeResult{NoOp: resizeNoOpExp.MatchString(out)}
+
+	matches := resizeSizeExp.FindAllStringSubmatch(out, -1)
+	if len(matches) == 0 {
+		return result
+	}
+	result.Size = parseGroupedDigits(matches[len(matches)-1][1])
+
+	return result
+}
+
+// parseGroupedDigits parses a number which may include digit grouping separators (e.g. "121,122,037,760"). If the
+// number can't be parsed, 0 is returned.
+func parseGroupedDigits(s string) uint64 {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+
+	size, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return size
+}
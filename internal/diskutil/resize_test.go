@@ -0,0 +1,91 @@
+package diskutil
+
+import (
+	_ "embed"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	//go:embed testdata/resize/grow.txt
+	// resizeGrow contains the output of a container grown to its maximum size.
+	resizeGrow string
+
+	//go:embed testdata/resize/grow_with_progress.txt
+	// resizeGrowWithProgress contains the output of a container grown to its maximum size with progress noise.
+	resizeGrowWithProgress string
+
+	//go:embed testdata/resize/grow_localized.txt
+	// resizeGrowLocalized contains the output of a container grown with localized digit grouping.
+	resizeGrowLocalized string
+
+	//go:embed testdata/resize/progress_only.txt
+	// resizeProgressOnly contains resize output that only reports progress percentages.
+	resizeProgressOnly string
+
+	//go:embed testdata/resize/already_whole_disk.txt
+	// resizeAlreadyWholeDisk contains the output of a container which already fills the whole disk.
+	resizeAlreadyWholeDisk string
+)
+
+func TestParseResizeOutput(t *testing.T) {
+	type args struct {
+		out string
+	}
+	tests := []struct {
+		name string
+		args args
+		want ResizeResult
+	}{
+		{
+			name: "without output",
+			args: args{
+				out: "",
+			},
+			want: ResizeResult{},
+		},
+		{
+			name: "with grow output",
+			args: args{
+				out: resizeGrow,
+			},
+			want: ResizeResult{Size: 121_122_037_760},
+		},
+		{
+			name: "with progress noise",
+			args: args{
+				out: resizeGrowWithProgress,
+			},
+			want: ResizeResult{Size: 121_122_037_760},
+		},
+		{
+			name: "with localized digit grouping",
+			args: args{
+				out: resizeGrowLocalized,
+			},
+			want: ResizeResult{Size: 121_122_037_760},
+		},
+		{
+			name: "with progress only",
+			args: args{
+				out: resizeProgressOnly,
+			},
+			want: ResizeResult{},
+		},
+		{
+			name: "with already whole disk",
+			args: args{
+				out: resizeAlreadyWholeDisk,
+			},
+			want: ResizeResult{Size: 121_122_037_760, NoOp: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseResizeOutput(tt.args.out)
+
+			assert.Equal(t, tt.want, got, "parsed result should match expected")
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package diskutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
+)
+
+// shrinkSafetyMargin defines the amount of space (in bytes) that must remain free in the container holding the
+// running OS after it's shrunk.
+const shrinkSafetyMargin = 5_000_000_000
+
+// UnsafeShrinkError defines an error to distinguish when shrinking a container would leave the running OS without
+// enough space.
+type UnsafeShrinkError struct {
+	requestedBytes uint64
+	requiredBytes  uint64
+}
+
+func (e UnsafeShrinkError) Error() string {
+	return fmt.Sprintf("%d bytes requested but the running system requires at least %d bytes", e.requestedBytes, e.requiredBytes)
+}
+
+// BelowUsedSpaceError defines an error to distinguish when shrinking a container would leave less space than its
+// volumes already use.
+type BelowUsedSpaceError struct {
+	requestedBytes uint64
+	usedBytes      uint64
+}
+
+func (e BelowUsedSpaceError) Error() string {
+	return fmt.Sprintf("%d bytes requested but the container's volumes use %d bytes", e.requestedBytes, e.usedBytes)
+}
+
+// ShrinkContainer shrinks a container to the given size (in bytes) by performing the following operations:
+//  1. Verify that the given types.DiskInfo is an unlocked APFS container that can be resized and, unless
+//     forceInternal is set, that it isn't on the internal disk.
+//  2. Verify that the requested size is smaller than the container's current size.
+//  3. Verify that the requested size isn't smaller than the space used by the container's volumes.
+//  4. Verify that the container doesn't hold the running OS or, if it does, that the requested size leaves enough
+//     space for the running OS's volumes (plus a safety margin).
+//  5. Resize the container to the requested size.
+func ShrinkContainer(ctx context.Context, u DiskUtil, container *types.DiskInfo, size uint64, forceInternal bool) error {
+	if container == nil {
+		return fmt.Errorf("unable to resize nil container")
+	}
+
+	if err := canAPFSResize(container); err != nil {
+		return fmt.Errorf("unable to resize container: %w", err)
+	}
+	if err := checkUnlocked(container); err != nil {
+		return fmt.Errorf("unable to resize container: %w", err)
+	}
+
+	// Like growing, refuse to shrink containers on the internal boot media unless explicitly allowed. Containers
+	// without a single physical store are left to diskutil.
+	if parentDiskID, err := container.ParentDeviceID(); err == nil {
+		if err := checkInternalDisk(parentDiskID, forceInternal); err != nil {
+			return fmt.Errorf("unable to resize container: %w", err)
+		}
+	}
+
+	if size == 0 || size >= container.TotalSize {
+		return fmt.Errorf("requested size %s is not smaller than the container's current size %s",
+			humanize.Bytes(size), humanize.Bytes(container.TotalSize))
+	}
+
+	if err := checkUsedSpace(container, size); err != nil {
+		return fmt.Errorf("unable to shrink container: %w", err)
+	}
+
+	logrus.WithField("device_id", container.DeviceIdentifier).Info("Checking if container holds the running system...")
+	if err := checkSafeShrink(ctx, u, container, size); err != nil {
+		return fmt.Errorf("unable to shrink container: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"device_id":   container.DeviceIdentifier,
+		"target_size": describeResizeTarget(size),
+	}).Info("Shrinking container...")
+	out, err := u.ResizeContainer(ctx, container.DeviceIdentifier, resizeTarget(size))
+	logrus.WithField("out", out).Debug("Resize output")
+	if errors.Is(err, ErrReadOnly) {
+		logrus.WithError(err).Warnf("Would have resized container to %s", describeResizeTarget(size))
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// checkUsedSpace checks that the requested size isn't smaller than the space used by the container's volumes, as
+// reported by the container's APFSContainerSize and APFSContainerFree. Containers without those sizes aren't checked.
+// A BelowUsedSpaceError is returned if the size is smaller.
+func checkUsedSpace(container *types.DiskInfo, size uint64) error {
+	if container.APFSContainerSize == 0 || container.APFSContainerFree > container.APFSContainerSize {
+		return nil
+	}
+
+	used := container.APFSContainerSize - container.APFSContainerFree
+	if size < used {
+		logrus.WithFields(logrus.Fields{
+			"requested_size": humanize.Bytes(size),
+			"used_space":     humanize.Bytes(used),
+		}).Warn("Requested size is smaller than the space used by the container's volumes")
+		return BelowUsedSpaceError{requestedBytes: size, usedBytes: used}
+	}
+
+	return nil
+}
+
+// checkSafeShrink identifies the volumes of the running OS (via the mount point "/") and, if they're held by the
+// given container, checks that the requested size leaves enough space for them. Like checkUsedSpace, the running OS
+// isn't checked when its container's sizes aren't reported. An UnsafeShrinkError is returned if it doesn't.
+func checkSafeShrink(ctx context.Context, u DiskUtil, container *types.DiskInfo, size uint64) error {
+	root, err := u.Info(ctx, "/")
+	if err != nil {
+		return fmt.Errorf("cannot determine running system's container: %w", err)
+	}
+
+	if !strings.EqualFold(containerReference(root), containerReference(container)) {
+		return nil
+	}
+
+	if root.APFSContainerSize == 0 || root.APFSContainerFree > root.APFSContainerSize {
+		logrus.WithField("device_id", root.DeviceIdentifier).Warn("Running system's container doesn't report its used space")
+		return nil
+	}
+
+	used := root.APFSContainerSize - root.APFSContainerFree
+	required := used + shrinkSafetyMargin
+	if size < required {
+		logrus.WithFields(logrus.Fields{
+			"requested_size": humanize.Bytes(size),
+			"used_space":     humanize.Bytes(used),
+			"safety_margin":  humanize.Bytes(shrinkSafetyMargin),
+		}).Warn("Requested size doesn't leave enough space for the running system")
+		return UnsafeShrinkError{requestedBytes: size, requiredBytes: required}
+	}
+
+	return nil
+}
+
+// containerReference gets the device identifier of the APFS container the disk belongs to. Disks that don't reference
+// a container are assumed to be the container themselves.
+func containerReference(disk *types.DiskInfo) string {
+	if disk.APFSContainerReference != "" {
+		return disk.APFSContainerReference
+	}
+
+	return disk.DeviceIdentifier
+}
@@ -0,0 +1,385 @@
+package diskutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	mock_diskutil "github.com/aws/ec2-macos-utils/internal/diskutil/mocks"
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShrinkContainer_WithoutContainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+
+	err := ShrinkContainer(context.Background(), mockUtility, nil, 1_000_000, false)
+
+	assert.Error(t, err, "shouldn't be able to shrink nil container")
+}
+
+func TestShrinkContainer_WithLargerSize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+
+	disk := types.DiskInfo{
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier: "disk2",
+		TotalSize:        100_000_000_000,
+	}
+
+	err := ShrinkContainer(context.Background(), mockUtility, &disk, 200_000_000_000, false)
+
+	assert.Error(t, err, "shouldn't be able to shrink container to a larger size")
+}
+
+func TestShrinkContainer_WithLockedContainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+
+	disk := types.DiskInfo{
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+			Locked:         true,
+		},
+		DeviceIdentifier: "disk2",
+		TotalSize:        100_000_000_000,
+	}
+
+	err := ShrinkContainer(context.Background(), mockUtility, &disk, 50_000_000_000, false)
+
+	var lockedErr LockedContainerError
+	assert.True(t, errors.As(err, &lockedErr), "shouldn't be able to shrink locked container")
+}
+
+func TestShrinkContainer_WithRootInfoErr(t *testing.T) {
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	mockUtility.EXPECT().Info(ctx, "/").Return(nil, fmt.Errorf("error"))
+
+	disk := types.DiskInfo{
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier: "disk2",
+		TotalSize:        100_000_000_000,
+	}
+
+	err := ShrinkContainer(ctx, mockUtility, &disk, 50_000_000_000, false)
+
+	assert.Error(t, err, "shouldn't be able to shrink without knowing the running system's container")
+}
+
+func TestShrinkContainer_RunningSystemBelowUsedSpace(t *testing.T) {
+	const (
+		testContainerID = "disk2"
+		// current container size
+		containerSize uint64 = 100_000_000_000
+		// space used by the running system's volumes
+		usedSize uint64 = 40_000_000_000
+		// requested size which leaves the used space but not the safety margin
+		requestedSize uint64 = 42_000_000_000
+	)
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	root := types.DiskInfo{
+		APFSContainerReference: testContainerID,
+		ContainerInfo: types.ContainerInfo{
+			APFSContainerFree: containerSize - usedSize,
+			APFSContainerSize: containerSize,
+			FilesystemType:    "apfs",
+		},
+		DeviceIdentifier: "disk2s1",
+		MountPoint:       "/",
+	}
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	mockUtility.EXPECT().Info(ctx, "/").Return(&root, nil)
+
+	disk := types.DiskInfo{
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier: testContainerID,
+		TotalSize:        containerSize,
+	}
+
+	err := ShrinkContainer(ctx, mockUtility, &disk, requestedSize, false)
+
+	assert.Error(t, err, "shouldn't be able to shrink the running system's container below its used space")
+	assert.True(t, errors.As(err, &UnsafeShrinkError{}), "should get UnsafeShrinkError")
+}
+
+func TestShrinkContainer_RunningSystemAboveUsedSpace(t *testing.T) {
+	const (
+		testContainerID = "disk2"
+		// current container size
+		containerSize uint64 = 100_000_000_000
+		// space used by the running system's volumes
+		usedSize uint64 = 40_000_000_000
+		// requested size which leaves the used space and the safety margin
+		requestedSize uint64 = 60_000_000_000
+	)
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	root := types.DiskInfo{
+		APFSContainerReference: testContainerID,
+		ContainerInfo: types.ContainerInfo{
+			APFSContainerFree: containerSize - usedSize,
+			APFSContainerSize: containerSize,
+			FilesystemType:    "apfs",
+		},
+		DeviceIdentifier: "disk2s1",
+		MountPoint:       "/",
+	}
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mockUtility.EXPECT().Info(ctx, "/").Return(&root, nil),
+		mockUtility.EXPECT().ResizeContainer(ctx, testContainerID, "60000000000B").Return("", nil),
+	)
+
+	disk := types.DiskInfo{
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier: testContainerID,
+		TotalSize:        containerSize,
+	}
+
+	err := ShrinkContainer(ctx, mockUtility, &disk, requestedSize, false)
+
+	assert.NoError(t, err, "should be able to shrink the running system's container while leaving enough space")
+}
+
+func TestShrinkContainer_RootContainerWithoutUsedSpace(t *testing.T) {
+	const (
+		testContainerID = "disk2"
+		// current container size
+		containerSize uint64 = 100_000_000_000
+		// requested size which is only refused if the used space is miscalculated
+		requestedSize uint64 = 60_000_000_000
+	)
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The running system's container reports more free space than its size, which would underflow the used space
+	root := types.DiskInfo{
+		APFSContainerReference: testContainerID,
+		ContainerInfo: types.ContainerInfo{
+			APFSContainerFree: containerSize + 1,
+			APFSContainerSize: containerSize,
+			FilesystemType:    "apfs",
+		},
+		DeviceIdentifier: "disk2s1",
+		MountPoint:       "/",
+	}
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mockUtility.EXPECT().Info(ctx, "/").Return(&root, nil),
+		mockUtility.EXPECT().ResizeContainer(ctx, testContainerID, "60000000000B").Return("", nil),
+	)
+
+	disk := types.DiskInfo{
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier: testContainerID,
+		TotalSize:        containerSize,
+	}
+
+	err := ShrinkContainer(ctx, mockUtility, &disk, requestedSize, false)
+
+	var unsafeErr UnsafeShrinkError
+	assert.False(t, errors.As(err, &unsafeErr), "shouldn't refuse the shrink from a miscalculated used space")
+	assert.NoError(t, err, "should shrink when the running system's used space isn't reported")
+}
+
+func TestShrinkContainer_OtherContainer(t *testing.T) {
+	const (
+		testContainerID = "disk4"
+		// current container size
+		containerSize uint64 = 100_000_000_000
+		// requested size which would be unsafe for the running system
+		requestedSize uint64 = 10_000_000_000
+	)
+	var ctx = context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	root := types.DiskInfo{
+		APFSContainerReference: "disk2",
+		ContainerInfo: types.ContainerInfo{
+			APFSContainerFree: 10_000_000_000,
+			APFSContainerSize: containerSize,
+			FilesystemType:    "apfs",
+		},
+		DeviceIdentifier: "disk2s1",
+		MountPoint:       "/",
+	}
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+	gomock.InOrder(
+		mockUtility.EXPECT().Info(ctx, "/").Return(&root, nil),
+		mockUtility.EXPECT().ResizeContainer(ctx, testContainerID, "10000000000B").Return("", nil),
+	)
+
+	disk := types.DiskInfo{
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier: testContainerID,
+		TotalSize:        containerSize,
+	}
+
+	err := ShrinkContainer(ctx, mockUtility, &disk, requestedSize, false)
+
+	assert.NoError(t, err, "should be able to shrink containers that don't hold the running system")
+}
+
+func TestShrinkContainer_BelowUsedSpace(t *testing.T) {
+	const (
+		// current container size
+		containerSize uint64 = 100_000_000_000
+		// space used by the container's volumes
+		usedSize uint64 = 40_000_000_000
+		// requested size which is smaller than the used space
+		requestedSize uint64 = 30_000_000_000
+	)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Neither Info nor ResizeContainer are expected since the size is rejected first
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+
+	disk := types.DiskInfo{
+		ContainerInfo: types.ContainerInfo{
+			APFSContainerFree: containerSize - usedSize,
+			APFSContainerSize: containerSize,
+			FilesystemType:    "apfs",
+		},
+		DeviceIdentifier: "disk4",
+		TotalSize:        containerSize,
+	}
+
+	err := ShrinkContainer(context.Background(), mockUtility, &disk, requestedSize, false)
+
+	var usedErr BelowUsedSpaceError
+	assert.True(t, errors.As(err, &usedErr), "should refuse to shrink below the used space")
+}
+
+func TestCheckUsedSpace(t *testing.T) {
+	const (
+		containerSize uint64 = 100_000_000_000
+		usedSize      uint64 = 40_000_000_000
+	)
+
+	tests := []struct {
+		name      string
+		container types.DiskInfo
+		size      uint64
+		wantErr   bool
+	}{
+		{
+			name:      "above used space",
+			container: types.DiskInfo{ContainerInfo: types.ContainerInfo{APFSContainerSize: containerSize, APFSContainerFree: containerSize - usedSize}},
+			size:      usedSize + 1,
+		},
+		{
+			name:      "at used space",
+			container: types.DiskInfo{ContainerInfo: types.ContainerInfo{APFSContainerSize: containerSize, APFSContainerFree: containerSize - usedSize}},
+			size:      usedSize,
+		},
+		{
+			name:      "below used space",
+			container: types.DiskInfo{ContainerInfo: types.ContainerInfo{APFSContainerSize: containerSize, APFSContainerFree: containerSize - usedSize}},
+			size:      usedSize - 1,
+			wantErr:   true,
+		},
+		{
+			name:      "without container sizes",
+			container: types.DiskInfo{},
+			size:      1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkUsedSpace(&tt.container, tt.size)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestBelowUsedSpaceError_Error(t *testing.T) {
+	e := BelowUsedSpaceError{
+		requestedBytes: 1,
+		usedBytes:      2,
+	}
+
+	assert.Equal(t, "1 bytes requested but the container's volumes use 2 bytes", e.Error(), "expected message to include metadata")
+}
+
+func TestUnsafeShrinkError_Error(t *testing.T) {
+	e := UnsafeShrinkError{
+		requestedBytes: 1,
+		requiredBytes:  2,
+	}
+
+	expectedErrorMessage := "1 bytes requested but the running system requires at least 2 bytes"
+
+	actualErrorMessage := e.Error()
+
+	assert.Equal(t, expectedErrorMessage, actualErrorMessage, "expected message to include metadata")
+}
+
+func TestShrinkContainer_WithInternalDisk(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUtility := mock_diskutil.NewMockDiskUtil(ctrl)
+
+	disk := types.DiskInfo{
+		APFSPhysicalStores: []types.APFSPhysicalStore{{DeviceIdentifier: "disk0s2"}},
+		ContainerInfo: types.ContainerInfo{
+			FilesystemType: "apfs",
+		},
+		DeviceIdentifier: "disk1",
+		TotalSize:        100_000_000_000,
+	}
+
+	err := ShrinkContainer(context.Background(), mockUtility, &disk, 50_000_000_000, false)
+
+	var internalErr InternalDiskError
+	assert.True(t, errors.As(err, &internalErr), "shouldn't shrink a container on the internal disk without force")
+}
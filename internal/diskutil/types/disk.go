@@ -64,6 +64,23 @@ func (d *DiskInfo) IsPhysical() bool {
 	return strings.EqualFold(d.VirtualOrPhysical, "Physical")
 }
 
+// ValidatePhysicalStores checks that each of the disk's physical stores has a well-formed device identifier (e.g.
+// "disk0s2"). The error lists every malformed identifier.
+func (d *DiskInfo) ValidatePhysicalStores() error {
+	var malformed []string
+	for _, store := range d.APFSPhysicalStores {
+		if !identifier.IsDeviceID(store.DeviceIdentifier) {
+			malformed = append(malformed, fmt.Sprintf("%q", store.DeviceIdentifier))
+		}
+	}
+
+	if len(malformed) > 0 {
+		return fmt.Errorf("malformed physical store identifiers: %s", strings.Join(malformed, ", "))
+	}
+
+	return nil
+}
+
 // ParentDeviceID gets the parent device identifier for a physical store.
 func (d *DiskInfo) ParentDeviceID() (string, error) {
 	// APFS Containers and Volumes are virtualized and should have a physical store which represents a physical disk
@@ -0,0 +1,9 @@
+package types
+
+// ResizeLimits mirrors the output format of the command "diskutil apfs resizeContainer <id> limits -plist" to store
+// the sizes (in bytes) an APFS container can be resized to.
+type ResizeLimits struct {
+	CurrentSize uint64 `plist:"CurrentSize"`
+	MaximumSize uint64 `plist:"MaximumSize"`
+	MinimumSize uint64 `plist:"MinimumSize"`
+}
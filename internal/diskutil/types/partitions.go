@@ -1,10 +1,15 @@
 package types
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 )
 
+// ErrFilteredList indicates that disks were listed without their partition information, which happens when the list
+// is filtered (e.g. by args passed to "diskutil list").
+var ErrFilteredList = errors.New("disks were listed without partition information, the list was likely filtered by args")
+
 // SystemPartitions mirrors the output format of the command "diskutil list -plist" to store all disk
 // and partition information.
 type SystemPartitions struct {
@@ -14,31 +19,107 @@ type SystemPartitions struct {
 	WholeDisks            []string   `plist:"WholeDisks"`
 }
 
-// AvailableDiskSpace calculates the amount of unallocated disk space for a specific device id.
+// AvailableDiskSpace calculates the amount of unallocated disk space for a specific device id. For APFS containers
+// (disks with APFS volumes but no partitions), this is the space in the container that isn't used by its volumes.
 func (p *SystemPartitions) AvailableDiskSpace(id string) (uint64, error) {
-	// Loop through all the partitions in the system and attempt to find the struct with a matching ID
-	var target *DiskPart
-	for i, disk := range p.AllDisksAndPartitions {
-		if strings.EqualFold(disk.DeviceIdentifier, id) {
-			target = &p.AllDisksAndPartitions[i]
-			break
-		}
-	}
-
 	// Ensure a DiskPart struct was found
-	if target == nil {
+	target, ok := p.FindDisk(id)
+	if !ok {
+		if len(p.AllDisksAndPartitions) == 0 && p.hasDisk(id) {
+			return 0, fmt.Errorf("no partition information found for ID [%s]: %w", id, ErrFilteredList)
+		}
 		return 0, fmt.Errorf("no partition information found for ID [%s]", id)
 	}
 
 	// Sum up disk's current allocations.
 	var allocated uint64
+	if len(target.Partitions) == 0 && len(target.APFSVolumes) > 0 {
+		allocated = target.apfsVolumeUsage()
+	}
 	for _, p := range target.Partitions {
 		allocated += p.Size
 	}
 
+	// Volumes may report more usage than the container's size while space is being reclaimed
+	if allocated > target.Size {
+		return 0, nil
+	}
+
 	return target.Size - allocated, nil
 }
 
+// FindDisk searches the system's disks for the DiskPart with the given device identifier. The identifier is matched
+// case-insensitively.
+func (p *SystemPartitions) FindDisk(id string) (*DiskPart, bool) {
+	for i, disk := range p.AllDisksAndPartitions {
+		if strings.EqualFold(disk.DeviceIdentifier, id) {
+			return &p.AllDisksAndPartitions[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// APFSContainers provides the system's APFS containers, which are the disks with APFS volumes.
+func (p *SystemPartitions) APFSContainers() []DiskPart {
+	var containers []DiskPart
+	for _, disk := range p.AllDisksAndPartitions {
+		if len(disk.APFSVolumes) > 0 {
+			containers = append(containers, disk)
+		}
+	}
+
+	return containers
+}
+
+// HasSlice checks if the device identifier of a slice (e.g. "disk2s1") is listed in AllDisks or as one of the partitions
+// or APFS volumes of the system's disks. The identifier is matched case-insensitively.
+func (p *SystemPartitions) HasSlice(id string) bool {
+	if p.hasDisk(id) {
+		return true
+	}
+
+	for _, disk := range p.AllDisksAndPartitions {
+		for _, part := range disk.Partitions {
+			if strings.EqualFold(part.DeviceIdentifier, id) {
+				return true
+			}
+		}
+		for _, volume := range disk.APFSVolumes {
+			if strings.EqualFold(volume.DeviceIdentifier, id) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// hasDisk checks if the device id is listed in AllDisks.
+func (p *SystemPartitions) hasDisk(id string) bool {
+	for _, disk := range p.AllDisks {
+		if strings.EqualFold(disk, id) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FindByVolumeName searches the system's APFS volumes for a volume with the given name. The name is matched
+// case-insensitively. If found, the DiskPart (the APFS container) holding the volume is returned along with the volume.
+func (p *SystemPartitions) FindByVolumeName(name string) (*DiskPart, *APFSVolume, bool) {
+	for i, disk := range p.AllDisksAndPartitions {
+		for j, volume := range disk.APFSVolumes {
+			if strings.EqualFold(volume.VolumeName, name) {
+				return &p.AllDisksAndPartitions[i], &p.AllDisksAndPartitions[i].APFSVolumes[j], true
+			}
+		}
+	}
+
+	return nil, nil, false
+}
+
 // APFSPhysicalStoreID represents the physical device usually relating to synthesized virtual devices.
 type APFSPhysicalStoreID struct {
 	DeviceIdentifier string `plist:"DeviceIdentifier"`
@@ -55,6 +136,21 @@ type DiskPart struct {
 	Size               uint64                `plist:"Size"`
 }
 
+// apfsVolumeUsage sums the space used by the disk's APFS volumes. The capacity in use is preferred since the size of
+// each volume may be reported as the size of the container it shares.
+func (d *DiskPart) apfsVolumeUsage() uint64 {
+	var used uint64
+	for _, v := range d.APFSVolumes {
+		if v.CapacityInUse > 0 {
+			used += v.CapacityInUse
+		} else {
+			used += v.Size
+		}
+	}
+
+	return used
+}
+
 // Partition stores relevant information about a partition in macOS.
 type Partition struct {
 	Content          string `plist:"Content"`
@@ -67,6 +163,7 @@ type Partition struct {
 
 // APFSVolume represents a macOS APFS Volume with relevant information.
 type APFSVolume struct {
+	CapacityInUse    uint64     `plist:"CapacityInUse"`
 	DeviceIdentifier string     `plist:"DeviceIdentifier"`
 	DiskUUID         string     `plist:"DiskUUID"`
 	MountPoint       string     `plist:"MountPoint"`
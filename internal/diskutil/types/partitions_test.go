@@ -1,6 +1,7 @@
 package types
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -27,6 +28,32 @@ func TestSystemPartitions_AvailableDiskSpace_WithoutTargetDisk(t *testing.T) {
 	assert.Equal(t, expectedAvailableSize, actual, "shouldn't return anything since the disk doesn't exist")
 }
 
+func TestSystemPartitions_AvailableDiskSpace_WithFilteredList(t *testing.T) {
+	const testDiskID = "disk1"
+
+	p := &SystemPartitions{
+		AllDisks:              []string{"disk0", testDiskID},
+		AllDisksAndPartitions: []DiskPart{},
+	}
+
+	actual, err := p.AvailableDiskSpace(testDiskID)
+
+	assert.Error(t, err, "shouldn't be able to find partition information in a filtered list")
+	assert.True(t, errors.Is(err, ErrFilteredList), "should explain that the list was likely filtered")
+	assert.Equal(t, uint64(0), actual, "shouldn't return anything without partition information")
+}
+
+func TestSystemPartitions_AvailableDiskSpace_WithFilteredListWithoutDisk(t *testing.T) {
+	p := &SystemPartitions{
+		AllDisks: []string{"disk0"},
+	}
+
+	_, err := p.AvailableDiskSpace("disk1")
+
+	assert.Error(t, err, "shouldn't be able to find disk in partitions")
+	assert.False(t, errors.Is(err, ErrFilteredList), "shouldn't blame filtering for a disk that isn't listed")
+}
+
 func TestSystemPartitions_AvailableDiskSpace_GoodDisk(t *testing.T) {
 	const (
 		testDiskID = "disk1"
@@ -58,3 +85,257 @@ func TestSystemPartitions_AvailableDiskSpace_GoodDisk(t *testing.T) {
 	assert.NoError(t, err, "should be able to calculate free space with valid data")
 	assert.Equal(t, expectedAvailableSize, actual, "should have calculated free space based on partitions")
 }
+
+func TestSystemPartitions_AvailableDiskSpace_APFSContainer(t *testing.T) {
+	const (
+		testDiskID = "disk2"
+		// total container size
+		containerSize uint64 = 2_000_000
+	)
+
+	tests := []struct {
+		name    string
+		volumes []APFSVolume
+		want    uint64
+	}{
+		{
+			name: "with capacity in use",
+			volumes: []APFSVolume{
+				{DeviceIdentifier: "disk2s1", CapacityInUse: 500_000, Size: containerSize},
+				{DeviceIdentifier: "disk2s2", CapacityInUse: 250_000, Size: containerSize},
+			},
+			want: 1_250_000,
+		},
+		{
+			name: "with sizes only",
+			volumes: []APFSVolume{
+				{DeviceIdentifier: "disk2s1", Size: 500_000},
+				{DeviceIdentifier: "disk2s2", Size: 250_000},
+			},
+			want: 1_250_000,
+		},
+		{
+			name: "with usage over container size",
+			volumes: []APFSVolume{
+				{DeviceIdentifier: "disk2s1", Size: containerSize},
+				{DeviceIdentifier: "disk2s2", Size: containerSize},
+			},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &SystemPartitions{
+				AllDisksAndPartitions: []DiskPart{
+					{
+						APFSPhysicalStores: []APFSPhysicalStoreID{{DeviceIdentifier: "disk0s2"}},
+						APFSVolumes:        tt.volumes,
+						Content:            "Apple_APFS_Container",
+						DeviceIdentifier:   testDiskID,
+						Size:               containerSize,
+					},
+				},
+			}
+
+			actual, err := p.AvailableDiskSpace(testDiskID)
+
+			assert.NoError(t, err, "should be able to calculate free space for APFS container")
+			assert.Equal(t, tt.want, actual, "should have calculated free space based on volume usage")
+		})
+	}
+}
+
+func TestSystemPartitions_FindByVolumeName(t *testing.T) {
+	p := &SystemPartitions{
+		AllDisksAndPartitions: []DiskPart{
+			{
+				DeviceIdentifier: "disk0",
+				Partitions: []Partition{
+					{DeviceIdentifier: "disk0s1", VolumeName: "EFI"},
+				},
+			},
+			{
+				DeviceIdentifier: "disk1",
+				APFSVolumes: []APFSVolume{
+					{DeviceIdentifier: "disk1s1", VolumeName: "Macintosh HD - Data"},
+					{DeviceIdentifier: "disk1s5", VolumeName: "Macintosh HD"},
+				},
+			},
+			{
+				DeviceIdentifier: "disk3",
+				APFSVolumes: []APFSVolume{
+					{DeviceIdentifier: "disk3s1", VolumeName: "scratch"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		volumeName string
+		wantDisk   string
+		wantVolume string
+		wantFound  bool
+	}{
+		{
+			name:       "exact name",
+			volumeName: "Macintosh HD - Data",
+			wantDisk:   "disk1",
+			wantVolume: "disk1s1",
+			wantFound:  true,
+		},
+		{
+			name:       "different case",
+			volumeName: "macintosh hd",
+			wantDisk:   "disk1",
+			wantVolume: "disk1s5",
+			wantFound:  true,
+		},
+		{
+			name:       "volume in another container",
+			volumeName: "SCRATCH",
+			wantDisk:   "disk3",
+			wantVolume: "disk3s1",
+			wantFound:  true,
+		},
+		{
+			name:       "non-APFS partition",
+			volumeName: "EFI",
+			wantFound:  false,
+		},
+		{
+			name:       "unknown volume",
+			volumeName: "missing",
+			wantFound:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			disk, volume, found := p.FindByVolumeName(tt.volumeName)
+
+			assert.Equal(t, tt.wantFound, found)
+			if !tt.wantFound {
+				assert.Nil(t, disk, "shouldn't return a disk for an unknown volume")
+				assert.Nil(t, volume, "shouldn't return a volume for an unknown volume")
+				return
+			}
+			assert.Equal(t, tt.wantDisk, disk.DeviceIdentifier, "should resolve to the volume's container")
+			assert.Equal(t, tt.wantVolume, volume.DeviceIdentifier, "should resolve to the named volume")
+		})
+	}
+}
+
+func TestSystemPartitions_FindDisk(t *testing.T) {
+	p := &SystemPartitions{
+		AllDisksAndPartitions: []DiskPart{
+			{DeviceIdentifier: "disk0", Size: 1_000_000},
+			{DeviceIdentifier: "disk1", Size: 2_000_000},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		id        string
+		wantSize  uint64
+		wantFound bool
+	}{
+		{name: "exact id", id: "disk1", wantSize: 2_000_000, wantFound: true},
+		{name: "different case", id: "DISK0", wantSize: 1_000_000, wantFound: true},
+		{name: "partition id", id: "disk0s1", wantFound: false},
+		{name: "unknown disk", id: "disk9", wantFound: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			disk, found := p.FindDisk(tt.id)
+
+			assert.Equal(t, tt.wantFound, found)
+			if !tt.wantFound {
+				assert.Nil(t, disk, "shouldn't return a disk for an unknown id")
+				return
+			}
+			assert.Equal(t, tt.wantSize, disk.Size, "should find the disk with the id")
+		})
+	}
+}
+
+func TestSystemPartitions_FindDisk_ReturnsListedDisk(t *testing.T) {
+	p := &SystemPartitions{
+		AllDisksAndPartitions: []DiskPart{{DeviceIdentifier: "disk0"}},
+	}
+
+	disk, _ := p.FindDisk("disk0")
+
+	assert.True(t, &p.AllDisksAndPartitions[0] == disk, "should point into the listed disks rather than a copy")
+}
+
+func TestSystemPartitions_HasSlice(t *testing.T) {
+	p := &SystemPartitions{
+		AllDisks: []string{"disk0", "disk0s1"},
+		AllDisksAndPartitions: []DiskPart{
+			{
+				DeviceIdentifier: "disk2",
+				Partitions:       []Partition{{DeviceIdentifier: "disk2s1"}},
+			},
+			{
+				DeviceIdentifier: "disk3",
+				APFSVolumes:      []APFSVolume{{DeviceIdentifier: "disk3s1"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		id   string
+		want bool
+	}{
+		{name: "listed slice", id: "disk0s1", want: true},
+		{name: "partition", id: "disk2s1", want: true},
+		{name: "different case", id: "DISK2S1", want: true},
+		{name: "APFS volume", id: "disk3s1", want: true},
+		{name: "absent slice", id: "disk2s2", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, p.HasSlice(tt.id), "should match expected slice check")
+		})
+	}
+}
+
+func TestSystemPartitions_APFSContainers(t *testing.T) {
+	p := &SystemPartitions{
+		AllDisksAndPartitions: []DiskPart{
+			{
+				DeviceIdentifier: "disk0",
+				Partitions:       []Partition{{DeviceIdentifier: "disk0s2"}},
+			},
+			{
+				DeviceIdentifier: "disk1",
+				APFSVolumes:      []APFSVolume{{DeviceIdentifier: "disk1s1"}},
+			},
+			{
+				DeviceIdentifier: "disk2",
+				APFSVolumes:      []APFSVolume{},
+			},
+			{
+				DeviceIdentifier: "disk3",
+				APFSVolumes:      []APFSVolume{{DeviceIdentifier: "disk3s1"}, {DeviceIdentifier: "disk3s2"}},
+			},
+		},
+	}
+
+	containers := p.APFSContainers()
+
+	var ids []string
+	for _, c := range containers {
+		ids = append(ids, c.DeviceIdentifier)
+	}
+	assert.Equal(t, []string{"disk1", "disk3"}, ids, "should only include disks with APFS volumes")
+}
+
+func TestSystemPartitions_APFSContainers_WithoutContainers(t *testing.T) {
+	p := &SystemPartitions{
+		AllDisksAndPartitions: []DiskPart{{DeviceIdentifier: "disk0"}},
+	}
+
+	assert.Empty(t, p.APFSContainers(), "should be empty without APFS volumes")
+}
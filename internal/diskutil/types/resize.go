@@ -0,0 +1,11 @@
+package types
+
+// ResizeOption is a trailing flag supported by the command "diskutil apfs resizeContainer <id> <size>".
+type ResizeOption string
+
+const (
+	// ResizeNoEFIUpdate skips updating the EFI firmware while resizing the container.
+	ResizeNoEFIUpdate ResizeOption = "-noEFIUpdate"
+	// ResizeDontAutoMount doesn't mount the container's volumes once it's resized.
+	ResizeDontAutoMount ResizeOption = "-dontAutoMount"
+)
@@ -0,0 +1,66 @@
+package diskutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// Usage is the file system's view of the space of a mounted volume (like df) rather than diskutil's view of the space
+// allocated to its partitions. This is what users see in Finder.
+type Usage struct {
+	// MountPoint is the path the volume is mounted at (e.g. "/").
+	MountPoint string
+	// BlockSize is the size (in bytes) of the file system's blocks.
+	BlockSize uint64
+	// TotalBytes is the size of the file system.
+	TotalBytes uint64
+	// FreeBytes is the amount of free space in the file system.
+	FreeBytes uint64
+	// AvailableBytes is the amount of free space available to unprivileged users, which is less than FreeBytes when
+	// space is reserved for root.
+	AvailableBytes uint64
+}
+
+// UsedBytes provides the amount of space used in the file system.
+func (u Usage) UsedBytes() uint64 {
+	if u.FreeBytes > u.TotalBytes {
+		return 0
+	}
+
+	return u.TotalBytes - u.FreeBytes
+}
+
+// UsageOf fetches the file system's Usage for the volume mounted at the mount point with statfs(2). Any path on the
+// volume may be given, though the usage is always that of the whole volume.
+func UsageOf(ctx context.Context, mountPoint string) (*Usage, error) {
+	if strings.TrimSpace(mountPoint) == "" {
+		return nil, errors.New("empty mount point")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var stat unix.Statfs_t
+	if err := unix.Statfs(mountPoint, &stat); err != nil {
+		return nil, fmt.Errorf("cannot fetch file system usage of %s: %w", mountPoint, err)
+	}
+
+	return newUsage(mountPoint, &stat), nil
+}
+
+// newUsage creates the Usage for the mount point from its statfs(2) result.
+func newUsage(mountPoint string, stat *unix.Statfs_t) *Usage {
+	blockSize := uint64(stat.Bsize)
+
+	return &Usage{
+		MountPoint:     mountPoint,
+		BlockSize:      blockSize,
+		TotalBytes:     uint64(stat.Blocks) * blockSize,
+		FreeBytes:      uint64(stat.Bfree) * blockSize,
+		AvailableBytes: uint64(stat.Bavail) * blockSize,
+	}
+}
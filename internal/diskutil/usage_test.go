@@ -0,0 +1,68 @@
+package diskutil
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+func TestUsageOf(t *testing.T) {
+	dir := t.TempDir()
+
+	usage, err := UsageOf(context.Background(), dir)
+	if !assert.NoError(t, err, "should be able to fetch the usage of a directory") {
+		return
+	}
+
+	var stat unix.Statfs_t
+	if !assert.NoError(t, unix.Statfs(dir, &stat), "should be able to statfs the directory") {
+		return
+	}
+	blockSize := uint64(stat.Bsize)
+	assert.Equal(t, dir, usage.MountPoint, "should include the mount point")
+	assert.Equal(t, blockSize, usage.BlockSize, "should use the file system's block size")
+	assert.Equal(t, uint64(stat.Blocks)*blockSize, usage.TotalBytes, "should convert the total blocks to bytes")
+	// Other processes may change the free space between the calls so only check it's plausible
+	assert.True(t, usage.FreeBytes <= usage.TotalBytes, "shouldn't have more free space than the total")
+	assert.True(t, usage.AvailableBytes <= usage.FreeBytes, "shouldn't have more available space than free space")
+}
+
+func TestUsageOf_WithoutMountPoint(t *testing.T) {
+	_, emptyErr := UsageOf(context.Background(), " ")
+	_, missingErr := UsageOf(context.Background(), filepath.Join(t.TempDir(), "missing"))
+
+	assert.Error(t, emptyErr, "should fail without a mount point")
+	assert.Error(t, missingErr, "should fail for a missing path")
+}
+
+func TestUsageOf_WithCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	usage, err := UsageOf(ctx, t.TempDir())
+
+	assert.Equal(t, context.Canceled, err, "should stop when the context is done")
+	assert.Nil(t, usage)
+}
+
+func TestNewUsage(t *testing.T) {
+	stat := unix.Statfs_t{Bsize: 4096, Blocks: 1000, Bfree: 400, Bavail: 300}
+
+	usage := newUsage("/", &stat)
+
+	assert.Equal(t, &Usage{
+		MountPoint:     "/",
+		BlockSize:      4096,
+		TotalBytes:     4_096_000,
+		FreeBytes:      1_638_400,
+		AvailableBytes: 1_228_800,
+	}, usage, "should convert the blocks to bytes")
+	assert.Equal(t, uint64(2_457_600), usage.UsedBytes(), "should use the space which isn't free")
+}
+
+func TestUsage_UsedBytesWithoutTotal(t *testing.T) {
+	assert.Equal(t, uint64(0), Usage{FreeBytes: 10}.UsedBytes(), "shouldn't underflow when the total is unknown")
+}
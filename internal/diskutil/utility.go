@@ -2,11 +2,37 @@ package diskutil
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
 	"github.com/aws/ec2-macos-utils/internal/util"
+
+	"howett.net/plist"
 )
 
+// executeCommandWithInput runs commands which are given input on stdin. It's replaced in tests to observe the
+// invocation.
+var executeCommandWithInput = util.ExecuteCommandWithInput
+
+// withoutCommandTimeout removes the command timeout (see util.WithCommandTimeout) from the context for diskutil commands
+// which change disks or can take a long time to finish (e.g. resizeContainer or verifyVolume). Killing them partway
+// through could leave the disk in an inconsistent state, so only the read-only queries (e.g. list and info) are bounded
+// by the command timeout while these are bounded by the context alone.
+func withoutCommandTimeout(ctx context.Context) context.Context {
+	return util.WithCommandTimeout(ctx, 0)
+}
+
+// repairDiskConfirmation is the input which confirms diskutil's repairDisk prompt to proceed with the repair.
+const repairDiskConfirmation = "y\n"
+
+// diskNotFoundExp is the regexp expression for the message diskutil reports when there's no disk for a device
+// identifier (e.g. "Unable to find disk for /dev/disk5").
+var diskNotFoundExp = regexp.MustCompile(`Unable to find disk for (\S+)`)
+
 // UtilImpl outlines the functionality necessary for wrapping macOS's diskutil tool. The methods are intentionally
 // named to correspond to diskutil(8)'s subcommand names as its API.
 type UtilImpl interface {
@@ -17,28 +43,77 @@ type UtilImpl interface {
 	// List fetches all disk and partition information for the system.
 	// This output will be filtered based on the args provided.
 	List(ctx context.Context, args []string) (string, error)
+	// Mount mounts the volume for the specified device identifier and returns its mount point.
+	Mount(ctx context.Context, id string) (string, error)
+	// Unmount unmounts the volume for the specified device identifier, forcefully if force is true.
+	Unmount(ctx context.Context, id string, force bool) (string, error)
 	// RepairDisk attempts to repair the disk for the specified device identifier.
 	// This process requires root access.
 	RepairDisk(ctx context.Context, id string) (string, error)
+	// RepairVolume attempts to repair the volume (or APFS container) for the specified device identifier.
+	// This process requires root access.
+	RepairVolume(ctx context.Context, id string) (string, error)
+	// VerifyDisk verifies the partition map of the disk for the specified device identifier without changing it.
+	VerifyDisk(ctx context.Context, id string) (string, error)
+	// VerifyVolume verifies the file system of the volume for the specified device identifier without changing it.
+	VerifyVolume(ctx context.Context, id string) (string, error)
+	// EraseVolume erases the volume for the specified device identifier, reformatting it with the given format and
+	// name. This process requires root access.
+	EraseVolume(ctx context.Context, id string, format string, name string) (string, error)
+	// RenameVolume renames the volume for the specified device identifier.
+	RenameVolume(ctx context.Context, id string, name string) (string, error)
 }
 
 // APFSImpl outlines the functionality necessary for wrapping diskutil's APFS verb.
 type APFSImpl interface {
 	// ResizeContainer attempts to grow the APFS container with the given device identifier
 	// to the specified size. If the given size is 0, ResizeContainer will attempt to grow
-	// the disk to its maximum size.
-	ResizeContainer(ctx context.Context, id string, size string) (string, error)
+	// the disk to its maximum size. Any options are appended to the command in order.
+	ResizeContainer(ctx context.Context, id string, size string, opts ...types.ResizeOption) (string, error)
+	// ResizeLimits fetches the raw resize limits for the APFS container with the given device identifier.
+	ResizeLimits(ctx context.Context, id string) (string, error)
+	// ListSnapshots fetches the raw list of snapshots for the APFS volume with the given device identifier.
+	ListSnapshots(ctx context.Context, volumeID string) (string, error)
+	// DeleteSnapshot deletes the snapshot with the given UUID from the APFS volume with the given device identifier.
+	DeleteSnapshot(ctx context.Context, volumeID string, uuid string) (string, error)
+	// AddVolume adds a new volume with the given name and format to the APFS container with the given device
+	// identifier.
+	AddVolume(ctx context.Context, containerID string, name string, format string) (string, error)
+	// UnlockVolume unlocks the encrypted APFS volume with the given device identifier using the passphrase.
+	UnlockVolume(ctx context.Context, id string, passphrase string) (string, error)
 }
 
-// DiskUtilityCmd is an empty struct that provides the implementation for the DiskUtility interface.
-type DiskUtilityCmd struct{}
+// OutputFormat is the flag which selects the format of diskutil's machine-readable output.
+type OutputFormat string
+
+const (
+	// OutputPlist requests diskutil's output in the plist format (see PlistDecoder).
+	OutputPlist OutputFormat = "-plist"
+	// OutputJSON requests diskutil's output in the JSON format (see JSONDecoder).
+	OutputJSON OutputFormat = "-json"
+)
 
-// List uses the macOS diskutil list command to list disks and partitions in a plist format by passing the -plist arg.
-// List also appends any given args to fully support the diskutil list verb.
+// DiskUtilityCmd provides the implementation for the DiskUtility interface.
+type DiskUtilityCmd struct {
+	// Format selects the format of diskutil's machine-readable output, OutputPlist if empty.
+	Format OutputFormat
+}
+
+// outputFormat provides the format of diskutil's machine-readable output, defaulting to OutputPlist.
+func (d *DiskUtilityCmd) outputFormat() OutputFormat {
+	if d.Format == "" {
+		return OutputPlist
+	}
+
+	return d.Format
+}
+
+// List uses the macOS diskutil list command to list disks and partitions in a plist format by passing the -plist arg
+// (or the Format's arg). List also appends any given args to fully support the diskutil list verb.
 func (d *DiskUtilityCmd) List(ctx context.Context, args []string) (string, error) {
 	// Create the diskutil command for retrieving all disk and partition information
 	//   * -plist converts diskutil's output from human-readable to the plist format
-	cmdListDisks := []string{"diskutil", "list", "-plist"}
+	cmdListDisks := []string{"diskutil", "list", string(d.outputFormat())}
 
 	// Append arguments to the diskutil list verb
 	if len(args) > 0 {
@@ -48,61 +123,362 @@ func (d *DiskUtilityCmd) List(ctx context.Context, args []string) (string, error
 	// Execute the diskutil list command and store the output
 	cmdOut, err := util.ExecuteCommand(ctx, cmdListDisks, "", nil, nil)
 	if err != nil {
-		return cmdOut.Stdout, fmt.Errorf("diskutil: failed to run diskutil command to list all disks, stderr: [%s]: %w", cmdOut.Stderr, err)
+		return cmdOut.Stdout, fmt.Errorf("diskutil: failed to run diskutil command to list all disks: %w", newDiskUtilError(err))
 	}
 
 	return cmdOut.Stdout, nil
 }
 
 // Info uses the macOS diskutil info command to get detailed information about a disk, partition, or container
-// format by passing the -plist arg.
+// format by passing the -plist arg (or the Format's arg).
 func (d *DiskUtilityCmd) Info(ctx context.Context, id string) (string, error) {
 	// Create the diskutil command for retrieving disk information given a device identifier
 	//   * -plist converts diskutil's output from human-readable to the plist format
 	//   * id - the device identifier for the disk to be fetched
-	cmdDiskInfo := []string{"diskutil", "info", "-plist", id}
+	cmdDiskInfo := []string{"diskutil", "info", string(d.outputFormat()), id}
 
 	// Execute the diskutil info command and store the output
 	cmdOut, err := util.ExecuteCommand(ctx, cmdDiskInfo, "", nil, nil)
 	if err != nil {
-		return cmdOut.Stdout, fmt.Errorf("diskutil: failed to run diskutil command to fetch disk information, stderr: [%s]: %w", cmdOut.Stderr, err)
+		return cmdOut.Stdout, fmt.Errorf("diskutil: failed to run diskutil command to fetch disk information: %w", newDiskUtilError(err))
+	}
+
+	return cmdOut.Stdout, nil
+}
+
+// Mount uses the macOS diskutil mount command to mount the volume for the specified device identifier (e.g. disk2s1 or
+// /dev/disk2s1). Since diskutil doesn't report where the volume was mounted, the mount point is looked up with the
+// diskutil info command once mounted.
+func (d *DiskUtilityCmd) Mount(ctx context.Context, id string) (string, error) {
+	// Execute the diskutil mount command
+	_, err := util.ExecuteCommand(withoutCommandTimeout(ctx), mountCommand(id), "", nil, nil)
+	if err != nil {
+		return "", fmt.Errorf("diskutil: failed to run diskutil command to mount the volume: %w", newDiskUtilError(err))
+	}
+
+	// Look up the resolved mount point so callers can chain operations on the mounted volume
+	rawInfo, err := d.Info(ctx, normalizeDeviceNode(id))
+	if err != nil {
+		return "", fmt.Errorf("diskutil: mounted volume but failed to fetch its mount point: %w", err)
+	}
+
+	return parseMountPoint(rawInfo, d.outputFormat())
+}
+
+// Unmount uses the macOS diskutil unmount command to unmount the volume for the specified device identifier (e.g.
+// disk2s1 or /dev/disk2s1). Busy volumes can only be unmounted if force is true.
+func (d *DiskUtilityCmd) Unmount(ctx context.Context, id string, force bool) (string, error) {
+	// Execute the diskutil unmount command and store the output
+	cmdOut, err := util.ExecuteCommand(withoutCommandTimeout(ctx), unmountCommand(id, force), "", nil, nil)
+	if err != nil {
+		return cmdOut.Stdout, fmt.Errorf("diskutil: failed to run diskutil command to unmount the volume: %w", newDiskUtilError(err))
+	}
+
+	return cmdOut.Stdout, nil
+}
+
+// EraseVolume uses the macOS diskutil eraseVolume command to erase the volume for the specified device identifier (e.g.
+// disk4s1 or /dev/disk4s1), reformatting it with the given format (e.g. APFS or JHFS+) and name. Everything on the
+// volume is destroyed. This process requires root access.
+func (d *DiskUtilityCmd) EraseVolume(ctx context.Context, id string, format string, name string) (string, error) {
+	// Execute the diskutil eraseVolume command and store the output
+	cmdOut, err := util.ExecuteCommand(withoutCommandTimeout(ctx), eraseVolumeCommand(id, format, name), "", nil, nil)
+	if err != nil {
+		return cmdOut.Stdout, fmt.Errorf("diskutil: failed to run diskutil command to erase the volume: %w", newDiskUtilError(err))
 	}
 
 	return cmdOut.Stdout, nil
 }
 
+// RenameVolume uses the macOS diskutil rename command to rename the volume for the specified device identifier (e.g.
+// disk4s1 or /dev/disk4s1) to the given name.
+func (d *DiskUtilityCmd) RenameVolume(ctx context.Context, id string, name string) (string, error) {
+	// Execute the diskutil rename command and store the output
+	cmdOut, err := util.ExecuteCommand(withoutCommandTimeout(ctx), renameVolumeCommand(id, name), "", nil, nil)
+	if err != nil {
+		return cmdOut.Stdout, fmt.Errorf("diskutil: failed to run diskutil command to rename the volume: %w", newDiskUtilError(err))
+	}
+
+	return cmdOut.Stdout, nil
+}
+
+// mountCommand creates the command used for executing macOS's diskutil to mount a volume.
+//   - mount - indicates that a volume is going to be mounted
+//   - id - the device identifier for the volume
+func mountCommand(id string) []string {
+	return []string{"diskutil", "mount", normalizeDeviceNode(id)}
+}
+
+// unmountCommand creates the command used for executing macOS's diskutil to unmount a volume.
+//   - unmount - indicates that a volume is going to be unmounted
+//   - force - unmounts the volume even if it's busy (only included if force is true)
+//   - id - the device identifier for the volume
+func unmountCommand(id string, force bool) []string {
+	cmd := []string{"diskutil", "unmount"}
+	if force {
+		cmd = append(cmd, "force")
+	}
+
+	return append(cmd, normalizeDeviceNode(id))
+}
+
+// eraseVolumeCommand creates the command used for executing macOS's diskutil to erase a volume.
+//   - eraseVolume - indicates that a volume is going to be erased
+//   - format - the file system the volume is reformatted with (e.g. APFS)
+//   - name - the name of the reformatted volume
+//   - id - the device identifier for the volume
+func eraseVolumeCommand(id, format, name string) []string {
+	return []string{"diskutil", "eraseVolume", format, name, normalizeDeviceNode(id)}
+}
+
+// renameVolumeCommand creates the command used for executing macOS's diskutil to rename a volume.
+//   - rename - indicates that a volume is going to be renamed
+//   - id - the device identifier for the volume
+//   - name - the new name of the volume
+func renameVolumeCommand(id, name string) []string {
+	return []string{"diskutil", "rename", normalizeDeviceNode(id), name}
+}
+
+// repairVolumeCommand creates the command used for executing macOS's diskutil to repair a volume.
+//   - repairVolume - indicates that a volume (or APFS container) is going to be repaired
+//   - id - the device identifier for the volume
+func repairVolumeCommand(id string) []string {
+	return []string{"diskutil", "repairVolume", normalizeDeviceNode(id)}
+}
+
+// normalizeDeviceNode converts a device node (e.g. /dev/disk2s1) into its device identifier (e.g. disk2s1). Other
+// identifiers are returned as-is.
+func normalizeDeviceNode(id string) string {
+	return strings.TrimPrefix(id, "/dev/")
+}
+
+// parseMountPoint parses the mount point from raw diskutil info data in the given format. An error is returned if the
+// volume isn't mounted.
+func parseMountPoint(rawInfo string, format OutputFormat) (string, error) {
+	var info struct {
+		MountPoint string `plist:"MountPoint" json:"MountPoint"`
+	}
+	var err error
+	if format == OutputJSON {
+		err = json.Unmarshal([]byte(rawInfo), &info)
+	} else {
+		_, err = plist.Unmarshal([]byte(rawInfo), &info)
+	}
+	if err != nil {
+		return "", fmt.Errorf("diskutil: failed to decode volume information: %w", err)
+	}
+
+	if info.MountPoint == "" {
+		return "", errors.New("diskutil: volume has no mount point")
+	}
+
+	return info.MountPoint, nil
+}
+
 // RepairDisk uses the macOS diskutil diskRepair command to repair the specified volume and get updated information
 // (e.g. amount of free space).
 func (d *DiskUtilityCmd) RepairDisk(ctx context.Context, id string) (string, error) {
-	// cmdRepairDisk represents the command used for executing macOS's diskutil to repair a disk.
-	// The repairDisk command requires interactive-input ("yes"/"no") but is automated with util.ExecuteCommandYes.
-	//   * repairDisk - indicates that a disk is going to be repaired (used to fetch amount of free space)
-	//   * id - the device identifier for the disk to be repaired
-	cmdRepairDisk := []string{"diskutil", "repairDisk", id}
+	// Execute the diskutil repairDisk command and store the output. The repairDisk command requires interactive-input
+	// ("y"/"n") which is automated by confirming with repairDiskConfirmation.
+	cmdOut, err := executeCommandWithInput(withoutCommandTimeout(ctx), repairDiskCommand(id), repairDiskConfirmation)
+	if err != nil {
+		return cmdOut.Stdout, fmt.Errorf("diskutil: failed to run repairDisk command: %w", newDiskUtilError(err))
+	}
 
-	// Execute the diskutil repairDisk command and store the output
-	cmdOut, err := util.ExecuteCommandYes(ctx, cmdRepairDisk, "", []string{})
+	return cmdOut.Stdout, nil
+}
+
+// repairDiskCommand creates the command used for executing macOS's diskutil to repair a disk.
+//   - repairDisk - indicates that a disk is going to be repaired (used to fetch amount of free space)
+//   - id - the device identifier for the disk to be repaired
+func repairDiskCommand(id string) []string {
+	return []string{"diskutil", "repairDisk", id}
+}
+
+// RepairVolume uses the macOS diskutil repairVolume command to repair the file system of the specified volume (or APFS
+// container). Unlike RepairDisk, the partition map isn't repaired so the disk's free space isn't updated.
+func (d *DiskUtilityCmd) RepairVolume(ctx context.Context, id string) (string, error) {
+	// Execute the diskutil repairVolume command and store the output
+	cmdOut, err := util.ExecuteCommand(withoutCommandTimeout(ctx), repairVolumeCommand(id), "", nil, nil)
+	if err != nil {
+		return cmdOut.Stdout, fmt.Errorf("diskutil: failed to run repairVolume command: %w", newDiskUtilError(err))
+	}
+
+	return cmdOut.Stdout, nil
+}
+
+// VerifyDisk uses the macOS diskutil verifyDisk command to verify the partition map of the specified disk. The disk
+// isn't changed so the verification doesn't require root access. diskutil's findings are provided in the output, even
+// when the disk fails verification.
+func (d *DiskUtilityCmd) VerifyDisk(ctx context.Context, id string) (string, error) {
+	// Execute the diskutil verifyDisk command and store the output
+	cmdOut, err := util.ExecuteCommand(withoutCommandTimeout(ctx), verifyDiskCommand(id), "", nil, nil)
+	if err != nil {
+		return cmdOut.Stdout, fmt.Errorf("diskutil: failed to run verifyDisk command: %w", newDiskUtilError(err))
+	}
+
+	return cmdOut.Stdout, nil
+}
+
+// VerifyVolume uses the macOS diskutil verifyVolume command to verify the file system of the specified volume (or
+// APFS container). The volume isn't changed, though diskutil may briefly freeze it while it's verified. diskutil's
+// findings are provided in the output, even when the volume fails verification.
+func (d *DiskUtilityCmd) VerifyVolume(ctx context.Context, id string) (string, error) {
+	// Execute the diskutil verifyVolume command and store the output
+	cmdOut, err := util.ExecuteCommand(withoutCommandTimeout(ctx), verifyVolumeCommand(id), "", nil, nil)
 	if err != nil {
-		return cmdOut.Stdout, fmt.Errorf("diskutil: failed to run repairDisk command, stderr: [%s]: %w", cmdOut.Stderr, err)
+		return cmdOut.Stdout, fmt.Errorf("diskutil: failed to run verifyVolume command: %w", newDiskUtilError(err))
 	}
 
 	return cmdOut.Stdout, nil
 }
 
+// verifyDiskCommand creates the command used for executing macOS's diskutil to verify a disk's partition map.
+//   - verifyDisk - indicates that a disk is going to be verified
+//   - id - the device identifier for the disk
+func verifyDiskCommand(id string) []string {
+	return []string{"diskutil", "verifyDisk", normalizeDeviceNode(id)}
+}
+
+// verifyVolumeCommand creates the command used for executing macOS's diskutil to verify a volume's file system.
+//   - verifyVolume - indicates that a volume is going to be verified
+//   - id - the device identifier for the volume
+func verifyVolumeCommand(id string) []string {
+	return []string{"diskutil", "verifyVolume", normalizeDeviceNode(id)}
+}
+
 // ResizeContainer uses the macOS diskutil apfs resizeContainer command to change the size of the specific container ID.
-func (d *DiskUtilityCmd) ResizeContainer(ctx context.Context, id string, size string) (string, error) {
-	// cmdResizeContainer represents the command used for executing macOS's diskutil to resize a container
-	//   * apfs - specifies that a virtual APFS volume is going to be modified
-	//   * resizeContainer - indicates that a container is going to be resized
+func (d *DiskUtilityCmd) ResizeContainer(ctx context.Context, id string, size string, opts ...types.ResizeOption) (string, error) {
+	cmdResizeContainer := resizeContainerCommand(id, size, opts)
+
+	// Execute the diskutil apfs resizeContainer command and store the output. The C locale is forced so the output
+	// can be reliably parsed (see ParseResizeOutput).
+	cmdOut, err := util.ExecuteCommand(withoutCommandTimeout(ctx), cmdResizeContainer, "", []string{"LC_ALL=C"}, nil)
+	if err != nil {
+		return cmdOut.Stdout, fmt.Errorf("diskutil: failed to run diskutil command to resize the container: %w", newDiskUtilError(err))
+	}
+
+	return cmdOut.Stdout, nil
+}
+
+// resizeContainerCommand creates the command used for executing macOS's diskutil to resize a container.
+//   - apfs - specifies that a virtual APFS volume is going to be modified
+//   - resizeContainer - indicates that a container is going to be resized
+//   - id - the device identifier for the container
+//   - size - the size which can be in a human-readable format (e.g. "0", "110g", and "1.5t")
+//   - opts - the trailing flags (e.g. -noEFIUpdate), in order
+func resizeContainerCommand(id string, size string, opts []types.ResizeOption) []string {
+	cmd := []string{"diskutil", "apfs", "resizeContainer", id, size}
+	for _, opt := range opts {
+		cmd = append(cmd, string(opt))
+	}
+
+	return cmd
+}
+
+// ResizeLimits uses the macOS diskutil apfs resizeContainer command's limits mode to get the sizes the specific
+// container ID can be resized to in a plist format by passing the -plist arg (or the Format's arg). The container isn't
+// modified.
+func (d *DiskUtilityCmd) ResizeLimits(ctx context.Context, id string) (string, error) {
+	// cmdResizeLimits represents the command used for executing macOS's diskutil to fetch a container's resize limits
+	//   * apfs - specifies that a virtual APFS volume is going to be queried
+	//   * resizeContainer - indicates that a container's resize is being queried
 	//   * id - the device identifier for the container
-	//   * size - the size which can be in a human-readable format (e.g. "0", "110g", and "1.5t")
-	cmdResizeContainer := []string{"diskutil", "apfs", "resizeContainer", id, size}
+	//   * limits - reports the limits instead of resizing the container
+	//   * -plist converts diskutil's output from human-readable to the plist format
+	cmdResizeLimits := []string{"diskutil", "apfs", "resizeContainer", id, "limits", string(d.outputFormat())}
 
-	// Execute the diskutil apfs resizeContainer command and store the output
-	cmdOut, err := util.ExecuteCommand(ctx, cmdResizeContainer, "", nil, nil)
+	// Execute the diskutil apfs resizeContainer limits command and store the output
+	cmdOut, err := util.ExecuteCommand(ctx, cmdResizeLimits, "", nil, nil)
 	if err != nil {
-		return cmdOut.Stdout, fmt.Errorf("diskutil: failed to run diskutil command to resize the container, stderr [%s]: %w", cmdOut.Stderr, err)
+		return cmdOut.Stdout, fmt.Errorf("diskutil: failed to run diskutil command to fetch the container's resize limits: %w", newDiskUtilError(err))
 	}
 
 	return cmdOut.Stdout, nil
 }
+
+// ListSnapshots uses the macOS diskutil apfs listSnapshots command to list the snapshots of the specified volume (e.g.
+// Time Machine local snapshots) in a plist format by passing the -plist arg (or the Format's arg).
+func (d *DiskUtilityCmd) ListSnapshots(ctx context.Context, volumeID string) (string, error) {
+	// Execute the diskutil apfs listSnapshots command and store the output
+	cmdOut, err := util.ExecuteCommand(ctx, listSnapshotsCommand(volumeID, d.outputFormat()), "", nil, nil)
+	if err != nil {
+		return cmdOut.Stdout, fmt.Errorf("diskutil: failed to run diskutil command to list snapshots: %w", newDiskUtilError(err))
+	}
+
+	return cmdOut.Stdout, nil
+}
+
+// DeleteSnapshot uses the macOS diskutil apfs deleteSnapshot command to delete the snapshot with the given UUID from the
+// specified volume. This process requires root access.
+func (d *DiskUtilityCmd) DeleteSnapshot(ctx context.Context, volumeID string, uuid string) (string, error) {
+	// Execute the diskutil apfs deleteSnapshot command and store the output
+	cmdOut, err := util.ExecuteCommand(withoutCommandTimeout(ctx), deleteSnapshotCommand(volumeID, uuid), "", nil, nil)
+	if err != nil {
+		return cmdOut.Stdout, fmt.Errorf("diskutil: failed to run diskutil command to delete the snapshot: %w", newDiskUtilError(err))
+	}
+
+	return cmdOut.Stdout, nil
+}
+
+// AddVolume uses the macOS diskutil apfs addVolume command to add a new volume with the given name and format (e.g.
+// APFS or "Case-sensitive APFS") to the specified container. This process requires root access.
+func (d *DiskUtilityCmd) AddVolume(ctx context.Context, containerID string, name string, format string) (string, error) {
+	// Execute the diskutil apfs addVolume command and store the output
+	cmdOut, err := util.ExecuteCommand(withoutCommandTimeout(ctx), addVolumeCommand(containerID, name, format), "", nil, nil)
+	if err != nil {
+		return cmdOut.Stdout, fmt.Errorf("diskutil: failed to run diskutil command to add the volume: %w", newDiskUtilError(err))
+	}
+
+	return cmdOut.Stdout, nil
+}
+
+// UnlockVolume uses the macOS diskutil apfs unlockVolume command to unlock the specified encrypted volume. The
+// passphrase is given to diskutil on stdin, rather than as an argument, so it isn't exposed in the process table.
+// This process requires root access.
+func (d *DiskUtilityCmd) UnlockVolume(ctx context.Context, id string, passphrase string) (string, error) {
+	// Execute the diskutil apfs unlockVolume command and store the output
+	cmdOut, err := executeCommandWithInput(withoutCommandTimeout(ctx), unlockVolumeCommand(id), passphrase)
+	if err != nil {
+		return cmdOut.Stdout, fmt.Errorf("diskutil: failed to run diskutil command to unlock the volume: %w", newDiskUtilError(err))
+	}
+
+	return cmdOut.Stdout, nil
+}
+
+// listSnapshotsCommand creates the command used for executing macOS's diskutil to list a volume's snapshots.
+//   - apfs - specifies that a virtual APFS volume is going to be queried
+//   - listSnapshots - indicates that the volume's snapshots are going to be listed
+//   - volumeID - the device identifier for the volume
+//   - format - converts diskutil's output from human-readable to the given format (e.g. -plist)
+func listSnapshotsCommand(volumeID string, format OutputFormat) []string {
+	return []string{"diskutil", "apfs", "listSnapshots", normalizeDeviceNode(volumeID), string(format)}
+}
+
+// deleteSnapshotCommand creates the command used for executing macOS's diskutil to delete a volume's snapshot.
+//   - apfs - specifies that a virtual APFS volume is going to be modified
+//   - deleteSnapshot - indicates that a snapshot is going to be deleted
+//   - volumeID - the device identifier for the volume
+//   - -uuid - selects the snapshot to delete by its UUID
+func deleteSnapshotCommand(volumeID string, uuid string) []string {
+	return []string{"diskutil", "apfs", "deleteSnapshot", normalizeDeviceNode(volumeID), "-uuid", uuid}
+}
+
+// addVolumeCommand creates the command used for executing macOS's diskutil to add a volume to a container.
+//   - apfs - specifies that a virtual APFS volume is going to be created
+//   - addVolume - indicates that a volume is going to be added
+//   - containerID - the device identifier for the container
+//   - format - the file system of the new volume (e.g. APFS)
+//   - name - the name of the new volume
+func addVolumeCommand(containerID, name, format string) []string {
+	return []string{"diskutil", "apfs", "addVolume", normalizeDeviceNode(containerID), format, name}
+}
+
+// unlockVolumeCommand creates the command used for executing macOS's diskutil to unlock an encrypted volume.
+//   - apfs - specifies that a virtual APFS volume is going to be modified
+//   - unlockVolume - indicates that a volume is going to be unlocked
+//   - id - the device identifier for the volume
+//   - -stdinpassphrase - reads the passphrase from stdin so it's never an argument
+func unlockVolumeCommand(id string) []string {
+	return []string{"diskutil", "apfs", "unlockVolume", normalizeDeviceNode(id), "-stdinpassphrase"}
+}
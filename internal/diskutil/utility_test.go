@@ -0,0 +1,361 @@
+package diskutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+	"github.com/aws/ec2-macos-utils/internal/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMountCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want []string
+	}{
+		{name: "with device id", id: "disk2s1", want: []string{"diskutil", "mount", "disk2s1"}},
+		{name: "with device node", id: "/dev/disk2s1", want: []string{"diskutil", "mount", "disk2s1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, mountCommand(tt.id), "should mount the device id")
+		})
+	}
+}
+
+func TestUnmountCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		id    string
+		force bool
+		want  []string
+	}{
+		{name: "with device id", id: "disk2s1", want: []string{"diskutil", "unmount", "disk2s1"}},
+		{name: "with device node", id: "/dev/disk2s1", want: []string{"diskutil", "unmount", "disk2s1"}},
+		{name: "with force", id: "disk2s1", force: true, want: []string{"diskutil", "unmount", "force", "disk2s1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, unmountCommand(tt.id, tt.force), "should unmount the device id")
+		})
+	}
+}
+
+func TestEraseVolumeCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want []string
+	}{
+		{name: "with device id", id: "disk5s1", want: []string{"diskutil", "eraseVolume", "APFS", "Scratch", "disk5s1"}},
+		{name: "with device node", id: "/dev/disk5s1", want: []string{"diskutil", "eraseVolume", "APFS", "Scratch", "disk5s1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, eraseVolumeCommand(tt.id, "APFS", "Scratch"), "should erase the device id")
+		})
+	}
+}
+
+func TestRepairVolumeCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want []string
+	}{
+		{name: "with device id", id: "disk2", want: []string{"diskutil", "repairVolume", "disk2"}},
+		{name: "with device node", id: "/dev/disk2", want: []string{"diskutil", "repairVolume", "disk2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, repairVolumeCommand(tt.id), "should repair the device id")
+		})
+	}
+}
+
+func TestResizeContainerCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []types.ResizeOption
+		want []string
+	}{
+		{name: "without options", want: []string{"diskutil", "apfs", "resizeContainer", "disk1", "0"}},
+		{
+			name: "with options",
+			opts: []types.ResizeOption{types.ResizeDontAutoMount, types.ResizeNoEFIUpdate},
+			want: []string{"diskutil", "apfs", "resizeContainer", "disk1", "0", "-dontAutoMount", "-noEFIUpdate"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, resizeContainerCommand("disk1", "0", tt.opts), "should append the options in order")
+		})
+	}
+}
+
+func TestRenameVolumeCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want []string
+	}{
+		{name: "with device id", id: "disk5s1", want: []string{"diskutil", "rename", "disk5s1", "Data"}},
+		{name: "with device node", id: "/dev/disk5s1", want: []string{"diskutil", "rename", "disk5s1", "Data"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, renameVolumeCommand(tt.id, "Data"), "should rename the device id")
+		})
+	}
+}
+
+func TestAddVolumeCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		containerID string
+		want        []string
+	}{
+		{name: "with device id", containerID: "disk5", want: []string{"diskutil", "apfs", "addVolume", "disk5", "APFS", "Data"}},
+		{name: "with device node", containerID: "/dev/disk5", want: []string{"diskutil", "apfs", "addVolume", "disk5", "APFS", "Data"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, addVolumeCommand(tt.containerID, "Data", "APFS"), "should add the volume to the container")
+		})
+	}
+}
+
+func TestRepairDisk(t *testing.T) {
+	var actualArgv []string
+	var actualInput string
+	t.Cleanup(func() { executeCommandWithInput = util.ExecuteCommandWithInput })
+	executeCommandWithInput = func(ctx context.Context, c []string, input string) (util.CommandOutput, error) {
+		actualArgv, actualInput = c, input
+
+		return util.CommandOutput{Stdout: "Finished partition map repair on disk0\n"}, nil
+	}
+
+	_, err := (&DiskUtilityCmd{}).RepairDisk(context.Background(), "disk0")
+
+	assert.NoError(t, err, "should be able to repair the disk")
+	assert.Equal(t, []string{"diskutil", "repairDisk", "disk0"}, actualArgv, "should repair the device id")
+	assert.Equal(t, "y\n", actualInput, "should confirm the repair exactly once on stdin")
+}
+
+func TestUnlockVolumeCommand(t *testing.T) {
+	const passphrase = "correct horse battery staple"
+
+	var actualArgv []string
+	var actualInput string
+	t.Cleanup(func() { executeCommandWithInput = util.ExecuteCommandWithInput })
+	executeCommandWithInput = func(ctx context.Context, c []string, input string) (util.CommandOutput, error) {
+		actualArgv, actualInput = c, input
+
+		return util.CommandOutput{Stdout: "Unlocking any cryptographic user on APFS Volume disk5s1\n"}, nil
+	}
+
+	_, err := (&DiskUtilityCmd{}).UnlockVolume(context.Background(), "/dev/disk5s1", passphrase)
+
+	assert.NoError(t, err, "should be able to unlock the volume")
+	assert.Equal(t, []string{"diskutil", "apfs", "unlockVolume", "disk5s1", "-stdinpassphrase"}, actualArgv, "should unlock the device id")
+	assert.Equal(t, passphrase, actualInput, "should pass the passphrase on stdin")
+	for _, arg := range actualArgv {
+		assert.NotContains(t, arg, passphrase, "shouldn't pass the passphrase as an argument")
+	}
+}
+
+func TestVerifyCommands(t *testing.T) {
+	tests := []struct {
+		name    string
+		command func(id string) []string
+		id      string
+		want    []string
+	}{
+		{name: "disk with device id", command: verifyDiskCommand, id: "disk0", want: []string{"diskutil", "verifyDisk", "disk0"}},
+		{name: "disk with device node", command: verifyDiskCommand, id: "/dev/disk0", want: []string{"diskutil", "verifyDisk", "disk0"}},
+		{name: "volume with device id", command: verifyVolumeCommand, id: "disk1", want: []string{"diskutil", "verifyVolume", "disk1"}},
+		{name: "volume with device node", command: verifyVolumeCommand, id: "/dev/disk1", want: []string{"diskutil", "verifyVolume", "disk1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.command(tt.id), "should verify the device id")
+		})
+	}
+}
+
+func TestSnapshotCommands(t *testing.T) {
+	const uuid = "4B2E0C19-6E4F-4A43-9C63-5D2C0B7A5F01"
+
+	assert.Equal(t, []string{"diskutil", "apfs", "listSnapshots", "disk1s1", "-plist"}, listSnapshotsCommand("/dev/disk1s1", OutputPlist),
+		"should list the volume's snapshots as a plist")
+	assert.Equal(t, []string{"diskutil", "apfs", "listSnapshots", "disk1s1", "-json"}, listSnapshotsCommand("disk1s1", OutputJSON),
+		"should list the volume's snapshots as JSON")
+	assert.Equal(t, []string{"diskutil", "apfs", "deleteSnapshot", "disk1s1", "-uuid", uuid}, deleteSnapshotCommand("disk1s1", uuid),
+		"should delete the snapshot by its UUID")
+}
+
+func TestParseMountPoint(t *testing.T) {
+	const mounted = `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>DeviceIdentifier</key>
+	<string>disk2s1</string>
+	<key>MountPoint</key>
+	<string>/Volumes/Scratch</string>
+</dict>
+</plist>`
+	const unmounted = `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>DeviceIdentifier</key>
+	<string>disk2s1</string>
+	<key>MountPoint</key>
+	<string></string>
+</dict>
+</plist>`
+
+	got, err := parseMountPoint(mounted, OutputPlist)
+	assert.NoError(t, err, "should parse the mount point of a mounted volume")
+	assert.Equal(t, "/Volumes/Scratch", got, "should parse the mount point")
+
+	_, err = parseMountPoint(unmounted, OutputPlist)
+	assert.Error(t, err, "should fail for a volume without a mount point")
+
+	_, err = parseMountPoint("this is not a plist", OutputPlist)
+	assert.Error(t, err, "should fail for invalid volume information")
+
+	got, err = parseMountPoint(`{"DeviceIdentifier":"disk2s1","MountPoint":"/Volumes/Scratch"}`, OutputJSON)
+	assert.NoError(t, err, "should parse the mount point from JSON volume information")
+	assert.Equal(t, "/Volumes/Scratch", got, "should parse the mount point")
+}
+
+func TestDiskUtilError(t *testing.T) {
+	_, err := util.ExecuteCommand(context.Background(), []string{"sh", "-c", "echo 'Resource busy' >&2; exit 1"}, "", nil, nil)
+	if !assert.Error(t, err, "should fail to run the command") {
+		return
+	}
+
+	// Errors are wrapped with context by the commands so check the typed error survives wrapping
+	wrapped := fmt.Errorf("diskutil: failed to run diskutil command: %w", newDiskUtilError(err))
+
+	var diskutilErr DiskUtilError
+	if assert.True(t, errors.As(wrapped, &diskutilErr), "should be a DiskUtilError") {
+		assert.Equal(t, 1, diskutilErr.ExitCode(), "should carry the command's exit code")
+		assert.Equal(t, "Resource busy\n", diskutilErr.Stderr(), "should carry the command's stderr")
+	}
+	var cmdErr util.CommandError
+	assert.True(t, errors.As(wrapped, &cmdErr), "should wrap the command's CommandError")
+	var exitErr *exec.ExitError
+	assert.True(t, errors.As(wrapped, &exitErr), "should wrap the command's error")
+}
+
+func TestDiskUtilityCmd_WithoutDiskutil(t *testing.T) {
+	// Nothing resolves on an empty directory, including diskutil
+	t.Setenv("PATH", t.TempDir())
+	d := &DiskUtilityCmd{}
+
+	_, listErr := d.List(context.Background(), nil)
+	_, infoErr := d.Info(context.Background(), "disk1")
+	_, repairErr := d.RepairDisk(context.Background(), "disk0")
+
+	assert.True(t, errors.Is(listErr, ErrDiskutilNotFound), "should report that diskutil wasn't found when listing")
+	assert.True(t, errors.Is(infoErr, ErrDiskutilNotFound), "should report that diskutil wasn't found for info")
+	assert.True(t, errors.Is(repairErr, ErrDiskutilNotFound), "should report that diskutil wasn't found when repairing")
+	var diskutilErr DiskUtilError
+	if assert.True(t, errors.As(listErr, &diskutilErr), "should be a DiskUtilError") {
+		assert.Equal(t, -1, diskutilErr.ExitCode(), "shouldn't have an exit code since diskutil didn't run")
+	}
+}
+
+func TestDiskUtilityCmd_CommandTimeout(t *testing.T) {
+	// A stand-in diskutil which is slower than the command timeout for every verb
+	dir := t.TempDir()
+	script := "#!/bin/sh\nsleep 1\n"
+	if err := os.WriteFile(filepath.Join(dir, "diskutil"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	ctx := util.WithCommandTimeout(context.Background(), 50*time.Millisecond)
+	d := &DiskUtilityCmd{}
+
+	_, listErr := d.List(ctx, nil)
+	_, infoErr := d.Info(ctx, "disk1")
+	_, resizeErr := d.ResizeContainer(ctx, "disk1", "0")
+	_, renameErr := d.RenameVolume(ctx, "disk4s1", "Scratch")
+
+	assert.True(t, errors.Is(listErr, context.DeadlineExceeded), "should bound list by the command timeout")
+	assert.True(t, errors.Is(infoErr, context.DeadlineExceeded), "should bound info by the command timeout")
+	assert.NoError(t, resizeErr, "shouldn't kill the resize once the command timeout elapses")
+	assert.NoError(t, renameErr, "shouldn't kill the rename once the command timeout elapses")
+}
+
+func TestDiskUtilityCmd_WithDiskNotFound(t *testing.T) {
+	// A stand-in diskutil which can't find any disk
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho 'Unable to find disk for disk5' >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(dir, "diskutil"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	d := &DiskUtilityCmd{}
+
+	_, infoErr := d.Info(context.Background(), "disk5")
+	_, eraseErr := d.EraseVolume(context.Background(), "disk5", "APFS", "Data")
+	_, verifyErr := d.VerifyDisk(context.Background(), "disk5")
+
+	for _, err := range []error{infoErr, eraseErr, verifyErr} {
+		var notFoundErr DiskNotFoundError
+		if assert.True(t, errors.As(err, &notFoundErr), "should be a DiskNotFoundError") {
+			assert.Equal(t, "disk5", notFoundErr.DeviceID(), "should extract the device id")
+		}
+		var diskutilErr DiskUtilError
+		if assert.True(t, errors.As(err, &diskutilErr), "should keep the DiskUtilError") {
+			assert.Equal(t, 1, diskutilErr.ExitCode(), "should carry diskutil's exit code")
+		}
+	}
+}
+
+func TestNewDiskUtilError_WithDiskNotFound(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr string
+		wantID string
+	}{
+		{name: "with device id", stderr: "Unable to find disk for disk5\n", wantID: "disk5"},
+		{name: "with device node", stderr: "Unable to find disk for /dev/disk5s2\n", wantID: "disk5s2"},
+		{name: "with other output", stderr: "Started partition map repair on disk5\nUnable to find disk for disk5\n", wantID: "disk5"},
+		{name: "with other error", stderr: "Error: -69877: Couldn't open device\n"},
+		{name: "without stderr", stderr: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cmdErr := util.ExecuteCommand(context.Background(), []string{"sh", "-c", `printf '%s' "$1" >&2; exit 1`, "sh", tt.stderr}, "", nil, nil)
+			err := newDiskUtilError(cmdErr)
+
+			// Errors are wrapped with context by the commands so check the typed errors survive wrapping
+			wrapped := fmt.Errorf("diskutil: failed to run diskutil command to fetch disk information: %w", err)
+			var diskutilErr DiskUtilError
+			if assert.True(t, errors.As(wrapped, &diskutilErr), "should keep the DiskUtilError") {
+				assert.Equal(t, 1, diskutilErr.ExitCode(), "should carry the command's exit code")
+				assert.Equal(t, tt.stderr, diskutilErr.Stderr(), "should carry the command's stderr")
+			}
+			var notFoundErr DiskNotFoundError
+			if tt.wantID == "" {
+				assert.False(t, errors.As(wrapped, &notFoundErr), "shouldn't be a not found error")
+				return
+			}
+			if assert.True(t, errors.As(wrapped, &notFoundErr), "should be a DiskNotFoundError") {
+				assert.Equal(t, tt.wantID, notFoundErr.DeviceID(), "should extract the device id")
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package diskutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/sirupsen/logrus"
+)
+
+// AddVolume adds a new volume with the given name and format to the APFS container with the given device identifier
+// by performing the following operations:
+//  1. Fetch the types.DiskInfo for the container and verify that it's APFS (see canAPFSResize).
+//  2. List the container's volumes before adding the volume.
+//  3. Add the volume with DiskUtil.AddVolume.
+//  4. List the container's volumes again to find the device identifier of the new volume.
+//
+// The device identifier of the new volume is returned. In a dry run (see Dryrun) the volume isn't added and the wrapped
+// ErrReadOnly is returned.
+func AddVolume(ctx context.Context, u DiskUtil, containerID, name, format string) (string, error) {
+	if strings.TrimSpace(containerID) == "" {
+		return "", errors.New("empty container identifier")
+	}
+	if strings.TrimSpace(format) == "" {
+		return "", errors.New("empty volume format")
+	}
+	if err := validateVolumeName(name); err != nil {
+		return "", fmt.Errorf("invalid volume name: %w", err)
+	}
+
+	container, err := u.Info(ctx, containerID)
+	if err != nil {
+		return "", fmt.Errorf("cannot fetch container information: %w", err)
+	}
+
+	logrus.WithField("device_id", container.DeviceIdentifier).Info("Checking that container is APFS...")
+	if err := canAPFSResize(container); err != nil {
+		return "", fmt.Errorf("cannot add volume to %s: %w", container.DeviceIdentifier, err)
+	}
+
+	existing, err := containerVolumes(ctx, u, container.DeviceIdentifier)
+	if err != nil {
+		return "", err
+	}
+	// Copy the existing volumes since the listed partitions may be shared with the next listing
+	before := make(map[string]bool, len(existing))
+	for _, volume := range existing {
+		before[volume.DeviceIdentifier] = true
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"device_id": container.DeviceIdentifier,
+		"format":    format,
+		"name":      name,
+	}).Info("Adding volume...")
+	out, err := u.AddVolume(ctx, container.DeviceIdentifier, name, format)
+	logrus.WithField("out", out).Debug("AddVolume output")
+	if err != nil {
+		return "", fmt.Errorf("unable to add volume: %w", err)
+	}
+
+	added, err := containerVolumes(ctx, u, container.DeviceIdentifier)
+	if err != nil {
+		return "", err
+	}
+	for _, volume := range added {
+		if !before[volume.DeviceIdentifier] {
+			return volume.DeviceIdentifier, nil
+		}
+	}
+
+	return "", fmt.Errorf("volume %s was added but isn't listed in %s", name, container.DeviceIdentifier)
+}
+
+// containerVolumes lists the APFS volumes of the container with the given device identifier.
+func containerVolumes(ctx context.Context, u DiskUtil, containerID string) ([]types.APFSVolume, error) {
+	partitions, err := u.List(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list partitions: %w", err)
+	}
+
+	container, ok := partitions.FindDisk(containerID)
+	if !ok {
+		return nil, fmt.Errorf("container %s isn't listed", containerID)
+	}
+
+	return container.APFSVolumes, nil
+}
+
+// UnlockVolume unlocks the encrypted volume with the given device identifier using the passphrase. The passphrase is
+// never logged. In a dry run (see Dryrun) the unlock is skipped and the wrapped ErrReadOnly is returned.
+func UnlockVolume(ctx context.Context, u DiskUtil, id, passphrase string) error {
+	if strings.TrimSpace(id) == "" {
+		return errors.New("empty volume identifier")
+	}
+	if passphrase == "" {
+		return errors.New("empty passphrase")
+	}
+
+	logrus.WithField("device_id", id).Info("Unlocking volume...")
+	out, err := u.UnlockVolume(ctx, id, passphrase)
+	logrus.WithField("out", out).Debug("UnlockVolume output")
+	if err != nil {
+		return fmt.Errorf("unable to unlock volume: %w", err)
+	}
+
+	return nil
+}
+
+// VolumeResult is the outcome of mounting or unmounting one of a container's volumes (see MountAll and UnmountAll).
+type VolumeResult struct {
+	// DeviceID is the device identifier of the volume.
+	DeviceID string
+	// MountPoint is where the volume was mounted, only set by MountAll.
+	MountPoint string
+	// Skipped is true when the volume wasn't mounted or unmounted since it's a dry run (see Dryrun).
+	Skipped bool
+	// Err is why the volume couldn't be mounted or unmounted, if it couldn't.
+	Err error
+}
+
+// MountAll mounts each APFS volume listed for the container with the given device identifier. Every volume is attempted
+// even if an earlier one fails. The result of each volume is returned in the order they're listed, along with an error
+// naming the volumes which failed, if any. In a dry run (see Dryrun) the volumes are skipped rather than mounted, which
+// isn't a failure.
+func MountAll(ctx context.Context, u DiskUtil, containerID string) ([]VolumeResult, error) {
+	return forEachVolume(ctx, u, containerID, "mount", func(id string) VolumeResult {
+		logrus.WithField("device_id", id).Info("Mounting volume...")
+		mountPoint, err := u.Mount(ctx, id)
+		if errors.Is(err, ErrReadOnly) {
+			logrus.WithError(err).WithField("device_id", id).Warn("Would have mounted volume")
+			return VolumeResult{DeviceID: id, Skipped: true}
+		}
+
+		return VolumeResult{DeviceID: id, MountPoint: mountPoint, Err: err}
+	})
+}
+
+// UnmountAll unmounts each APFS volume listed for the container with the given device identifier. Every volume is
+// attempted even if an earlier one fails. The result of each volume is returned in the order they're listed, along
+// with an error naming the volumes which failed, if any. In a dry run (see Dryrun) the volumes are skipped rather than
+// unmounted, which isn't a failure.
+func UnmountAll(ctx context.Context, u DiskUtil, containerID string) ([]VolumeResult, error) {
+	return forEachVolume(ctx, u, containerID, "unmount", func(id string) VolumeResult {
+		logrus.WithField("device_id", id).Info("Unmounting volume...")
+		out, err := u.Unmount(ctx, id, false)
+		logrus.WithField("out", out).Debug("Unmount output")
+		if errors.Is(err, ErrReadOnly) {
+			logrus.WithError(err).WithField("device_id", id).Warn("Would have unmounted volume")
+			return VolumeResult{DeviceID: id, Skipped: true}
+		}
+
+		return VolumeResult{DeviceID: id, Err: err}
+	})
+}
+
+// forEachVolume calls fn for each APFS volume listed for the container with the given device identifier and collects
+// the results. The action (e.g. "mount") describes fn in the error naming the volumes which failed.
+func forEachVolume(ctx context.Context, u DiskUtil, containerID, action string, fn func(id string) VolumeResult) ([]VolumeResult, error) {
+	if strings.TrimSpace(containerID) == "" {
+		return nil, errors.New("empty container identifier")
+	}
+
+	volumes, err := containerVolumes(ctx, u, normalizeDeviceNode(containerID))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]VolumeResult, 0, len(volumes))
+	var failed []string
+	for _, volume := range volumes {
+		result := fn(volume.DeviceIdentifier)
+		if result.Err != nil {
+			logrus.WithError(result.Err).WithField("device_id", volume.DeviceIdentifier).Errorf("Failed to %s volume", action)
+			failed = append(failed, volume.DeviceIdentifier)
+		}
+		results = append(results, result)
+	}
+
+	if len(failed) > 0 {
+		return results, fmt.Errorf("failed to %s %d of %d volumes: %s", action, len(failed), len(volumes), strings.Join(failed, ", "))
+	}
+
+	return results, nil
+}
@@ -0,0 +1,233 @@
+package diskutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/ec2-macos-utils/internal/diskutil/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// addVolumeFixture creates a FakeUtil with the APFS container disk5 (holding the volume disk5s1) and the non-APFS disk6.
+// Adding a volume to disk5 lists it as disk5s2.
+func addVolumeFixture() *FakeUtil {
+	partitions := &types.SystemPartitions{
+		AllDisks: []string{"disk5", "disk5s1", "disk6"},
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: "disk5",
+				APFSVolumes:      []types.APFSVolume{{DeviceIdentifier: "disk5s1", VolumeName: "Scratch"}},
+			},
+			{DeviceIdentifier: "disk6", Content: "GUID_partition_scheme"},
+		},
+	}
+
+	fake := NewFakeUtil(partitions, map[string]*types.DiskInfo{
+		"disk5": {
+			ContainerInfo:    types.ContainerInfo{FilesystemType: "apfs"},
+			DeviceIdentifier: "disk5",
+		},
+		"disk6": {
+			DeviceIdentifier: "disk6",
+			Content:          "GUID_partition_scheme",
+		},
+	})
+	fake.AddVolumeFunc = func(ctx context.Context, containerID string, name string, format string) (string, error) {
+		container := &partitions.AllDisksAndPartitions[0]
+		container.APFSVolumes = append(container.APFSVolumes, types.APFSVolume{DeviceIdentifier: "disk5s2", VolumeName: name})
+
+		return "", nil
+	}
+
+	return fake
+}
+
+func TestAddVolume(t *testing.T) {
+	fake := addVolumeFixture()
+
+	id, err := AddVolume(context.Background(), fake, "disk5", "Data", "APFS")
+
+	assert.NoError(t, err, "should add the volume")
+	assert.Equal(t, "disk5s2", id, "should find the new volume")
+	assert.Contains(t, fake.Calls(), FakeCall{Method: "AddVolume", Args: []string{"disk5", "Data", "APFS"}})
+}
+
+func TestAddVolume_WithoutAPFS(t *testing.T) {
+	fake := addVolumeFixture()
+
+	_, err := AddVolume(context.Background(), fake, "disk6", "Data", "APFS")
+
+	assert.Error(t, err, "should refuse to add a volume to a disk which isn't APFS")
+	for _, call := range fake.Calls() {
+		assert.NotEqual(t, "AddVolume", call.Method, "shouldn't add the volume")
+	}
+}
+
+func TestAddVolume_WithInvalidName(t *testing.T) {
+	fake := addVolumeFixture()
+
+	_, err := AddVolume(context.Background(), fake, "disk5", "Data/Scratch", "APFS")
+
+	assert.Error(t, err, "should reject the name")
+	assert.Empty(t, fake.Calls(), "shouldn't call diskutil")
+}
+
+func TestAddVolume_WithoutNewVolume(t *testing.T) {
+	fake := addVolumeFixture()
+	fake.AddVolumeFunc = nil
+
+	_, err := AddVolume(context.Background(), fake, "disk5", "Data", "APFS")
+
+	assert.Error(t, err, "should fail when the new volume isn't listed")
+}
+
+func TestAddVolume_WithDryrun(t *testing.T) {
+	fake := addVolumeFixture()
+
+	id, err := AddVolume(context.Background(), Dryrun(fake), "disk5", "Data", "APFS")
+
+	assert.True(t, errors.Is(err, ErrReadOnly), "should skip adding the volume in a dry run")
+	assert.Empty(t, id)
+	for _, call := range fake.Calls() {
+		assert.NotEqual(t, "AddVolume", call.Method, "shouldn't add the volume in a dry run")
+	}
+}
+
+func TestUnlockVolume(t *testing.T) {
+	var actualPassphrase string
+	fake := NewFakeUtil(nil, nil)
+	fake.UnlockVolumeFunc = func(ctx context.Context, id string, passphrase string) (string, error) {
+		actualPassphrase = passphrase
+
+		return "", nil
+	}
+
+	err := UnlockVolume(context.Background(), fake, "disk5s1", "passphrase")
+
+	assert.NoError(t, err, "should unlock the volume")
+	assert.Equal(t, "passphrase", actualPassphrase, "should unlock with the passphrase")
+	assert.Equal(t, []FakeCall{{Method: "UnlockVolume", Args: []string{"disk5s1"}}}, fake.Calls())
+}
+
+func TestUnlockVolume_WithoutPassphrase(t *testing.T) {
+	fake := NewFakeUtil(nil, nil)
+
+	err := UnlockVolume(context.Background(), fake, "disk5s1", "")
+
+	assert.Error(t, err, "should require a passphrase")
+	assert.Empty(t, fake.Calls(), "shouldn't call diskutil")
+}
+
+func TestUnlockVolume_WithDryrun(t *testing.T) {
+	fake := NewFakeUtil(nil, nil)
+
+	err := UnlockVolume(context.Background(), Dryrun(fake), "disk5s1", "passphrase")
+
+	assert.True(t, errors.Is(err, ErrReadOnly), "should skip the unlock in a dry run")
+	assert.Empty(t, fake.Calls(), "shouldn't unlock the volume")
+}
+
+// mountAllFixture creates a FakeUtil with the APFS container disk5 holding the volumes disk5s1, disk5s2, and disk5s3.
+func mountAllFixture() *FakeUtil {
+	partitions := &types.SystemPartitions{
+		AllDisks: []string{"disk5", "disk5s1", "disk5s2", "disk5s3"},
+		AllDisksAndPartitions: []types.DiskPart{
+			{
+				DeviceIdentifier: "disk5",
+				APFSVolumes: []types.APFSVolume{
+					{DeviceIdentifier: "disk5s1", VolumeName: "Data"},
+					{DeviceIdentifier: "disk5s2", VolumeName: "Scratch"},
+					{DeviceIdentifier: "disk5s3", VolumeName: "Cache"},
+				},
+			},
+		},
+	}
+
+	return NewFakeUtil(partitions, map[string]*types.DiskInfo{
+		"disk5s1": {DeviceIdentifier: "disk5s1", MountPoint: "/Volumes/Data"},
+		"disk5s2": {DeviceIdentifier: "disk5s2", MountPoint: "/Volumes/Scratch"},
+		"disk5s3": {DeviceIdentifier: "disk5s3", MountPoint: "/Volumes/Cache"},
+	})
+}
+
+func TestMountAll(t *testing.T) {
+	fake := mountAllFixture()
+
+	results, err := MountAll(context.Background(), fake, "/dev/disk5")
+
+	expected := []VolumeResult{
+		{DeviceID: "disk5s1", MountPoint: "/Volumes/Data"},
+		{DeviceID: "disk5s2", MountPoint: "/Volumes/Scratch"},
+		{DeviceID: "disk5s3", MountPoint: "/Volumes/Cache"},
+	}
+
+	assert.NoError(t, err, "should mount every volume")
+	assert.Equal(t, expected, results, "should report the mount point of each volume")
+}
+
+func TestMountAll_WithDryrun(t *testing.T) {
+	fake := mountAllFixture()
+
+	results, err := MountAll(context.Background(), Dryrun(fake), "disk5")
+
+	assert.NoError(t, err, "shouldn't fail in a dry run")
+	if assert.Len(t, results, 3) {
+		for _, result := range results {
+			assert.True(t, result.Skipped, "should skip mounting %s", result.DeviceID)
+		}
+	}
+	for _, call := range fake.Calls() {
+		assert.NotEqual(t, "Mount", call.Method, "shouldn't mount the volumes")
+	}
+}
+
+func TestUnmountAll_WithUnmountErr(t *testing.T) {
+	fake := mountAllFixture()
+	unmountErr := errors.New("Volume disk5s2 failed to unmount: dissented by PID 123")
+	fake.UnmountFunc = func(ctx context.Context, id string, force bool) (string, error) {
+		if id == "disk5s2" {
+			return "", unmountErr
+		}
+
+		return "", nil
+	}
+
+	results, err := UnmountAll(context.Background(), fake, "disk5")
+
+	expected := []VolumeResult{
+		{DeviceID: "disk5s1"},
+		{DeviceID: "disk5s2", Err: unmountErr},
+		{DeviceID: "disk5s3"},
+	}
+
+	if assert.Error(t, err, "should fail when a volume can't be unmounted") {
+		assert.Contains(t, err.Error(), "failed to unmount 1 of 3 volumes: disk5s2", "should name the failed volume")
+	}
+	assert.Equal(t, expected, results, "should still unmount the other volumes")
+}
+
+func TestUnmountAll_WithDryrun(t *testing.T) {
+	fake := mountAllFixture()
+
+	results, err := UnmountAll(context.Background(), Dryrun(fake), "disk5")
+
+	assert.NoError(t, err, "shouldn't fail in a dry run")
+	if assert.Len(t, results, 3) {
+		for _, result := range results {
+			assert.True(t, result.Skipped, "should skip unmounting %s", result.DeviceID)
+		}
+	}
+	for _, call := range fake.Calls() {
+		assert.NotEqual(t, "Unmount", call.Method, "shouldn't unmount the volumes")
+	}
+}
+
+func TestUnmountAll_WithoutContainer(t *testing.T) {
+	fake := mountAllFixture()
+
+	_, err := UnmountAll(context.Background(), fake, "disk9")
+
+	assert.Error(t, err, "should fail when the container isn't listed")
+}
@@ -0,0 +1,162 @@
+// Package imds provides lookups of the instance's metadata from the EC2 instance metadata service (IMDS) using the
+// IMDSv2 session token flow.
+package imds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultEndpoint is the address of the instance metadata service.
+	DefaultEndpoint = "http://169.254.169.254"
+
+	// tokenPath is the path of the IMDSv2 session token.
+	tokenPath = "/latest/api/token"
+	// metadataPath is the path metadata categories are relative to.
+	metadataPath = "/latest/meta-data/"
+	// tokenHeader is the header which provides the session token with each metadata request.
+	tokenHeader = "X-aws-ec2-metadata-token"
+	// tokenTTLHeader is the header which requests the session token's lifetime.
+	tokenTTLHeader = "X-aws-ec2-metadata-token-ttl-seconds"
+	// tokenTTL is the lifetime (in seconds) requested for session tokens. Tokens are only used for a single lookup so
+	// they don't need to outlive it.
+	tokenTTL = 60
+	// maxResponseBytes limits how much of a response is read since metadata values are small.
+	maxResponseBytes = 1 << 20
+)
+
+// Client looks up the instance's metadata from IMDS.
+type Client struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+// New creates a new Client for the instance metadata service at DefaultEndpoint which makes requests with httpClient.
+func New(httpClient *http.Client) *Client {
+	return NewWithEndpoint(DefaultEndpoint, httpClient)
+}
+
+// NewWithEndpoint creates a new Client for the instance metadata service at the given endpoint (e.g.
+// "http://169.254.169.254") which makes requests with httpClient.
+func NewWithEndpoint(endpoint string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// InstanceID looks up the instance's ID (e.g. i-0123456789abcdef0).
+func (c *Client) InstanceID(ctx context.Context) (string, error) {
+	token, err := c.token(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return c.get(ctx, token, "instance-id")
+}
+
+// Region looks up the region the instance is running in (e.g. us-west-2).
+func (c *Client) Region(ctx context.Context) (string, error) {
+	token, err := c.token(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return c.get(ctx, token, "placement/region")
+}
+
+// BlockDeviceMappings looks up the instance's block device mappings, keyed by their virtual name (e.g. "root" or
+// "ebs1") with the device name as the value (e.g. "/dev/sda1"). Mappings only reflect the volumes attached at launch.
+func (c *Client) BlockDeviceMappings(ctx context.Context) (map[string]string, error) {
+	token, err := c.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := c.get(ctx, token, "block-device-mapping/")
+	if err != nil {
+		return nil, err
+	}
+
+	mappings := map[string]string{}
+	for _, name := range strings.Split(list, "\n") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		device, err := c.get(ctx, token, "block-device-mapping/"+name)
+		if err != nil {
+			return nil, err
+		}
+		mappings[name] = device
+	}
+
+	return mappings, nil
+}
+
+// token requests a new IMDSv2 session token.
+func (c *Client) token(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.endpoint+tokenPath, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(tokenTTLHeader, strconv.Itoa(tokenTTL))
+
+	token, err := c.do(req)
+	if err != nil {
+		return "", fmt.Errorf("imds: cannot get session token: %w", err)
+	}
+	if token == "" {
+		return "", errors.New("imds: empty session token")
+	}
+
+	return token, nil
+}
+
+// get fetches the metadata at the path (e.g. "instance-id") with the session token.
+func (c *Client) get(ctx context.Context, token, path string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+metadataPath+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(tokenHeader, token)
+
+	value, err := c.do(req)
+	if err != nil {
+		return "", fmt.Errorf("imds: cannot get %s: %w", path, err)
+	}
+
+	return value, nil
+}
+
+// do makes the request and returns the response body with surrounding whitespace trimmed. An error is returned for
+// unsuccessful response statuses.
+func (c *Client) do(req *http.Request) (string, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
@@ -0,0 +1,110 @@
+package imds
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testToken = "test-token"
+
+// newMetadataServer creates a server which emulates IMDSv2: session tokens are issued by PUT requests to the token path
+// and metadata is only served to requests with a valid token.
+func newMetadataServer(t *testing.T, metadata map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == tokenPath {
+			if r.Method != http.MethodPut || r.Header.Get(tokenTTLHeader) == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(testToken))
+			return
+		}
+
+		if r.Header.Get(tokenHeader) != testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		value, ok := metadata[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(value))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestClient_InstanceID(t *testing.T) {
+	server := newMetadataServer(t, map[string]string{
+		"/latest/meta-data/instance-id": "i-0123456789abcdef0",
+	})
+
+	id, err := NewWithEndpoint(server.URL, server.Client()).InstanceID(context.Background())
+
+	assert.NoError(t, err, "should look up the instance id")
+	assert.Equal(t, "i-0123456789abcdef0", id)
+}
+
+func TestClient_Region(t *testing.T) {
+	server := newMetadataServer(t, map[string]string{
+		"/latest/meta-data/placement/region": "us-west-2\n",
+	})
+
+	region, err := NewWithEndpoint(server.URL, server.Client()).Region(context.Background())
+
+	assert.NoError(t, err, "should look up the region")
+	assert.Equal(t, "us-west-2", region, "should trim surrounding whitespace")
+}
+
+func TestClient_BlockDeviceMappings(t *testing.T) {
+	server := newMetadataServer(t, map[string]string{
+		"/latest/meta-data/block-device-mapping/":     "ami\nroot\nebs1",
+		"/latest/meta-data/block-device-mapping/ami":  "/dev/sda1",
+		"/latest/meta-data/block-device-mapping/root": "/dev/sda1",
+		"/latest/meta-data/block-device-mapping/ebs1": "sdf",
+	})
+
+	mappings, err := NewWithEndpoint(server.URL, server.Client()).BlockDeviceMappings(context.Background())
+
+	assert.NoError(t, err, "should look up the block device mappings")
+	assert.Equal(t, map[string]string{"ami": "/dev/sda1", "root": "/dev/sda1", "ebs1": "sdf"}, mappings)
+}
+
+func TestClient_WithMissingMetadata(t *testing.T) {
+	server := newMetadataServer(t, map[string]string{})
+
+	_, err := NewWithEndpoint(server.URL, server.Client()).InstanceID(context.Background())
+
+	assert.Error(t, err, "should fail when the metadata isn't found")
+}
+
+func TestClient_WithoutToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// IMDS may be disabled on the instance
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, err := NewWithEndpoint(server.URL, server.Client()).Region(context.Background())
+
+	assert.Error(t, err, "should fail without a session token")
+}
+
+func TestClient_WithUnreachableEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	endpoint := server.URL
+	server.Close()
+
+	_, err := NewWithEndpoint(endpoint, nil).InstanceID(context.Background())
+
+	assert.Error(t, err, "should fail when the endpoint can't be reached")
+}
@@ -0,0 +1,48 @@
+package system
+
+import (
+	"context"
+	"runtime"
+	"strings"
+)
+
+// Arch is the CPU architecture of a Mac (e.g. Apple Silicon or Intel).
+type Arch string
+
+const (
+	// ArchUnknown is used when the architecture couldn't be determined.
+	ArchUnknown Arch = ""
+	// ArchARM64 is the architecture of Apple Silicon Macs.
+	ArchARM64 Arch = "arm64"
+	// ArchX86 is the architecture of Intel Macs.
+	ArchX86 Arch = "x86_64"
+)
+
+// DetectArch determines the CPU architecture from the hw.optional.arm64 sysctl, which reports the hardware's
+// architecture even when running translated by Rosetta. If the sysctl is missing (e.g. on older Intel releases) or
+// can't be read, the architecture the program was built for is used instead.
+func DetectArch(ctx context.Context, sysctl SysctlFunc) Arch {
+	out, err := sysctl(ctx, "hw.optional.arm64")
+	if err == nil {
+		switch strings.TrimSpace(out) {
+		case "1":
+			return ArchARM64
+		case "0":
+			return ArchX86
+		}
+	}
+
+	return archForGOARCH(runtime.GOARCH)
+}
+
+// archForGOARCH maps a Go architecture (e.g. runtime.GOARCH) to its Arch.
+func archForGOARCH(goarch string) Arch {
+	switch goarch {
+	case "arm64":
+		return ArchARM64
+	case "amd64":
+		return ArchX86
+	default:
+		return ArchUnknown
+	}
+}
@@ -0,0 +1,44 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectArch(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		err  error
+		want Arch
+	}{
+		{name: "Apple Silicon", out: "1\n", want: ArchARM64},
+		{name: "Intel", out: "0\n", want: ArchX86},
+		{name: "missing sysctl", err: fmt.Errorf("unknown oid"), want: archForGOARCH(runtime.GOARCH)},
+		{name: "unexpected output", out: "yes", want: archForGOARCH(runtime.GOARCH)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var name string
+			sysctl := func(ctx context.Context, n string) (string, error) {
+				name = n
+				return tt.out, tt.err
+			}
+
+			got := DetectArch(context.Background(), sysctl)
+
+			assert.Equal(t, "hw.optional.arm64", name, "should read the arm64 sysctl")
+			assert.Equal(t, tt.want, got, "should detect the expected architecture")
+		})
+	}
+}
+
+func TestArchForGOARCH(t *testing.T) {
+	assert.Equal(t, ArchARM64, archForGOARCH("arm64"))
+	assert.Equal(t, ArchX86, archForGOARCH("amd64"))
+	assert.Equal(t, ArchUnknown, archForGOARCH("riscv64"))
+}
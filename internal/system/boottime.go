@@ -0,0 +1,57 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/aws/ec2-macos-utils/internal/util"
+)
+
+// bootTimeExp is the regexp expression for the boot time reported by "sysctl -n kern.boottime" (e.g.
+// "{ sec = 1697040000, usec = 123456 } Wed Oct 11 16:00:00 2023").
+var bootTimeExp = regexp.MustCompile(`sec\s*=\s*([0-9]+),\s*usec\s*=\s*([0-9]+)`)
+
+// SysctlFunc fetches the raw value of the named sysctl.
+type SysctlFunc func(ctx context.Context, name string) (string, error)
+
+// Sysctl fetches the raw value of the named sysctl using the sysctl command.
+func Sysctl(ctx context.Context, name string) (string, error) {
+	out, err := util.ExecuteCommand(ctx, []string{"sysctl", "-n", name}, "", nil, nil)
+	if err != nil {
+		return "", fmt.Errorf("unable to read sysctl %s, stderr: [%s]: %w", name, out.Stderr, err)
+	}
+
+	return out.Stdout, nil
+}
+
+// BootTime fetches the time the system was last booted from the kern.boottime sysctl.
+func BootTime(ctx context.Context, sysctl SysctlFunc) (time.Time, error) {
+	out, err := sysctl(ctx, "kern.boottime")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return parseBootTime(out)
+}
+
+// parseBootTime parses the output of "sysctl -n kern.boottime" into a time.
+func parseBootTime(out string) (time.Time, error) {
+	match := bootTimeExp.FindStringSubmatch(out)
+	if match == nil {
+		return time.Time{}, fmt.Errorf("unexpected boot time format: %q", out)
+	}
+
+	sec, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid boot time seconds: %w", err)
+	}
+	usec, err := strconv.ParseInt(match[2], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid boot time microseconds: %w", err)
+	}
+
+	return time.Unix(sec, usec*int64(time.Microsecond)), nil
+}
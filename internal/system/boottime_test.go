@@ -0,0 +1,71 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBootTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		out     string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name:    "without output",
+			out:     "",
+			wantErr: true,
+		},
+		{
+			name: "with boot time",
+			out:  "{ sec = 1697040000, usec = 250000 } Wed Oct 11 16:00:00 2023\n",
+			want: time.Unix(1697040000, 250_000_000),
+		},
+		{
+			name:    "with unexpected output",
+			out:     "kern.boottime: unknown oid",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBootTime(tt.out)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.True(t, tt.want.Equal(got), "should parse expected boot time")
+		})
+	}
+}
+
+func TestBootTime(t *testing.T) {
+	var name string
+	sysctl := func(ctx context.Context, n string) (string, error) {
+		name = n
+		return "{ sec = 1697040000, usec = 0 } Wed Oct 11 16:00:00 2023", nil
+	}
+
+	got, err := BootTime(context.Background(), sysctl)
+
+	assert.NoError(t, err, "should be able to get boot time")
+	assert.Equal(t, "kern.boottime", name, "should read the boot time sysctl")
+	assert.True(t, time.Unix(1697040000, 0).Equal(got), "should get boot time")
+}
+
+func TestBootTime_WithSysctlErr(t *testing.T) {
+	sysctl := func(ctx context.Context, n string) (string, error) {
+		return "", fmt.Errorf("error")
+	}
+
+	_, err := BootTime(context.Background(), sysctl)
+
+	assert.Error(t, err, "shouldn't be able to get boot time with sysctl error")
+}
@@ -18,6 +18,9 @@ const (
 	Ventura
 	Sonoma
 	Sequoia
+	// Latest is any release newer than the newest known release (e.g. a future macOS 26) which is expected to behave
+	// like the newest known release.
+	Latest
 	CompatMode
 )
 
@@ -37,6 +40,8 @@ func (r Release) String() string {
 		return "Sonoma"
 	case Sequoia:
 		return "Sequoia"
+	case Latest:
+		return "Latest"
 	case CompatMode:
 		return "Compatability Mode"
 	default:
@@ -59,6 +64,8 @@ var (
 	sonomaConstraints = mustInitConstraint(semver.NewConstraint("~14"))
 	// sequoiaConstraints are the constraints used to identify Sequoia versions (15.x.x).
 	sequoiaConstraints = mustInitConstraint(semver.NewConstraint("~15"))
+	// latestConstraints are the constraints used to identify releases newer than Sequoia (16 and later).
+	latestConstraints = mustInitConstraint(semver.NewConstraint(">= 16"))
 	// compatModeConstraints are the constraints used to identify macOS Big Sur and later. This version is returned
 	// when the system is in compat mode (SYSTEM_VERSION_COMPAT=1).
 	compatModeConstraints = mustInitConstraint(semver.NewConstraint("~10.16"))
@@ -72,14 +79,54 @@ func mustInitConstraint(c *semver.Constraints, err error) *semver.Constraints {
 	return c
 }
 
-// Product identifies a macOS release and product version (e.g. Big Sur 11.x).
+// Product identifies a macOS release and product version (e.g. Big Sur 11.x) and, when known, the CPU architecture
+// it's running on.
 type Product struct {
 	Release
 	Version semver.Version
+	// Arch is the CPU architecture, if known (see DetectArch).
+	Arch Arch
 }
 
 func (p Product) String() string {
-	return fmt.Sprintf("macOS %s %s", p.Release, p.Version.String())
+	if p.Arch == ArchUnknown {
+		return fmt.Sprintf("macOS %s %s", p.Release, p.Version.String())
+	}
+
+	return fmt.Sprintf("macOS %s %s (%s)", p.Release, p.Version.String(), p.Arch)
+}
+
+// IsAppleSilicon checks if the product is running on Apple Silicon. Unlike IsAppleSiliconEra, this depends on the
+// detected architecture rather than the release.
+func (p Product) IsAppleSilicon() bool {
+	return p.Arch == ArchARM64
+}
+
+// IsAppleSiliconEra checks if the product is Big Sur or newer, the releases which support Apple Silicon. Compat mode
+// is only reported by Big Sur and newer so it's included.
+func (p Product) IsAppleSiliconEra() bool {
+	return p.Release >= BigSur
+}
+
+// HasSealedSystemVolume checks if the product boots from a sealed (read-only, signed) system volume. The sealed system
+// volume was introduced with Big Sur.
+func (p Product) HasSealedSystemVolume() bool {
+	return p.Release >= BigSur
+}
+
+// ProductForVersion initializes a new Product for the given macOS version (e.g. "14.0"). Unlike a scanned Product, the
+// version must identify a known release.
+func ProductForVersion(version string) (*Product, error) {
+	product, err := newProduct(version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %q: %w", version, err)
+	}
+
+	if product.Release == Unknown || product.Release == CompatMode {
+		return nil, fmt.Errorf("version %q isn't a known macOS release", version)
+	}
+
+	return product, nil
 }
 
 // newProduct initializes a new Product given the version string as input. It attempts to parse the version into a new
@@ -100,7 +147,8 @@ func newProduct(version string) (*Product, error) {
 	return product, nil
 }
 
-// getVersionRelease checks all known release constraints to determine which Release the version belongs to.
+// getVersionRelease checks all known release constraints to determine which Release the version belongs to. Versions
+// newer than the newest known release are Latest while versions older than Mojave are Unknown.
 func getVersionRelease(version semver.Version) Release {
 	switch {
 	case mojaveConstraints.Check(&version):
@@ -117,6 +165,8 @@ func getVersionRelease(version semver.Version) Release {
 		return Sonoma
 	case sequoiaConstraints.Check(&version):
 		return Sequoia
+	case latestConstraints.Check(&version):
+		return Latest
 	case compatModeConstraints.Check(&version):
 		return CompatMode
 	default:
@@ -0,0 +1,109 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetVersionRelease(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    Release
+	}{
+		{name: "before Mojave", version: "10.13.6", want: Unknown},
+		{name: "Mojave", version: "10.14.6", want: Mojave},
+		{name: "Catalina", version: "10.15.7", want: Catalina},
+		{name: "compat mode", version: "10.16", want: CompatMode},
+		{name: "Big Sur", version: "11.7.10", want: BigSur},
+		{name: "Monterey", version: "12.6.1", want: Monterey},
+		{name: "Ventura", version: "13.6", want: Ventura},
+		{name: "Sonoma", version: "14.1", want: Sonoma},
+		{name: "Sequoia", version: "15.0", want: Sequoia},
+		{name: "Tahoe", version: "26.0.0", want: Latest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version := semver.MustParse(tt.version)
+
+			got := getVersionRelease(*version)
+
+			assert.Equal(t, tt.want, got, "version should resolve to expected release")
+		})
+	}
+}
+
+func TestProduct_ReleasePredicates(t *testing.T) {
+	tests := []struct {
+		release          Release
+		wantAppleSilicon bool
+		wantSealed       bool
+	}{
+		{release: Unknown, wantAppleSilicon: false, wantSealed: false},
+		{release: Mojave, wantAppleSilicon: false, wantSealed: false},
+		{release: Catalina, wantAppleSilicon: false, wantSealed: false},
+		{release: BigSur, wantAppleSilicon: true, wantSealed: true},
+		{release: Monterey, wantAppleSilicon: true, wantSealed: true},
+		{release: Ventura, wantAppleSilicon: true, wantSealed: true},
+		{release: Sonoma, wantAppleSilicon: true, wantSealed: true},
+		{release: Sequoia, wantAppleSilicon: true, wantSealed: true},
+		{release: Latest, wantAppleSilicon: true, wantSealed: true},
+		{release: CompatMode, wantAppleSilicon: true, wantSealed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.release.String(), func(t *testing.T) {
+			p := Product{Release: tt.release}
+
+			assert.Equal(t, tt.wantAppleSilicon, p.IsAppleSiliconEra(), "should match expected Apple Silicon era")
+			assert.Equal(t, tt.wantSealed, p.HasSealedSystemVolume(), "should match expected sealed system volume")
+		})
+	}
+}
+
+func TestProduct_String(t *testing.T) {
+	version := semver.MustParse("14.1.0")
+
+	assert.Equal(t, "macOS Sonoma 14.1.0", Product{Release: Sonoma, Version: *version}.String(),
+		"should omit an unknown architecture")
+	assert.Equal(t, "macOS Sonoma 14.1.0 (arm64)", Product{Release: Sonoma, Version: *version, Arch: ArchARM64}.String(),
+		"should include the architecture")
+}
+
+func TestProduct_IsAppleSilicon(t *testing.T) {
+	assert.True(t, Product{Release: Sonoma, Arch: ArchARM64}.IsAppleSilicon(), "arm64 should be Apple Silicon")
+	assert.False(t, Product{Release: Sonoma, Arch: ArchX86}.IsAppleSilicon(), "x86_64 shouldn't be Apple Silicon")
+	assert.False(t, Product{Release: Sonoma}.IsAppleSilicon(), "unknown architecture shouldn't be Apple Silicon")
+}
+
+func TestProductForVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    Release
+		wantErr bool
+	}{
+		{name: "Sonoma", version: "14.0", want: Sonoma},
+		{name: "Sequoia patch", version: "15.1.1", want: Sequoia},
+		{name: "future release", version: "26.0", want: Latest},
+		{name: "unknown release", version: "10.13", wantErr: true},
+		{name: "compat mode", version: "10.16", wantErr: true},
+		{name: "invalid version", version: "latest", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProductForVersion(tt.version)
+
+			if tt.wantErr {
+				assert.Error(t, err, "should fail for version %s", tt.version)
+				assert.Nil(t, got, "shouldn't return a product on error")
+				return
+			}
+			assert.NoError(t, err, "should identify version %s", tt.version)
+			if assert.NotNil(t, got, "should return a product") {
+				assert.Equal(t, tt.want, got.Release, "version should resolve to expected release")
+			}
+		})
+	}
+}
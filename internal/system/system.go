@@ -2,10 +2,13 @@
 package system
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 
+	"github.com/Masterminds/semver"
 	"howett.net/plist"
 )
 
@@ -32,9 +35,23 @@ func (sys *System) Product() *Product {
 	return sys.product
 }
 
-// Scan reads the VersionInfo and creates a new System struct from that and the associated Product.
+// Scan reads the VersionInfo and creates a new System struct from that and the associated Product. The Product's
+// architecture is detected with DetectArch.
 func Scan() (*System, error) {
-	version, err := readVersion()
+	system, err := ScanRoot("/")
+	if err != nil {
+		return nil, err
+	}
+	system.product.Arch = DetectArch(context.Background(), Sysctl)
+
+	return system, nil
+}
+
+// ScanRoot reads the VersionInfo relative to the given root directory and creates a new System struct from that and
+// the associated Product. The resulting Product is never identified as CompatMode; if the true version can't be
+// recovered from dotVersionPath, an error is returned instead.
+func ScanRoot(root string) (*System, error) {
+	version, err := readVersion(root)
 	if err != nil {
 		return nil, err
 	}
@@ -44,6 +61,10 @@ func Scan() (*System, error) {
 		return nil, err
 	}
 
+	if product.Release == CompatMode {
+		return nil, fmt.Errorf("system version %s is reported in compat mode and could not be resolved", product.Version.String())
+	}
+
 	system := &System{
 		versionInfo: version,
 		product:     product,
@@ -82,23 +103,38 @@ func decodeVersionInfo(reader io.ReadSeeker) (version *VersionInfo, err error) {
 	return version, nil
 }
 
-// readVersion reads the SystemVersion plist data from disk (versionPath). If "SYSTEM_VERSION_COMPAT" is enabled, it will instead
-// read from dotVersionPath to bypass macOS's compat mode.
-func readVersion() (*VersionInfo, error) {
+// readVersion reads the SystemVersion plist data from disk (versionPath) relative to root. If "SYSTEM_VERSION_COMPAT"
+// is enabled, it will instead read from dotVersionPath to bypass macOS's compat mode.
+func readVersion(root string) (*VersionInfo, error) {
 	// Read the version info from the standard file path
-	version, err := readProductVersionFile(versionPath)
+	version, err := readProductVersionFile(filepath.Join(root, versionPath))
 	if err != nil {
 		return nil, err
 	}
 
 	// If the returned product version is in compat mode, read the version info from the dot file to bypass compat mode.
-	if version.ProductVersion == dotVersionSwitch {
-		return readProductVersionFile(dotVersionPath)
+	if isCompatVersion(version.ProductVersion) {
+		return readProductVersionFile(filepath.Join(root, dotVersionPath))
 	}
 
 	return version, nil
 }
 
+// isCompatVersion checks if the given product version is the version reported by macOS in compat mode (dotVersionSwitch
+// or any of its patch versions).
+func isCompatVersion(version string) bool {
+	if version == dotVersionSwitch {
+		return true
+	}
+
+	ver, err := semver.NewVersion(version)
+	if err != nil {
+		return false
+	}
+
+	return compatModeConstraints.Check(ver)
+}
+
 // readProductVersion opens the given file and attempts to decode it as VersionInfo.
 func readProductVersionFile(path string) (*VersionInfo, error) {
 	// Open the SystemVersion.plist file
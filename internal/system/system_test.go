@@ -0,0 +1,58 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanRoot_Monterey(t *testing.T) {
+	sys, err := ScanRoot("testdata/monterey")
+
+	assert.NoError(t, err, "should be able to scan system with valid version")
+	assert.Equal(t, Monterey, sys.Product().Release, "should resolve to Monterey")
+	assert.Equal(t, "12.6.1", sys.Product().Version.String(), "should have the version from SystemVersion plist")
+}
+
+func TestScanRoot_MontereyInCompatMode(t *testing.T) {
+	sys, err := ScanRoot("testdata/monterey_compat")
+
+	assert.NoError(t, err, "should be able to scan system in compat mode")
+	assert.Equal(t, Monterey, sys.Product().Release, "should resolve to Monterey instead of compat mode")
+	assert.Equal(t, "12.6.1", sys.Product().Version.String(), "should have the version from the platform plist")
+}
+
+func TestScanRoot_UnresolvedCompatMode(t *testing.T) {
+	sys, err := ScanRoot("testdata/compat_unresolved")
+
+	assert.Error(t, err, "shouldn't resolve a system that is still in compat mode")
+	assert.Nil(t, sys, "should get nil since the release couldn't be resolved")
+}
+
+func TestScanRoot_WithoutVersionFile(t *testing.T) {
+	sys, err := ScanRoot(t.TempDir())
+
+	assert.Error(t, err, "shouldn't be able to scan system without a version file")
+	assert.Nil(t, sys, "should get nil since the version file couldn't be read")
+}
+
+func TestIsCompatVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    bool
+	}{
+		{name: "compat switch", version: "10.16", want: true},
+		{name: "compat patch version", version: "10.16.1", want: true},
+		{name: "Catalina", version: "10.15.7", want: false},
+		{name: "Monterey", version: "12.6.1", want: false},
+		{name: "invalid version", version: "not a version", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := isCompatVersion(tt.version)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
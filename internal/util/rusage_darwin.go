@@ -0,0 +1,23 @@
+//go:build darwin
+
+package util
+
+import (
+	"os"
+	"syscall"
+)
+
+// maxRSSBytes gets the peak resident set size (in bytes) of an exited process. On macOS, the rusage's maxrss is
+// already reported in bytes.
+func maxRSSBytes(state *os.ProcessState) uint64 {
+	if state == nil {
+		return 0
+	}
+
+	usage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || usage.Maxrss < 0 {
+		return 0
+	}
+
+	return uint64(usage.Maxrss)
+}
@@ -5,29 +5,203 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"os/exec"
 	"os/user"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
 )
 
 // CommandOutput wraps the output from an exec command as strings.
 type CommandOutput struct {
 	Stdout string
 	Stderr string
+	// MaxRSSBytes is the peak resident set size (in bytes) of the command's process. This is 0 when the command didn't
+	// run or the platform doesn't report resource usage.
+	MaxRSSBytes uint64
+	// ExitCode is the exit code of the command's process. This is -1 when the command didn't exit on its own (e.g. it
+	// couldn't be started or was killed by a signal).
+	ExitCode int
+}
+
+// CommandError defines an error to distinguish when a command run by ExecuteCommand fails to start or exits
+// unsuccessfully. It provides the command's argv and output alongside the underlying error (e.g. *exec.ExitError).
+type CommandError struct {
+	argv     []string
+	stdout   string
+	stderr   string
+	exitCode int
+	err      error
+}
+
+func (e CommandError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e CommandError) Unwrap() error {
+	return e.err
+}
+
+// Argv returns the command and arguments which were run.
+func (e CommandError) Argv() []string {
+	return append([]string(nil), e.argv...)
+}
+
+// Stdout returns the standard output of the command.
+func (e CommandError) Stdout() string {
+	return e.stdout
+}
+
+// Stderr returns the standard error of the command.
+func (e CommandError) Stderr() string {
+	return e.stderr
+}
+
+// ExitCode returns the exit code of the command's process, or -1 if it didn't exit on its own.
+func (e CommandError) ExitCode() int {
+	return e.exitCode
+}
+
+// newCommandError creates a new CommandError for the command's output and error.
+func newCommandError(argv []string, out CommandOutput, err error) CommandError {
+	return CommandError{
+		argv:     append([]string(nil), argv...),
+		stdout:   out.Stdout,
+		stderr:   out.Stderr,
+		exitCode: out.ExitCode,
+		err:      err,
+	}
+}
+
+// CommandObserver is called each time a command run by ExecuteCommand exits (or fails to start) with the command's
+// argv, how long it ran, and the error it returned, if any.
+type CommandObserver func(argv []string, elapsed time.Duration, err error)
+
+var (
+	// observerMu guards commandObserver.
+	observerMu sync.RWMutex
+	// commandObserver is the CommandObserver notified of each executed command, if any.
+	commandObserver CommandObserver
+)
+
+// SetCommandObserver sets the CommandObserver notified of each command run by ExecuteCommand. Passing nil removes the
+// current observer.
+func SetCommandObserver(o CommandObserver) {
+	observerMu.Lock()
+	defer observerMu.Unlock()
+
+	commandObserver = o
+}
+
+// observeCommand notifies the current CommandObserver, if any, of an executed command.
+func observeCommand(argv []string, elapsed time.Duration, err error) {
+	observerMu.RLock()
+	o := commandObserver
+	observerMu.RUnlock()
+
+	if o != nil {
+		o(argv, elapsed, err)
+	}
 }
 
-// ExecuteCommand executes the command and returns Stdout and Stderr as strings.
+// commandTimeoutKey is used to set and retrieve the command timeout held in contexts.
+var commandTimeoutKey = struct{ name string }{"commandTimeout"}
+
+// WithCommandTimeout extends the context to bound each command run by ExecuteCommand with it to the timeout. A timeout
+// of 0 removes the bound so commands are only limited by the context itself.
+func WithCommandTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, commandTimeoutKey, timeout)
+}
+
+// commandTimeout provides the command timeout held in the context, or 0 if there isn't one.
+func commandTimeout(ctx context.Context) time.Duration {
+	timeout, _ := ctx.Value(commandTimeoutKey).(time.Duration)
+
+	return timeout
+}
+
+// credentialFunc resolves the credential (e.g. the UID and GID of a user) a command is run with.
+type credentialFunc func(ctx context.Context) (*syscall.Credential, error)
+
+// ExecuteCommand executes the command and returns Stdout and Stderr as strings. A CommandError is returned if the
+// command fails to start or exits unsuccessfully. The command is killed once the context is done or its command timeout
+// (see WithCommandTimeout) elapses. The command is run as runAsUser, if provided, whose UID and GID are looked up.
 func ExecuteCommand(ctx context.Context, c []string, runAsUser string, envVars []string, stdin io.ReadCloser) (output CommandOutput, err error) {
+	var credential credentialFunc
+	if runAsUser != "" {
+		credential = func(ctx context.Context) (*syscall.Credential, error) {
+			return userCredential(ctx, runAsUser, false)
+		}
+	}
+
+	return executeCommand(ctx, c, credential, envVars, stdin)
+}
+
+// ExecuteCommandWithGroups wraps ExecuteCommand to also run the command with the supplementary groups of runAsUser, for
+// commands which need them (e.g. to access files shared with one of the user's groups). Unlike ExecuteCommand, the
+// command isn't run if the user's groups can't be looked up. Without a runAsUser, it's the same as ExecuteCommand.
+func ExecuteCommandWithGroups(ctx context.Context, c []string, runAsUser string, envVars []string, stdin io.ReadCloser) (output CommandOutput, err error) {
+	if runAsUser == "" {
+		return ExecuteCommand(ctx, c, runAsUser, envVars, stdin)
+	}
+
+	credential := func(ctx context.Context) (*syscall.Credential, error) {
+		return userCredential(ctx, runAsUser, true)
+	}
+
+	return executeCommand(ctx, c, credential, envVars, stdin)
+}
+
+// ExecuteCommandAsIDs wraps ExecuteCommand to run the command as the user with the numeric UID and GID, skipping the
+// lookup of a username (see getUIDandGID). The command's only group is the GID, unless the process isn't running as
+// root in which case the supplementary groups can't be changed and are inherited.
+func ExecuteCommandAsIDs(ctx context.Context, c []string, uid, gid int, envVars []string, stdin io.ReadCloser) (output CommandOutput, err error) {
+	if err := validateID("uid", uid); err != nil {
+		return CommandOutput{ExitCode: -1}, err
+	}
+	if err := validateID("gid", gid); err != nil {
+		return CommandOutput{ExitCode: -1}, err
+	}
+
+	credential := func(ctx context.Context) (*syscall.Credential, error) {
+		return &syscall.Credential{
+			Uid:         uint32(uid),
+			Gid:         uint32(gid),
+			Groups:      []uint32{uint32(gid)},
+			NoSetGroups: os.Geteuid() != 0,
+		}, nil
+	}
+
+	return executeCommand(ctx, c, credential, envVars, stdin)
+}
+
+// validateID checks that the numeric user or group ID (named kind, e.g. "uid") is non-negative and fits in 32 bits.
+func validateID(kind string, id int) error {
+	if id < 0 || uint64(id) > math.MaxUint32 {
+		return fmt.Errorf("invalid %s %d, must be between 0 and %d", kind, id, uint32(math.MaxUint32))
+	}
+
+	return nil
+}
+
+// executeCommand executes the command for ExecuteCommand, running it with the credential resolved by the
+// credentialFunc if it isn't nil. Otherwise, the command is run as the current user.
+func executeCommand(ctx context.Context, c []string, credential credentialFunc, envVars []string, stdin io.ReadCloser) (output CommandOutput, err error) {
 	// Separate name and args, plus catch a few error cases
 	var name string
 	var args []string
 
 	// Check the empty struct case ([]string{}) for the command
 	if len(c) == 0 {
-		return CommandOutput{}, fmt.Errorf("must provide a command")
+		return CommandOutput{ExitCode: -1}, fmt.Errorf("must provide a command")
 	}
 
 	// Set the name of the command and check if args are also provided
@@ -36,6 +210,13 @@ func ExecuteCommand(ctx context.Context, c []string, runAsUser string, envVars [
 		args = c[1:]
 	}
 
+	// Bound the command to its timeout, if any
+	if timeout := commandTimeout(ctx); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	// Set command and create output buffers
 	cmd := exec.CommandContext(ctx, name, args...)
 	var stdoutb, stderrb bytes.Buffer
@@ -47,14 +228,14 @@ func ExecuteCommand(ctx context.Context, c []string, runAsUser string, envVars [
 		cmd.Stdin = stdin
 	}
 
-	// Set runAsUser, if defined, otherwise will run as root
-	if runAsUser != "" {
-		uid, gid, err := getUIDandGID(runAsUser)
+	// Set the credential, if defined, otherwise will run as the current user (e.g. root)
+	if credential != nil {
+		cred, err := credential(ctx)
 		if err != nil {
-			return CommandOutput{Stdout: stdoutb.String(), Stderr: stderrb.String()}, fmt.Errorf("error looking up user: %s\n", err)
+			return CommandOutput{Stdout: stdoutb.String(), Stderr: stderrb.String(), ExitCode: -1}, err
 		}
 		cmd.SysProcAttr = &syscall.SysProcAttr{}
-		cmd.SysProcAttr.Credential = &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+		cmd.SysProcAttr.Credential = cred
 	}
 
 	// Append environment variables
@@ -62,42 +243,114 @@ func ExecuteCommand(ctx context.Context, c []string, runAsUser string, envVars [
 	cmd.Env = append(cmd.Env, envVars...)
 
 	// Start the command's execution
+	start := time.Now()
 	if err = cmd.Start(); err != nil {
-		return CommandOutput{Stdout: stdoutb.String(), Stderr: stderrb.String()}, fmt.Errorf("error starting specified command: %w", err)
+		observeCommand(c, time.Since(start), err)
+		output = CommandOutput{Stdout: stdoutb.String(), Stderr: stderrb.String(), ExitCode: -1}
+		return output, newCommandError(c, output, fmt.Errorf("error starting specified command: %w", err))
 	}
 
 	// Wait for the command to exit
-	if err = cmd.Wait(); err != nil {
-		return CommandOutput{Stdout: stdoutb.String(), Stderr: stderrb.String()}, fmt.Errorf("error waiting for specified command to exit: %w", err)
+	err = cmd.Wait()
+	observeCommand(c, time.Since(start), err)
+	maxRSS := maxRSSBytes(cmd.ProcessState)
+	exitCode := cmd.ProcessState.ExitCode()
+	logrus.WithFields(logrus.Fields{
+		"command":   name,
+		"max_rss":   humanize.Bytes(maxRSS),
+		"exit_code": exitCode,
+	}).Debug("Command exited")
+
+	output = CommandOutput{Stdout: stdoutb.String(), Stderr: stderrb.String(), MaxRSSBytes: maxRSS, ExitCode: exitCode}
+	if err != nil && ctx.Err() != nil {
+		// The command was killed because the context is done, so report why (e.g. the deadline was exceeded)
+		err = ctx.Err()
 	}
+	if err != nil {
+		return output, newCommandError(c, output, fmt.Errorf("error waiting for specified command to exit: %w", err))
+	}
+
+	return output, nil
+}
 
-	return CommandOutput{Stdout: stdoutb.String(), Stderr: stderrb.String()}, err
+// ExecuteCommandWithInput wraps ExecuteCommand with the input piped to the command's stdin. Exactly the input is
+// delivered, which suits commands expecting a specific confirmation (e.g. answering repairDisk's prompt once).
+func ExecuteCommandWithInput(ctx context.Context, c []string, input string) (output CommandOutput, err error) {
+	return ExecuteCommand(ctx, c, "", nil, io.NopCloser(strings.NewReader(input)))
 }
 
-// ExecuteCommandYes wraps ExecuteCommand with the yes binary in order to bypass user input states in automation.
-func ExecuteCommandYes(ctx context.Context, c []string, runAsUser string, envVars []string) (output CommandOutput, err error) {
-	// Set exec commands, one for yes and another for the specified command
-	cmdYes := exec.Command("/usr/bin/yes")
+// userCredential looks up the UID and GID of the user for running commands as them. The user's supplementary groups
+// are only looked up when withGroups is set.
+func userCredential(ctx context.Context, username string, withGroups bool) (*syscall.Credential, error) {
+	uid, gid, err := getUIDandGID(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("error looking up user: %s\n", err)
+	}
+	credential := &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+	if !withGroups {
+		return credential, nil
+	}
 
-	// Pipe cmdYes into cmd
-	stdin, err := cmdYes.StdoutPipe()
+	groups, err := getGroupIDs(ctx, username)
 	if err != nil {
-		return CommandOutput{}, fmt.Errorf("error creating pipe between commands")
+		return nil, fmt.Errorf("error looking up groups for user: %s\n", err)
 	}
+	credential.Groups = groups
+
+	return credential, nil
+}
+
+// userLookupAttempts is the number of times a user (e.g. their UID and GID) is looked up before giving up. On the very
+// first boot, a freshly created user (e.g. ec2-user) can be briefly missing from both user.Lookup() and dscacheutil.
+const userLookupAttempts = 5
 
-	// Start the command to run /usr/bin/yes
-	if err = cmdYes.Start(); err != nil {
-		return CommandOutput{}, fmt.Errorf("error starting /usr/bin/yes command: %w", err)
+// userLookupRetryDelay is the amount of time between each attempt to look up a user. It's replaced in tests.
+var userLookupRetryDelay = 500 * time.Millisecond
+
+// getUIDandGID takes a username and returns the uid and gid for that user, retrying the lookup (see
+// lookupUIDandGIDOnce) with retryUserLookup.
+func getUIDandGID(ctx context.Context, username string) (uid int, gid int, err error) {
+	err = retryUserLookup(ctx, username, func() error {
+		var lookupErr error
+		uid, gid, lookupErr = lookupUIDandGIDOnce(username)
+		return lookupErr
+	})
+	if err != nil {
+		return 0, 0, err
 	}
 
-	return ExecuteCommand(ctx, c, runAsUser, envVars, stdin)
+	return uid, gid, nil
 }
 
-// getUIDandGID takes a username and returns the uid and gid for that user.
+// retryUserLookup attempts the lookup of the user up to userLookupAttempts times, waiting userLookupRetryDelay between
+// each, until it succeeds or the context is done. Users which already exist are found with a single lookup.
+func retryUserLookup(ctx context.Context, username string, lookup func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := lookup()
+		if err == nil {
+			return nil
+		}
+		if attempt >= userLookupAttempts {
+			return fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+		}
+
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"user":    username,
+			"attempt": attempt,
+		}).Debug("User lookup failed, retrying")
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("stopped retrying after %d attempts: %w", attempt, ctx.Err())
+		case <-time.After(userLookupRetryDelay):
+		}
+	}
+}
+
+// lookupUIDandGIDOnce takes a username and returns the uid and gid for that user.
 // While testing UID/GID lookup for a user, it was found that the user.Lookup() function does not always return
 // information for a new user on first boot. In the case that user.Lookup() fails, try dscacheutil, which has a
 // higher success rate. If that fails, return an error. Any successful case returns the UID and GID as ints.
-func getUIDandGID(username string) (uid int, gid int, err error) {
+func lookupUIDandGIDOnce(username string) (uid int, gid int, err error) {
 	var uidstr, gidstr string
 	// Preference is user.Lookup(), if it works
 	u, lookuperr := user.Lookup(username)
@@ -166,3 +419,62 @@ func getUIDandGID(username string) (uid int, gid int, err error) {
 
 	return uid, gid, nil
 }
+
+// getGroupIDs takes a username and returns the ids of all groups the user is a member of, retrying the lookup (see
+// lookupGroupIDsOnce) with retryUserLookup like getUIDandGID.
+func getGroupIDs(ctx context.Context, username string) (groups []uint32, err error) {
+	err = retryUserLookup(ctx, username, func() error {
+		var lookupErr error
+		groups, lookupErr = lookupGroupIDsOnce(username)
+		return lookupErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// lookupGroupIDsOnce takes a username and returns the ids of all groups the user is a member of, including
+// supplementary groups. Similar to lookupUIDandGIDOnce, user.LookupGroupIds() may not return information for a new user
+// on first boot so "id -G" is used as a fallback when the lookup fails.
+func lookupGroupIDsOnce(username string) ([]uint32, error) {
+	// Preference is user.Lookup() and GroupIds(), if they work
+	u, lookuperr := user.Lookup(username)
+	if lookuperr == nil {
+		ids, idserr := u.GroupIds()
+		if idserr == nil {
+			return parseGroupIDs(strings.Join(ids, " "))
+		}
+		lookuperr = idserr
+	}
+
+	// The user lookup has failed, second try by asking id for the user's groups
+	out, cmderr := ExecuteCommand(context.Background(), []string{"id", "-G", username}, "", []string{}, nil)
+	if cmderr != nil {
+		return nil, fmt.Errorf("error while looking up groups for user %s: \n"+
+			"user.GroupIds() error: %s \nid error: %s\nid Stderr: %s\n",
+			username, lookuperr, cmderr, out.Stderr)
+	}
+
+	return parseGroupIDs(out.Stdout)
+}
+
+// parseGroupIDs parses whitespace separated group ids (e.g. the output of "id -G") into a slice of gids.
+func parseGroupIDs(raw string) ([]uint32, error) {
+	fields := strings.Fields(raw)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no group ids found")
+	}
+
+	groups := make([]uint32, 0, len(fields))
+	for _, f := range fields {
+		gid, err := strconv.ParseUint(f, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("error while converting group id %q to int: %w", f, err)
+		}
+		groups = append(groups, uint32(gid))
+	}
+
+	return groups, nil
+}
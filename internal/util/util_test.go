@@ -0,0 +1,313 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"os/user"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGroupIDs(t *testing.T) {
+	type args struct {
+		raw string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []uint32
+		wantErr bool
+	}{
+		{
+			name: "without output",
+			args: args{
+				raw: "",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "with single group",
+			args: args{
+				raw: "20\n",
+			},
+			want:    []uint32{20},
+			wantErr: false,
+		},
+		{
+			name: "with supplementary groups",
+			args: args{
+				raw: "20 12 61 79 80 81 98 701 33 100\n",
+			},
+			want:    []uint32{20, 12, 61, 79, 80, 81, 98, 701, 33, 100},
+			wantErr: false,
+		},
+		{
+			name: "with non-numeric group",
+			args: args{
+				raw: "20 staff\n",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "with negative group",
+			args: args{
+				raw: "-1",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGroupIDs(tt.args.raw)
+
+			assert.Equal(t, tt.want, got, "parsed groups should match expected")
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestExecuteCommand_MaxRSSBytes(t *testing.T) {
+	if runtime.GOOS != "darwin" && runtime.GOOS != "linux" {
+		t.Skipf("resource usage isn't reported on %s", runtime.GOOS)
+	}
+
+	out, err := ExecuteCommand(context.Background(), []string{"echo", "hello"}, "", nil, nil)
+
+	assert.NoError(t, err, "should be able to run command")
+	assert.Equal(t, "hello\n", out.Stdout, "should capture the command's output")
+	assert.True(t, out.MaxRSSBytes > 0, "should capture the command's peak memory usage")
+}
+
+func TestExecuteCommand_ExitCode(t *testing.T) {
+	out, err := ExecuteCommand(context.Background(), []string{"sh", "-c", "echo busy >&2; exit 2"}, "", nil, nil)
+
+	assert.Error(t, err, "should fail when the command exits unsuccessfully")
+	assert.Equal(t, 2, out.ExitCode, "should capture the command's exit code")
+	assert.Equal(t, "busy\n", out.Stderr, "should capture the command's stderr")
+}
+
+func TestExecuteCommand_ExitCodeWithSuccess(t *testing.T) {
+	out, err := ExecuteCommand(context.Background(), []string{"true"}, "", nil, nil)
+
+	assert.NoError(t, err, "should be able to run command")
+	assert.Equal(t, 0, out.ExitCode, "should capture the command's exit code")
+}
+
+func TestExecuteCommand_ExitCodeWithoutStart(t *testing.T) {
+	out, err := ExecuteCommand(context.Background(), []string{"/nonexistent/command"}, "", nil, nil)
+
+	assert.Error(t, err, "should fail when the command can't be started")
+	assert.Equal(t, -1, out.ExitCode, "shouldn't report an exit code for a command which didn't run")
+}
+
+func TestExecuteCommand_CommandError(t *testing.T) {
+	argv := []string{"sh", "-c", "echo partial; echo busy >&2; exit 2"}
+	_, err := ExecuteCommand(context.Background(), argv, "", nil, nil)
+
+	var cmdErr CommandError
+	if !assert.True(t, errors.As(err, &cmdErr), "should be a CommandError") {
+		return
+	}
+	assert.Equal(t, argv, cmdErr.Argv(), "should carry the command's argv")
+	assert.Equal(t, "partial\n", cmdErr.Stdout(), "should carry the command's stdout")
+	assert.Equal(t, "busy\n", cmdErr.Stderr(), "should carry the command's stderr")
+	assert.Equal(t, 2, cmdErr.ExitCode(), "should carry the command's exit code")
+
+	var exitErr *exec.ExitError
+	assert.True(t, errors.As(err, &exitErr), "should wrap the command's *exec.ExitError")
+}
+
+func TestExecuteCommand_CommandErrorWithoutStart(t *testing.T) {
+	argv := []string{"/nonexistent/command"}
+	_, err := ExecuteCommand(context.Background(), argv, "", nil, nil)
+
+	var cmdErr CommandError
+	if !assert.True(t, errors.As(err, &cmdErr), "should be a CommandError") {
+		return
+	}
+	assert.Equal(t, argv, cmdErr.Argv(), "should carry the command's argv")
+	assert.Equal(t, -1, cmdErr.ExitCode(), "shouldn't report an exit code for a command which didn't run")
+	assert.True(t, errors.Is(err, os.ErrNotExist), "should wrap the start error")
+}
+
+func TestExecuteCommand_WithCommandTimeout(t *testing.T) {
+	ctx := WithCommandTimeout(context.Background(), 100*time.Millisecond)
+
+	start := time.Now()
+	_, err := ExecuteCommand(ctx, []string{"sleep", "5"}, "", nil, nil)
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "should stop the command once the timeout elapses")
+	var cmdErr CommandError
+	assert.True(t, errors.As(err, &cmdErr), "should be a CommandError")
+	assert.True(t, time.Since(start) < 5*time.Second, "shouldn't wait for the command to exit on its own")
+}
+
+func TestExecuteCommand_WithoutCommandTimeout(t *testing.T) {
+	ctx := WithCommandTimeout(WithCommandTimeout(context.Background(), time.Nanosecond), 0)
+
+	_, err := ExecuteCommand(ctx, []string{"true"}, "", nil, nil)
+
+	assert.NoError(t, err, "should remove the timeout")
+}
+
+func TestExecuteCommand_WithObserver(t *testing.T) {
+	var observed [][]string
+	SetCommandObserver(func(argv []string, elapsed time.Duration, err error) {
+		assert.NoError(t, err, "should observe the command's result")
+		observed = append(observed, argv)
+	})
+	defer SetCommandObserver(nil)
+
+	_, err := ExecuteCommand(context.Background(), []string{"echo", "hello"}, "", nil, nil)
+	assert.NoError(t, err, "should be able to run command")
+	_, err = ExecuteCommand(context.Background(), []string{"echo", "world"}, "", nil, nil)
+	assert.NoError(t, err, "should be able to run command")
+
+	expected := [][]string{{"echo", "hello"}, {"echo", "world"}}
+	assert.Equal(t, expected, observed, "should observe each command's argv")
+}
+
+func TestExecuteCommandWithInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "without input", input: ""},
+		{name: "with confirmation", input: "y\n"},
+		{name: "with volume name", input: "Macintosh HD\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// cat echoes its stdin so the output is exactly what was delivered
+			out, err := ExecuteCommandWithInput(context.Background(), []string{"cat"}, tt.input)
+
+			assert.NoError(t, err, "should be able to run command")
+			assert.Equal(t, tt.input, out.Stdout, "should deliver exactly the input to stdin")
+		})
+	}
+}
+
+// fakeUserLookup creates a user lookup which fails the given number of times before succeeding, and shortens the
+// delay between attempts for the test. The number of lookups made is recorded in the returned count.
+func fakeUserLookup(t *testing.T, failures int) (func() error, *int) {
+	t.Helper()
+
+	delay := userLookupRetryDelay
+	t.Cleanup(func() { userLookupRetryDelay = delay })
+	userLookupRetryDelay = time.Millisecond
+
+	lookups := 0
+	return func() error {
+		lookups++
+		if lookups <= failures {
+			return errors.New("user not found")
+		}
+		return nil
+	}, &lookups
+}
+
+func TestRetryUserLookup(t *testing.T) {
+	lookup, lookups := fakeUserLookup(t, 0)
+
+	err := retryUserLookup(context.Background(), "ec2-user", lookup)
+
+	assert.NoError(t, err, "should find an existing user")
+	assert.Equal(t, 1, *lookups, "should only look up an existing user once")
+}
+
+func TestRetryUserLookup_WithRetry(t *testing.T) {
+	lookup, lookups := fakeUserLookup(t, 1)
+
+	err := retryUserLookup(context.Background(), "ec2-user", lookup)
+
+	assert.NoError(t, err, "should find the user once the lookup succeeds")
+	assert.Equal(t, 2, *lookups, "should retry the failed lookup")
+}
+
+func TestRetryUserLookup_WithoutUser(t *testing.T) {
+	lookup, lookups := fakeUserLookup(t, userLookupAttempts)
+
+	err := retryUserLookup(context.Background(), "ec2-user", lookup)
+
+	assert.Error(t, err, "should fail once every attempt failed")
+	assert.Equal(t, userLookupAttempts, *lookups, "should stop after the last attempt")
+}
+
+func TestRetryUserLookup_WithCancelledContext(t *testing.T) {
+	lookup, lookups := fakeUserLookup(t, userLookupAttempts)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := retryUserLookup(ctx, "ec2-user", lookup)
+
+	assert.True(t, errors.Is(err, context.Canceled), "should stop retrying when the context is done")
+	assert.Equal(t, 1, *lookups, "shouldn't retry once the context is done")
+}
+
+func TestGetGroupIDs_WithoutUser(t *testing.T) {
+	// Every attempt fails for a user which doesn't exist, but the context stops the retries
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := getGroupIDs(ctx, "ec2-macos-utils-no-such-user")
+
+	assert.True(t, errors.Is(err, context.Canceled), "should retry the group lookup until the context is done")
+}
+
+func TestExecuteCommandAsIDs(t *testing.T) {
+	out, err := ExecuteCommandAsIDs(context.Background(), []string{"id", "-u"}, os.Getuid(), os.Getgid(), nil, nil)
+
+	assert.NoError(t, err, "should be able to run the command as the current user's ids")
+	assert.Equal(t, strconv.Itoa(os.Getuid()), strings.TrimSpace(out.Stdout), "should run the command as the uid")
+	assert.Equal(t, 0, out.ExitCode)
+}
+
+func TestExecuteCommandAsIDs_WithInvalidIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		uid  int
+		gid  int
+	}{
+		{name: "negative uid", uid: -1, gid: os.Getgid()},
+		{name: "negative gid", uid: os.Getuid(), gid: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := ExecuteCommandAsIDs(context.Background(), []string{"true"}, tt.uid, tt.gid, nil, nil)
+
+			assert.Error(t, err, "shouldn't run the command with a negative id")
+			assert.Equal(t, -1, out.ExitCode, "shouldn't have run the command")
+		})
+	}
+}
+
+func TestUserCredential(t *testing.T) {
+	current, err := user.Current()
+	if err != nil {
+		t.Skipf("unable to look up the current user: %v", err)
+	}
+
+	credential, err := userCredential(context.Background(), current.Username, false)
+	assert.NoError(t, err, "should look up the user")
+	assert.Equal(t, strconv.Itoa(int(credential.Uid)), current.Uid, "should run as the user's uid")
+	assert.Nil(t, credential.Groups, "shouldn't look up the supplementary groups unless requested")
+
+	credential, err = userCredential(context.Background(), current.Username, true)
+	assert.NoError(t, err, "should look up the user and their groups")
+	assert.NotEmpty(t, credential.Groups, "should include the supplementary groups when requested")
+}